@@ -15,6 +15,7 @@ import (
 	"github.com/pteronimbus/pteronimbus/apps/backend/internal/discord"
 	"github.com/pteronimbus/pteronimbus/apps/backend/internal/handlers"
 	"github.com/pteronimbus/pteronimbus/apps/backend/internal/middleware"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
 	"github.com/pteronimbus/pteronimbus/apps/backend/internal/services"
 	"log/slog"
 )
@@ -22,6 +23,9 @@ import (
 func main() {
 	// Load configuration
 	cfg := config.Load()
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
 
 	// Set Gin mode based on environment
 	if cfg.Server.Environment == "production" {
@@ -45,6 +49,41 @@ func main() {
 
 	auditService := services.NewAuditService()
 
+	// Initialize RBAC service first
+	rbacService := services.NewRBACServiceWithCache(dbService.GetDB(), &cfg.RBAC, services.NewRedisPermissionCache(redisService))
+	
+	if err := rbacService.SeedPermissionCatalog(context.Background()); err != nil {
+		log.Fatalf("Failed to seed permission catalog: %v", err)
+	}
+
+	// Initialize JWT service with RBAC integration
+	jwtService := services.NewJWTServiceWithRBAC(cfg, rbacService)
+	if len(cfg.JWT.SigningKeyFiles) > 0 {
+		signingKeys, err := services.LoadSigningKeys(cfg.JWT.SigningKeyFiles)
+		if err != nil {
+			log.Fatalf("Failed to load JWT signing keys: %v", err)
+		}
+		jwtService = services.NewJWTServiceWithKeys(cfg, rbacService, signingKeys)
+	}
+	
+	// Initialize auth service with RBAC integration
+	authService := services.NewAuthServiceWithRBAC(dbService.GetDB(), discordService, jwtService, redisService, rbacService)
+	for _, providerConfig := range cfg.OIDC.Providers {
+		authService.RegisterIdentityProvider(services.NewOIDCProvider(providerConfig))
+	}
+	twoFactorService := services.NewTwoFactorServiceWithAttemptLimit(dbService.GetDB(), rbacService, services.NewRedisTwoFactorAttemptCounter(redisService))
+	authService.SetTwoFactorService(twoFactorService)
+	tenantService := services.NewTenantServiceWithRBAC(dbService.GetDB(), discordService, rbacService)
+	tenantService.SetBotToken(cfg.Discord.BotToken)
+	gameServerService := services.NewGameServerService(dbService.GetDB())
+	notificationService := services.NewNotificationService(dbService.GetDB(), &cfg.Discord)
+	controllerService := services.NewControllerServiceWithNotifier(dbService.GetDB(), cfg, jwtService, notificationService)
+	controllerService.StartOfflineMonitor(cfg.Controller.HeartbeatTTL)
+	adminService := services.NewAdminService(dbService.GetDB(), rbacService)
+	apiTokenService := services.NewAPITokenService(dbService.GetDB(), rbacService)
+	membershipService := services.NewMembershipService(dbService.GetDB(), rbacService)
+	ownershipService := services.NewOwnershipService(dbService.GetDB(), rbacService, notificationService)
+
 	// Initialize Discord Bot
 	var bot *discord.Bot
 	var syncService *services.SyncService
@@ -55,9 +94,9 @@ func main() {
 		if err != nil {
 			log.Fatalf("Failed to create temporary Discord session: %v", err)
 		}
-		syncService = services.NewSyncService(dbService.GetDB(), tempSession)
+		syncService = services.NewSyncServiceWithOwnership(dbService.GetDB(), tempSession, rbacService, ownershipService)
 
-		bot, err = discord.NewBot(cfg.Discord.BotToken, syncService, auditService)
+		bot, err = discord.NewBot(cfg.Discord.BotToken, syncService, auditService, tenantService, authService, rbacService, gameServerService)
 		if err != nil {
 			log.Fatalf("Failed to initialize Discord bot: %v", err)
 		}
@@ -67,25 +106,13 @@ func main() {
 				log.Printf("Discord bot error: %v", err)
 			}
 		}()
+		bot.StartReconcileLoop(cfg.Discord.ReconcileInterval)
 	} else {
 		log.Println("Discord bot token not configured, skipping bot initialization.")
 		// If the bot is not configured, we can still create the sync service without a session.
-		syncService = services.NewSyncService(dbService.GetDB(), nil)
+		syncService = services.NewSyncServiceWithRBAC(dbService.GetDB(), nil, rbacService)
 	}
 
-	// Initialize RBAC service first
-	rbacService := services.NewRBACService(dbService.GetDB(), &cfg.RBAC)
-	
-	// Initialize JWT service with RBAC integration
-	jwtService := services.NewJWTServiceWithRBAC(cfg, rbacService)
-	
-	// Initialize auth service with RBAC integration
-	authService := services.NewAuthServiceWithRBAC(dbService.GetDB(), discordService, jwtService, redisService, rbacService)
-	tenantService := services.NewTenantService(dbService.GetDB(), discordService)
-	gameServerService := services.NewGameServerService(dbService.GetDB())
-	controllerService := services.NewControllerService(dbService.GetDB(), cfg, jwtService)
-	adminService := services.NewAdminService(dbService.GetDB())
-
 	// Test Redis connection
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -102,16 +129,28 @@ func main() {
 
 	// Initialize handlers
 	healthHandler := handlers.NewHealthHandler()
-	authHandler := handlers.NewAuthHandler(authService, logger)
-	tenantHandler := handlers.NewTenantHandler(tenantService, discordService, authService, redisService)
-	gameServerHandler := handlers.NewGameServerHandler(gameServerService, tenantService)
+	jwksHandler := handlers.NewJWKSHandler(jwtService)
+	authHandler := handlers.NewAuthHandlerWithStateStore(authService, services.NewRedisOAuthStateStore(redisService), logger)
+	// Only a sync service with a Discord session can sync on request
+	var guildSyncer services.GuildSyncer
+	if bot != nil {
+		guildSyncer = syncService
+	}
+	tenantHandler := handlers.NewTenantHandlerWithSync(tenantService, discordService, authService, redisService, membershipService, guildSyncer)
+	gameServerHandler := handlers.NewGameServerHandlerWithRBAC(gameServerService, tenantService, rbacService)
 	controllerHandler := handlers.NewControllerHandler(controllerService)
-	adminHandler := handlers.NewAdminHandler(adminService)
+	adminHandler := handlers.NewAdminHandlerWithAuth(adminService, authService)
+	rbacHandler := handlers.NewRBACHandler(rbacService)
+	apiTokenHandler := handlers.NewAPITokenHandler(apiTokenService)
+	twoFactorHandler := handlers.NewTwoFactorHandler(twoFactorService)
+	membershipHandler := handlers.NewMembershipHandler(membershipService)
+	ownershipHandler := handlers.NewOwnershipHandler(ownershipService)
 
 	// Initialize middleware
-	authMiddleware := middleware.NewAuthMiddleware(authService)
-	tenantMiddleware := middleware.NewTenantMiddleware(tenantService)
+	authMiddleware := middleware.NewAuthMiddlewareWithAPITokens(authService, apiTokenService)
+	tenantMiddleware := middleware.NewTenantMiddlewareWithTwoFactor(tenantService, twoFactorService)
 	controllerMiddleware := middleware.NewControllerMiddleware(controllerService)
+	permissionMiddleware := middleware.NewPermissionMiddleware(rbacService)
 
 	// Setup Gin router
 	router := gin.Default()
@@ -133,6 +172,9 @@ func main() {
 		healthHandler.Live(w, r)
 	}))
 
+	// Public keys for verifying tokens issued by this backend
+	router.GET("/.well-known/jwks.json", jwksHandler.JWKS)
+
 	// Authentication routes
 	authRoutes := router.Group("/auth")
 	{
@@ -140,24 +182,74 @@ func main() {
 		authRoutes.GET("/callback", authHandler.Callback)
 		authRoutes.POST("/refresh", authHandler.Refresh)
 		authRoutes.GET("/me", authMiddleware.RequireAuth(), authHandler.Me)
-		authRoutes.POST("/logout", authMiddleware.RequireAuth(), authHandler.Logout)
+		authRoutes.POST("/logout", authMiddleware.RequireAuth(), authMiddleware.RequireSession(), authHandler.Logout)
+		authRoutes.GET("/providers", authHandler.Providers)
+		authRoutes.GET("/oidc/:provider/login", authHandler.ProviderLogin)
+		authRoutes.GET("/oidc/:provider/callback", authHandler.ProviderCallback)
+		authRoutes.GET("/identities", authMiddleware.RequireAuth(), authMiddleware.RequireSession(), authHandler.Identities)
+		authRoutes.POST("/identities/:provider/link", authMiddleware.RequireAuth(), authMiddleware.RequireSession(), authHandler.LinkIdentity)
+		authRoutes.DELETE("/identities/:id", authMiddleware.RequireAuth(), authMiddleware.RequireSession(), authHandler.UnlinkIdentity)
+		authRoutes.GET("/sessions", authMiddleware.RequireAuth(), authMiddleware.RequireSession(), authHandler.Sessions)
+		authRoutes.DELETE("/sessions", authMiddleware.RequireAuth(), authMiddleware.RequireSession(), authHandler.RevokeAllSessions)
+		authRoutes.DELETE("/sessions/:id", authMiddleware.RequireAuth(), authMiddleware.RequireSession(), authHandler.RevokeSession)
+		authRoutes.GET("/step-up", authMiddleware.RequireAuth(), authMiddleware.RequireSession(), authHandler.StepUpStatus)
+		authRoutes.POST("/step-up/totp", authMiddleware.RequireAuth(), authMiddleware.RequireSession(), authHandler.StepUpWithTOTP)
+		authRoutes.POST("/step-up/:provider", authMiddleware.RequireAuth(), authMiddleware.RequireSession(), authHandler.BeginStepUp)
+		authRoutes.POST("/two-factor/verify", authHandler.VerifyTwoFactor)
+		authRoutes.GET("/two-factor", authMiddleware.RequireAuth(), authMiddleware.RequireSession(), twoFactorHandler.Status)
+		authRoutes.POST("/two-factor/enroll", authMiddleware.RequireAuth(), authMiddleware.RequireSession(), twoFactorHandler.Enroll)
+		authRoutes.POST("/two-factor/confirm", authMiddleware.RequireAuth(), authMiddleware.RequireSession(), twoFactorHandler.Confirm)
+		authRoutes.POST("/two-factor/recovery-codes", authMiddleware.RequireAuth(), authMiddleware.RequireSession(), twoFactorHandler.RegenerateRecoveryCodes)
+		authRoutes.POST("/two-factor/disable", authMiddleware.RequireAuth(), authMiddleware.RequireSession(), twoFactorHandler.Disable)
 	}
 
 	// API routes (protected)
 	apiRoutes := router.Group("/api")
 	apiRoutes.Use(authMiddleware.RequireAuth())
 	{
+		// Permission catalog for role editors
+		apiRoutes.GET("/permissions", rbacHandler.ListPermissions)
+
+		// Personal access token routes
+		tokenRoutes := apiRoutes.Group("/tokens")
+		tokenRoutes.Use(authMiddleware.RequireSession())
+		{
+			tokenRoutes.GET("", apiTokenHandler.ListTokens)
+			tokenRoutes.POST("", apiTokenHandler.CreateToken)
+			tokenRoutes.DELETE("/:id", apiTokenHandler.RevokeToken)
+		}
+
+		// Invites addressed to the current user
+		inviteRoutes := apiRoutes.Group("/invites")
+		inviteRoutes.Use(authMiddleware.RequireSession())
+		{
+			inviteRoutes.GET("", membershipHandler.ListMyInvites)
+			inviteRoutes.POST("/join", membershipHandler.JoinTenant)
+			inviteRoutes.POST("/:id/accept", membershipHandler.AcceptInvite)
+			inviteRoutes.POST("/:id/decline", membershipHandler.DeclineInvite)
+		}
+
+		// Ownership transfers offered to the current user
+		transferRoutes := apiRoutes.Group("/ownership-transfers")
+		transferRoutes.Use(authMiddleware.RequireSession())
+		{
+			transferRoutes.GET("", ownershipHandler.ListMyTransfers)
+			transferRoutes.POST("/:id/accept", authMiddleware.RequireStepUp(), ownershipHandler.AcceptTransfer)
+			transferRoutes.POST("/:id/decline", ownershipHandler.DeclineTransfer)
+		}
+
 		// Tenant management routes
 		tenantRoutes := apiRoutes.Group("/tenants")
+		tenantRoutes.Use(authMiddleware.RequireSession())
 		{
 			tenantRoutes.GET("", tenantHandler.GetUserTenants)
 			tenantRoutes.GET("/available-guilds", tenantHandler.GetAvailableGuilds)
 			tenantRoutes.POST("", tenantHandler.CreateTenant)
 			tenantRoutes.GET("/:id", tenantHandler.GetTenant)
 			tenantRoutes.GET("/:id/bot-status", tenantHandler.GetBotStatus)
-			tenantRoutes.PUT("/:id/config", tenantHandler.UpdateTenantConfig)
-			tenantRoutes.POST("/:id/sync", tenantHandler.SyncTenantData)
-			tenantRoutes.DELETE("/:id", tenantHandler.DeleteTenant)
+			tenantRoutes.PUT("/:id/config", tenantMiddleware.RequireOwnerTwoFactor(), tenantHandler.UpdateTenantConfig)
+			tenantRoutes.POST("/:id/sync", tenantMiddleware.RequireOwnerTwoFactor(), tenantHandler.SyncTenantData)
+			tenantRoutes.DELETE("/:id", authMiddleware.RequireStepUp(), tenantMiddleware.RequireOwnerTwoFactor(), tenantHandler.DeleteTenant)
 		}
 
 		// Tenant-scoped routes (require tenant context)
@@ -165,12 +257,60 @@ func main() {
 		tenantScopedRoutes.Use(tenantMiddleware.RequireTenant())
 		{
 			// Game server routes
-			tenantScopedRoutes.GET("/servers", gameServerHandler.GetTenantServers)
-			tenantScopedRoutes.GET("/activity", gameServerHandler.GetTenantActivity)
-			tenantScopedRoutes.GET("/discord/stats", gameServerHandler.GetTenantDiscordStats)
+			tenantScopedRoutes.GET("/servers", permissionMiddleware.RequireTokenScope(models.PermissionServerRead), gameServerHandler.GetTenantServers)
+			tenantScopedRoutes.POST("/servers/:serverId/start", permissionMiddleware.RequireResourcePermission(models.PermissionServerStart, models.ResourceTypeGameServer, "serverId"), gameServerHandler.StartServer)
+			tenantScopedRoutes.POST("/servers/:serverId/stop", permissionMiddleware.RequireResourcePermission(models.PermissionServerStop, models.ResourceTypeGameServer, "serverId"), gameServerHandler.StopServer)
+			tenantScopedRoutes.POST("/servers/:serverId/restart", permissionMiddleware.RequireResourcePermission(models.PermissionServerRestart, models.ResourceTypeGameServer, "serverId"), gameServerHandler.RestartServer)
+
+			// Per-server grants
+			tenantScopedRoutes.GET("/servers/:serverId/grants", permissionMiddleware.RequirePermission(models.PermissionRoleRead), rbacHandler.GetServerGrants)
+			tenantScopedRoutes.POST("/servers/:serverId/grants", authMiddleware.RequireSession(), permissionMiddleware.RequirePermission(models.PermissionRoleWrite), rbacHandler.CreateServerGrant)
+			tenantScopedRoutes.DELETE("/servers/:serverId/grants/:grantId", authMiddleware.RequireSession(), permissionMiddleware.RequirePermission(models.PermissionRoleWrite), rbacHandler.DeleteServerGrant)
+			tenantScopedRoutes.GET("/activity", permissionMiddleware.RequireTokenScope(models.PermissionServerRead), gameServerHandler.GetTenantActivity)
+			tenantScopedRoutes.GET("/discord/stats", permissionMiddleware.RequireTokenScope(models.PermissionUserRead), gameServerHandler.GetTenantDiscordStats)
+
+			// Tenant role routes
+			tenantScopedRoutes.GET("/roles", permissionMiddleware.RequirePermission(models.PermissionRoleRead), rbacHandler.GetRoles)
+			tenantScopedRoutes.POST("/roles", authMiddleware.RequireSession(), permissionMiddleware.RequirePermission(models.PermissionRoleCreate), rbacHandler.CreateRole)
+			tenantScopedRoutes.PUT("/roles/:roleId", authMiddleware.RequireSession(), permissionMiddleware.RequirePermission(models.PermissionRoleWrite), rbacHandler.UpdateRole)
+			tenantScopedRoutes.DELETE("/roles/:roleId", authMiddleware.RequireSession(), permissionMiddleware.RequirePermission(models.PermissionRoleDelete), rbacHandler.DeleteRole)
+			tenantScopedRoutes.POST("/members/:userId/roles", authMiddleware.RequireSession(), permissionMiddleware.RequirePermission(models.PermissionUserWrite), rbacHandler.AssignMemberRole)
+			tenantScopedRoutes.DELETE("/members/:userId/roles/:roleId", authMiddleware.RequireSession(), permissionMiddleware.RequirePermission(models.PermissionUserWrite), rbacHandler.RemoveMemberRole)
+
+			// Membership and invite routes
+			tenantScopedRoutes.GET("/members", permissionMiddleware.RequirePermission(models.PermissionUserRead), membershipHandler.ListMembers)
+			tenantScopedRoutes.DELETE("/members/:userId", authMiddleware.RequireSession(), permissionMiddleware.RequirePermission(models.PermissionUserDelete), membershipHandler.RemoveMember)
+			tenantScopedRoutes.DELETE("/membership", authMiddleware.RequireSession(), membershipHandler.LeaveTenant)
+			tenantScopedRoutes.GET("/invites", permissionMiddleware.RequirePermission(models.PermissionUserRead), membershipHandler.ListInvites)
+			tenantScopedRoutes.POST("/invites", authMiddleware.RequireSession(), permissionMiddleware.RequirePermission(models.PermissionUserCreate), membershipHandler.CreateInvite)
+			tenantScopedRoutes.DELETE("/invites/:inviteId", authMiddleware.RequireSession(), permissionMiddleware.RequirePermission(models.PermissionUserWrite), membershipHandler.RevokeInvite)
+
+			// Ownership transfer routes
+			tenantScopedRoutes.GET("/ownership-transfer", permissionMiddleware.RequireTokenScope(models.PermissionTenantManage), ownershipHandler.GetTransfer)
+			tenantScopedRoutes.POST("/ownership-transfer", authMiddleware.RequireStepUp(), tenantMiddleware.TenantOwnerOnly(), ownershipHandler.RequestTransfer)
+			tenantScopedRoutes.DELETE("/ownership-transfer", authMiddleware.RequireSession(), tenantMiddleware.TenantOwnerOnly(), ownershipHandler.CancelTransfer)
+
+			// Discord role permission mapping routes
+			tenantScopedRoutes.GET("/discord-roles", permissionMiddleware.RequirePermission(models.PermissionRoleRead), rbacHandler.GetDiscordRoles)
+			tenantScopedRoutes.PUT("/discord-roles/:roleId", authMiddleware.RequireSession(), permissionMiddleware.RequirePermission(models.PermissionRoleWrite), rbacHandler.UpdateDiscordRoleMapping)
+
+			// Policy as code
+			tenantScopedRoutes.GET("/policy", permissionMiddleware.RequirePermission(models.PermissionRoleRead), rbacHandler.ExportPolicy)
+			tenantScopedRoutes.POST("/policy", authMiddleware.RequireStepUp(), permissionMiddleware.RequireAllPermissions(models.PermissionRoleWrite, models.PermissionUserWrite), rbacHandler.ImportPolicy)
+
+			// Authorization decision explanations
+			tenantScopedRoutes.GET("/authz/explain", permissionMiddleware.RequirePermission(models.PermissionRoleRead), rbacHandler.ExplainPermission)
+
+			// Service account routes
+			tenantScopedRoutes.GET("/service-accounts", permissionMiddleware.RequirePermission(models.PermissionUserRead), apiTokenHandler.ListServiceAccounts)
+			tenantScopedRoutes.POST("/service-accounts", authMiddleware.RequireSession(), permissionMiddleware.RequirePermission(models.PermissionUserCreate), apiTokenHandler.CreateServiceAccount)
+			tenantScopedRoutes.DELETE("/service-accounts/:id", authMiddleware.RequireStepUp(), permissionMiddleware.RequirePermission(models.PermissionUserDelete), apiTokenHandler.DeleteServiceAccount)
+			tenantScopedRoutes.GET("/service-accounts/:id/tokens", permissionMiddleware.RequirePermission(models.PermissionUserRead), apiTokenHandler.ListServiceAccountTokens)
+			tenantScopedRoutes.POST("/service-accounts/:id/tokens", authMiddleware.RequireSession(), permissionMiddleware.RequirePermission(models.PermissionUserWrite), apiTokenHandler.CreateServiceAccountToken)
+			tenantScopedRoutes.DELETE("/service-accounts/:id/tokens/:tokenId", authMiddleware.RequireSession(), permissionMiddleware.RequirePermission(models.PermissionUserWrite), apiTokenHandler.RevokeServiceAccountToken)
 
 			// Tenant info route
-			tenantScopedRoutes.GET("/info", func(c *gin.Context) {
+			tenantScopedRoutes.GET("/info", permissionMiddleware.RequireTokenScope(models.PermissionTenantManage), func(c *gin.Context) {
 				tenant, _ := c.Get("tenant")
 				c.JSON(http.StatusOK, gin.H{
 					"tenant": tenant,
@@ -187,21 +327,36 @@ func main() {
 			})
 		})
 
-		// Controller management routes (admin only)
+		// Controller management routes (superadmin only)
 		controllerRoutes := apiRoutes.Group("/controllers")
+		controllerRoutes.Use(authMiddleware.RequireSession(), permissionMiddleware.RequireSuperAdmin())
 		{
 			controllerRoutes.GET("", controllerHandler.GetAllControllers)
 			controllerRoutes.GET("/:id", controllerHandler.GetControllerStatus)
 			controllerRoutes.POST("/:id/approve", controllerHandler.ApproveController)
 			controllerRoutes.POST("/:id/reject", controllerHandler.RejectController)
+			controllerRoutes.POST("/:id/servers/:serverId", controllerHandler.AssignGameServer)
 		}
 
-		// Admin routes (superadmin only)
+		// Admin routes
 		adminRoutes := apiRoutes.Group("/admin")
+		adminRoutes.Use(authMiddleware.RequireSession())
 		{
+			// Any user may ask whether they are a superadmin
 			adminRoutes.GET("/check-access", adminHandler.CheckAccess)
-			adminRoutes.GET("/stats", adminHandler.GetStats)
-			adminRoutes.POST("/cleanup-controllers", adminHandler.CleanupInactiveControllers)
+
+			superAdminRoutes := adminRoutes.Group("")
+			superAdminRoutes.Use(permissionMiddleware.RequireSuperAdmin())
+			superAdminRoutes.GET("/stats", adminHandler.GetStats)
+			superAdminRoutes.POST("/cleanup-controllers", adminHandler.CleanupInactiveControllers)
+			superAdminRoutes.POST("/users/:id/logout", adminHandler.ForceLogoutUser)
+			superAdminRoutes.GET("/system-roles", adminHandler.ListSystemRoles)
+			superAdminRoutes.GET("/users/:id/system-roles", adminHandler.GetUserSystemRoles)
+			superAdminRoutes.POST("/users/:id/system-roles", authMiddleware.RequireStepUp(), adminHandler.GrantSystemRole)
+			superAdminRoutes.DELETE("/users/:id/system-roles/:role", authMiddleware.RequireStepUp(), adminHandler.RevokeSystemRole)
+			superAdminRoutes.GET("/two-factor-policy", twoFactorHandler.GetPolicy)
+			superAdminRoutes.PUT("/two-factor-policy", twoFactorHandler.UpdatePolicy)
+			superAdminRoutes.PUT("/tenants/:id/owner", authMiddleware.RequireStepUp(), ownershipHandler.ForceTransfer)
 		}
 	}
 
@@ -210,6 +365,7 @@ func main() {
 	{
 		controllerRoutes.POST("/handshake", controllerHandler.Handshake)
 		controllerRoutes.POST("/heartbeat", controllerMiddleware.RequireControllerAuth(), controllerHandler.Heartbeat)
+		controllerRoutes.POST("/events", controllerMiddleware.RequireControllerAuth(), controllerHandler.ReportServerEvent)
 	}
 
 	// Setup HTTP server
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"strconv"
 	"strings"
@@ -16,6 +17,7 @@ type Config struct {
 	Database   DatabaseConfig
 	Controller ControllerConfig
 	RBAC       RBACConfig
+	OIDC       OIDCConfig
 }
 
 // ServerConfig holds server configuration
@@ -33,14 +35,24 @@ type DiscordConfig struct {
 	RedirectURL  string
 	BotToken     string
 	APIBaseURL   string
+	// ReconcileInterval is how often the bot runs a full role and member sync
+	ReconcileInterval time.Duration
 }
 
+// DefaultJWTSecret is the development fallback for JWT_SECRET. It is public,
+// so tokens signed with it can be forged by anyone.
+const DefaultJWTSecret = "your-secret-key-change-in-production"
+
 // JWTConfig holds JWT configuration
 type JWTConfig struct {
 	Secret          string
 	AccessTokenTTL  time.Duration
 	RefreshTokenTTL time.Duration
 	Issuer          string
+	// SigningKeyFiles are PEM encoded RSA or Ed25519 keys. The first one signs
+	// new tokens; the others are only used to verify tokens during a rotation.
+	// When empty, tokens are signed with Secret using HS256.
+	SigningKeyFiles []string
 }
 
 // RedisConfig holds Redis configuration
@@ -68,12 +80,33 @@ type ControllerConfig struct {
 	MaxHeartbeatAge time.Duration
 }
 
+// OIDCConfig holds the OpenID Connect login providers offered alongside Discord
+type OIDCConfig struct {
+	Providers []OIDCProviderConfig
+}
+
+// OIDCProviderConfig holds the configuration of a single OpenID Connect provider
+type OIDCProviderConfig struct {
+	Name         string // Identifier used in URLs, e.g. "authentik"
+	DisplayName  string
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	// Claims read from the ID token to fill in the user's profile
+	UsernameClaim string
+	EmailClaim    string
+	AvatarClaim   string
+}
+
 // RBACConfig holds RBAC system configuration
 type RBACConfig struct {
 	SuperAdminDiscordID string
 	RoleSyncTTL         time.Duration
 	GuildCacheTTL       time.Duration
 	GracePeriod         time.Duration
+	PermissionCacheTTL  time.Duration
 }
 
 // Load loads configuration from environment variables
@@ -86,17 +119,19 @@ func Load() *Config {
 			AllowOrigins: getAllowedOrigins(),
 		},
 		Discord: DiscordConfig{
-			ClientID:     getEnv("DISCORD_CLIENT_ID", ""),
-			ClientSecret: getEnv("DISCORD_CLIENT_SECRET", ""),
-			RedirectURL:  getEnv("DISCORD_REDIRECT_URL", "http://localhost:8080/auth/callback"),
-			BotToken:     getEnv("DISCORD_BOT_TOKEN", ""),
-			APIBaseURL:   "https://discord.com/api/v10",
+			ClientID:          getEnv("DISCORD_CLIENT_ID", ""),
+			ClientSecret:      getEnv("DISCORD_CLIENT_SECRET", ""),
+			RedirectURL:       getEnv("DISCORD_REDIRECT_URL", "http://localhost:8080/auth/callback"),
+			BotToken:          getEnv("DISCORD_BOT_TOKEN", ""),
+			APIBaseURL:        "https://discord.com/api/v10",
+			ReconcileInterval: getEnvAsDuration("DISCORD_RECONCILE_INTERVAL", time.Hour*6),
 		},
 		JWT: JWTConfig{
-			Secret:          getEnv("JWT_SECRET", "your-secret-key-change-in-production"),
+			Secret:          getEnv("JWT_SECRET", DefaultJWTSecret),
 			AccessTokenTTL:  time.Hour,
 			RefreshTokenTTL: time.Hour * 24 * 7, // 7 days
 			Issuer:          getEnv("JWT_ISSUER", "pteronimbus"),
+			SigningKeyFiles: splitAndTrim(getEnv("JWT_SIGNING_KEY_FILES", ""), ","),
 		},
 		Redis: RedisConfig{
 			Host:     getEnv("REDIS_HOST", "localhost"),
@@ -122,12 +157,25 @@ func Load() *Config {
 			RoleSyncTTL:         time.Minute * 5,  // 5 minutes
 			GuildCacheTTL:       time.Minute * 5,  // 5 minutes
 			GracePeriod:         time.Minute * 2,  // 2 minutes for security
+			PermissionCacheTTL:  getEnvAsDuration("PERMISSION_CACHE_TTL", time.Minute*5),
+		},
+		OIDC: OIDCConfig{
+			Providers: getOIDCProviders(),
 		},
 	}
 
 	return config
 }
 
+// Validate checks for configuration that is unsafe to run with
+func (c *Config) Validate() error {
+	if c.Server.Environment == "production" && len(c.JWT.SigningKeyFiles) == 0 &&
+		(c.JWT.Secret == "" || c.JWT.Secret == DefaultJWTSecret) {
+		return errors.New("JWT_SECRET must be changed from its default, or JWT_SIGNING_KEY_FILES set, in production")
+	}
+	return nil
+}
+
 // getEnv gets an environment variable with a fallback value
 func getEnv(key, fallback string) string {
 	if value := os.Getenv(key); value != "" {
@@ -146,6 +194,39 @@ func getEnvAsInt(key string, fallback int) int {
 	return fallback
 }
 
+// getEnvAsDuration gets an environment variable as a duration (e.g. "30m") with a fallback value
+func getEnvAsDuration(key string, fallback time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return fallback
+}
+
+// getOIDCProviders reads the providers listed in OIDC_PROVIDERS (e.g. "authentik,keycloak").
+// Each provider is configured with OIDC_<NAME>_* variables such as OIDC_AUTHENTIK_ISSUER_URL.
+func getOIDCProviders() []OIDCProviderConfig {
+	providers := []OIDCProviderConfig{}
+	for _, name := range splitAndTrim(getEnv("OIDC_PROVIDERS", ""), ",") {
+		name = strings.ToLower(name)
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		providers = append(providers, OIDCProviderConfig{
+			Name:          name,
+			DisplayName:   getEnv(prefix+"DISPLAY_NAME", name),
+			IssuerURL:     getEnv(prefix+"ISSUER_URL", ""),
+			ClientID:      getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret:  getEnv(prefix+"CLIENT_SECRET", ""),
+			RedirectURL:   getEnv(prefix+"REDIRECT_URL", "http://localhost:8080/auth/oidc/"+name+"/callback"),
+			Scopes:        splitAndTrim(getEnv(prefix+"SCOPES", "openid,profile,email"), ","),
+			UsernameClaim: getEnv(prefix+"USERNAME_CLAIM", "preferred_username"),
+			EmailClaim:    getEnv(prefix+"EMAIL_CLAIM", "email"),
+			AvatarClaim:   getEnv(prefix+"AVATAR_CLAIM", "picture"),
+		})
+	}
+	return providers
+}
+
 // getAllowedOrigins returns the list of allowed CORS origins
 func getAllowedOrigins() []string {
 	// Get the primary frontend URL
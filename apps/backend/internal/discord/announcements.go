@@ -0,0 +1,426 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/services"
+)
+
+// Announcement approval button actions
+const (
+	announceApprove = "approve"
+	announceReject  = "reject"
+)
+
+var announceCommand = &discordgo.ApplicationCommand{
+	Name:        "announce",
+	Description: "Post an announcement in an allowed channel",
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:         discordgo.ApplicationCommandOptionChannel,
+			Name:         "channel",
+			Description:  "The channel to post the announcement in",
+			Required:     true,
+			ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "message",
+			Description: "The announcement text",
+			MaxLength:   2000,
+		},
+		{
+			Type:         discordgo.ApplicationCommandOptionString,
+			Name:         "template",
+			Description:  "An announcement template, such as a server status card",
+			Autocomplete: true,
+		},
+		{
+			Type:         discordgo.ApplicationCommandOptionString,
+			Name:         "server",
+			Description:  "The game server used by the template",
+			Autocomplete: true,
+		},
+	},
+}
+
+// announcementAccess is what a member may do with announcements in a tenant
+type announcementAccess struct {
+	Tenant     *models.Tenant
+	UserID     string // Empty when the Discord account is not linked
+	CanPublish bool   // Holds PermissionAnnouncementPublish and skips approval
+	CanRequest bool   // Has a role allowed to submit announcements for approval
+}
+
+// resolveAnnouncementAccess resolves the invoking member's announcement rights.
+// A non-empty message is a user-facing denial.
+func (b *Bot) resolveAnnouncementAccess(ctx context.Context, i *discordgo.InteractionCreate) (*announcementAccess, string) {
+	if i.GuildID == "" {
+		return nil, "Announcements can only be made inside a Discord server."
+	}
+
+	user := interactionUser(i)
+	if user == nil {
+		return nil, "Could not determine who sent this command."
+	}
+
+	tenant, err := b.tenants.GetTenantByDiscordServerID(ctx, i.GuildID)
+	if err != nil {
+		return nil, "Pteronimbus is not set up for this Discord server."
+	}
+
+	access := &announcementAccess{Tenant: tenant}
+	if i.Member != nil {
+		access.CanRequest = tenant.Config.Announcements.RoleAllowed(i.Member.Roles)
+	}
+
+	linked, err := b.users.GetUserByDiscordID(ctx, user.ID)
+	if err == nil {
+		access.UserID = linked.ID
+		access.CanPublish, err = b.permissions.HasPermission(ctx, linked.ID, tenant.ID, models.PermissionAnnouncementPublish)
+		if err != nil {
+			fmt.Printf("Error checking announcement permission for user %s: %v\n", linked.ID, err)
+			access.CanPublish = false
+		}
+	} else if !errors.Is(err, services.ErrUserNotFound) {
+		fmt.Printf("Error resolving Discord user %s: %v\n", user.ID, err)
+	}
+
+	if !access.CanPublish && !access.CanRequest {
+		return nil, fmt.Sprintf("You need the `%s` permission or an announcer role to make announcements.", models.PermissionAnnouncementPublish)
+	}
+	return access, ""
+}
+
+func (b *Bot) handleAnnounce(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	ctx := context.Background()
+
+	access, denied := b.resolveAnnouncementAccess(ctx, i)
+	if denied != "" {
+		respondEphemeral(s, i, denied)
+		return
+	}
+	policy := access.Tenant.Config.Announcements
+
+	options := i.ApplicationCommandData().Options
+	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
+	for _, opt := range options {
+		optionMap[opt.Name] = opt
+	}
+
+	channelID := ""
+	if opt, ok := optionMap["channel"]; ok {
+		channelID = opt.ChannelValue(nil).ID
+	}
+	if !policy.ChannelAllowed(channelID) {
+		respondEphemeral(s, i, "Announcements are not allowed in that channel.")
+		return
+	}
+
+	var template *models.AnnouncementTemplate
+	if opt, ok := optionMap["template"]; ok {
+		found, ok := policy.FindTemplate(opt.StringValue())
+		if !ok {
+			respondEphemeral(s, i, fmt.Sprintf("No announcement template named **%s** exists.", opt.StringValue()))
+			return
+		}
+		template = &found
+	}
+
+	var server *models.GameServer
+	if opt, ok := optionMap["server"]; ok {
+		servers, err := b.servers.GetTenantServers(ctx, access.Tenant.ID)
+		if err != nil {
+			fmt.Printf("Error listing servers for tenant %s: %v\n", access.Tenant.ID, err)
+			respondEphemeral(s, i, "Failed to load game servers.")
+			return
+		}
+		server = findServer(servers, opt.StringValue())
+		if server == nil {
+			respondEphemeral(s, i, fmt.Sprintf("No game server named **%s** was found.", opt.StringValue()))
+			return
+		}
+	}
+
+	message := ""
+	if opt, ok := optionMap["message"]; ok {
+		message = opt.StringValue()
+	}
+
+	embed, invalid := renderAnnouncement(template, message, server)
+	if invalid != "" {
+		respondEphemeral(s, i, invalid)
+		return
+	}
+
+	requester := interactionUser(i)
+	audit := map[string]interface{}{
+		"user_id":         access.UserID,
+		"discord_user_id": requester.ID,
+		"tenant_id":       access.Tenant.ID,
+		"guild_id":        i.GuildID,
+		"channel_id":      channelID,
+		"message":         message,
+	}
+	if template != nil {
+		audit["template"] = template.Name
+	}
+
+	if access.CanPublish {
+		b.auditService.Log("announcement_published", audit)
+		if err := publishAnnouncement(s, channelID, []*discordgo.MessageEmbed{embed}); err != nil {
+			fmt.Printf("Error posting announcement to channel %s: %v\n", channelID, err)
+			respondEphemeral(s, i, "Failed to post the announcement.")
+			return
+		}
+		respondEphemeral(s, i, fmt.Sprintf("Announcement posted in <#%s>.", channelID))
+		return
+	}
+
+	if policy.ApprovalChannelID == "" || !channelInGuild(s, policy.ApprovalChannelID, i.GuildID) {
+		respondEphemeral(s, i, "Your announcement needs approval, but no approval channel is configured.")
+		return
+	}
+
+	b.auditService.Log("announcement_requested", audit)
+	_, err := s.ChannelMessageSendComplex(policy.ApprovalChannelID, &discordgo.MessageSend{
+		Content:         fmt.Sprintf("<@%s> wants to post this announcement in <#%s>. Members with `%s` can approve it.", requester.ID, channelID, models.PermissionAnnouncementPublish),
+		Embeds:          []*discordgo.MessageEmbed{embed},
+		Components:      announcementApprovalButtons(channelID, requester.ID),
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		fmt.Printf("Error posting announcement request to channel %s: %v\n", policy.ApprovalChannelID, err)
+		respondEphemeral(s, i, "Failed to submit the announcement for approval.")
+		return
+	}
+	respondEphemeral(s, i, "Your announcement was submitted for approval.")
+}
+
+func (b *Bot) handleAnnounceAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	choices := []*discordgo.ApplicationCommandOptionChoice{}
+	ctx := context.Background()
+
+	access, denied := b.resolveAnnouncementAccess(ctx, i)
+	if focused := focusedOption(i.ApplicationCommandData().Options); denied == "" && focused != nil {
+		switch focused.Name {
+		case "template":
+			choices = templateChoices(access.Tenant.Config.Announcements.AvailableTemplates(), focused.StringValue())
+		case "server":
+			servers, err := b.servers.GetTenantServers(ctx, access.Tenant.ID)
+			if err == nil {
+				choices = serverChoices(servers, focused.StringValue())
+			}
+		}
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{
+			Choices: choices,
+		},
+	})
+}
+
+func (b *Bot) handleAnnounceButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	action, channelID, requesterID, ok := parseAnnouncementButtonID(i.MessageComponentData().CustomID)
+	if !ok {
+		return
+	}
+	ctx := context.Background()
+
+	actor, denied := b.authorize(ctx, i, models.PermissionAnnouncementPublish)
+	if denied != "" {
+		respondEphemeral(s, i, denied)
+		return
+	}
+
+	// Only requests in the server's own approval channel can be decided, so
+	// that a request posted into another server cannot be approved there
+	tenant, err := b.tenants.GetTenantByDiscordServerID(ctx, i.GuildID)
+	if err != nil || i.ChannelID != tenant.Config.Announcements.ApprovalChannelID {
+		respondEphemeral(s, i, "This request was not made in this server's approval channel.")
+		return
+	}
+
+	audit := map[string]interface{}{
+		"user_id":              actor.UserID,
+		"discord_user_id":      actor.DiscordUserID,
+		"tenant_id":            actor.TenantID,
+		"guild_id":             i.GuildID,
+		"channel_id":           channelID,
+		"requested_by_discord": requesterID,
+	}
+
+	content := fmt.Sprintf("Announcement by <@%s> for <#%s> was rejected by <@%s>.", requesterID, channelID, actor.DiscordUserID)
+	if action == announceApprove {
+		// The policy may have changed since the request was made
+		if !tenant.Config.Announcements.ChannelAllowed(channelID) || !channelInGuild(s, channelID, i.GuildID) {
+			respondEphemeral(s, i, "Announcements are no longer allowed in that channel.")
+			return
+		}
+
+		b.auditService.Log("announcement_approved", audit)
+		if err := publishAnnouncement(s, channelID, i.Message.Embeds); err != nil {
+			fmt.Printf("Error posting announcement to channel %s: %v\n", channelID, err)
+			respondEphemeral(s, i, "Failed to post the announcement.")
+			return
+		}
+		content = fmt.Sprintf("Announcement by <@%s> was approved by <@%s> and posted in <#%s>.", requesterID, actor.DiscordUserID, channelID)
+	} else {
+		b.auditService.Log("announcement_rejected", audit)
+	}
+
+	// Resolve the request in place so it cannot be approved twice
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:         content,
+			Embeds:          i.Message.Embeds,
+			Components:      []discordgo.MessageComponent{},
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+}
+
+// channelInGuild reports whether a channel belongs to a guild, preferring the
+// session's cache over a request to Discord
+func channelInGuild(s *discordgo.Session, channelID, guildID string) bool {
+	channel, err := s.State.Channel(channelID)
+	if err != nil {
+		channel, err = s.Channel(channelID)
+	}
+	return err == nil && channel.GuildID == guildID
+}
+
+// publishAnnouncement posts announcement embeds without pinging anyone
+func publishAnnouncement(s *discordgo.Session, channelID string, embeds []*discordgo.MessageEmbed) error {
+	_, err := s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Embeds:          embeds,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	return err
+}
+
+// renderAnnouncement builds the announcement embed from an optional template,
+// message and server. A non-empty message explains why nothing could be rendered.
+func renderAnnouncement(template *models.AnnouncementTemplate, message string, server *models.GameServer) (*discordgo.MessageEmbed, string) {
+	if template == nil {
+		if strings.TrimSpace(message) == "" {
+			return nil, "Provide a message or pick a template."
+		}
+		return &discordgo.MessageEmbed{Description: message, Color: 0x5865F2}, ""
+	}
+
+	if template.NeedsServer() && server == nil {
+		return nil, fmt.Sprintf("The **%s** template needs a server.", template.Name)
+	}
+
+	replacements := []string{"{message}", message}
+	if server != nil {
+		address := server.Status.Address
+		if address == "" {
+			address = "unknown address"
+		}
+		replacements = append(replacements,
+			"{server.name}", server.Name,
+			"{server.game}", server.GameType,
+			"{server.status}", server.Status.Phase,
+			"{server.players}", fmt.Sprintf("%d", server.Status.PlayerCount),
+			"{server.address}", address,
+		)
+	}
+	replacer := strings.NewReplacer(replacements...)
+
+	embed := &discordgo.MessageEmbed{Color: 0x5865F2}
+	if template.ServerCard {
+		embed = serverStatusEmbed(server)
+		if server.Status.Address != "" {
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Address", Value: server.Status.Address, Inline: true})
+		}
+		embed.Footer = nil
+	}
+	if template.Title != "" {
+		embed.Title = replacer.Replace(template.Title)
+	}
+	if content := strings.TrimSpace(replacer.Replace(template.Content)); content != "" {
+		embed.Description = content
+	} else if !template.ServerCard && strings.TrimSpace(message) != "" {
+		embed.Description = message
+	}
+
+	if embed.Title == "" && embed.Description == "" && len(embed.Fields) == 0 {
+		return nil, "Provide a message or pick a template."
+	}
+	return embed, ""
+}
+
+// focusedOption returns the option being autocompleted
+func focusedOption(options []*discordgo.ApplicationCommandInteractionDataOption) *discordgo.ApplicationCommandInteractionDataOption {
+	for _, opt := range options {
+		if opt.Focused {
+			return opt
+		}
+	}
+	return nil
+}
+
+// templateChoices builds autocomplete choices for templates whose name contains the query
+func templateChoices(templates []models.AnnouncementTemplate, query string) []*discordgo.ApplicationCommandOptionChoice {
+	query = strings.ToLower(query)
+	choices := []*discordgo.ApplicationCommandOptionChoice{}
+	for _, template := range templates {
+		if !strings.Contains(strings.ToLower(template.Name), query) {
+			continue
+		}
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  template.Name,
+			Value: template.Name,
+		})
+		if len(choices) == maxAutocompleteChoices {
+			break
+		}
+	}
+	return choices
+}
+
+// announcementButtonID encodes an approval button as "announce:<action>:<channelID>:<requesterID>"
+func announcementButtonID(action, channelID, requesterID string) string {
+	return "announce:" + action + ":" + channelID + ":" + requesterID
+}
+
+// parseAnnouncementButtonID decodes a button custom ID created by announcementButtonID
+func parseAnnouncementButtonID(customID string) (action, channelID, requesterID string, ok bool) {
+	parts := strings.Split(customID, ":")
+	if len(parts) != 4 || parts[0] != "announce" || parts[2] == "" || parts[3] == "" {
+		return "", "", "", false
+	}
+	if parts[1] != announceApprove && parts[1] != announceReject {
+		return "", "", "", false
+	}
+	return parts[1], parts[2], parts[3], true
+}
+
+func announcementApprovalButtons(channelID, requesterID string) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "Approve",
+					Style:    discordgo.SuccessButton,
+					CustomID: announcementButtonID(announceApprove, channelID, requesterID),
+				},
+				discordgo.Button{
+					Label:    "Reject",
+					Style:    discordgo.DangerButton,
+					CustomID: announcementButtonID(announceReject, channelID, requesterID),
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,121 @@
+package discord
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBot_ResolveAnnouncementAccess(t *testing.T) {
+	bot := newTestBot()
+	bot.tenants.(*fakeTenants).tenant.Config.Announcements = models.AnnouncementPolicy{
+		AllowedRoleIDs: []string{"role-announcer"},
+	}
+	bot.permissions.(*fakePermissions).granted["user-1|"+models.PermissionAnnouncementPublish] = true
+	ctx := context.Background()
+
+	t.Run("publisher", func(t *testing.T) {
+		access, denied := bot.resolveAnnouncementAccess(ctx, guildInteraction("guild-1", "discord-1"))
+		assert.Empty(t, denied)
+		require.NotNil(t, access)
+		assert.True(t, access.CanPublish)
+		assert.Equal(t, "user-1", access.UserID)
+	})
+
+	t.Run("announcer role needs approval", func(t *testing.T) {
+		i := guildInteraction("guild-1", "discord-2")
+		i.Member.Roles = []string{"role-announcer"}
+		access, denied := bot.resolveAnnouncementAccess(ctx, i)
+		assert.Empty(t, denied)
+		require.NotNil(t, access)
+		assert.False(t, access.CanPublish)
+		assert.True(t, access.CanRequest)
+	})
+
+	t.Run("regular member", func(t *testing.T) {
+		i := guildInteraction("guild-1", "discord-2")
+		i.Member.Roles = []string{"role-member"}
+		access, denied := bot.resolveAnnouncementAccess(ctx, i)
+		assert.Nil(t, access)
+		assert.Contains(t, denied, models.PermissionAnnouncementPublish)
+	})
+}
+
+func TestRenderAnnouncement(t *testing.T) {
+	server := &models.GameServer{
+		ID:       "1",
+		Name:     "Survival World",
+		GameType: "minecraft",
+		Status:   models.GameServerStatus{Phase: "Running", PlayerCount: 4, Address: "mc.example.com:25565"},
+	}
+
+	t.Run("plain message", func(t *testing.T) {
+		embed, invalid := renderAnnouncement(nil, "Maintenance tonight", nil)
+		assert.Empty(t, invalid)
+		assert.Equal(t, "Maintenance tonight", embed.Description)
+
+		_, invalid = renderAnnouncement(nil, "  ", nil)
+		assert.NotEmpty(t, invalid)
+	})
+
+	t.Run("placeholders", func(t *testing.T) {
+		template := &models.AnnouncementTemplate{Name: "join", Title: "Join {server.name}", Content: "{server.players} online at {server.address}. {message}"}
+		embed, invalid := renderAnnouncement(template, "See you there!", server)
+		assert.Empty(t, invalid)
+		assert.Equal(t, "Join Survival World", embed.Title)
+		assert.Equal(t, "4 online at mc.example.com:25565. See you there!", embed.Description)
+	})
+
+	t.Run("server card", func(t *testing.T) {
+		embed, invalid := renderAnnouncement(&models.AnnouncementTemplate{Name: "server-status", ServerCard: true}, "", server)
+		assert.Empty(t, invalid)
+		assert.Equal(t, "Survival World", embed.Title)
+		assert.Equal(t, "Address", embed.Fields[len(embed.Fields)-1].Name)
+	})
+
+	t.Run("template needs server", func(t *testing.T) {
+		_, invalid := renderAnnouncement(&models.AnnouncementTemplate{Name: "server-status", ServerCard: true}, "", nil)
+		assert.Contains(t, invalid, "needs a server")
+	})
+}
+
+func TestAnnouncementButtonID(t *testing.T) {
+	action, channelID, requesterID, ok := parseAnnouncementButtonID(announcementButtonID(announceApprove, "chan-1", "discord-2"))
+	assert.True(t, ok)
+	assert.Equal(t, announceApprove, action)
+	assert.Equal(t, "chan-1", channelID)
+	assert.Equal(t, "discord-2", requesterID)
+
+	for _, customID := range []string{"announce:approve:chan-1", "announce:publish:chan-1:discord-2", "server:approve:chan-1:discord-2", "announce:reject::discord-2"} {
+		_, _, _, ok := parseAnnouncementButtonID(customID)
+		assert.False(t, ok, customID)
+	}
+}
+
+func TestChannelInGuild(t *testing.T) {
+	s, err := discordgo.New("")
+	require.NoError(t, err)
+	for _, guildID := range []string{"guild-1", "guild-2"} {
+		require.NoError(t, s.State.GuildAdd(&discordgo.Guild{ID: guildID}))
+	}
+	require.NoError(t, s.State.ChannelAdd(&discordgo.Channel{ID: "chan-1", GuildID: "guild-1"}))
+	require.NoError(t, s.State.ChannelAdd(&discordgo.Channel{ID: "chan-2", GuildID: "guild-2"}))
+
+	assert.True(t, channelInGuild(s, "chan-1", "guild-1"))
+	assert.False(t, channelInGuild(s, "chan-2", "guild-1"))
+}
+
+func TestFocusedOption(t *testing.T) {
+	options := []*discordgo.ApplicationCommandInteractionDataOption{
+		{Name: "template", Type: discordgo.ApplicationCommandOptionString, Value: "server"},
+		{Name: "server", Type: discordgo.ApplicationCommandOptionString, Value: "surv", Focused: true},
+	}
+	focused := focusedOption(options)
+	require.NotNil(t, focused)
+	assert.Equal(t, "server", focused.Name)
+	assert.Nil(t, focusedOption(options[:1]))
+}
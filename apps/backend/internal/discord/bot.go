@@ -1,9 +1,12 @@
 package discord
 
 import (
+	"context"
 	"fmt"
+	"strings"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
 )
 
 var (
@@ -16,27 +19,14 @@ var (
 			Name:        "sync",
 			Description: "Sync roles and users from Discord",
 		},
-		{
-			Name:        "say",
-			Description: "Make the bot say something in a channel",
-			Options: []*discordgo.ApplicationCommandOption{
-				{
-					Type:        discordgo.ApplicationCommandOptionChannel,
-					Name:        "channel",
-					Description: "The channel to send the message to",
-					Required:    true,
-				},
-				{
-					Type:        discordgo.ApplicationCommandOptionString,
-					Name:        "message",
-					Description: "The message to send",
-					Required:    true,
-				},
-			},
-		},
+		serverCommand,
+		announceCommand,
 	}
 
-	commandHandlers = map[string]func(s *discordgo.Session, i *discordgo.InteractionCreate){}
+	commandHandlers      = map[string]func(s *discordgo.Session, i *discordgo.InteractionCreate){}
+	autocompleteHandlers = map[string]func(s *discordgo.Session, i *discordgo.InteractionCreate){}
+	// componentHandlers are keyed by the prefix of the component's custom ID
+	componentHandlers = map[string]func(s *discordgo.Session, i *discordgo.InteractionCreate){}
 )
 
 type Bot struct {
@@ -44,6 +34,11 @@ type Bot struct {
 	token        string
 	syncService  Syncer
 	auditService Auditer
+	tenants      TenantResolver
+	users        UserResolver
+	permissions  PermissionChecker
+	servers      ServerManager
+	stopSync     chan struct{}
 }
 
 type Auditer interface {
@@ -53,9 +48,36 @@ type Auditer interface {
 type Syncer interface {
 	SyncRoles(tenantID string, guildID string) error
 	SyncUsers(tenantID string, guildID string) error
+	ReconcileAll() error
+	UpsertMember(guildID string, member *discordgo.Member) error
+	RemoveMember(guildID string, discordUserID string) error
+	UpsertRole(guildID string, role *discordgo.Role) error
+	DeleteRole(guildID string, discordRoleID string) error
+	UpdateGuild(guild *discordgo.Guild) error
+}
+
+// TenantResolver resolves the tenant installed in a Discord guild
+type TenantResolver interface {
+	GetTenantByDiscordServerID(ctx context.Context, discordServerID string) (*models.Tenant, error)
+}
+
+// UserResolver resolves the Pteronimbus user linked to a Discord account
+type UserResolver interface {
+	GetUserByDiscordID(ctx context.Context, discordUserID string) (*models.User, error)
+}
+
+// PermissionChecker authorizes a user's actions within a tenant
+type PermissionChecker interface {
+	HasPermission(ctx context.Context, userID, tenantID, permission string) (bool, error)
+}
+
+// ServerManager exposes the game server operations available from Discord
+type ServerManager interface {
+	GetTenantServers(ctx context.Context, tenantID string) ([]models.GameServer, error)
+	RequestPowerAction(ctx context.Context, tenantID, serverID, action string) (*models.GameServer, error)
 }
 
-func NewBot(token string, syncService Syncer, auditService Auditer) (*Bot, error) {
+func NewBot(token string, syncService Syncer, auditService Auditer, tenants TenantResolver, users UserResolver, permissions PermissionChecker, servers ServerManager) (*Bot, error) {
 	s, err := discordgo.New("Bot " + token)
 	if err != nil {
 		return nil, fmt.Errorf("error creating Discord session: %w", err)
@@ -65,7 +87,14 @@ func NewBot(token string, syncService Syncer, auditService Auditer) (*Bot, error
 		token:        token,
 		syncService:  syncService,
 		auditService: auditService,
+		tenants:      tenants,
+		users:        users,
+		permissions:  permissions,
+		servers:      servers,
+		stopSync:     make(chan struct{}),
 	}
+	// Member events are privileged and must also be enabled in the developer portal
+	s.Identify.Intents |= discordgo.IntentsGuildMembers
 	bot.setupHandlers()
 	return bot, nil
 }
@@ -73,7 +102,12 @@ func NewBot(token string, syncService Syncer, auditService Auditer) (*Bot, error
 func (b *Bot) setupHandlers() {
 	commandHandlers["ping"] = b.handlePing
 	commandHandlers["sync"] = b.handleSync
-	commandHandlers["say"] = b.handleSay
+	commandHandlers["server"] = b.handleServer
+	autocompleteHandlers["server"] = b.handleServerAutocomplete
+	componentHandlers["server"] = b.handleServerButton
+	commandHandlers["announce"] = b.handleAnnounce
+	autocompleteHandlers["announce"] = b.handleAnnounceAutocomplete
+	componentHandlers["announce"] = b.handleAnnounceButton
 }
 
 func (b *Bot) handlePing(s *discordgo.Session, i *discordgo.InteractionCreate) {
@@ -89,42 +123,6 @@ func (b *Bot) handlePing(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	})
 }
 
-func (b *Bot) handleSay(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	options := i.ApplicationCommandData().Options
-	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
-	for _, opt := range options {
-		optionMap[opt.Name] = opt
-	}
-
-	channel := optionMap["channel"].ChannelValue(s)
-	message := optionMap["message"].StringValue()
-
-	b.auditService.Log("say_command", map[string]interface{}{
-		"user_id":    i.Member.User.ID,
-		"guild_id":   i.GuildID,
-		"channel_id": channel.ID,
-		"message":    message,
-	})
-
-	_, err := b.SendMessage(channel.ID, message)
-	if err != nil {
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: "Failed to send message.",
-			},
-		})
-		return
-	}
-
-	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Content: "Message sent!",
-		},
-	})
-}
-
 func (b *Bot) handleSync(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	// Acknowledge the interaction immediately.
 	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
@@ -140,10 +138,16 @@ func (b *Bot) handleSync(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		"guild_id": i.GuildID,
 	})
 
-	// For now, hardcoding tenant and guild ID.
-	// This will be replaced with dynamic values later.
-	tenantID := "f9c1b2a9-7c9c-4f7d-8e4a-5f0e1c2b3a4d" // Example UUID
 	guildID := i.GuildID
+	tenant, err := b.tenants.GetTenantByDiscordServerID(context.Background(), guildID)
+	if err != nil {
+		content := "Pteronimbus is not set up for this Discord server."
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			Content: &content,
+		})
+		return
+	}
+	tenantID := tenant.ID
 
 	err = b.syncService.SyncRoles(tenantID, guildID)
 	if err != nil {
@@ -174,9 +178,23 @@ func (b *Bot) Start() error {
 		fmt.Printf("Logged in as: %v#%v\n", s.State.User.Username, s.State.User.Discriminator)
 	})
 
+	b.addSyncHandlers()
+
 	b.Session.AddHandler(func(s *discordgo.Session, i *discordgo.InteractionCreate) {
-		if h, ok := commandHandlers[i.ApplicationCommandData().Name]; ok {
-			h(s, i)
+		switch i.Type {
+		case discordgo.InteractionApplicationCommand:
+			if h, ok := commandHandlers[i.ApplicationCommandData().Name]; ok {
+				h(s, i)
+			}
+		case discordgo.InteractionApplicationCommandAutocomplete:
+			if h, ok := autocompleteHandlers[i.ApplicationCommandData().Name]; ok {
+				h(s, i)
+			}
+		case discordgo.InteractionMessageComponent:
+			prefix, _, _ := strings.Cut(i.MessageComponentData().CustomID, ":")
+			if h, ok := componentHandlers[prefix]; ok {
+				h(s, i)
+			}
 		}
 	})
 
@@ -201,6 +219,8 @@ func (b *Bot) Start() error {
 }
 
 func (b *Bot) Stop() {
+	close(b.stopSync)
+
 	fmt.Println("Removing commands...")
 	for _, v := range commands {
 		err := b.Session.ApplicationCommandDelete(b.Session.State.User.ID, "", v.ID)
@@ -210,6 +230,14 @@ func (b *Bot) Stop() {
 	}
 }
 
+// interactionUser returns the user who triggered an interaction, in a guild or a DM
+func interactionUser(i *discordgo.InteractionCreate) *discordgo.User {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User
+	}
+	return i.User
+}
+
 func (b *Bot) SendMessage(channelID string, message string) (*discordgo.Message, error) {
 	return b.Session.ChannelMessageSend(channelID, message)
 }
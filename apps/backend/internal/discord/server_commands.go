@@ -0,0 +1,417 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/services"
+)
+
+// maxAutocompleteChoices is the most choices Discord accepts in an autocomplete response
+const maxAutocompleteChoices = 25
+
+var serverNameOption = &discordgo.ApplicationCommandOption{
+	Type:         discordgo.ApplicationCommandOptionString,
+	Name:         "name",
+	Description:  "The game server name",
+	Required:     true,
+	Autocomplete: true,
+}
+
+var serverCommand = &discordgo.ApplicationCommand{
+	Name:        "server",
+	Description: "Manage your community's game servers",
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "list",
+			Description: "List the game servers of this Discord server",
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "status",
+			Description: "Show the status of a game server",
+			Options:     []*discordgo.ApplicationCommandOption{serverNameOption},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "start",
+			Description: "Start a game server",
+			Options:     []*discordgo.ApplicationCommandOption{serverNameOption},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "stop",
+			Description: "Stop a game server",
+			Options:     []*discordgo.ApplicationCommandOption{serverNameOption},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "restart",
+			Description: "Restart a game server",
+			Options:     []*discordgo.ApplicationCommandOption{serverNameOption},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "players",
+			Description: "Show who is playing on a game server",
+			Options:     []*discordgo.ApplicationCommandOption{serverNameOption},
+		},
+	},
+}
+
+// serverSubcommandPermissions maps /server subcommands to the permission they require
+var serverSubcommandPermissions = map[string]string{
+	"list":                      models.PermissionServerRead,
+	"status":                    models.PermissionServerRead,
+	"players":                   models.PermissionServerRead,
+	services.PowerActionStart:   models.PermissionServerStart,
+	services.PowerActionStop:    models.PermissionServerStop,
+	services.PowerActionRestart: models.PermissionServerRestart,
+}
+
+// commandActor is a Discord member resolved to a Pteronimbus user within a tenant
+type commandActor struct {
+	TenantID      string
+	UserID        string
+	DiscordUserID string
+}
+
+// authorize resolves the invoking member to a Pteronimbus user and checks the
+// permission in the guild's tenant. A non-empty message is a user-facing denial.
+func (b *Bot) authorize(ctx context.Context, i *discordgo.InteractionCreate, permission string) (*commandActor, string) {
+	if i.GuildID == "" {
+		return nil, "Server commands can only be used inside a Discord server."
+	}
+
+	user := interactionUser(i)
+	if user == nil {
+		return nil, "Could not determine who sent this command."
+	}
+
+	tenant, err := b.tenants.GetTenantByDiscordServerID(ctx, i.GuildID)
+	if err != nil {
+		return nil, "Pteronimbus is not set up for this Discord server."
+	}
+
+	linked, err := b.users.GetUserByDiscordID(ctx, user.ID)
+	if err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			return nil, "Your Discord account is not linked to Pteronimbus. Log in to the dashboard first."
+		}
+		fmt.Printf("Error resolving Discord user %s: %v\n", user.ID, err)
+		return nil, "Something went wrong while checking your permissions."
+	}
+
+	allowed, err := b.permissions.HasPermission(ctx, linked.ID, tenant.ID, permission)
+	if err != nil {
+		fmt.Printf("Error checking permission %s for user %s: %v\n", permission, linked.ID, err)
+		return nil, "Something went wrong while checking your permissions."
+	}
+	if !allowed {
+		return nil, fmt.Sprintf("You need the `%s` permission to do that.", permission)
+	}
+
+	return &commandActor{
+		TenantID:      tenant.ID,
+		UserID:        linked.ID,
+		DiscordUserID: user.ID,
+	}, ""
+}
+
+func (b *Bot) handleServer(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	options := i.ApplicationCommandData().Options
+	if len(options) == 0 {
+		return
+	}
+	sub := options[0]
+	ctx := context.Background()
+
+	actor, denied := b.authorize(ctx, i, serverSubcommandPermissions[sub.Name])
+	if denied != "" {
+		respondEphemeral(s, i, denied)
+		return
+	}
+
+	b.auditService.Log("server_command", map[string]interface{}{
+		"user_id":         actor.UserID,
+		"discord_user_id": actor.DiscordUserID,
+		"tenant_id":       actor.TenantID,
+		"guild_id":        i.GuildID,
+		"subcommand":      sub.Name,
+	})
+
+	servers, err := b.servers.GetTenantServers(ctx, actor.TenantID)
+	if err != nil {
+		fmt.Printf("Error listing servers for tenant %s: %v\n", actor.TenantID, err)
+		respondEphemeral(s, i, "Failed to load game servers.")
+		return
+	}
+
+	if sub.Name == "list" {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Embeds: []*discordgo.MessageEmbed{serverListEmbed(servers)},
+			},
+		})
+		return
+	}
+
+	name := ""
+	if len(sub.Options) > 0 {
+		name = sub.Options[0].StringValue()
+	}
+	server := findServer(servers, name)
+	if server == nil {
+		respondEphemeral(s, i, fmt.Sprintf("No game server named **%s** was found.", name))
+		return
+	}
+
+	switch sub.Name {
+	case "status":
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Embeds:     []*discordgo.MessageEmbed{serverStatusEmbed(server)},
+				Components: serverActionButtons(server),
+			},
+		})
+	case "players":
+		respondEphemeral(s, i, fmt.Sprintf("**%s** has %d player(s) online.", server.Name, server.Status.PlayerCount))
+	default:
+		updated, err := b.servers.RequestPowerAction(ctx, actor.TenantID, server.ID, sub.Name)
+		if err != nil {
+			respondEphemeral(s, i, powerActionErrorMessage(server, sub.Name, err))
+			return
+		}
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content:    fmt.Sprintf("<@%s> requested **%s** for **%s**.", actor.DiscordUserID, sub.Name, updated.Name),
+				Embeds:     []*discordgo.MessageEmbed{serverStatusEmbed(updated)},
+				Components: serverActionButtons(updated),
+			},
+		})
+	}
+}
+
+func (b *Bot) handleServerAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	choices := []*discordgo.ApplicationCommandOptionChoice{}
+
+	options := i.ApplicationCommandData().Options
+	if len(options) > 0 {
+		if actor, denied := b.authorize(context.Background(), i, models.PermissionServerRead); denied == "" {
+			servers, err := b.servers.GetTenantServers(context.Background(), actor.TenantID)
+			if err == nil {
+				choices = serverChoices(servers, focusedValue(options[0].Options))
+			}
+		}
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{
+			Choices: choices,
+		},
+	})
+}
+
+func (b *Bot) handleServerButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	action, serverID, ok := parseServerButtonID(i.MessageComponentData().CustomID)
+	if !ok {
+		return
+	}
+	ctx := context.Background()
+
+	actor, denied := b.authorize(ctx, i, serverSubcommandPermissions[action])
+	if denied != "" {
+		respondEphemeral(s, i, denied)
+		return
+	}
+
+	b.auditService.Log("server_button", map[string]interface{}{
+		"user_id":         actor.UserID,
+		"discord_user_id": actor.DiscordUserID,
+		"tenant_id":       actor.TenantID,
+		"guild_id":        i.GuildID,
+		"server_id":       serverID,
+		"action":          action,
+	})
+
+	updated, err := b.servers.RequestPowerAction(ctx, actor.TenantID, serverID, action)
+	if err != nil {
+		respondEphemeral(s, i, powerActionErrorMessage(&models.GameServer{ID: serverID, Name: "this server"}, action, err))
+		return
+	}
+
+	// Refresh the status embed in place so everyone sees the new phase
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    fmt.Sprintf("<@%s> requested **%s** for **%s**.", actor.DiscordUserID, action, updated.Name),
+			Embeds:     []*discordgo.MessageEmbed{serverStatusEmbed(updated)},
+			Components: serverActionButtons(updated),
+		},
+	})
+}
+
+func respondEphemeral(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+func powerActionErrorMessage(server *models.GameServer, action string, err error) string {
+	if errors.Is(err, services.ErrGameServerNotFound) {
+		return fmt.Sprintf("Could not %s **%s**: the server no longer exists.", action, server.Name)
+	}
+	fmt.Printf("Error requesting %s for server %s: %v\n", action, server.ID, err)
+	return fmt.Sprintf("Failed to %s **%s**.", action, server.Name)
+}
+
+// findServer matches a server by name (case-insensitive) or by ID
+func findServer(servers []models.GameServer, name string) *models.GameServer {
+	for idx := range servers {
+		if strings.EqualFold(servers[idx].Name, name) || servers[idx].ID == name {
+			return &servers[idx]
+		}
+	}
+	return nil
+}
+
+// focusedValue returns the partial input of the option being autocompleted
+func focusedValue(options []*discordgo.ApplicationCommandInteractionDataOption) string {
+	for _, opt := range options {
+		if opt.Focused {
+			return opt.StringValue()
+		}
+	}
+	return ""
+}
+
+// serverChoices builds autocomplete choices for servers whose name contains the query
+func serverChoices(servers []models.GameServer, query string) []*discordgo.ApplicationCommandOptionChoice {
+	query = strings.ToLower(query)
+	choices := []*discordgo.ApplicationCommandOptionChoice{}
+	for _, server := range servers {
+		if !strings.Contains(strings.ToLower(server.Name), query) {
+			continue
+		}
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  server.Name,
+			Value: server.Name,
+		})
+		if len(choices) == maxAutocompleteChoices {
+			break
+		}
+	}
+	return choices
+}
+
+// serverButtonID encodes a power action button as "server:<action>:<serverID>"
+func serverButtonID(action, serverID string) string {
+	return "server:" + action + ":" + serverID
+}
+
+// parseServerButtonID decodes a button custom ID created by serverButtonID
+func parseServerButtonID(customID string) (action, serverID string, ok bool) {
+	parts := strings.SplitN(customID, ":", 3)
+	if len(parts) != 3 || parts[0] != "server" || parts[2] == "" {
+		return "", "", false
+	}
+	switch parts[1] {
+	case services.PowerActionStart, services.PowerActionStop, services.PowerActionRestart:
+		return parts[1], parts[2], true
+	}
+	return "", "", false
+}
+
+func serverActionButtons(server *models.GameServer) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "Start",
+					Style:    discordgo.SuccessButton,
+					CustomID: serverButtonID(services.PowerActionStart, server.ID),
+					Disabled: server.Status.Phase == "Running",
+				},
+				discordgo.Button{
+					Label:    "Stop",
+					Style:    discordgo.DangerButton,
+					CustomID: serverButtonID(services.PowerActionStop, server.ID),
+					Disabled: server.Status.Phase == "Stopped",
+				},
+				discordgo.Button{
+					Label:    "Restart",
+					Style:    discordgo.SecondaryButton,
+					CustomID: serverButtonID(services.PowerActionRestart, server.ID),
+				},
+			},
+		},
+	}
+}
+
+// phaseColor picks an embed color for a server phase
+func phaseColor(phase string) int {
+	switch phase {
+	case "Running":
+		return 0x2ECC71
+	case "Stopped":
+		return 0x95A5A6
+	case "Failed":
+		return 0xE74C3C
+	default:
+		return 0xF1C40F
+	}
+}
+
+func serverStatusEmbed(server *models.GameServer) *discordgo.MessageEmbed {
+	uptime := server.Status.Uptime
+	if uptime == "" {
+		uptime = "-"
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: server.Name,
+		Color: phaseColor(server.Status.Phase),
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Game", Value: server.GameType, Inline: true},
+			{Name: "Status", Value: server.Status.Phase, Inline: true},
+			{Name: "Players", Value: fmt.Sprintf("%d", server.Status.PlayerCount), Inline: true},
+			{Name: "Uptime", Value: uptime, Inline: true},
+		},
+		Footer: &discordgo.MessageEmbedFooter{Text: server.ID},
+	}
+	if server.Status.Message != "" {
+		embed.Description = server.Status.Message
+	}
+	return embed
+}
+
+func serverListEmbed(servers []models.GameServer) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title: "Game servers",
+		Color: 0x5865F2,
+	}
+	if len(servers) == 0 {
+		embed.Description = "No game servers have been created yet."
+		return embed
+	}
+
+	lines := make([]string, 0, len(servers))
+	for _, server := range servers {
+		lines = append(lines, fmt.Sprintf("**%s** (%s) - %s, %d player(s)", server.Name, server.GameType, server.Status.Phase, server.Status.PlayerCount))
+	}
+	embed.Description = strings.Join(lines, "\n")
+	return embed
+}
@@ -0,0 +1,212 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/services"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTenants struct{ tenant *models.Tenant }
+
+func (f *fakeTenants) GetTenantByDiscordServerID(ctx context.Context, discordServerID string) (*models.Tenant, error) {
+	if f.tenant == nil || f.tenant.DiscordServerID != discordServerID {
+		return nil, errors.New("tenant not found")
+	}
+	return f.tenant, nil
+}
+
+type fakeUsers struct{ users map[string]*models.User }
+
+func (f *fakeUsers) GetUserByDiscordID(ctx context.Context, discordUserID string) (*models.User, error) {
+	if user, ok := f.users[discordUserID]; ok {
+		return user, nil
+	}
+	return nil, services.ErrUserNotFound
+}
+
+type fakePermissions struct{ granted map[string]bool }
+
+func (f *fakePermissions) HasPermission(ctx context.Context, userID, tenantID, permission string) (bool, error) {
+	return f.granted[userID+"|"+permission], nil
+}
+
+func newTestBot() *Bot {
+	return &Bot{
+		tenants: &fakeTenants{tenant: &models.Tenant{ID: "tenant-1", DiscordServerID: "guild-1"}},
+		users: &fakeUsers{users: map[string]*models.User{
+			"discord-1": {ID: "user-1", DiscordUserID: "discord-1"},
+		}},
+		permissions: &fakePermissions{granted: map[string]bool{
+			"user-1|" + models.PermissionServerRead: true,
+		}},
+	}
+}
+
+func guildInteraction(guildID, discordUserID string) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		GuildID: guildID,
+		Member:  &discordgo.Member{User: &discordgo.User{ID: discordUserID}},
+	}}
+}
+
+func TestBot_Authorize(t *testing.T) {
+	bot := newTestBot()
+	ctx := context.Background()
+
+	t.Run("allowed", func(t *testing.T) {
+		actor, denied := bot.authorize(ctx, guildInteraction("guild-1", "discord-1"), models.PermissionServerRead)
+		assert.Empty(t, denied)
+		require.NotNil(t, actor)
+		assert.Equal(t, "tenant-1", actor.TenantID)
+		assert.Equal(t, "user-1", actor.UserID)
+	})
+
+	t.Run("missing permission", func(t *testing.T) {
+		actor, denied := bot.authorize(ctx, guildInteraction("guild-1", "discord-1"), models.PermissionServerStart)
+		assert.Nil(t, actor)
+		assert.Contains(t, denied, models.PermissionServerStart)
+	})
+
+	t.Run("unlinked account", func(t *testing.T) {
+		actor, denied := bot.authorize(ctx, guildInteraction("guild-1", "discord-2"), models.PermissionServerRead)
+		assert.Nil(t, actor)
+		assert.Contains(t, denied, "not linked")
+	})
+
+	t.Run("unknown guild", func(t *testing.T) {
+		actor, denied := bot.authorize(ctx, guildInteraction("guild-2", "discord-1"), models.PermissionServerRead)
+		assert.Nil(t, actor)
+		assert.Contains(t, denied, "not set up")
+	})
+
+	t.Run("direct message", func(t *testing.T) {
+		i := &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{User: &discordgo.User{ID: "discord-1"}}}
+		actor, denied := bot.authorize(ctx, i, models.PermissionServerRead)
+		assert.Nil(t, actor)
+		assert.NotEmpty(t, denied)
+	})
+}
+
+func TestServerButtonID(t *testing.T) {
+	action, serverID, ok := parseServerButtonID(serverButtonID(services.PowerActionRestart, "abc"))
+	assert.True(t, ok)
+	assert.Equal(t, services.PowerActionRestart, action)
+	assert.Equal(t, "abc", serverID)
+
+	for _, customID := range []string{"server:start:", "server:explode:abc", "other:start:abc", "server"} {
+		_, _, ok := parseServerButtonID(customID)
+		assert.False(t, ok, customID)
+	}
+}
+
+func TestServerChoicesAndLookup(t *testing.T) {
+	servers := []models.GameServer{
+		{ID: "1", Name: "Survival World"},
+		{ID: "2", Name: "Creative World"},
+		{ID: "3", Name: "Competitive Server"},
+	}
+
+	choices := serverChoices(servers, "world")
+	require.Len(t, choices, 2)
+	assert.Equal(t, "Survival World", choices[0].Name)
+	assert.Len(t, serverChoices(servers, ""), 3)
+
+	assert.Equal(t, "3", findServer(servers, "competitive server").ID)
+	assert.Equal(t, "2", findServer(servers, "2").ID)
+	assert.Nil(t, findServer(servers, "missing"))
+}
+
+type fakeAudit struct{ events []string }
+
+func (f *fakeAudit) Log(event string, details map[string]interface{}) {
+	f.events = append(f.events, event)
+}
+
+// recordedResponse is the part of an interaction response the tests inspect
+type recordedResponse struct {
+	Type discordgo.InteractionResponseType `json:"type"`
+	Data struct {
+		Content string                    `json:"content"`
+		Embeds  []*discordgo.MessageEmbed `json:"embeds"`
+	} `json:"data"`
+}
+
+// recordingTransport captures interaction responses instead of sending them to Discord
+type recordingTransport struct{ responses []recordedResponse }
+
+func (r *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var response recordedResponse
+	if err := json.NewDecoder(req.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	r.responses = append(r.responses, response)
+	return &http.Response{
+		StatusCode: http.StatusNoContent,
+		Body:       io.NopCloser(strings.NewReader("")),
+		Header:     make(http.Header),
+		Request:    req,
+	}, nil
+}
+
+func serverCommandInteraction(guildID, discordUserID, sub, name string) *discordgo.InteractionCreate {
+	i := guildInteraction(guildID, discordUserID)
+	i.Type = discordgo.InteractionApplicationCommand
+	option := &discordgo.ApplicationCommandInteractionDataOption{Name: sub, Type: discordgo.ApplicationCommandOptionSubCommand}
+	if name != "" {
+		option.Options = []*discordgo.ApplicationCommandInteractionDataOption{
+			{Name: "name", Type: discordgo.ApplicationCommandOptionString, Value: name},
+		}
+	}
+	i.Data = discordgo.ApplicationCommandInteractionData{Name: "server", Options: []*discordgo.ApplicationCommandInteractionDataOption{option}}
+	return i
+}
+
+func TestBot_ServerListAndStart(t *testing.T) {
+	db, cleanup := testutils.SetupTestDatabaseWithModels(t, &models.Tenant{}, &models.GameServer{})
+	defer cleanup()
+
+	tenant := &models.Tenant{DiscordServerID: "guild-1", Name: "Guild", OwnerID: "user-1"}
+	require.NoError(t, db.Create(tenant).Error)
+	server := &models.GameServer{TenantID: tenant.ID, Name: "Survival World", GameType: "minecraft", Status: models.GameServerStatus{Phase: "Stopped"}}
+	require.NoError(t, db.Create(server).Error)
+	require.NoError(t, db.Create(&models.GameServer{TenantID: tenant.ID, Name: "Creative World", GameType: "minecraft"}).Error)
+
+	bot := newTestBot()
+	bot.tenants = &fakeTenants{tenant: tenant}
+	bot.permissions = &fakePermissions{granted: map[string]bool{
+		"user-1|" + models.PermissionServerRead:  true,
+		"user-1|" + models.PermissionServerStart: true,
+	}}
+	bot.auditService = &fakeAudit{}
+	bot.servers = services.NewGameServerService(db)
+
+	transport := &recordingTransport{}
+	session, err := discordgo.New("Bot test")
+	require.NoError(t, err)
+	session.Client = &http.Client{Transport: transport}
+
+	bot.handleServer(session, serverCommandInteraction("guild-1", "discord-1", "list", ""))
+	require.Len(t, transport.responses, 1)
+	listed := transport.responses[0].Data.Embeds[0].Description
+	assert.Contains(t, listed, "**Creative World**")
+	assert.Contains(t, listed, "**Survival World** (minecraft) - Stopped")
+
+	bot.handleServer(session, serverCommandInteraction("guild-1", "discord-1", services.PowerActionStart, "Survival World"))
+	require.Len(t, transport.responses, 2)
+	assert.Contains(t, transport.responses[1].Data.Content, "requested **start** for **Survival World**")
+
+	var stored models.GameServer
+	require.NoError(t, db.First(&stored, "id = ?", server.ID).Error)
+	assert.Equal(t, "Starting", stored.Status.Phase)
+}
@@ -0,0 +1,70 @@
+package discord
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// addSyncHandlers keeps tenant roles, members and owners current from gateway events
+func (b *Bot) addSyncHandlers() {
+	b.Session.AddHandler(func(s *discordgo.Session, e *discordgo.GuildMemberAdd) {
+		logSyncError("member add", b.syncService.UpsertMember(e.GuildID, e.Member))
+	})
+	b.Session.AddHandler(func(s *discordgo.Session, e *discordgo.GuildMemberUpdate) {
+		logSyncError("member update", b.syncService.UpsertMember(e.GuildID, e.Member))
+	})
+	b.Session.AddHandler(func(s *discordgo.Session, e *discordgo.GuildMemberRemove) {
+		if e.Member == nil || e.User == nil {
+			return
+		}
+		logSyncError("member remove", b.syncService.RemoveMember(e.GuildID, e.User.ID))
+	})
+	b.Session.AddHandler(func(s *discordgo.Session, e *discordgo.GuildRoleCreate) {
+		if e.GuildRole == nil {
+			return
+		}
+		logSyncError("role create", b.syncService.UpsertRole(e.GuildID, e.Role))
+	})
+	b.Session.AddHandler(func(s *discordgo.Session, e *discordgo.GuildRoleUpdate) {
+		if e.GuildRole == nil {
+			return
+		}
+		logSyncError("role update", b.syncService.UpsertRole(e.GuildID, e.Role))
+	})
+	b.Session.AddHandler(func(s *discordgo.Session, e *discordgo.GuildRoleDelete) {
+		logSyncError("role delete", b.syncService.DeleteRole(e.GuildID, e.RoleID))
+	})
+	b.Session.AddHandler(func(s *discordgo.Session, e *discordgo.GuildUpdate) {
+		logSyncError("guild update", b.syncService.UpdateGuild(e.Guild))
+	})
+}
+
+// StartReconcileLoop runs a full sync of every tenant on the given interval,
+// catching anything missed while the gateway was disconnected. It stops with the bot.
+func (b *Bot) StartReconcileLoop(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				logSyncError("reconcile", b.syncService.ReconcileAll())
+			case <-b.stopSync:
+				return
+			}
+		}
+	}()
+}
+
+func logSyncError(event string, err error) {
+	if err != nil {
+		fmt.Printf("Error syncing Discord %s: %v\n", event, err)
+	}
+}
@@ -1,25 +1,42 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/middleware"
 	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
 	"github.com/pteronimbus/pteronimbus/apps/backend/internal/services"
 )
 
-// AdminHandler handles admin-related HTTP requests
+// AdminHandler handles admin-related HTTP requests. Apart from CheckAccess,
+// its routes are expected to sit behind PermissionMiddleware.RequireSuperAdmin.
 type AdminHandler struct {
-	adminService *services.AdminService
+	adminService services.AdminServiceInterface
+	authService  services.AuthServiceInterface
 }
 
 // NewAdminHandler creates a new admin handler
-func NewAdminHandler(adminService *services.AdminService) *AdminHandler {
+func NewAdminHandler(adminService services.AdminServiceInterface) *AdminHandler {
 	return &AdminHandler{
 		adminService: adminService,
 	}
 }
 
+// NewAdminHandlerWithAuth creates a new admin handler that can also end user sessions
+func NewAdminHandlerWithAuth(adminService services.AdminServiceInterface, authService services.AuthServiceInterface) *AdminHandler {
+	return &AdminHandler{
+		adminService: adminService,
+		authService:  authService,
+	}
+}
+
+// SystemRoleRequest is the body for granting a system role to a user
+type SystemRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
 // CheckAccess checks if the current user has admin access
 func (h *AdminHandler) CheckAccess(c *gin.Context) {
 	// Get authenticated user
@@ -53,58 +70,91 @@ func (h *AdminHandler) CheckAccess(c *gin.Context) {
 
 // GetStats returns admin-level statistics
 func (h *AdminHandler) GetStats(c *gin.Context) {
-	// Get authenticated user
-	user, exists := c.Get("user")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, models.APIError{
-			Code:    "UNAUTHORIZED",
-			Message: "User not authenticated",
+	stats, err := h.adminService.GetAdminStats(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIError{
+			Code:    "INTERNAL_ERROR",
+			Message: "Failed to get admin statistics",
+			Details: map[string]interface{}{"error": err.Error()},
 		})
 		return
 	}
 
-	userModel := user.(*models.User)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"stats":   stats,
+	})
+}
 
-	// Check if user has superadmin access
-	hasAccess, err := h.adminService.CheckSuperAdminAccess(c.Request.Context(), userModel.ID)
+// CleanupInactiveControllers removes inactive controllers
+func (h *AdminHandler) CleanupInactiveControllers(c *gin.Context) {
+	err := h.adminService.CleanupInactiveControllers(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIError{
 			Code:    "INTERNAL_ERROR",
-			Message: "Failed to check admin access",
+			Message: "Failed to cleanup inactive controllers",
 			Details: map[string]interface{}{"error": err.Error()},
 		})
 		return
 	}
 
-	if !hasAccess {
-		c.JSON(http.StatusForbidden, models.APIError{
-			Code:    "FORBIDDEN",
-			Message: "Insufficient permissions to access admin statistics",
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Inactive controllers cleaned up successfully",
+	})
+}
+
+// ForceLogoutUser revokes every session of a user
+func (h *AdminHandler) ForceLogoutUser(c *gin.Context) {
+	revoked, err := h.authService.RevokeAllSessions(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIError{
+			Code:    "INTERNAL_ERROR",
+			Message: "Failed to revoke user sessions",
+			Details: map[string]interface{}{"error": err.Error()},
 		})
 		return
 	}
 
-	// Get admin statistics
-	stats, err := h.adminService.GetAdminStats(c.Request.Context())
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"revoked": revoked,
+	})
+}
+
+// ListSystemRoles returns the system roles that can be granted
+func (h *AdminHandler) ListSystemRoles(c *gin.Context) {
+	roles, err := h.adminService.ListSystemRoles(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIError{
 			Code:    "INTERNAL_ERROR",
-			Message: "Failed to get admin statistics",
+			Message: "Failed to get system roles",
 			Details: map[string]interface{}{"error": err.Error()},
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"stats":   stats,
+		"system_roles": roles,
 	})
 }
 
-// CleanupInactiveControllers removes inactive controllers
-func (h *AdminHandler) CleanupInactiveControllers(c *gin.Context) {
-	// Get authenticated user
-	user, exists := c.Get("user")
+// GetUserSystemRoles returns the system roles held by a user
+func (h *AdminHandler) GetUserSystemRoles(c *gin.Context) {
+	roles, err := h.adminService.GetUserSystemRoles(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeSystemRoleError(c, err, "Failed to get user system roles")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"system_roles": roles,
+	})
+}
+
+// GrantSystemRole gives a user a system role
+func (h *AdminHandler) GrantSystemRole(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
 	if !exists {
 		c.JSON(http.StatusUnauthorized, models.APIError{
 			Code:    "UNAUTHORIZED",
@@ -113,40 +163,70 @@ func (h *AdminHandler) CleanupInactiveControllers(c *gin.Context) {
 		return
 	}
 
-	userModel := user.(*models.User)
-
-	// Check if user has superadmin access
-	hasAccess, err := h.adminService.CheckSuperAdminAccess(c.Request.Context(), userModel.ID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIError{
-			Code:    "INTERNAL_ERROR",
-			Message: "Failed to check admin access",
+	var req SystemRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "VALIDATION_ERROR",
+			Message: "Role is required",
 			Details: map[string]interface{}{"error": err.Error()},
 		})
 		return
 	}
 
-	if !hasAccess {
-		c.JSON(http.StatusForbidden, models.APIError{
-			Code:    "FORBIDDEN",
-			Message: "Insufficient permissions to perform cleanup",
-		})
+	if err := h.adminService.GrantSystemRole(c.Request.Context(), c.Param("id"), req.Role, user.ID); err != nil {
+		writeSystemRoleError(c, err, "Failed to grant system role")
 		return
 	}
 
-	// Perform cleanup
-	err = h.adminService.CleanupInactiveControllers(c.Request.Context())
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIError{
-			Code:    "INTERNAL_ERROR",
-			Message: "Failed to cleanup inactive controllers",
-			Details: map[string]interface{}{"error": err.Error()},
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// RevokeSystemRole takes a system role away from a user
+func (h *AdminHandler) RevokeSystemRole(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIError{
+			Code:    "UNAUTHORIZED",
+			Message: "User not authenticated",
 		})
 		return
 	}
 
+	if err := h.adminService.RevokeSystemRole(c.Request.Context(), c.Param("id"), c.Param("role"), user.ID); err != nil {
+		writeSystemRoleError(c, err, "Failed to revoke system role")
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Inactive controllers cleaned up successfully",
 	})
 }
+
+// writeSystemRoleError responds to a failed system role operation
+func writeSystemRoleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, models.APIError{
+			Code:    "USER_NOT_FOUND",
+			Message: "User not found",
+		})
+	case errors.Is(err, services.ErrSystemRoleNotFound):
+		c.JSON(http.StatusNotFound, models.APIError{
+			Code:    "SYSTEM_ROLE_NOT_FOUND",
+			Message: "System role not found",
+		})
+	case errors.Is(err, services.ErrSuperAdminSelfRevoke):
+		c.JSON(http.StatusConflict, models.APIError{
+			Code:    "SUPERADMIN_SELF_REVOKE",
+			Message: "You cannot revoke your own superadmin role",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.APIError{
+			Code:    "INTERNAL_ERROR",
+			Message: message,
+			Details: map[string]interface{}{"error": err.Error()},
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockAdminService is a mock implementation of AdminServiceInterface
+type MockAdminService struct {
+	mock.Mock
+}
+
+func (m *MockAdminService) CheckSuperAdminAccess(ctx context.Context, userID string) (bool, error) {
+	args := m.Called(ctx, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAdminService) GetAdminStats(ctx context.Context) (*models.AdminStats, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AdminStats), args.Error(1)
+}
+
+func (m *MockAdminService) CleanupInactiveControllers(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockAdminService) ListSystemRoles(ctx context.Context) ([]models.SystemRole, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.SystemRole), args.Error(1)
+}
+
+func (m *MockAdminService) GetUserSystemRoles(ctx context.Context, userID string) ([]models.SystemRole, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.SystemRole), args.Error(1)
+}
+
+func (m *MockAdminService) GrantSystemRole(ctx context.Context, userID, roleName, performedBy string) error {
+	args := m.Called(ctx, userID, roleName, performedBy)
+	return args.Error(0)
+}
+
+func (m *MockAdminService) RevokeSystemRole(ctx context.Context, userID, roleName, performedBy string) error {
+	args := m.Called(ctx, userID, roleName, performedBy)
+	return args.Error(0)
+}
+
+func setupAdminRouter(handler *AdminHandler) *gin.Engine {
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("user", &models.User{ID: "admin-1", Username: "admin"})
+		c.Next()
+	})
+	router.GET("/admin/check-access", handler.CheckAccess)
+	router.GET("/admin/users/:id/system-roles", handler.GetUserSystemRoles)
+	router.POST("/admin/users/:id/system-roles", handler.GrantSystemRole)
+	router.DELETE("/admin/users/:id/system-roles/:role", handler.RevokeSystemRole)
+	return router
+}
+
+func TestAdminHandler_CheckAccess(t *testing.T) {
+	mockAdminService := new(MockAdminService)
+	router := setupAdminRouter(NewAdminHandler(mockAdminService))
+	mockAdminService.On("CheckSuperAdminAccess", mock.Anything, "admin-1").Return(false, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/admin/check-access", nil)
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, false, response["hasAdminAccess"])
+}
+
+func TestAdminHandler_GrantSystemRole(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           interface{}
+		err            error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{name: "granted", body: SystemRoleRequest{Role: "superadmin"}, expectedStatus: http.StatusOK},
+		{name: "missing role", body: gin.H{}, expectedStatus: http.StatusBadRequest, expectedCode: "VALIDATION_ERROR"},
+		{name: "unknown role", body: SystemRoleRequest{Role: "superadmin"}, err: services.ErrSystemRoleNotFound, expectedStatus: http.StatusNotFound, expectedCode: "SYSTEM_ROLE_NOT_FOUND"},
+		{name: "unknown user", body: SystemRoleRequest{Role: "superadmin"}, err: services.ErrUserNotFound, expectedStatus: http.StatusNotFound, expectedCode: "USER_NOT_FOUND"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAdminService := new(MockAdminService)
+			router := setupAdminRouter(NewAdminHandler(mockAdminService))
+			mockAdminService.On("GrantSystemRole", mock.Anything, "user-2", "superadmin", "admin-1").Return(tt.err)
+
+			w := postJSON(router, "/admin/users/user-2/system-roles", tt.body)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedCode != "" {
+				var response models.APIError
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.expectedCode, response.Code)
+			}
+		})
+	}
+}
+
+func TestAdminHandler_RevokeSystemRole(t *testing.T) {
+	mockAdminService := new(MockAdminService)
+	router := setupAdminRouter(NewAdminHandler(mockAdminService))
+	mockAdminService.On("RevokeSystemRole", mock.Anything, "user-2", "superadmin", "admin-1").Return(nil)
+	mockAdminService.On("RevokeSystemRole", mock.Anything, "admin-1", "superadmin", "admin-1").Return(services.ErrSuperAdminSelfRevoke)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/admin/users/user-2/system-roles/superadmin", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("DELETE", "/admin/users/admin-1/system-roles/superadmin", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	var response models.APIError
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "SUPERADMIN_SELF_REVOKE", response.Code)
+	mockAdminService.AssertExpectations(t)
+}
+
+func TestAdminHandler_GetUserSystemRoles(t *testing.T) {
+	mockAdminService := new(MockAdminService)
+	router := setupAdminRouter(NewAdminHandler(mockAdminService))
+	mockAdminService.On("GetUserSystemRoles", mock.Anything, "user-2").Return([]models.SystemRole{{Name: "superadmin"}}, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/admin/users/user-2/system-roles", nil)
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		SystemRoles []models.SystemRole `json:"system_roles"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.SystemRoles, 1)
+	assert.Equal(t, "superadmin", response.SystemRoles[0].Name)
+}
@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/middleware"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/services"
+)
+
+// APITokenHandler handles personal access token and service account requests
+type APITokenHandler struct {
+	apiTokenService services.APITokenServiceInterface
+}
+
+// NewAPITokenHandler creates a new API token handler
+func NewAPITokenHandler(apiTokenService services.APITokenServiceInterface) *APITokenHandler {
+	return &APITokenHandler{
+		apiTokenService: apiTokenService,
+	}
+}
+
+// ListTokens lists the current user's personal access tokens
+func (h *APITokenHandler) ListTokens(c *gin.Context) {
+	user, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	tokens, err := h.apiTokenService.ListPersonalTokens(c.Request.Context(), user.ID)
+	if err != nil {
+		h.writeError(c, err, "Failed to get API tokens")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tokens": tokens,
+	})
+}
+
+// CreateToken creates a personal access token for the current user
+func (h *APITokenHandler) CreateToken(c *gin.Context) {
+	user, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateAPITokenRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	response, err := h.apiTokenService.CreatePersonalToken(c.Request.Context(), user.ID, req)
+	if err != nil {
+		h.writeError(c, err, "Failed to create API token")
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// RevokeToken revokes one of the current user's personal access tokens
+func (h *APITokenHandler) RevokeToken(c *gin.Context) {
+	user, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	if err := h.apiTokenService.RevokePersonalToken(c.Request.Context(), user.ID, c.Param("id")); err != nil {
+		h.writeError(c, err, "Failed to revoke API token")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "API token revoked",
+	})
+}
+
+// ListServiceAccounts lists the tenant's service accounts
+func (h *APITokenHandler) ListServiceAccounts(c *gin.Context) {
+	tenant, ok := requireTenant(c)
+	if !ok {
+		return
+	}
+
+	accounts, err := h.apiTokenService.ListServiceAccounts(c.Request.Context(), tenant.ID)
+	if err != nil {
+		h.writeError(c, err, "Failed to get service accounts")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"service_accounts": accounts,
+	})
+}
+
+// CreateServiceAccount creates a service account in the tenant
+func (h *APITokenHandler) CreateServiceAccount(c *gin.Context) {
+	tenant, ok := requireTenant(c)
+	if !ok {
+		return
+	}
+	user, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateServiceAccountRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	account, err := h.apiTokenService.CreateServiceAccount(c.Request.Context(), tenant.ID, req, user.ID)
+	if err != nil {
+		h.writeError(c, err, "Failed to create service account")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"service_account": account,
+	})
+}
+
+// DeleteServiceAccount deletes a service account and revokes its tokens
+func (h *APITokenHandler) DeleteServiceAccount(c *gin.Context) {
+	tenant, ok := requireTenant(c)
+	if !ok {
+		return
+	}
+
+	if err := h.apiTokenService.DeleteServiceAccount(c.Request.Context(), tenant.ID, c.Param("id")); err != nil {
+		h.writeError(c, err, "Failed to delete service account")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Service account deleted",
+	})
+}
+
+// ListServiceAccountTokens lists the tokens of a service account
+func (h *APITokenHandler) ListServiceAccountTokens(c *gin.Context) {
+	tenant, ok := requireTenant(c)
+	if !ok {
+		return
+	}
+
+	tokens, err := h.apiTokenService.ListServiceAccountTokens(c.Request.Context(), tenant.ID, c.Param("id"))
+	if err != nil {
+		h.writeError(c, err, "Failed to get API tokens")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tokens": tokens,
+	})
+}
+
+// CreateServiceAccountToken creates a token for a service account
+func (h *APITokenHandler) CreateServiceAccountToken(c *gin.Context) {
+	tenant, ok := requireTenant(c)
+	if !ok {
+		return
+	}
+	user, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateAPITokenRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	response, err := h.apiTokenService.CreateServiceAccountToken(c.Request.Context(), tenant.ID, c.Param("id"), req, user.ID)
+	if err != nil {
+		h.writeError(c, err, "Failed to create API token")
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// RevokeServiceAccountToken revokes a token of a service account
+func (h *APITokenHandler) RevokeServiceAccountToken(c *gin.Context) {
+	tenant, ok := requireTenant(c)
+	if !ok {
+		return
+	}
+
+	err := h.apiTokenService.RevokeServiceAccountToken(c.Request.Context(), tenant.ID, c.Param("id"), c.Param("tokenId"))
+	if err != nil {
+		h.writeError(c, err, "Failed to revoke API token")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "API token revoked",
+	})
+}
+
+// writeError maps API token service errors to responses
+func (h *APITokenHandler) writeError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidPermission), errors.Is(err, services.ErrInvalidTokenRequest):
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "VALIDATION_ERROR",
+			Message: message,
+			Details: map[string]interface{}{"error": err.Error()},
+		})
+	case errors.Is(err, services.ErrPermissionNotHeld):
+		c.JSON(http.StatusForbidden, models.APIError{
+			Code:    "INSUFFICIENT_PERMISSIONS",
+			Message: message,
+			Details: map[string]interface{}{"error": err.Error()},
+		})
+	case errors.Is(err, services.ErrAPITokenNotFound):
+		c.JSON(http.StatusNotFound, models.APIError{
+			Code:    "TOKEN_NOT_FOUND",
+			Message: "API token not found",
+		})
+	case errors.Is(err, services.ErrServiceAccountNotFound):
+		c.JSON(http.StatusNotFound, models.APIError{
+			Code:    "SERVICE_ACCOUNT_NOT_FOUND",
+			Message: "Service account not found",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.APIError{
+			Code:    "INTERNAL_ERROR",
+			Message: message,
+			Details: map[string]interface{}{"error": err.Error()},
+		})
+	}
+}
+
+// requireUser gets the authenticated user, writing an error response when missing
+func requireUser(c *gin.Context) (*models.User, bool) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIError{
+			Code:    "UNAUTHORIZED",
+			Message: "User not authenticated",
+		})
+		return nil, false
+	}
+	return user, true
+}
+
+// requireTenant gets the tenant set by the tenant middleware, writing an error response when missing
+func requireTenant(c *gin.Context) (*models.Tenant, bool) {
+	tenant, exists := c.Get("tenant")
+	if !exists {
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "TENANT_REQUIRED",
+			Message: "Tenant context is required",
+		})
+		return nil, false
+	}
+	return tenant.(*models.Tenant), true
+}
+
+// bindJSON binds the request body, writing a validation error when it is invalid
+func bindJSON(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "VALIDATION_ERROR",
+			Message: "Invalid request body",
+			Details: map[string]interface{}{"error": err.Error()},
+		})
+		return false
+	}
+	return true
+}
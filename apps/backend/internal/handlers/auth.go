@@ -1,11 +1,12 @@
 package handlers
 
 import (
+	"crypto/subtle"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -16,48 +17,32 @@ import (
 	"log/slog"
 )
 
-// stateEntry represents a stored OAuth state with expiration
-type stateEntry struct {
-	value     string
-	expiresAt time.Time
-}
+const (
+	// oauthStateTTL is how long a started login stays valid
+	oauthStateTTL = 10 * time.Minute
+	// oauthStateCookie binds a login to the browser that started it
+	oauthStateCookie = "oauth_state"
+)
 
 // AuthHandler handles authentication endpoints
 type AuthHandler struct {
 	authService services.AuthServiceInterface
-	stateStore  map[string]stateEntry
-	stateMutex  sync.RWMutex
+	stateStore  services.OAuthStateStore
 	logger      *slog.Logger
 }
 
-// NewAuthHandler creates a new auth handler
+// NewAuthHandler creates a new auth handler that keeps OAuth state in memory.
+// Use NewAuthHandlerWithStateStore when running more than one replica.
 func NewAuthHandler(authService services.AuthServiceInterface, logger *slog.Logger) *AuthHandler {
-	h := &AuthHandler{
-		authService: authService,
-		stateStore:  make(map[string]stateEntry),
-		logger:      logger,
-	}
-	
-	// Start cleanup goroutine for expired states
-	go h.cleanupExpiredStates()
-	
-	return h
+	return NewAuthHandlerWithStateStore(authService, services.NewMemoryOAuthStateStore(), logger)
 }
 
-// cleanupExpiredStates periodically removes expired state entries
-func (h *AuthHandler) cleanupExpiredStates() {
-	ticker := time.NewTicker(5 * time.Minute)
-	defer ticker.Stop()
-	
-	for range ticker.C {
-		h.stateMutex.Lock()
-		now := time.Now()
-		for state, entry := range h.stateStore {
-			if now.After(entry.expiresAt) {
-				delete(h.stateStore, state)
-			}
-		}
-		h.stateMutex.Unlock()
+// NewAuthHandlerWithStateStore creates a new auth handler with a shared OAuth state store
+func NewAuthHandlerWithStateStore(authService services.AuthServiceInterface, stateStore services.OAuthStateStore, logger *slog.Logger) *AuthHandler {
+	return &AuthHandler{
+		authService: authService,
+		stateStore:  stateStore,
+		logger:      logger,
 	}
 }
 
@@ -65,14 +50,10 @@ func (h *AuthHandler) cleanupExpiredStates() {
 func (h *AuthHandler) Login(c *gin.Context) {
 	// Generate state parameter for CSRF protection
 	state := uuid.New().String()
-	
-	// Store state in memory with expiration (10 minutes)
-	h.stateMutex.Lock()
-	h.stateStore[state] = stateEntry{
-		value:     state,
-		expiresAt: time.Now().Add(10 * time.Minute),
+
+	if !h.saveState(c, &models.OAuthState{State: state, ExpiresAt: time.Now().Add(oauthStateTTL)}) {
+		return
 	}
-	h.stateMutex.Unlock()
 
 	// Get Discord authorization URL
 	authURL := h.authService.GetAuthURL(state)
@@ -125,61 +106,143 @@ func (h *AuthHandler) Callback(c *gin.Context) {
 	}
 
 	// Validate state parameter (CSRF protection)
-	h.stateMutex.RLock()
-	storedEntry, exists := h.stateStore[state]
-	h.stateMutex.RUnlock()
-	
-	if !exists {
+	storedState, ok := h.consumeState(c, state)
+	if !ok {
+		return
+	}
+
+	if storedState.Login != nil && storedState.Login.StepUpSessionID != "" {
+		h.completeStepUp(c, storedState, code)
+		return
+	}
+
+	// Handle the callback, linking the Discord account when the login was started for linking
+	var authResponse *models.AuthResponse
+	var err error
+	if storedState.Login != nil && storedState.Login.LinkUserID != "" {
+		authResponse, err = h.authService.HandleProviderCallback(sessionContext(c), storedState.Login, code)
+	} else {
+		authResponse, err = h.authService.HandleCallback(sessionContext(c), code)
+	}
+	if err != nil {
+		// Redirect to login page with error message
+		frontendURL := h.getFrontendURL(c)
+		c.Redirect(http.StatusTemporaryRedirect, frontendURL+"/login?error=discord_auth_failed")
+		return
+	}
+
+	h.redirectWithTokens(c, authResponse, storedState.RedirectTo)
+}
+
+// saveState stores an OAuth state, along with the frontend path to return to,
+// and binds it to the browser with a cookie
+func (h *AuthHandler) saveState(c *gin.Context, oauthState *models.OAuthState) bool {
+	oauthState.RedirectTo = sanitizeRedirect(c.Query("redirect"))
+
+	if err := h.stateStore.SaveState(c.Request.Context(), oauthState); err != nil {
+		h.logger.Error("Failed to store OAuth state", "error", err)
+		c.JSON(http.StatusServiceUnavailable, models.APIError{
+			Code:    "STATE_STORE_UNAVAILABLE",
+			Message: "Login is temporarily unavailable",
+		})
+		return false
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oauthStateCookie, oauthState.State, int(oauthStateTTL.Seconds()), "/auth", "", isSecureRequest(c), true)
+	return true
+}
+
+// consumeState validates and removes a stored OAuth state, responding with an
+// error when it is unknown, expired or was started from another browser
+func (h *AuthHandler) consumeState(c *gin.Context, state string) (*models.OAuthState, bool) {
+	// Consuming removes the state so it can only be used once
+	storedState, err := h.stateStore.ConsumeState(c.Request.Context(), state)
+	boundState, _ := c.Cookie(oauthStateCookie)
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oauthStateCookie, "", -1, "/auth", "", isSecureRequest(c), true)
+
+	if err != nil {
+		if !errors.Is(err, services.ErrOAuthStateNotFound) {
+			h.logger.Error("Failed to load OAuth state", "error", err)
+			c.JSON(http.StatusServiceUnavailable, models.APIError{
+				Code:    "STATE_STORE_UNAVAILABLE",
+				Message: "Login is temporarily unavailable",
+			})
+			return nil, false
+		}
 		c.JSON(http.StatusBadRequest, models.APIError{
 			Code:    "VALIDATION_ERROR",
 			Message: "Invalid state parameter",
 			Details: map[string]interface{}{
-				"error": "State not found or expired",
+				"error":          "State not found or expired",
 				"received_state": state,
 			},
 		})
-		return
+		return nil, false
 	}
-	
-	// Check if state has expired
-	if time.Now().After(storedEntry.expiresAt) {
-		// Clean up expired state
-		h.stateMutex.Lock()
-		delete(h.stateStore, state)
-		h.stateMutex.Unlock()
-		
+
+	if time.Now().After(storedState.ExpiresAt) {
 		c.JSON(http.StatusBadRequest, models.APIError{
 			Code:    "VALIDATION_ERROR",
 			Message: "Invalid state parameter",
 			Details: map[string]interface{}{
-				"error": "State expired",
+				"error":          "State expired",
 				"received_state": state,
 			},
 		})
-		return
+		return nil, false
 	}
-	
-	// Remove the used state to prevent replay attacks
-	h.stateMutex.Lock()
-	delete(h.stateStore, state)
-	h.stateMutex.Unlock()
-
-	// Handle the callback
-	authResponse, err := h.authService.HandleCallback(c.Request.Context(), code)
-	if err != nil {
-		// Redirect to login page with error message
-		frontendURL := h.getFrontendURL(c)
-		c.Redirect(http.StatusTemporaryRedirect, frontendURL+"/login?error=discord_auth_failed")
+
+	// Login CSRF protection: the callback must come from the browser that started the login
+	if subtle.ConstantTimeCompare([]byte(boundState), []byte(state)) != 1 {
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "VALIDATION_ERROR",
+			Message: "Invalid state parameter",
+			Details: map[string]interface{}{
+				"error": "State was not issued to this browser",
+			},
+		})
+		return nil, false
+	}
+
+	return storedState, true
+}
+
+// sanitizeRedirect only allows paths on the frontend so the login cannot be
+// used as an open redirect
+func sanitizeRedirect(redirect string) string {
+	if !strings.HasPrefix(redirect, "/") || strings.HasPrefix(redirect, "//") || strings.Contains(redirect, "\\") {
+		return ""
+	}
+	return redirect
+}
+
+// isSecureRequest reports whether the request reached us over HTTPS, directly or through a proxy
+func isSecureRequest(c *gin.Context) bool {
+	return c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+}
+
+// redirectWithTokens sends the browser to the frontend callback with tokens as query parameters
+func (h *AuthHandler) redirectWithTokens(c *gin.Context, authResponse *models.AuthResponse, redirectTo string) {
+	frontendURL := h.getFrontendURL(c)
+	if authResponse.TwoFactorChallenge != "" {
+		challengeURL := frontendURL + "/auth/two-factor?challenge=" + url.QueryEscape(authResponse.TwoFactorChallenge)
+		if redirectTo != "" {
+			challengeURL += "&redirect=" + url.QueryEscape(redirectTo)
+		}
+		c.Redirect(http.StatusTemporaryRedirect, challengeURL)
 		return
 	}
 
-	// Redirect to frontend callback with tokens as query parameters
-	frontendURL = h.getFrontendURL(c)
 	callbackURL := frontendURL + "/auth/callback" +
 		"?access_token=" + authResponse.AccessToken +
 		"&refresh_token=" + authResponse.RefreshToken +
 		"&expires_in=" + fmt.Sprintf("%d", authResponse.ExpiresIn)
-	
+	if redirectTo != "" {
+		callbackURL += "&redirect=" + url.QueryEscape(redirectTo)
+	}
+
 	c.Redirect(http.StatusTemporaryRedirect, callbackURL)
 }
 
@@ -220,6 +283,29 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 	c.JSON(http.StatusOK, authResponse)
 }
 
+// VerifyTwoFactor completes a login that is waiting for its second factor
+func (h *AuthHandler) VerifyTwoFactor(c *gin.Context) {
+	var req models.TwoFactorVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "VALIDATION_ERROR",
+			Message: "Challenge and code are required",
+			Details: map[string]interface{}{
+				"error": err.Error(),
+			},
+		})
+		return
+	}
+
+	authResponse, err := h.authService.VerifyTwoFactorLogin(sessionContext(c), req.Challenge, req.Code)
+	if err != nil {
+		writeTwoFactorError(c, err, "Failed to verify two-factor code")
+		return
+	}
+
+	c.JSON(http.StatusOK, authResponse)
+}
+
 // Me returns current user information
 func (h *AuthHandler) Me(c *gin.Context) {
 	user, exists := middleware.GetUserFromContext(c)
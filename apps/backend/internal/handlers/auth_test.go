@@ -7,13 +7,16 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/services"
 	"log/slog"
 	"io"
 )
@@ -65,6 +68,100 @@ func (m *MockAuthService) Logout(ctx context.Context, accessToken string) error
 	return args.Error(0)
 }
 
+func (m *MockAuthService) IdentityProviders() []models.IdentityProviderInfo {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).([]models.IdentityProviderInfo)
+}
+
+func (m *MockAuthService) BeginProviderLogin(ctx context.Context, providerName, linkUserID string) (*models.IdentityLoginState, error) {
+	args := m.Called(ctx, providerName, linkUserID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.IdentityLoginState), args.Error(1)
+}
+
+func (m *MockAuthService) HandleProviderCallback(ctx context.Context, login *models.IdentityLoginState, code string) (*models.AuthResponse, error) {
+	args := m.Called(ctx, login, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AuthResponse), args.Error(1)
+}
+
+func (m *MockAuthService) GetUserIdentities(ctx context.Context, userID string) ([]models.UserIdentity, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.UserIdentity), args.Error(1)
+}
+
+func (m *MockAuthService) UnlinkIdentity(ctx context.Context, userID, identityID string) error {
+	args := m.Called(ctx, userID, identityID)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) ListSessions(ctx context.Context, userID, currentSessionID string) ([]models.SessionInfo, error) {
+	args := m.Called(ctx, userID, currentSessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.SessionInfo), args.Error(1)
+}
+
+func (m *MockAuthService) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	args := m.Called(ctx, userID, sessionID)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) RevokeAllSessions(ctx context.Context, userID string) (int, error) {
+	args := m.Called(ctx, userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockAuthService) GetDiscordGuilds(ctx context.Context, sessionID string) ([]models.DiscordGuild, error) {
+	args := m.Called(ctx, sessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.DiscordGuild), args.Error(1)
+}
+
+func (m *MockAuthService) BeginStepUp(ctx context.Context, providerName, sessionID string) (*models.IdentityLoginState, error) {
+	args := m.Called(ctx, providerName, sessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.IdentityLoginState), args.Error(1)
+}
+
+func (m *MockAuthService) CompleteStepUp(ctx context.Context, login *models.IdentityLoginState, code string) (time.Time, error) {
+	args := m.Called(ctx, login, code)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *MockAuthService) StepUpExpiry(ctx context.Context, sessionID string) (time.Time, error) {
+	args := m.Called(ctx, sessionID)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *MockAuthService) StepUpWithTOTP(ctx context.Context, sessionID, code string) (time.Time, error) {
+	args := m.Called(ctx, sessionID, code)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *MockAuthService) VerifyTwoFactorLogin(ctx context.Context, challengeID, code string) (*models.AuthResponse, error) {
+	args := m.Called(ctx, challengeID, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AuthResponse), args.Error(1)
+}
+
 func setupTestRouter() *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	return gin.New()
@@ -248,10 +345,10 @@ func TestAuthHandler_Callback(t *testing.T) {
 
 			// Pre-populate stateStore for tests that use 'test_state' and expect a redirect
 			if req.URL.Query().Get("state") == "test_state" && tt.expectedStatus == http.StatusTemporaryRedirect {
-				handler.stateStore["test_state"] = stateEntry{
-					value:     "test_state",
-					expiresAt: time.Now().Add(10 * time.Minute),
-				}
+				handler.stateStore.SaveState(context.Background(), &models.OAuthState{
+					State:     "test_state",
+					ExpiresAt: time.Now().Add(10 * time.Minute),
+				})
 			}
 
 			// Set the state cookie for tests that need it
@@ -517,4 +614,71 @@ func TestAuthHandler_Logout(t *testing.T) {
 			mockAuthService.AssertExpectations(t)
 		})
 	}
+}
+func TestAuthHandler_StateStore(t *testing.T) {
+	mockAuthService := new(MockAuthService)
+	mockAuthService.On("GetAuthURL", mock.AnythingOfType("string")).Return("https://discord.com/oauth2/authorize")
+	mockAuthService.On("HandleCallback", mock.Anything, "test_code").Return(&models.AuthResponse{
+		AccessToken:  "access_token",
+		RefreshToken: "refresh_token",
+		ExpiresIn:    3600,
+	}, nil)
+
+	// Two replicas sharing one store, as with Redis behind a load balancer
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	stateStore := services.NewMemoryOAuthStateStore()
+	replicaA := NewAuthHandlerWithStateStore(mockAuthService, stateStore, logger)
+	replicaB := NewAuthHandlerWithStateStore(mockAuthService, stateStore, logger)
+
+	routerA := setupTestRouter()
+	routerA.GET("/auth/login", replicaA.Login)
+	routerB := setupTestRouter()
+	routerB.GET("/auth/callback", replicaB.Callback)
+
+	login := func(redirect string) (string, []*http.Cookie) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/auth/login?redirect="+url.QueryEscape(redirect), nil)
+		routerA.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]string
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response["state"], w.Result().Cookies()
+	}
+	callback := func(state string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/auth/callback?code=test_code&state="+state, nil)
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		routerB.ServeHTTP(w, req)
+		return w
+	}
+
+	state, cookies := login("/tenants/123")
+	require.Len(t, cookies, 1)
+	assert.Equal(t, "oauth_state", cookies[0].Name)
+	assert.True(t, cookies[0].HttpOnly)
+
+	w := callback(state, cookies)
+	assert.Equal(t, http.StatusTemporaryRedirect, w.Code)
+	assert.Contains(t, w.Header().Get("Location"), "redirect=%2Ftenants%2F123")
+
+	// States are single use
+	w = callback(state, cookies)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// A callback from a browser that did not start the login is rejected
+	state, _ = login("/")
+	w = callback(state, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var response models.APIError
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "State was not issued to this browser", response.Details["error"])
+
+	// Redirect targets outside the frontend are dropped
+	state, cookies = login("//evil.example.com")
+	w = callback(state, cookies)
+	assert.Equal(t, http.StatusTemporaryRedirect, w.Code)
+	assert.NotContains(t, w.Header().Get("Location"), "evil")
 }
\ No newline at end of file
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
@@ -105,6 +106,89 @@ func (h *ControllerHandler) Heartbeat(c *gin.Context) {
 	}
 }
 
+// ReportServerEvent handles game server events reported by a controller
+func (h *ControllerHandler) ReportServerEvent(c *gin.Context) {
+	controllerID, exists := c.Get("controller_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "Controller not authenticated",
+		})
+		return
+	}
+
+	var req models.ServerEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request format: " + err.Error(),
+		})
+		return
+	}
+
+	err := h.controllerService.ReportServerEvent(c.Request.Context(), controllerID.(string), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidServerEvent):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": err.Error(),
+			})
+		case errors.Is(err, services.ErrGameServerNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"message": "Game server not found",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Internal server error: " + err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Event received",
+	})
+}
+
+// AssignGameServer places a game server on a controller's cluster
+func (h *ControllerHandler) AssignGameServer(c *gin.Context) {
+	server, err := h.controllerService.AssignGameServer(c.Request.Context(), c.Param("id"), c.Param("serverId"))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrControllerNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"message": "Controller not found",
+			})
+		case errors.Is(err, services.ErrGameServerNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"message": "Game server not found",
+			})
+		case errors.Is(err, services.ErrControllerNotApproved):
+			c.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"message": "Controller has not been approved",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Internal server error: " + err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"server":  server,
+	})
+}
+
 // GetControllerStatus returns the status of a specific controller
 func (h *ControllerHandler) GetControllerStatus(c *gin.Context) {
 	controllerID := c.Param("id")
@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/middleware"
 	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
 	"github.com/pteronimbus/pteronimbus/apps/backend/internal/services"
 )
@@ -13,6 +15,7 @@ import (
 type GameServerHandler struct {
 	gameServerService services.GameServerServiceInterface
 	tenantService     services.TenantServiceInterface
+	rbacService       services.RBACServiceInterface
 }
 
 // NewGameServerHandler creates a new game server handler
@@ -23,6 +26,16 @@ func NewGameServerHandler(gameServerService services.GameServerServiceInterface,
 	}
 }
 
+// NewGameServerHandlerWithRBAC creates a game server handler that only lists
+// the servers each user may read
+func NewGameServerHandlerWithRBAC(gameServerService services.GameServerServiceInterface, tenantService services.TenantServiceInterface, rbacService services.RBACServiceInterface) *GameServerHandler {
+	return &GameServerHandler{
+		gameServerService: gameServerService,
+		tenantService:     tenantService,
+		rbacService:       rbacService,
+	}
+}
+
 // GetTenantServers retrieves all game servers for a tenant
 func (gsh *GameServerHandler) GetTenantServers(c *gin.Context) {
 	tenant, exists := c.Get("tenant")
@@ -46,6 +59,18 @@ func (gsh *GameServerHandler) GetTenantServers(c *gin.Context) {
 		return
 	}
 
+	if gsh.rbacService != nil {
+		servers, err = gsh.visibleServers(c, tenantModel.ID, servers)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIError{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to check server permissions",
+				Details: map[string]interface{}{"error": err.Error()},
+			})
+			return
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"servers": servers,
 	})
@@ -113,4 +138,83 @@ func (gsh *GameServerHandler) GetTenantDiscordStats(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"stats": stats,
 	})
-}
\ No newline at end of file
+}
+
+// StartServer requests that a game server is started
+func (gsh *GameServerHandler) StartServer(c *gin.Context) {
+	gsh.requestPowerAction(c, services.PowerActionStart)
+}
+
+// StopServer requests that a game server is stopped
+func (gsh *GameServerHandler) StopServer(c *gin.Context) {
+	gsh.requestPowerAction(c, services.PowerActionStop)
+}
+
+// RestartServer requests that a game server is restarted
+func (gsh *GameServerHandler) RestartServer(c *gin.Context) {
+	gsh.requestPowerAction(c, services.PowerActionRestart)
+}
+
+// requestPowerAction applies a power action to the game server in the route
+func (gsh *GameServerHandler) requestPowerAction(c *gin.Context, action string) {
+	tenant, exists := c.Get("tenant")
+	if !exists {
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "TENANT_REQUIRED",
+			Message: "Tenant context is required",
+		})
+		return
+	}
+
+	tenantModel := tenant.(*models.Tenant)
+
+	server, err := gsh.gameServerService.RequestPowerAction(c.Request.Context(), tenantModel.ID, c.Param("serverId"), action)
+	if err != nil {
+		if errors.Is(err, services.ErrGameServerNotFound) {
+			c.JSON(http.StatusNotFound, models.APIError{
+				Code:    "SERVER_NOT_FOUND",
+				Message: "Game server not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIError{
+			Code:    "INTERNAL_ERROR",
+			Message: "Failed to request power action",
+			Details: map[string]interface{}{"error": err.Error()},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"server": server,
+	})
+}
+
+// visibleServers keeps the servers the current user may read, tenant-wide or
+// through a resource grant
+func (gsh *GameServerHandler) visibleServers(c *gin.Context, tenantID string, servers []models.GameServer) ([]models.GameServer, error) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		return []models.GameServer{}, nil
+	}
+
+	all, ids, err := gsh.rbacService.GetAccessibleResourceIDs(c.Request.Context(), user.ID, tenantID, models.PermissionServerRead, models.ResourceTypeGameServer)
+	if err != nil {
+		return nil, err
+	}
+	if all {
+		return servers, nil
+	}
+
+	allowed := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		allowed[id] = true
+	}
+	visible := make([]models.GameServer, 0, len(ids))
+	for _, server := range servers {
+		if allowed[server.ID] {
+			visible = append(visible, server)
+		}
+	}
+	return visible, nil
+}
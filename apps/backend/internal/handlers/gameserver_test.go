@@ -11,6 +11,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/services"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -35,6 +36,14 @@ func (m *MockGameServerService) GetTenantDiscordStats(ctx context.Context, tenan
 	return args.Get(0).(*models.DiscordStats), args.Error(1)
 }
 
+func (m *MockGameServerService) RequestPowerAction(ctx context.Context, tenantID, serverID, action string) (*models.GameServer, error) {
+	args := m.Called(ctx, tenantID, serverID, action)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.GameServer), args.Error(1)
+}
+
 // MockTenantService is a mock implementation of TenantServiceInterface for game server tests
 type MockTenantServiceForGameServer struct {
 	mock.Mock
@@ -70,18 +79,8 @@ func (m *MockTenantServiceForGameServer) RemoveUserFromTenant(ctx context.Contex
 	return args.Error(0)
 }
 
-func (m *MockTenantServiceForGameServer) SyncDiscordRoles(ctx context.Context, tenantID, botToken string) error {
-	args := m.Called(ctx, tenantID, botToken)
-	return args.Error(0)
-}
-
-func (m *MockTenantServiceForGameServer) SyncDiscordUsers(ctx context.Context, tenantID, botToken string) error {
-	args := m.Called(ctx, tenantID, botToken)
-	return args.Error(0)
-}
-
-func (m *MockTenantServiceForGameServer) UpdateTenantConfig(ctx context.Context, tenantID string, config models.TenantConfig) error {
-	args := m.Called(ctx, tenantID, config)
+func (m *MockTenantServiceForGameServer) UpdateTenantConfig(ctx context.Context, tenantID string, config models.TenantConfig, performedBy string) error {
+	args := m.Called(ctx, tenantID, config, performedBy)
 	return args.Error(0)
 }
 
@@ -90,6 +89,11 @@ func (m *MockTenantServiceForGameServer) DeleteTenant(ctx context.Context, tenan
 	return args.Error(0)
 }
 
+func (m *MockTenantServiceForGameServer) IsTenantMember(ctx context.Context, userID, tenantID string) (bool, error) {
+	args := m.Called(ctx, userID, tenantID)
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *MockTenantServiceForGameServer) HasPermission(ctx context.Context, userID, tenantID, permission string) (bool, error) {
 	args := m.Called(ctx, userID, tenantID, permission)
 	return args.Bool(0), args.Error(1)
@@ -168,6 +172,78 @@ func TestGetTenantServers_Success(t *testing.T) {
 	mockGameServerService.AssertExpectations(t)
 }
 
+func TestGetTenantServers_FilteredByResourceGrants(t *testing.T) {
+	tests := []struct {
+		name        string
+		all         bool
+		ids         []string
+		expectedIDs []string
+	}{
+		{name: "tenant-wide read", all: true, expectedIDs: []string{"server-1", "server-2"}},
+		{name: "granted on one server", ids: []string{"server-2"}, expectedIDs: []string{"server-2"}},
+		{name: "no access", expectedIDs: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockGameServerService := &MockGameServerService{}
+			mockRBACService := &MockRBACService{}
+			handler := NewGameServerHandlerWithRBAC(mockGameServerService, &MockTenantServiceForGameServer{}, mockRBACService)
+
+			mockGameServerService.On("GetTenantServers", mock.Anything, "tenant-123").Return([]models.GameServer{
+				{ID: "server-1", TenantID: "tenant-123", Name: "Survival World"},
+				{ID: "server-2", TenantID: "tenant-123", Name: "Creative World"},
+			}, nil)
+			mockRBACService.On("GetAccessibleResourceIDs", mock.Anything, "user-123", "tenant-123", models.PermissionServerRead, models.ResourceTypeGameServer).
+				Return(tt.all, tt.ids, nil)
+
+			c, w := setupGinContextForGameServer("GET", "/api/tenant/servers", nil)
+			c.Set("tenant", &models.Tenant{ID: "tenant-123"})
+			c.Set("user", &models.User{ID: "user-123"})
+
+			handler.GetTenantServers(c)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			var response struct {
+				Servers []models.GameServer `json:"servers"`
+			}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			ids := []string{}
+			for _, server := range response.Servers {
+				ids = append(ids, server.ID)
+			}
+			assert.Equal(t, tt.expectedIDs, ids)
+		})
+	}
+}
+
+func TestRequestPowerAction(t *testing.T) {
+	handler, mockGameServerService, _ := setupGameServerHandler()
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("tenant", &models.Tenant{ID: "tenant-123"})
+		c.Next()
+	})
+	router.POST("/servers/:serverId/start", handler.StartServer)
+	router.POST("/servers/:serverId/restart", handler.RestartServer)
+
+	mockGameServerService.On("RequestPowerAction", mock.Anything, "tenant-123", "server-1", services.PowerActionStart).
+		Return(&models.GameServer{ID: "server-1", Status: models.GameServerStatus{Phase: "Starting"}}, nil)
+	mockGameServerService.On("RequestPowerAction", mock.Anything, "tenant-123", "missing", services.PowerActionRestart).
+		Return(nil, services.ErrGameServerNotFound)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/servers/server-1/start", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/servers/missing/restart", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockGameServerService.AssertExpectations(t)
+}
+
 func TestGetTenantServers_NoTenantContext(t *testing.T) {
 	handler, _, _ := setupGameServerHandler()
 
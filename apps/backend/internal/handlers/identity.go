@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/middleware"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/services"
+)
+
+// Providers lists the available login providers
+func (h *AuthHandler) Providers(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"providers": h.authService.IdentityProviders(),
+	})
+}
+
+// ProviderLogin initiates a login with an OpenID Connect provider
+func (h *AuthHandler) ProviderLogin(c *gin.Context) {
+	h.beginProviderLogin(c, c.Param("provider"), "")
+}
+
+// LinkIdentity starts linking another provider's identity to the current user
+func (h *AuthHandler) LinkIdentity(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIError{
+			Code:    "UNAUTHORIZED",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	h.beginProviderLogin(c, c.Param("provider"), user.ID)
+}
+
+func (h *AuthHandler) beginProviderLogin(c *gin.Context, provider, linkUserID string) {
+	login, err := h.authService.BeginProviderLogin(c.Request.Context(), provider, linkUserID)
+	h.respondWithLogin(c, provider, login, err)
+}
+
+// respondWithLogin stores a started provider login and returns its authorization URL
+func (h *AuthHandler) respondWithLogin(c *gin.Context, provider string, login *models.IdentityLoginState, err error) {
+	if err != nil {
+		if errors.Is(err, services.ErrUnknownIdentityProvider) {
+			c.JSON(http.StatusNotFound, models.APIError{
+				Code:    "PROVIDER_NOT_FOUND",
+				Message: "Unknown login provider",
+			})
+			return
+		}
+		h.logger.Error("Failed to start provider login", "provider", provider, "error", err)
+		c.JSON(http.StatusBadGateway, models.APIError{
+			Code:    "PROVIDER_UNAVAILABLE",
+			Message: "Login provider is unavailable",
+		})
+		return
+	}
+
+	if !h.saveState(c, &models.OAuthState{State: login.State, Login: login, ExpiresAt: login.ExpiresAt}) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"auth_url": login.AuthURL,
+		"state":    login.State,
+	})
+}
+
+// ProviderCallback handles the redirect back from an OpenID Connect provider
+func (h *AuthHandler) ProviderCallback(c *gin.Context) {
+	provider := c.Param("provider")
+	code := c.Query("code")
+	state := c.Query("state")
+	frontendURL := h.getFrontendURL(c)
+
+	if providerError := c.Query("error"); providerError != "" {
+		h.logger.Warn("OIDC provider error", "provider", provider, "error", providerError, "description", c.Query("error_description"))
+		c.Redirect(http.StatusTemporaryRedirect, frontendURL+"/login?error="+url.QueryEscape(providerError))
+		return
+	}
+
+	if code == "" {
+		c.Redirect(http.StatusTemporaryRedirect, frontendURL+"/login?error=cancelled")
+		return
+	}
+
+	if state == "" {
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "VALIDATION_ERROR",
+			Message: "State parameter is required",
+		})
+		return
+	}
+
+	storedState, ok := h.consumeState(c, state)
+	if !ok {
+		return
+	}
+	if storedState.Login == nil || storedState.Login.Provider != provider {
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "VALIDATION_ERROR",
+			Message: "Invalid state parameter",
+			Details: map[string]interface{}{
+				"error": "State was issued for a different provider",
+			},
+		})
+		return
+	}
+
+	if storedState.Login.StepUpSessionID != "" {
+		h.completeStepUp(c, storedState, code)
+		return
+	}
+
+	authResponse, err := h.authService.HandleProviderCallback(sessionContext(c), storedState.Login, code)
+	if err != nil {
+		h.logger.Warn("OIDC login failed", "provider", provider, "error", err)
+		if errors.Is(err, services.ErrIdentityAlreadyLinked) {
+			c.Redirect(http.StatusTemporaryRedirect, frontendURL+"/login?error=identity_already_linked")
+			return
+		}
+		c.Redirect(http.StatusTemporaryRedirect, frontendURL+"/login?error=oidc_auth_failed")
+		return
+	}
+
+	h.redirectWithTokens(c, authResponse, storedState.RedirectTo)
+}
+
+// Identities lists the login identities linked to the current user
+func (h *AuthHandler) Identities(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIError{
+			Code:    "UNAUTHORIZED",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	identities, err := h.authService.GetUserIdentities(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIError{
+			Code:    "INTERNAL_ERROR",
+			Message: "Failed to get identities",
+			Details: map[string]interface{}{
+				"error": err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"identities": identities,
+	})
+}
+
+// UnlinkIdentity removes a login identity from the current user
+func (h *AuthHandler) UnlinkIdentity(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIError{
+			Code:    "UNAUTHORIZED",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	err := h.authService.UnlinkIdentity(c.Request.Context(), user.ID, c.Param("id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrIdentityNotFound):
+			c.JSON(http.StatusNotFound, models.APIError{
+				Code:    "IDENTITY_NOT_FOUND",
+				Message: "Identity not found",
+			})
+		case errors.Is(err, services.ErrLastIdentity):
+			c.JSON(http.StatusConflict, models.APIError{
+				Code:    "LAST_IDENTITY",
+				Message: "Cannot unlink the only way to log in",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, models.APIError{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to unlink identity",
+				Details: map[string]interface{}{
+					"error": err.Error(),
+				},
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Identity unlinked",
+	})
+}
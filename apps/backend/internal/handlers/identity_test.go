@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newIdentityTestHandler(mockAuthService *MockAuthService) *AuthHandler {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	return NewAuthHandler(mockAuthService, logger)
+}
+
+// addCookies sends the cookies set by an earlier response, as a browser would
+func addCookies(req *http.Request, cookies []*http.Cookie) {
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+}
+
+func TestAuthHandler_ProviderLoginAndCallback(t *testing.T) {
+	mockAuthService := new(MockAuthService)
+	handler := newIdentityTestHandler(mockAuthService)
+	router := setupTestRouter()
+	router.GET("/auth/oidc/:provider/login", handler.ProviderLogin)
+	router.GET("/auth/oidc/:provider/callback", handler.ProviderCallback)
+
+	login := &models.IdentityLoginState{
+		Provider:  "authentik",
+		State:     "state-1",
+		AuthURL:   "https://auth.example.com/authorize?state=state-1",
+		ExpiresAt: time.Now().Add(time.Minute),
+	}
+	mockAuthService.On("BeginProviderLogin", mock.Anything, "authentik", "").Return(login, nil)
+	mockAuthService.On("HandleProviderCallback", mock.Anything, login, "code-1").Return(&models.AuthResponse{
+		AccessToken:  "access",
+		RefreshToken: "refresh",
+		ExpiresIn:    3600,
+	}, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/auth/oidc/authentik/login", nil)
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	cookies := w.Result().Cookies()
+
+	var response map[string]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, login.AuthURL, response["auth_url"])
+	assert.Equal(t, "state-1", response["state"])
+
+	// A state issued for one provider cannot complete a login at another
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/auth/oidc/keycloak/callback?code=code-1&state=state-1", nil)
+	addCookies(req, cookies)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// The state was consumed by the rejected attempt
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/auth/oidc/authentik/callback?code=code-1&state=state-1", nil)
+	addCookies(req, cookies)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockAuthService.AssertNotCalled(t, "HandleProviderCallback", mock.Anything, mock.Anything, mock.Anything)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/auth/oidc/authentik/login", nil)
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	cookies = w.Result().Cookies()
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/auth/oidc/authentik/callback?code=code-1&state=state-1", nil)
+	addCookies(req, cookies)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTemporaryRedirect, w.Code)
+	assert.Contains(t, w.Header().Get("Location"), "access_token=access")
+}
+
+func TestAuthHandler_ProviderLoginUnknownProvider(t *testing.T) {
+	mockAuthService := new(MockAuthService)
+	handler := newIdentityTestHandler(mockAuthService)
+	router := setupTestRouter()
+	router.GET("/auth/oidc/:provider/login", handler.ProviderLogin)
+
+	mockAuthService.On("BeginProviderLogin", mock.Anything, "unknown", "").Return(nil, services.ErrUnknownIdentityProvider)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/auth/oidc/unknown/login", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	var response models.APIError
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "PROVIDER_NOT_FOUND", response.Code)
+}
+
+func TestAuthHandler_ProviderCallbackAlreadyLinked(t *testing.T) {
+	mockAuthService := new(MockAuthService)
+	handler := newIdentityTestHandler(mockAuthService)
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("user", &models.User{ID: "user-1"})
+		c.Next()
+	})
+	router.POST("/auth/identities/:provider/link", handler.LinkIdentity)
+	router.GET("/auth/oidc/:provider/callback", handler.ProviderCallback)
+
+	login := &models.IdentityLoginState{
+		Provider:   "authentik",
+		State:      "state-1",
+		LinkUserID: "user-1",
+		ExpiresAt:  time.Now().Add(time.Minute),
+	}
+	mockAuthService.On("BeginProviderLogin", mock.Anything, "authentik", "user-1").Return(login, nil)
+	mockAuthService.On("HandleProviderCallback", mock.Anything, login, "code-1").Return(nil, services.ErrIdentityAlreadyLinked)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/auth/identities/authentik/link", nil)
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	cookies := w.Result().Cookies()
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/auth/oidc/authentik/callback?code=code-1&state=state-1", nil)
+	addCookies(req, cookies)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTemporaryRedirect, w.Code)
+	assert.Contains(t, w.Header().Get("Location"), "/login?error=identity_already_linked")
+	mockAuthService.AssertExpectations(t)
+}
+
+func TestAuthHandler_UnlinkIdentity(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{name: "unlinked", expectedStatus: http.StatusOK},
+		{name: "not found", err: services.ErrIdentityNotFound, expectedStatus: http.StatusNotFound, expectedCode: "IDENTITY_NOT_FOUND"},
+		{name: "last identity", err: services.ErrLastIdentity, expectedStatus: http.StatusConflict, expectedCode: "LAST_IDENTITY"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuthService := new(MockAuthService)
+			handler := newIdentityTestHandler(mockAuthService)
+			router := setupTestRouter()
+			router.Use(func(c *gin.Context) {
+				c.Set("user", &models.User{ID: "user-1"})
+				c.Next()
+			})
+			router.DELETE("/auth/identities/:id", handler.UnlinkIdentity)
+
+			mockAuthService.On("UnlinkIdentity", mock.Anything, "user-1", "identity-1").Return(tt.err)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("DELETE", "/auth/identities/identity-1", nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedCode != "" {
+				var response models.APIError
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.expectedCode, response.Code)
+			}
+		})
+	}
+}
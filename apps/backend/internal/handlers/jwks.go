@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/services"
+)
+
+// JWKSHandler publishes the public keys tokens are signed with
+type JWKSHandler struct {
+	jwtService *services.JWTService
+}
+
+// NewJWKSHandler creates a new JWKS handler
+func NewJWKSHandler(jwtService *services.JWTService) *JWKSHandler {
+	return &JWKSHandler{
+		jwtService: jwtService,
+	}
+}
+
+// JWKS serves the JSON Web Key Set so that other services can verify tokens
+// without holding a secret
+func (h *JWKSHandler) JWKS(c *gin.Context) {
+	// Verifiers refetch when they see an unknown kid, so a short cache is enough
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.jwtService.JWKS())
+}
@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/services"
+)
+
+// MembershipHandler handles tenant member and invite requests
+type MembershipHandler struct {
+	membershipService services.MembershipServiceInterface
+}
+
+// NewMembershipHandler creates a new membership handler
+func NewMembershipHandler(membershipService services.MembershipServiceInterface) *MembershipHandler {
+	return &MembershipHandler{
+		membershipService: membershipService,
+	}
+}
+
+// ListMembers lists the tenant's members with their effective roles
+func (h *MembershipHandler) ListMembers(c *gin.Context) {
+	tenant, ok := requireTenant(c)
+	if !ok {
+		return
+	}
+
+	members, err := h.membershipService.ListMembers(c.Request.Context(), tenant.ID)
+	if err != nil {
+		h.writeError(c, err, "Failed to get members")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"members": members,
+	})
+}
+
+// RemoveMember removes a member from the tenant
+func (h *MembershipHandler) RemoveMember(c *gin.Context) {
+	user, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	tenant, ok := requireTenant(c)
+	if !ok {
+		return
+	}
+
+	if err := h.membershipService.RemoveMember(c.Request.Context(), tenant.ID, c.Param("userId"), user.ID); err != nil {
+		h.writeError(c, err, "Failed to remove member")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Member removed",
+	})
+}
+
+// LeaveTenant ends the current user's membership of the tenant
+func (h *MembershipHandler) LeaveTenant(c *gin.Context) {
+	user, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	tenant, ok := requireTenant(c)
+	if !ok {
+		return
+	}
+
+	if err := h.membershipService.LeaveTenant(c.Request.Context(), tenant.ID, user.ID); err != nil {
+		h.writeError(c, err, "Failed to leave tenant")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Left tenant",
+	})
+}
+
+// CreateInvite invites a Discord user to the tenant or creates an invite link
+func (h *MembershipHandler) CreateInvite(c *gin.Context) {
+	user, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	tenant, ok := requireTenant(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateInviteRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	response, err := h.membershipService.CreateInvite(c.Request.Context(), tenant.ID, req, user.ID)
+	if err != nil {
+		h.writeError(c, err, "Failed to create invite")
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// ListInvites lists the tenant's open invites
+func (h *MembershipHandler) ListInvites(c *gin.Context) {
+	tenant, ok := requireTenant(c)
+	if !ok {
+		return
+	}
+
+	invites, err := h.membershipService.ListInvites(c.Request.Context(), tenant.ID)
+	if err != nil {
+		h.writeError(c, err, "Failed to get invites")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"invites": invites,
+	})
+}
+
+// RevokeInvite stops one of the tenant's invites from being accepted
+func (h *MembershipHandler) RevokeInvite(c *gin.Context) {
+	user, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	tenant, ok := requireTenant(c)
+	if !ok {
+		return
+	}
+
+	if err := h.membershipService.RevokeInvite(c.Request.Context(), tenant.ID, c.Param("inviteId"), user.ID); err != nil {
+		h.writeError(c, err, "Failed to revoke invite")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Invite revoked",
+	})
+}
+
+// ListMyInvites lists the open invites addressed to the current user
+func (h *MembershipHandler) ListMyInvites(c *gin.Context) {
+	user, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	invites, err := h.membershipService.ListUserInvites(c.Request.Context(), user)
+	if err != nil {
+		h.writeError(c, err, "Failed to get invites")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"invites": invites,
+	})
+}
+
+// AcceptInvite accepts an invite addressed to the current user
+func (h *MembershipHandler) AcceptInvite(c *gin.Context) {
+	h.respondToInvite(c, true)
+}
+
+// DeclineInvite declines an invite addressed to the current user
+func (h *MembershipHandler) DeclineInvite(c *gin.Context) {
+	h.respondToInvite(c, false)
+}
+
+func (h *MembershipHandler) respondToInvite(c *gin.Context, accept bool) {
+	user, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	invite, err := h.membershipService.RespondToInvite(c.Request.Context(), user, c.Param("id"), accept)
+	if err != nil {
+		h.writeError(c, err, "Failed to respond to invite")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"invite": invite,
+	})
+}
+
+// JoinTenant joins a tenant with an invite link's code
+func (h *MembershipHandler) JoinTenant(c *gin.Context) {
+	user, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.JoinTenantRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	invite, err := h.membershipService.JoinWithCode(c.Request.Context(), user, req.Code)
+	if err != nil {
+		h.writeError(c, err, "Failed to join tenant")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"invite": invite,
+		"tenant": invite.Tenant,
+	})
+}
+
+// writeError maps membership errors to responses
+func (h *MembershipHandler) writeError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidInvite):
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "VALIDATION_ERROR",
+			Message: message,
+			Details: map[string]interface{}{"error": err.Error()},
+		})
+	case errors.Is(err, services.ErrRoleNotFound):
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "VALIDATION_ERROR",
+			Message: "One or more roles do not exist in this tenant",
+		})
+	case errors.Is(err, services.ErrPermissionNotHeld):
+		c.JSON(http.StatusForbidden, models.APIError{
+			Code:    "INSUFFICIENT_PERMISSIONS",
+			Message: message,
+			Details: map[string]interface{}{"error": err.Error()},
+		})
+	case errors.Is(err, services.ErrOwnerMembership):
+		c.JSON(http.StatusForbidden, models.APIError{
+			Code:    "TENANT_OWNER",
+			Message: "The tenant owner cannot leave or be removed",
+		})
+	case errors.Is(err, services.ErrNotTenantMember):
+		c.JSON(http.StatusNotFound, models.APIError{
+			Code:    "MEMBER_NOT_FOUND",
+			Message: "User is not a member of this tenant",
+		})
+	case errors.Is(err, services.ErrInviteNotFound):
+		c.JSON(http.StatusNotFound, models.APIError{
+			Code:    "INVITE_NOT_FOUND",
+			Message: "Invite not found",
+		})
+	case errors.Is(err, services.ErrInviteClosed):
+		c.JSON(http.StatusGone, models.APIError{
+			Code:    "INVITE_CLOSED",
+			Message: "Invite has expired or is no longer valid",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.APIError{
+			Code:    "INTERNAL_ERROR",
+			Message: message,
+			Details: map[string]interface{}{"error": err.Error()},
+		})
+	}
+}
@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockMembershipService is a mock implementation of MembershipServiceInterface
+type MockMembershipService struct {
+	mock.Mock
+}
+
+func (m *MockMembershipService) ListMembers(ctx context.Context, tenantID string) ([]models.TenantMember, error) {
+	args := m.Called(ctx, tenantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.TenantMember), args.Error(1)
+}
+
+func (m *MockMembershipService) RemoveMember(ctx context.Context, tenantID, userID, performedBy string) error {
+	args := m.Called(ctx, tenantID, userID, performedBy)
+	return args.Error(0)
+}
+
+func (m *MockMembershipService) LeaveTenant(ctx context.Context, tenantID, userID string) error {
+	args := m.Called(ctx, tenantID, userID)
+	return args.Error(0)
+}
+
+func (m *MockMembershipService) CreateInvite(ctx context.Context, tenantID string, req models.CreateInviteRequest, createdBy string) (*models.CreateInviteResponse, error) {
+	args := m.Called(ctx, tenantID, req, createdBy)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CreateInviteResponse), args.Error(1)
+}
+
+func (m *MockMembershipService) ListInvites(ctx context.Context, tenantID string) ([]models.TenantInvite, error) {
+	args := m.Called(ctx, tenantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.TenantInvite), args.Error(1)
+}
+
+func (m *MockMembershipService) RevokeInvite(ctx context.Context, tenantID, inviteID, performedBy string) error {
+	args := m.Called(ctx, tenantID, inviteID, performedBy)
+	return args.Error(0)
+}
+
+func (m *MockMembershipService) ListUserInvites(ctx context.Context, user *models.User) ([]models.TenantInvite, error) {
+	args := m.Called(ctx, user)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.TenantInvite), args.Error(1)
+}
+
+func (m *MockMembershipService) RespondToInvite(ctx context.Context, user *models.User, inviteID string, accept bool) (*models.TenantInvite, error) {
+	args := m.Called(ctx, user, inviteID, accept)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TenantInvite), args.Error(1)
+}
+
+func (m *MockMembershipService) JoinWithCode(ctx context.Context, user *models.User, code string) (*models.TenantInvite, error) {
+	args := m.Called(ctx, user, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TenantInvite), args.Error(1)
+}
+
+func (m *MockMembershipService) JoinGuildTenants(ctx context.Context, user *models.User, guildIDs []string) ([]models.Tenant, error) {
+	args := m.Called(ctx, user, guildIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Tenant), args.Error(1)
+}
+
+func setupMembershipRouter(handler *MembershipHandler) *gin.Engine {
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("user", &models.User{ID: "user-1", DiscordUserID: "1001", Username: "admin"})
+		c.Set("session_id", "session-1")
+		c.Set("tenant", &models.Tenant{ID: "tenant-1", Name: "Test Tenant"})
+		c.Next()
+	})
+	router.GET("/tenant/members", handler.ListMembers)
+	router.DELETE("/tenant/members/:userId", handler.RemoveMember)
+	router.DELETE("/tenant/membership", handler.LeaveTenant)
+	router.POST("/tenant/invites", handler.CreateInvite)
+	router.DELETE("/tenant/invites/:inviteId", handler.RevokeInvite)
+	router.POST("/invites/join", handler.JoinTenant)
+	router.POST("/invites/:id/accept", handler.AcceptInvite)
+	router.POST("/invites/:id/decline", handler.DeclineInvite)
+	return router
+}
+
+func deleteRequest(router *gin.Engine, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", path, nil)
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestMembershipHandler_ListMembers(t *testing.T) {
+	mockMemberships := new(MockMembershipService)
+	router := setupMembershipRouter(NewMembershipHandler(mockMemberships))
+
+	mockMemberships.On("ListMembers", mock.Anything, "tenant-1").Return([]models.TenantMember{{
+		UserID:   "user-2",
+		Username: "member",
+		Roles:    []models.MemberRole{{ID: "role-1", Name: "moderators", Source: models.GrantSourceRole}},
+	}}, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tenant/members", nil)
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Members []models.TenantMember `json:"members"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Members, 1)
+	assert.Equal(t, "moderators", response.Members[0].Roles[0].Name)
+}
+
+func TestMembershipHandler_CreateInvite(t *testing.T) {
+	mockMemberships := new(MockMembershipService)
+	router := setupMembershipRouter(NewMembershipHandler(mockMemberships))
+
+	linkRequest := models.CreateInviteRequest{RoleIDs: []string{"role-1"}}
+	mockMemberships.On("CreateInvite", mock.Anything, "tenant-1", linkRequest, "user-1").Return(&models.CreateInviteResponse{
+		Code:   "secret-code",
+		Invite: models.TenantInvite{ID: "invite-1", TenantID: "tenant-1", ExpiresAt: time.Now().Add(time.Hour)},
+	}, nil)
+	badRequest := models.CreateInviteRequest{DiscordUserID: "someone"}
+	mockMemberships.On("CreateInvite", mock.Anything, "tenant-1", badRequest, "user-1").Return(nil, services.ErrInvalidInvite)
+
+	w := postJSON(router, "/tenant/invites", linkRequest)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var response models.CreateInviteResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "secret-code", response.Code)
+	assert.Equal(t, "invite-1", response.Invite.ID)
+
+	w = postJSON(router, "/tenant/invites", badRequest)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var apiError models.APIError
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &apiError))
+	assert.Equal(t, "VALIDATION_ERROR", apiError.Code)
+}
+
+func TestMembershipHandler_RemoveMember(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{name: "removed", expectedStatus: http.StatusOK},
+		{name: "owner", err: services.ErrOwnerMembership, expectedStatus: http.StatusForbidden, expectedCode: "TENANT_OWNER"},
+		{name: "not a member", err: services.ErrNotTenantMember, expectedStatus: http.StatusNotFound, expectedCode: "MEMBER_NOT_FOUND"},
+		{name: "more powerful member", err: services.ErrPermissionNotHeld, expectedStatus: http.StatusForbidden, expectedCode: "INSUFFICIENT_PERMISSIONS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockMemberships := new(MockMembershipService)
+			router := setupMembershipRouter(NewMembershipHandler(mockMemberships))
+			mockMemberships.On("RemoveMember", mock.Anything, "tenant-1", "user-2", "user-1").Return(tt.err)
+
+			w := deleteRequest(router, "/tenant/members/user-2")
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedCode != "" {
+				var response models.APIError
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.expectedCode, response.Code)
+			}
+			mockMemberships.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMembershipHandler_LeaveTenant(t *testing.T) {
+	mockMemberships := new(MockMembershipService)
+	router := setupMembershipRouter(NewMembershipHandler(mockMemberships))
+	mockMemberships.On("LeaveTenant", mock.Anything, "tenant-1", "user-1").Return(nil)
+
+	w := deleteRequest(router, "/tenant/membership")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockMemberships.AssertExpectations(t)
+}
+
+func TestMembershipHandler_RespondToInvite(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		accept         bool
+		err            error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{name: "accept", path: "/invites/invite-1/accept", accept: true, expectedStatus: http.StatusOK},
+		{name: "decline", path: "/invites/invite-1/decline", accept: false, expectedStatus: http.StatusOK},
+		{name: "not addressed to user", path: "/invites/invite-1/accept", accept: true, err: services.ErrInviteNotFound, expectedStatus: http.StatusNotFound, expectedCode: "INVITE_NOT_FOUND"},
+		{name: "expired", path: "/invites/invite-1/accept", accept: true, err: services.ErrInviteClosed, expectedStatus: http.StatusGone, expectedCode: "INVITE_CLOSED"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockMemberships := new(MockMembershipService)
+			router := setupMembershipRouter(NewMembershipHandler(mockMemberships))
+			if tt.err != nil {
+				mockMemberships.On("RespondToInvite", mock.Anything, mock.Anything, "invite-1", tt.accept).Return(nil, tt.err)
+			} else {
+				mockMemberships.On("RespondToInvite", mock.Anything, mock.Anything, "invite-1", tt.accept).Return(&models.TenantInvite{ID: "invite-1"}, nil)
+			}
+
+			w := postJSON(router, tt.path, nil)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedCode != "" {
+				var response models.APIError
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.expectedCode, response.Code)
+			}
+			mockMemberships.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMembershipHandler_JoinTenant(t *testing.T) {
+	mockMemberships := new(MockMembershipService)
+	router := setupMembershipRouter(NewMembershipHandler(mockMemberships))
+	mockMemberships.On("JoinWithCode", mock.Anything, mock.Anything, "secret-code").Return(&models.TenantInvite{
+		ID:       "invite-1",
+		TenantID: "tenant-2",
+		Tenant:   &models.Tenant{ID: "tenant-2", Name: "Other Tenant"},
+	}, nil)
+
+	w := postJSON(router, "/invites/join", gin.H{})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = postJSON(router, "/invites/join", models.JoinTenantRequest{Code: "secret-code"})
+	require.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Tenant models.Tenant `json:"tenant"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "tenant-2", response.Tenant.ID)
+}
+
+func TestTenantHandler_GetUserTenantsJoinsGuildTenants(t *testing.T) {
+	mockTenantService := new(MockTenantService)
+	mockAuthService := new(MockAuthServiceForTenant)
+	mockMemberships := new(MockMembershipService)
+	handler := NewTenantHandlerWithMemberships(mockTenantService, new(MockDiscordServiceForHandler), mockAuthService, nil, mockMemberships)
+
+	user := &models.User{ID: "user-1"}
+	mockAuthService.On("GetDiscordGuilds", mock.Anything, "session-1").Return([]models.DiscordGuild{{ID: "guild-1"}, {ID: "guild-2"}}, nil)
+	mockMemberships.On("JoinGuildTenants", mock.Anything, user, []string{"guild-1", "guild-2"}).Return([]models.Tenant{{ID: "tenant-1"}}, nil)
+	mockTenantService.On("GetUserTenants", mock.Anything, "user-1").Return([]models.Tenant{{ID: "tenant-1"}}, nil)
+
+	router := setupTestRouter()
+	router.GET("/tenants", func(c *gin.Context) {
+		c.Set("user", user)
+		c.Set("session_id", "session-1")
+		handler.GetUserTenants(c)
+	})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tenants", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockAuthService.AssertExpectations(t)
+	mockMemberships.AssertExpectations(t)
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/services"
+)
+
+// OwnershipHandler handles tenant ownership transfer requests
+type OwnershipHandler struct {
+	ownershipService services.OwnershipServiceInterface
+}
+
+// NewOwnershipHandler creates a new ownership handler
+func NewOwnershipHandler(ownershipService services.OwnershipServiceInterface) *OwnershipHandler {
+	return &OwnershipHandler{
+		ownershipService: ownershipService,
+	}
+}
+
+// GetTransfer gets the tenant's open ownership transfer
+func (h *OwnershipHandler) GetTransfer(c *gin.Context) {
+	tenant, ok := requireTenant(c)
+	if !ok {
+		return
+	}
+
+	transfer, err := h.ownershipService.GetPendingTransfer(c.Request.Context(), tenant.ID)
+	if err != nil {
+		h.writeError(c, err, "Failed to get ownership transfer")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"transfer": transfer,
+	})
+}
+
+// RequestTransfer offers the tenant to one of its members
+func (h *OwnershipHandler) RequestTransfer(c *gin.Context) {
+	user, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	tenant, ok := requireTenant(c)
+	if !ok {
+		return
+	}
+
+	var req models.TransferOwnershipRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	transfer, err := h.ownershipService.RequestTransfer(c.Request.Context(), tenant.ID, req.UserID, user.ID)
+	if err != nil {
+		h.writeError(c, err, "Failed to request ownership transfer")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"transfer": transfer,
+	})
+}
+
+// CancelTransfer withdraws the tenant's open ownership transfer
+func (h *OwnershipHandler) CancelTransfer(c *gin.Context) {
+	user, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	tenant, ok := requireTenant(c)
+	if !ok {
+		return
+	}
+
+	if err := h.ownershipService.CancelTransfer(c.Request.Context(), tenant.ID, user.ID); err != nil {
+		h.writeError(c, err, "Failed to cancel ownership transfer")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Ownership transfer cancelled",
+	})
+}
+
+// ListMyTransfers lists the ownership transfers offered to the current user
+func (h *OwnershipHandler) ListMyTransfers(c *gin.Context) {
+	user, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	transfers, err := h.ownershipService.ListUserTransfers(c.Request.Context(), user.ID)
+	if err != nil {
+		h.writeError(c, err, "Failed to get ownership transfers")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"transfers": transfers,
+	})
+}
+
+// AcceptTransfer accepts an ownership transfer offered to the current user
+func (h *OwnershipHandler) AcceptTransfer(c *gin.Context) {
+	h.respondToTransfer(c, true)
+}
+
+// DeclineTransfer declines an ownership transfer offered to the current user
+func (h *OwnershipHandler) DeclineTransfer(c *gin.Context) {
+	h.respondToTransfer(c, false)
+}
+
+func (h *OwnershipHandler) respondToTransfer(c *gin.Context, accept bool) {
+	user, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	transfer, err := h.ownershipService.RespondToTransfer(c.Request.Context(), c.Param("id"), user.ID, accept)
+	if err != nil {
+		h.writeError(c, err, "Failed to respond to ownership transfer")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"transfer": transfer,
+	})
+}
+
+// ForceTransfer makes a user the owner of a tenant on a superadmin's authority
+func (h *OwnershipHandler) ForceTransfer(c *gin.Context) {
+	user, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.TransferOwnershipRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	tenant, err := h.ownershipService.ForceTransfer(c.Request.Context(), c.Param("id"), req.UserID, req.Reason, user.ID)
+	if err != nil {
+		h.writeError(c, err, "Failed to transfer ownership")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tenant": tenant,
+	})
+}
+
+// writeError maps ownership errors to responses
+func (h *OwnershipHandler) writeError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidTransfer):
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "VALIDATION_ERROR",
+			Message: message,
+			Details: map[string]interface{}{"error": err.Error()},
+		})
+	case errors.Is(err, services.ErrNotTenantOwner):
+		c.JSON(http.StatusForbidden, models.APIError{
+			Code:    "OWNER_ONLY",
+			Message: "Only the tenant owner can transfer ownership",
+		})
+	case errors.Is(err, services.ErrNotTenantMember):
+		c.JSON(http.StatusNotFound, models.APIError{
+			Code:    "MEMBER_NOT_FOUND",
+			Message: "The new owner must be a member of this tenant",
+		})
+	case errors.Is(err, services.ErrTenantNotFound):
+		c.JSON(http.StatusNotFound, models.APIError{
+			Code:    "NOT_FOUND",
+			Message: "Tenant not found",
+		})
+	case errors.Is(err, services.ErrTransferNotFound):
+		c.JSON(http.StatusNotFound, models.APIError{
+			Code:    "TRANSFER_NOT_FOUND",
+			Message: "Ownership transfer not found",
+		})
+	case errors.Is(err, services.ErrTransferClosed):
+		c.JSON(http.StatusGone, models.APIError{
+			Code:    "TRANSFER_CLOSED",
+			Message: "Ownership transfer has expired or is no longer valid",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.APIError{
+			Code:    "INTERNAL_ERROR",
+			Message: message,
+			Details: map[string]interface{}{"error": err.Error()},
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockOwnershipService is a mock implementation of OwnershipServiceInterface
+type MockOwnershipService struct {
+	mock.Mock
+}
+
+func (m *MockOwnershipService) RequestTransfer(ctx context.Context, tenantID, toUserID, requestedBy string) (*models.OwnershipTransfer, error) {
+	args := m.Called(ctx, tenantID, toUserID, requestedBy)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OwnershipTransfer), args.Error(1)
+}
+
+func (m *MockOwnershipService) GetPendingTransfer(ctx context.Context, tenantID string) (*models.OwnershipTransfer, error) {
+	args := m.Called(ctx, tenantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OwnershipTransfer), args.Error(1)
+}
+
+func (m *MockOwnershipService) CancelTransfer(ctx context.Context, tenantID, performedBy string) error {
+	args := m.Called(ctx, tenantID, performedBy)
+	return args.Error(0)
+}
+
+func (m *MockOwnershipService) ListUserTransfers(ctx context.Context, userID string) ([]models.OwnershipTransfer, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.OwnershipTransfer), args.Error(1)
+}
+
+func (m *MockOwnershipService) RespondToTransfer(ctx context.Context, transferID, userID string, accept bool) (*models.OwnershipTransfer, error) {
+	args := m.Called(ctx, transferID, userID, accept)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OwnershipTransfer), args.Error(1)
+}
+
+func (m *MockOwnershipService) ForceTransfer(ctx context.Context, tenantID, toUserID, reason, performedBy string) (*models.Tenant, error) {
+	args := m.Called(ctx, tenantID, toUserID, reason, performedBy)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Tenant), args.Error(1)
+}
+
+func (m *MockOwnershipService) FollowGuildOwner(ctx context.Context, tenantID, ownerDiscordUserID string) error {
+	args := m.Called(ctx, tenantID, ownerDiscordUserID)
+	return args.Error(0)
+}
+
+func setupOwnershipRouter(handler *OwnershipHandler) *gin.Engine {
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("user", &models.User{ID: "user-1", Username: "owner"})
+		c.Set("session_id", "session-1")
+		c.Set("tenant", &models.Tenant{ID: "tenant-1", Name: "Test Tenant", OwnerID: "user-1"})
+		c.Next()
+	})
+	router.GET("/tenant/ownership-transfer", handler.GetTransfer)
+	router.POST("/tenant/ownership-transfer", handler.RequestTransfer)
+	router.DELETE("/tenant/ownership-transfer", handler.CancelTransfer)
+	router.POST("/ownership-transfers/:id/accept", handler.AcceptTransfer)
+	router.POST("/ownership-transfers/:id/decline", handler.DeclineTransfer)
+	router.PUT("/admin/tenants/:id/owner", handler.ForceTransfer)
+	return router
+}
+
+func TestOwnershipHandler_RequestTransfer(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           interface{}
+		err            error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{name: "requested", body: models.TransferOwnershipRequest{UserID: "user-2"}, expectedStatus: http.StatusCreated},
+		{name: "missing user", body: gin.H{}, expectedStatus: http.StatusBadRequest, expectedCode: "VALIDATION_ERROR"},
+		{name: "not a member", body: models.TransferOwnershipRequest{UserID: "user-2"}, err: services.ErrNotTenantMember, expectedStatus: http.StatusNotFound, expectedCode: "MEMBER_NOT_FOUND"},
+		{name: "service account", body: models.TransferOwnershipRequest{UserID: "user-2"}, err: services.ErrInvalidTransfer, expectedStatus: http.StatusBadRequest, expectedCode: "VALIDATION_ERROR"},
+		{name: "not the owner", body: models.TransferOwnershipRequest{UserID: "user-2"}, err: services.ErrNotTenantOwner, expectedStatus: http.StatusForbidden, expectedCode: "OWNER_ONLY"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockOwnership := new(MockOwnershipService)
+			router := setupOwnershipRouter(NewOwnershipHandler(mockOwnership))
+			if tt.err != nil {
+				mockOwnership.On("RequestTransfer", mock.Anything, "tenant-1", "user-2", "user-1").Return(nil, tt.err)
+			} else {
+				mockOwnership.On("RequestTransfer", mock.Anything, "tenant-1", "user-2", "user-1").Return(&models.OwnershipTransfer{ID: "transfer-1", ToUserID: "user-2"}, nil)
+			}
+
+			w := postJSON(router, "/tenant/ownership-transfer", tt.body)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedCode != "" {
+				var response models.APIError
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.expectedCode, response.Code)
+			}
+		})
+	}
+}
+
+func TestOwnershipHandler_GetTransfer(t *testing.T) {
+	mockOwnership := new(MockOwnershipService)
+	router := setupOwnershipRouter(NewOwnershipHandler(mockOwnership))
+	mockOwnership.On("GetPendingTransfer", mock.Anything, "tenant-1").Return(nil, services.ErrTransferNotFound)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tenant/ownership-transfer", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	var response models.APIError
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "TRANSFER_NOT_FOUND", response.Code)
+}
+
+func TestOwnershipHandler_RespondToTransfer(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		accept         bool
+		err            error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{name: "accept", path: "/ownership-transfers/transfer-1/accept", accept: true, expectedStatus: http.StatusOK},
+		{name: "decline", path: "/ownership-transfers/transfer-1/decline", accept: false, expectedStatus: http.StatusOK},
+		{name: "offered to someone else", path: "/ownership-transfers/transfer-1/accept", accept: true, err: services.ErrTransferNotFound, expectedStatus: http.StatusNotFound, expectedCode: "TRANSFER_NOT_FOUND"},
+		{name: "owner changed meanwhile", path: "/ownership-transfers/transfer-1/accept", accept: true, err: services.ErrTransferClosed, expectedStatus: http.StatusGone, expectedCode: "TRANSFER_CLOSED"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockOwnership := new(MockOwnershipService)
+			router := setupOwnershipRouter(NewOwnershipHandler(mockOwnership))
+			if tt.err != nil {
+				mockOwnership.On("RespondToTransfer", mock.Anything, "transfer-1", "user-1", tt.accept).Return(nil, tt.err)
+			} else {
+				mockOwnership.On("RespondToTransfer", mock.Anything, "transfer-1", "user-1", tt.accept).Return(&models.OwnershipTransfer{ID: "transfer-1"}, nil)
+			}
+
+			w := postJSON(router, tt.path, nil)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedCode != "" {
+				var response models.APIError
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.expectedCode, response.Code)
+			}
+			mockOwnership.AssertExpectations(t)
+		})
+	}
+}
+
+func TestOwnershipHandler_ForceTransfer(t *testing.T) {
+	mockOwnership := new(MockOwnershipService)
+	router := setupOwnershipRouter(NewOwnershipHandler(mockOwnership))
+	mockOwnership.On("ForceTransfer", mock.Anything, "tenant-2", "user-3", "owner left Discord", "user-1").Return(&models.Tenant{ID: "tenant-2", OwnerID: "user-3"}, nil)
+	mockOwnership.On("ForceTransfer", mock.Anything, "missing", "user-3", "", "user-1").Return(nil, services.ErrTenantNotFound)
+
+	data, _ := json.Marshal(models.TransferOwnershipRequest{UserID: "user-3", Reason: "owner left Discord"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/admin/tenants/tenant-2/owner", bytes.NewBuffer(data))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Tenant models.Tenant `json:"tenant"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "user-3", response.Tenant.OwnerID)
+
+	data, _ = json.Marshal(models.TransferOwnershipRequest{UserID: "user-3"})
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("PUT", "/admin/tenants/missing/owner", bytes.NewBuffer(data))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+)
+
+// ListPermissions lists the permission catalog grouped by resource. With
+// scope=tenant only the permissions that can be granted in a tenant are listed.
+func (h *RBACHandler) ListPermissions(c *gin.Context) {
+	scope := models.PermissionScope(c.Query("scope"))
+	if scope != "" && scope != models.ScopeTenant && scope != models.ScopeGlobal {
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "VALIDATION_ERROR",
+			Message: "Scope must be tenant or global",
+		})
+		return
+	}
+
+	permissions, err := h.rbacService.ListPermissions(c.Request.Context(), scope)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIError{
+			Code:    "INTERNAL_ERROR",
+			Message: "Failed to get permissions",
+			Details: map[string]interface{}{"error": err.Error()},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"permissions": models.GroupPermissions(permissions),
+	})
+}
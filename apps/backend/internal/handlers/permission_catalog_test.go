@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListPermissions(t *testing.T) {
+	mockRBACService := &MockRBACService{}
+	router := setupTestRouter()
+	router.GET("/permissions", NewRBACHandler(mockRBACService).ListPermissions)
+	mockRBACService.On("ListPermissions", mock.Anything, models.ScopeTenant).Return([]models.Permission{
+		{Name: models.PermissionServerRead, Resource: "server", Action: "read", Scope: models.ScopeTenant},
+		{Name: models.PermissionServerStart, Resource: "server", Action: "start", Scope: models.ScopeTenant},
+		{Name: models.PermissionLogRead, Resource: "log", Action: "read", Scope: models.ScopeTenant},
+	}, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/permissions?scope=tenant", nil)
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Permissions []models.PermissionGroup `json:"permissions"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Permissions, 2)
+	assert.Equal(t, "server", response.Permissions[0].Resource)
+	assert.Len(t, response.Permissions[0].Permissions, 2)
+	assert.Equal(t, "log", response.Permissions[1].Resource)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/permissions?scope=galaxy", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockRBACService.AssertExpectations(t)
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/services"
+	"gopkg.in/yaml.v3"
+)
+
+// ExportPolicy returns the tenant's authorization setup as a policy document,
+// in JSON or, with format=yaml, in YAML
+func (h *RBACHandler) ExportPolicy(c *gin.Context) {
+	tenant, ok := requireTenant(c)
+	if !ok {
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "yaml" {
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "VALIDATION_ERROR",
+			Message: "Format must be json or yaml",
+		})
+		return
+	}
+
+	policy, err := h.rbacService.ExportPolicy(c.Request.Context(), tenant.ID)
+	if err != nil {
+		writePolicyError(c, err, "Failed to export policy")
+		return
+	}
+
+	if format == "json" {
+		c.JSON(http.StatusOK, policy)
+		return
+	}
+
+	data, err := yaml.Marshal(policy)
+	if err != nil {
+		writePolicyError(c, err, "Failed to export policy")
+		return
+	}
+	c.Data(http.StatusOK, "application/yaml", data)
+}
+
+// ImportPolicy applies a policy document to the tenant. The body is read as
+// YAML when its content type says so and as JSON otherwise. With dry_run=true
+// the changes are returned without being made.
+func (h *RBACHandler) ImportPolicy(c *gin.Context) {
+	user, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	tenant, ok := requireTenant(c)
+	if !ok {
+		return
+	}
+
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "VALIDATION_ERROR",
+			Message: "dry_run must be true or false",
+		})
+		return
+	}
+
+	policy, err := decodePolicy(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "VALIDATION_ERROR",
+			Message: "Invalid policy document",
+			Details: map[string]interface{}{"error": err.Error()},
+		})
+		return
+	}
+
+	result, err := h.rbacService.ImportPolicy(c.Request.Context(), tenant.ID, policy, dryRun, user.ID)
+	if err != nil {
+		writePolicyError(c, err, "Failed to import policy")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"result": result,
+	})
+}
+
+// decodePolicy reads a policy document from the request body, rejecting
+// unknown fields so that typos are not silently ignored
+func decodePolicy(c *gin.Context) (*models.TenantPolicy, error) {
+	body, err := c.GetRawData()
+	if err != nil {
+		return nil, err
+	}
+
+	var policy models.TenantPolicy
+	if strings.Contains(c.ContentType(), "yaml") {
+		decoder := yaml.NewDecoder(bytes.NewReader(body))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&policy)
+	} else {
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&policy)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+// writePolicyError maps policy errors to responses
+func writePolicyError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidPolicy), errors.Is(err, services.ErrInvalidPermission):
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "VALIDATION_ERROR",
+			Message: message,
+			Details: map[string]interface{}{"error": err.Error()},
+		})
+	case errors.Is(err, services.ErrPermissionNotHeld):
+		c.JSON(http.StatusForbidden, models.APIError{
+			Code:    "INSUFFICIENT_PERMISSIONS",
+			Message: message,
+			Details: map[string]interface{}{"error": err.Error()},
+		})
+	case errors.Is(err, services.ErrTenantNotFound):
+		c.JSON(http.StatusNotFound, models.APIError{
+			Code:    "NOT_FOUND",
+			Message: "Tenant not found",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.APIError{
+			Code:    "INTERNAL_ERROR",
+			Message: message,
+			Details: map[string]interface{}{"error": err.Error()},
+		})
+	}
+}
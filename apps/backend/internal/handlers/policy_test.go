@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func setupPolicyRouter(handler *RBACHandler) *gin.Engine {
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("user", &models.User{ID: "user-1"})
+		c.Set("tenant", &models.Tenant{ID: "tenant-1"})
+		c.Next()
+	})
+	router.GET("/tenant/policy", handler.ExportPolicy)
+	router.POST("/tenant/policy", handler.ImportPolicy)
+	return router
+}
+
+func testPolicy() *models.TenantPolicy {
+	return &models.TenantPolicy{
+		Version: models.PolicyVersion,
+		Roles: []models.PolicyRole{
+			{Name: "moderators", Permissions: []string{models.PermissionServerRestart, models.PermissionConsoleRead}},
+		},
+		DiscordRoles: []models.PolicyDiscordRole{
+			{ID: "discord-mod", Name: "Moderator", Roles: []string{"moderators"}},
+		},
+	}
+}
+
+func TestExportPolicy(t *testing.T) {
+	mockRBACService := &MockRBACService{}
+	router := setupPolicyRouter(NewRBACHandler(mockRBACService))
+	mockRBACService.On("ExportPolicy", mock.Anything, "tenant-1").Return(testPolicy(), nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tenant/policy", nil)
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var policy models.TenantPolicy
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &policy))
+	assert.Equal(t, testPolicy(), &policy)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/tenant/policy?format=yaml", nil)
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/yaml", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "name: moderators")
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/tenant/policy?format=xml", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestImportPolicy(t *testing.T) {
+	policyJSON, _ := json.Marshal(testPolicy())
+	policyYAML := `version: 1
+roles:
+  - name: moderators
+    permissions: [server:restart, console:read]
+discord_roles:
+  - id: discord-mod
+    name: Moderator
+    roles: [moderators]
+`
+
+	tests := []struct {
+		name           string
+		path           string
+		contentType    string
+		body           string
+		dryRun         bool
+		serviceErr     error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{name: "json", path: "/tenant/policy", contentType: "application/json", body: string(policyJSON), expectedStatus: http.StatusOK},
+		{name: "yaml dry run", path: "/tenant/policy?dry_run=true", contentType: "application/yaml", body: policyYAML, dryRun: true, expectedStatus: http.StatusOK},
+		{name: "unknown field", path: "/tenant/policy", contentType: "application/yaml", body: policyYAML + "rolez: []\n", expectedStatus: http.StatusBadRequest, expectedCode: "VALIDATION_ERROR"},
+		{name: "bad dry run flag", path: "/tenant/policy?dry_run=maybe", contentType: "application/json", body: string(policyJSON), expectedStatus: http.StatusBadRequest, expectedCode: "VALIDATION_ERROR"},
+		{name: "invalid policy", path: "/tenant/policy", contentType: "application/json", body: string(policyJSON), serviceErr: fmt.Errorf("%w: roles[0]: unknown permission console:sudo", services.ErrInvalidPolicy), expectedStatus: http.StatusBadRequest, expectedCode: "VALIDATION_ERROR"},
+		{name: "permission not held", path: "/tenant/policy", contentType: "application/json", body: string(policyJSON), serviceErr: fmt.Errorf("%w: server:restart", services.ErrPermissionNotHeld), expectedStatus: http.StatusForbidden, expectedCode: "INSUFFICIENT_PERMISSIONS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRBACService := &MockRBACService{}
+			router := setupPolicyRouter(NewRBACHandler(mockRBACService))
+			if tt.serviceErr != nil {
+				mockRBACService.On("ImportPolicy", mock.Anything, "tenant-1", testPolicy(), tt.dryRun, "user-1").Return(nil, tt.serviceErr)
+			} else {
+				mockRBACService.On("ImportPolicy", mock.Anything, "tenant-1", testPolicy(), tt.dryRun, "user-1").Return(&models.PolicyImportResult{
+					DryRun:  tt.dryRun,
+					Changes: []models.PolicyChange{{Action: models.PolicyActionCreate, Kind: models.PolicyKindRole, Key: "moderators"}},
+				}, nil)
+			}
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedCode != "" {
+				var response models.APIError
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.expectedCode, response.Code)
+				return
+			}
+
+			var response struct {
+				Result models.PolicyImportResult `json:"result"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tt.dryRun, response.Result.DryRun)
+			assert.Equal(t, "moderators", response.Result.Changes[0].Key)
+			mockRBACService.AssertExpectations(t)
+		})
+	}
+}
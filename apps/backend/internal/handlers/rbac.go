@@ -0,0 +1,608 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/services"
+)
+
+// RBACHandler handles tenant role and permission management requests
+type RBACHandler struct {
+	rbacService services.RBACServiceInterface
+}
+
+// NewRBACHandler creates a new RBAC handler
+func NewRBACHandler(rbacService services.RBACServiceInterface) *RBACHandler {
+	return &RBACHandler{
+		rbacService: rbacService,
+	}
+}
+
+// DiscordRoleMappingRequest is the body for updating a Discord role's permission mapping
+type DiscordRoleMappingRequest struct {
+	Permissions []string `json:"permissions"`
+	RoleIDs     []string `json:"role_ids"`
+}
+
+// GetDiscordRoles lists the tenant's Discord roles with their permission mappings
+func (h *RBACHandler) GetDiscordRoles(c *gin.Context) {
+	tenant, exists := c.Get("tenant")
+	if !exists {
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "TENANT_REQUIRED",
+			Message: "Tenant context is required",
+		})
+		return
+	}
+
+	tenantModel := tenant.(*models.Tenant)
+
+	roles, err := h.rbacService.GetDiscordRoles(c.Request.Context(), tenantModel.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIError{
+			Code:    "INTERNAL_ERROR",
+			Message: "Failed to get Discord roles",
+			Details: map[string]interface{}{"error": err.Error()},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"discord_roles": roles,
+	})
+}
+
+// UpdateDiscordRoleMapping replaces the permissions and internal roles granted by a Discord role
+func (h *RBACHandler) UpdateDiscordRoleMapping(c *gin.Context) {
+	tenant, exists := c.Get("tenant")
+	if !exists {
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "TENANT_REQUIRED",
+			Message: "Tenant context is required",
+		})
+		return
+	}
+
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIError{
+			Code:    "UNAUTHORIZED",
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	tenantModel := tenant.(*models.Tenant)
+	userModel := user.(*models.User)
+
+	var req DiscordRoleMappingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "VALIDATION_ERROR",
+			Message: "Invalid request body",
+			Details: map[string]interface{}{"error": err.Error()},
+		})
+		return
+	}
+
+	role, err := h.rbacService.SetDiscordRoleMapping(c.Request.Context(), tenantModel.ID, c.Param("roleId"), req.Permissions, req.RoleIDs, userModel.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidPermission):
+			c.JSON(http.StatusBadRequest, models.APIError{
+				Code:    "VALIDATION_ERROR",
+				Message: "Unknown permission",
+				Details: map[string]interface{}{"error": err.Error()},
+			})
+		case errors.Is(err, services.ErrRoleNotFound):
+			c.JSON(http.StatusBadRequest, models.APIError{
+				Code:    "VALIDATION_ERROR",
+				Message: "One or more roles do not exist in this tenant",
+			})
+		case errors.Is(err, services.ErrDiscordRoleNotFound):
+			c.JSON(http.StatusNotFound, models.APIError{
+				Code:    "ROLE_NOT_FOUND",
+				Message: "Discord role not found",
+			})
+		case errors.Is(err, services.ErrPermissionNotHeld):
+			c.JSON(http.StatusForbidden, models.APIError{
+				Code:    "INSUFFICIENT_PERMISSIONS",
+				Message: "You cannot grant or remove permissions you do not hold",
+				Details: map[string]interface{}{"error": err.Error()},
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, models.APIError{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to update Discord role mapping",
+				Details: map[string]interface{}{"error": err.Error()},
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"discord_role": role,
+	})
+}
+
+// GetRoles lists the tenant's internal roles
+func (h *RBACHandler) GetRoles(c *gin.Context) {
+	tenant, exists := c.Get("tenant")
+	if !exists {
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "TENANT_REQUIRED",
+			Message: "Tenant context is required",
+		})
+		return
+	}
+
+	tenantModel := tenant.(*models.Tenant)
+
+	roles, err := h.rbacService.GetRoles(c.Request.Context(), tenantModel.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIError{
+			Code:    "INTERNAL_ERROR",
+			Message: "Failed to get roles",
+			Details: map[string]interface{}{"error": err.Error()},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"roles": roles,
+	})
+}
+
+// CreateRole creates an internal role in the tenant
+func (h *RBACHandler) CreateRole(c *gin.Context) {
+	tenant, exists := c.Get("tenant")
+	if !exists {
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "TENANT_REQUIRED",
+			Message: "Tenant context is required",
+		})
+		return
+	}
+
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIError{
+			Code:    "UNAUTHORIZED",
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	tenantModel := tenant.(*models.Tenant)
+	userModel := user.(*models.User)
+
+	var req models.RoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "VALIDATION_ERROR",
+			Message: "Invalid request body",
+			Details: map[string]interface{}{"error": err.Error()},
+		})
+		return
+	}
+
+	role, err := h.rbacService.CreateTenantRole(c.Request.Context(), tenantModel.ID, req, userModel.ID)
+	if err != nil {
+		writeRoleError(c, err, "Failed to create role")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"role": role,
+	})
+}
+
+// UpdateRole renames an internal role and replaces its permissions
+func (h *RBACHandler) UpdateRole(c *gin.Context) {
+	tenant, exists := c.Get("tenant")
+	if !exists {
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "TENANT_REQUIRED",
+			Message: "Tenant context is required",
+		})
+		return
+	}
+
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIError{
+			Code:    "UNAUTHORIZED",
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	tenantModel := tenant.(*models.Tenant)
+	userModel := user.(*models.User)
+
+	var req models.RoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "VALIDATION_ERROR",
+			Message: "Invalid request body",
+			Details: map[string]interface{}{"error": err.Error()},
+		})
+		return
+	}
+
+	role, err := h.rbacService.UpdateTenantRole(c.Request.Context(), tenantModel.ID, c.Param("roleId"), req, userModel.ID)
+	if err != nil {
+		writeRoleError(c, err, "Failed to update role")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"role": role,
+	})
+}
+
+// DeleteRole deletes an internal role from the tenant
+func (h *RBACHandler) DeleteRole(c *gin.Context) {
+	tenant, exists := c.Get("tenant")
+	if !exists {
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "TENANT_REQUIRED",
+			Message: "Tenant context is required",
+		})
+		return
+	}
+
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIError{
+			Code:    "UNAUTHORIZED",
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	tenantModel := tenant.(*models.Tenant)
+	userModel := user.(*models.User)
+
+	err := h.rbacService.DeleteTenantRole(c.Request.Context(), tenantModel.ID, c.Param("roleId"), userModel.ID)
+	if err != nil {
+		writeRoleError(c, err, "Failed to delete role")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Role deleted",
+	})
+}
+
+// AssignMemberRole gives a tenant member an internal role
+func (h *RBACHandler) AssignMemberRole(c *gin.Context) {
+	tenant, exists := c.Get("tenant")
+	if !exists {
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "TENANT_REQUIRED",
+			Message: "Tenant context is required",
+		})
+		return
+	}
+
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIError{
+			Code:    "UNAUTHORIZED",
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	tenantModel := tenant.(*models.Tenant)
+	userModel := user.(*models.User)
+
+	var req models.AssignRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "VALIDATION_ERROR",
+			Message: "Invalid request body",
+			Details: map[string]interface{}{"error": err.Error()},
+		})
+		return
+	}
+
+	err := h.rbacService.AssignTenantRole(c.Request.Context(), tenantModel.ID, c.Param("userId"), req.RoleID, userModel.ID)
+	if err != nil {
+		writeRoleError(c, err, "Failed to assign role")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Role assigned",
+	})
+}
+
+// RemoveMemberRole takes an internal role away from a tenant member
+func (h *RBACHandler) RemoveMemberRole(c *gin.Context) {
+	tenant, exists := c.Get("tenant")
+	if !exists {
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "TENANT_REQUIRED",
+			Message: "Tenant context is required",
+		})
+		return
+	}
+
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIError{
+			Code:    "UNAUTHORIZED",
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	tenantModel := tenant.(*models.Tenant)
+	userModel := user.(*models.User)
+
+	err := h.rbacService.UnassignTenantRole(c.Request.Context(), tenantModel.ID, c.Param("userId"), c.Param("roleId"), userModel.ID)
+	if err != nil {
+		writeRoleError(c, err, "Failed to remove role")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Role removed",
+	})
+}
+
+// GetServerGrants lists the resource grants on a game server
+func (h *RBACHandler) GetServerGrants(c *gin.Context) {
+	tenant, exists := c.Get("tenant")
+	if !exists {
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "TENANT_REQUIRED",
+			Message: "Tenant context is required",
+		})
+		return
+	}
+
+	tenantModel := tenant.(*models.Tenant)
+
+	grants, err := h.rbacService.ListResourceGrants(c.Request.Context(), tenantModel.ID, models.ResourceTypeGameServer, c.Param("serverId"))
+	if err != nil {
+		writeResourceGrantError(c, err, "Failed to get server grants")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"grants": grants,
+	})
+}
+
+// CreateServerGrant grants a user or role a permission on a single game server
+func (h *RBACHandler) CreateServerGrant(c *gin.Context) {
+	tenant, exists := c.Get("tenant")
+	if !exists {
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "TENANT_REQUIRED",
+			Message: "Tenant context is required",
+		})
+		return
+	}
+
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIError{
+			Code:    "UNAUTHORIZED",
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	tenantModel := tenant.(*models.Tenant)
+	userModel := user.(*models.User)
+
+	var req models.CreateResourceGrantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "VALIDATION_ERROR",
+			Message: "Invalid request body",
+			Details: map[string]interface{}{"error": err.Error()},
+		})
+		return
+	}
+
+	grant, err := h.rbacService.CreateResourceGrant(c.Request.Context(), tenantModel.ID, models.ResourceTypeGameServer, c.Param("serverId"), req, userModel.ID)
+	if err != nil {
+		writeResourceGrantError(c, err, "Failed to create server grant")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"grant": grant,
+	})
+}
+
+// DeleteServerGrant removes a resource grant from a game server
+func (h *RBACHandler) DeleteServerGrant(c *gin.Context) {
+	tenant, exists := c.Get("tenant")
+	if !exists {
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "TENANT_REQUIRED",
+			Message: "Tenant context is required",
+		})
+		return
+	}
+
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIError{
+			Code:    "UNAUTHORIZED",
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	tenantModel := tenant.(*models.Tenant)
+	userModel := user.(*models.User)
+
+	err := h.rbacService.DeleteResourceGrant(c.Request.Context(), tenantModel.ID, models.ResourceTypeGameServer, c.Param("serverId"), c.Param("grantId"), userModel.ID)
+	if err != nil {
+		writeResourceGrantError(c, err, "Failed to delete server grant")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Grant removed",
+	})
+}
+
+// ExplainPermission reports whether a tenant member holds a permission,
+// optionally on a single game server, and which grants decided it. The user
+// defaults to the caller.
+func (h *RBACHandler) ExplainPermission(c *gin.Context) {
+	tenant, exists := c.Get("tenant")
+	if !exists {
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "TENANT_REQUIRED",
+			Message: "Tenant context is required",
+		})
+		return
+	}
+
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIError{
+			Code:    "UNAUTHORIZED",
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	tenantModel := tenant.(*models.Tenant)
+	userModel := user.(*models.User)
+
+	permission := c.Query("permission")
+	if !models.IsValidTenantPermission(permission) {
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "VALIDATION_ERROR",
+			Message: "A known permission is required",
+			Details: map[string]interface{}{"permission": permission},
+		})
+		return
+	}
+
+	userID := c.DefaultQuery("user", userModel.ID)
+	if _, err := uuid.Parse(userID); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "VALIDATION_ERROR",
+			Message: "User must be a valid user ID",
+			Details: map[string]interface{}{"user": userID},
+		})
+		return
+	}
+
+	var resourceType string
+	resourceID := c.Query("resource")
+	if resourceID != "" {
+		resourceType = models.ResourceTypeGameServer
+	}
+
+	decision, err := h.rbacService.ExplainPermission(c.Request.Context(), userID, tenantModel.ID, permission, resourceType, resourceID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIError{
+			Code:    "INTERNAL_ERROR",
+			Message: "Failed to evaluate permission",
+			Details: map[string]interface{}{"error": err.Error()},
+		})
+		return
+	}
+
+	// Only members of the tenant can be explained
+	if userID != userModel.ID && decision.Reason == models.DecisionReasonNotMember {
+		c.JSON(http.StatusNotFound, models.APIError{
+			Code:    "NOT_FOUND",
+			Message: "User is not a member of this tenant",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"decision": decision,
+	})
+}
+
+// writeRoleError maps tenant role errors to responses
+func writeRoleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidPermission), errors.Is(err, services.ErrInvalidRole):
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "VALIDATION_ERROR",
+			Message: message,
+			Details: map[string]interface{}{"error": err.Error()},
+		})
+	case errors.Is(err, services.ErrRoleExists):
+		c.JSON(http.StatusConflict, models.APIError{
+			Code:    "ROLE_EXISTS",
+			Message: "A role with this name already exists",
+		})
+	case errors.Is(err, services.ErrPermissionNotHeld):
+		c.JSON(http.StatusForbidden, models.APIError{
+			Code:    "INSUFFICIENT_PERMISSIONS",
+			Message: message,
+			Details: map[string]interface{}{"error": err.Error()},
+		})
+	case errors.Is(err, services.ErrSystemRoleProtected):
+		c.JSON(http.StatusForbidden, models.APIError{
+			Code:    "SYSTEM_ROLE",
+			Message: "System roles cannot be changed",
+		})
+	case errors.Is(err, services.ErrRoleNotFound):
+		c.JSON(http.StatusNotFound, models.APIError{
+			Code:    "ROLE_NOT_FOUND",
+			Message: "Role not found",
+		})
+	case errors.Is(err, services.ErrNotTenantMember):
+		c.JSON(http.StatusNotFound, models.APIError{
+			Code:    "MEMBER_NOT_FOUND",
+			Message: "User is not a member of this tenant",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.APIError{
+			Code:    "INTERNAL_ERROR",
+			Message: message,
+			Details: map[string]interface{}{"error": err.Error()},
+		})
+	}
+}
+
+// writeResourceGrantError maps resource grant errors to responses
+func writeResourceGrantError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidPermission), errors.Is(err, services.ErrInvalidResourceGrant):
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "VALIDATION_ERROR",
+			Message: message,
+			Details: map[string]interface{}{"error": err.Error()},
+		})
+	case errors.Is(err, services.ErrPermissionNotHeld):
+		c.JSON(http.StatusForbidden, models.APIError{
+			Code:    "INSUFFICIENT_PERMISSIONS",
+			Message: message,
+			Details: map[string]interface{}{"error": err.Error()},
+		})
+	case errors.Is(err, services.ErrResourceGrantNotFound):
+		c.JSON(http.StatusNotFound, models.APIError{
+			Code:    "GRANT_NOT_FOUND",
+			Message: "Grant not found",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.APIError{
+			Code:    "INTERNAL_ERROR",
+			Message: message,
+			Details: map[string]interface{}{"error": err.Error()},
+		})
+	}
+}
@@ -0,0 +1,500 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRBACService is a mock implementation of RBACServiceInterface
+type MockRBACService struct {
+	mock.Mock
+}
+
+func (m *MockRBACService) GetRoles(ctx context.Context, tenantID string) ([]models.Role, error) {
+	args := m.Called(ctx, tenantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Role), args.Error(1)
+}
+
+func (m *MockRBACService) CreateTenantRole(ctx context.Context, tenantID string, req models.RoleRequest, performedBy string) (*models.Role, error) {
+	args := m.Called(ctx, tenantID, req, performedBy)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Role), args.Error(1)
+}
+
+func (m *MockRBACService) UpdateTenantRole(ctx context.Context, tenantID, roleID string, req models.RoleRequest, performedBy string) (*models.Role, error) {
+	args := m.Called(ctx, tenantID, roleID, req, performedBy)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Role), args.Error(1)
+}
+
+func (m *MockRBACService) DeleteTenantRole(ctx context.Context, tenantID, roleID, performedBy string) error {
+	args := m.Called(ctx, tenantID, roleID, performedBy)
+	return args.Error(0)
+}
+
+func (m *MockRBACService) AssignTenantRole(ctx context.Context, tenantID, userID, roleID, performedBy string) error {
+	args := m.Called(ctx, tenantID, userID, roleID, performedBy)
+	return args.Error(0)
+}
+
+func (m *MockRBACService) UnassignTenantRole(ctx context.Context, tenantID, userID, roleID, performedBy string) error {
+	args := m.Called(ctx, tenantID, userID, roleID, performedBy)
+	return args.Error(0)
+}
+
+func (m *MockRBACService) GetDiscordRoles(ctx context.Context, tenantID string) ([]models.TenantDiscordRole, error) {
+	args := m.Called(ctx, tenantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.TenantDiscordRole), args.Error(1)
+}
+
+func (m *MockRBACService) SetDiscordRoleMapping(ctx context.Context, tenantID, discordRoleID string, permissions, roleIDs []string, performedBy string) (*models.TenantDiscordRole, error) {
+	args := m.Called(ctx, tenantID, discordRoleID, permissions, roleIDs, performedBy)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TenantDiscordRole), args.Error(1)
+}
+
+func (m *MockRBACService) GetAccessibleResourceIDs(ctx context.Context, userID, tenantID, permission, resourceType string) (bool, []string, error) {
+	args := m.Called(ctx, userID, tenantID, permission, resourceType)
+	if args.Get(1) == nil {
+		return args.Bool(0), nil, args.Error(2)
+	}
+	return args.Bool(0), args.Get(1).([]string), args.Error(2)
+}
+
+func (m *MockRBACService) ListResourceGrants(ctx context.Context, tenantID, resourceType, resourceID string) ([]models.ResourceGrant, error) {
+	args := m.Called(ctx, tenantID, resourceType, resourceID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.ResourceGrant), args.Error(1)
+}
+
+func (m *MockRBACService) CreateResourceGrant(ctx context.Context, tenantID, resourceType, resourceID string, req models.CreateResourceGrantRequest, performedBy string) (*models.ResourceGrant, error) {
+	args := m.Called(ctx, tenantID, resourceType, resourceID, req, performedBy)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ResourceGrant), args.Error(1)
+}
+
+func (m *MockRBACService) DeleteResourceGrant(ctx context.Context, tenantID, resourceType, resourceID, grantID, performedBy string) error {
+	args := m.Called(ctx, tenantID, resourceType, resourceID, grantID, performedBy)
+	return args.Error(0)
+}
+
+func (m *MockRBACService) ExplainPermission(ctx context.Context, userID, tenantID, permission, resourceType, resourceID string) (*models.AuthorizationDecision, error) {
+	args := m.Called(ctx, userID, tenantID, permission, resourceType, resourceID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AuthorizationDecision), args.Error(1)
+}
+
+func (m *MockRBACService) ExportPolicy(ctx context.Context, tenantID string) (*models.TenantPolicy, error) {
+	args := m.Called(ctx, tenantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TenantPolicy), args.Error(1)
+}
+
+func (m *MockRBACService) ImportPolicy(ctx context.Context, tenantID string, policy *models.TenantPolicy, dryRun bool, performedBy string) (*models.PolicyImportResult, error) {
+	args := m.Called(ctx, tenantID, policy, dryRun, performedBy)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PolicyImportResult), args.Error(1)
+}
+
+func (m *MockRBACService) ListPermissions(ctx context.Context, scope models.PermissionScope) ([]models.Permission, error) {
+	args := m.Called(ctx, scope)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Permission), args.Error(1)
+}
+
+func TestGetDiscordRoles_Success(t *testing.T) {
+	mockRBACService := &MockRBACService{}
+	handler := NewRBACHandler(mockRBACService)
+
+	roles := []models.TenantDiscordRole{
+		{DiscordRoleID: "role-mod", Name: "Moderator", Permissions: models.StringArray{models.PermissionConsoleRead}},
+	}
+	mockRBACService.On("GetDiscordRoles", mock.Anything, "tenant-123").Return(roles, nil)
+
+	c, w := setupGinContextForGameServer("GET", "/api/tenant/discord-roles", nil)
+	c.Set("tenant", &models.Tenant{ID: "tenant-123"})
+
+	handler.GetDiscordRoles(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response map[string][]models.TenantDiscordRole
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "Moderator", response["discord_roles"][0].Name)
+	mockRBACService.AssertExpectations(t)
+}
+
+func TestUpdateDiscordRoleMapping(t *testing.T) {
+	tests := []struct {
+		name         string
+		serviceErr   error
+		expectedCode int
+		expectedErr  string
+	}{
+		{name: "success", expectedCode: http.StatusOK},
+		{name: "unknown permission", serviceErr: fmt.Errorf("%w: console:sudo", services.ErrInvalidPermission), expectedCode: http.StatusBadRequest, expectedErr: "VALIDATION_ERROR"},
+		{name: "unknown role", serviceErr: services.ErrRoleNotFound, expectedCode: http.StatusBadRequest, expectedErr: "VALIDATION_ERROR"},
+		{name: "unknown Discord role", serviceErr: services.ErrDiscordRoleNotFound, expectedCode: http.StatusNotFound, expectedErr: "ROLE_NOT_FOUND"},
+		{name: "permission not held", serviceErr: fmt.Errorf("%w: console:read", services.ErrPermissionNotHeld), expectedCode: http.StatusForbidden, expectedErr: "INSUFFICIENT_PERMISSIONS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRBACService := &MockRBACService{}
+			handler := NewRBACHandler(mockRBACService)
+
+			permissions := []string{models.PermissionConsoleRead}
+			if tt.serviceErr != nil {
+				mockRBACService.On("SetDiscordRoleMapping", mock.Anything, "tenant-123", "role-mod", permissions, []string(nil), "user-123").Return(nil, tt.serviceErr)
+			} else {
+				mockRBACService.On("SetDiscordRoleMapping", mock.Anything, "tenant-123", "role-mod", permissions, []string(nil), "user-123").
+					Return(&models.TenantDiscordRole{DiscordRoleID: "role-mod", Permissions: models.StringArray(permissions)}, nil)
+			}
+
+			c, w := setupGinContextForGameServer("PUT", "/api/tenant/discord-roles/role-mod", DiscordRoleMappingRequest{Permissions: permissions})
+			c.Set("tenant", &models.Tenant{ID: "tenant-123"})
+			c.Set("user", &models.User{ID: "user-123"})
+			c.AddParam("roleId", "role-mod")
+
+			handler.UpdateDiscordRoleMapping(c)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedErr != "" {
+				var response models.APIError
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.expectedErr, response.Code)
+			}
+			mockRBACService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreateRole(t *testing.T) {
+	tests := []struct {
+		name         string
+		serviceErr   error
+		expectedCode int
+		expectedErr  string
+	}{
+		{name: "success", expectedCode: http.StatusCreated},
+		{name: "unknown permission", serviceErr: fmt.Errorf("%w: console:sudo", services.ErrInvalidPermission), expectedCode: http.StatusBadRequest, expectedErr: "VALIDATION_ERROR"},
+		{name: "name taken", serviceErr: fmt.Errorf("%w: operator", services.ErrRoleExists), expectedCode: http.StatusConflict, expectedErr: "ROLE_EXISTS"},
+		{name: "permission not held", serviceErr: fmt.Errorf("%w: server:delete", services.ErrPermissionNotHeld), expectedCode: http.StatusForbidden, expectedErr: "INSUFFICIENT_PERMISSIONS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRBACService := &MockRBACService{}
+			handler := NewRBACHandler(mockRBACService)
+
+			req := models.RoleRequest{Name: "operator", Permissions: []string{models.PermissionServerRestart}}
+			if tt.serviceErr != nil {
+				mockRBACService.On("CreateTenantRole", mock.Anything, "tenant-123", req, "user-123").Return(nil, tt.serviceErr)
+			} else {
+				mockRBACService.On("CreateTenantRole", mock.Anything, "tenant-123", req, "user-123").
+					Return(&models.Role{ID: "role-1", Name: req.Name, Permissions: models.StringArray(req.Permissions)}, nil)
+			}
+
+			c, w := setupGinContextForGameServer("POST", "/api/tenant/roles", req)
+			c.Set("tenant", &models.Tenant{ID: "tenant-123"})
+			c.Set("user", &models.User{ID: "user-123"})
+
+			handler.CreateRole(c)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedErr != "" {
+				var response models.APIError
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.expectedErr, response.Code)
+			}
+			mockRBACService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreateRole_MissingName(t *testing.T) {
+	mockRBACService := &MockRBACService{}
+	handler := NewRBACHandler(mockRBACService)
+
+	c, w := setupGinContextForGameServer("POST", "/api/tenant/roles", map[string]interface{}{"permissions": []string{models.PermissionServerRead}})
+	c.Set("tenant", &models.Tenant{ID: "tenant-123"})
+	c.Set("user", &models.User{ID: "user-123"})
+
+	handler.CreateRole(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockRBACService.AssertNotCalled(t, "CreateTenantRole", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDeleteRole(t *testing.T) {
+	tests := []struct {
+		name         string
+		serviceErr   error
+		expectedCode int
+		expectedErr  string
+	}{
+		{name: "success", expectedCode: http.StatusOK},
+		{name: "system role", serviceErr: services.ErrSystemRoleProtected, expectedCode: http.StatusForbidden, expectedErr: "SYSTEM_ROLE"},
+		{name: "unknown role", serviceErr: services.ErrRoleNotFound, expectedCode: http.StatusNotFound, expectedErr: "ROLE_NOT_FOUND"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRBACService := &MockRBACService{}
+			handler := NewRBACHandler(mockRBACService)
+			mockRBACService.On("DeleteTenantRole", mock.Anything, "tenant-123", "role-1", "user-123").Return(tt.serviceErr)
+
+			c, w := setupGinContextForGameServer("DELETE", "/api/tenant/roles/role-1", nil)
+			c.Set("tenant", &models.Tenant{ID: "tenant-123"})
+			c.Set("user", &models.User{ID: "user-123"})
+			c.AddParam("roleId", "role-1")
+
+			handler.DeleteRole(c)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedErr != "" {
+				var response models.APIError
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.expectedErr, response.Code)
+			}
+			mockRBACService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAssignMemberRole(t *testing.T) {
+	tests := []struct {
+		name         string
+		serviceErr   error
+		expectedCode int
+		expectedErr  string
+	}{
+		{name: "success", expectedCode: http.StatusOK},
+		{name: "not a member", serviceErr: services.ErrNotTenantMember, expectedCode: http.StatusNotFound, expectedErr: "MEMBER_NOT_FOUND"},
+		{name: "role more powerful than the assigner", serviceErr: fmt.Errorf("%w: server:delete", services.ErrPermissionNotHeld), expectedCode: http.StatusForbidden, expectedErr: "INSUFFICIENT_PERMISSIONS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRBACService := &MockRBACService{}
+			handler := NewRBACHandler(mockRBACService)
+			mockRBACService.On("AssignTenantRole", mock.Anything, "tenant-123", "member-1", "role-1", "user-123").Return(tt.serviceErr)
+
+			c, w := setupGinContextForGameServer("POST", "/api/tenant/members/member-1/roles", models.AssignRoleRequest{RoleID: "role-1"})
+			c.Set("tenant", &models.Tenant{ID: "tenant-123"})
+			c.Set("user", &models.User{ID: "user-123"})
+			c.AddParam("userId", "member-1")
+
+			handler.AssignMemberRole(c)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedErr != "" {
+				var response models.APIError
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.expectedErr, response.Code)
+			}
+			mockRBACService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreateServerGrant(t *testing.T) {
+	tests := []struct {
+		name         string
+		serviceErr   error
+		expectedCode int
+		expectedErr  string
+	}{
+		{name: "success", expectedCode: http.StatusCreated},
+		{name: "unknown principal type", serviceErr: fmt.Errorf("%w: unknown principal type group", services.ErrInvalidResourceGrant), expectedCode: http.StatusBadRequest, expectedErr: "VALIDATION_ERROR"},
+		{name: "permission not held", serviceErr: fmt.Errorf("%w: console:execute", services.ErrPermissionNotHeld), expectedCode: http.StatusForbidden, expectedErr: "INSUFFICIENT_PERMISSIONS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRBACService := &MockRBACService{}
+			handler := NewRBACHandler(mockRBACService)
+
+			req := models.CreateResourceGrantRequest{
+				PrincipalType: models.PrincipalTypeUser,
+				PrincipalID:   "friend-1",
+				Permission:    models.PermissionConsoleExecute,
+			}
+			if tt.serviceErr != nil {
+				mockRBACService.On("CreateResourceGrant", mock.Anything, "tenant-123", models.ResourceTypeGameServer, "server-1", req, "user-123").Return(nil, tt.serviceErr)
+			} else {
+				mockRBACService.On("CreateResourceGrant", mock.Anything, "tenant-123", models.ResourceTypeGameServer, "server-1", req, "user-123").
+					Return(&models.ResourceGrant{ID: "grant-1", PrincipalID: "friend-1", Permission: req.Permission}, nil)
+			}
+
+			c, w := setupGinContextForGameServer("POST", "/api/tenant/servers/server-1/grants", req)
+			c.Set("tenant", &models.Tenant{ID: "tenant-123"})
+			c.Set("user", &models.User{ID: "user-123"})
+			c.AddParam("serverId", "server-1")
+
+			handler.CreateServerGrant(c)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedErr != "" {
+				var response models.APIError
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.expectedErr, response.Code)
+			}
+			mockRBACService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeleteServerGrant_NotFound(t *testing.T) {
+	mockRBACService := &MockRBACService{}
+	handler := NewRBACHandler(mockRBACService)
+	mockRBACService.On("DeleteResourceGrant", mock.Anything, "tenant-123", models.ResourceTypeGameServer, "server-1", "grant-1", "user-123").Return(services.ErrResourceGrantNotFound)
+
+	c, w := setupGinContextForGameServer("DELETE", "/api/tenant/servers/server-1/grants/grant-1", nil)
+	c.Set("tenant", &models.Tenant{ID: "tenant-123"})
+	c.Set("user", &models.User{ID: "user-123"})
+	c.AddParam("serverId", "server-1")
+	c.AddParam("grantId", "grant-1")
+
+	handler.DeleteServerGrant(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockRBACService.AssertExpectations(t)
+}
+
+func TestExplainPermission(t *testing.T) {
+	callerID := "3b1f7c2e-5a4d-4e8b-9c6f-0d2a1b3c4e5f"
+	friendID := "7e9d8c6b-1a2f-4b3c-8d4e-5f6a7b8c9d0e"
+
+	t.Run("explains another user's access to a server", func(t *testing.T) {
+		mockRBACService := &MockRBACService{}
+		handler := NewRBACHandler(mockRBACService)
+
+		decision := &models.AuthorizationDecision{
+			UserID:       friendID,
+			TenantID:     "tenant-123",
+			Permission:   models.PermissionServerStart,
+			ResourceType: models.ResourceTypeGameServer,
+			ResourceID:   "server-1",
+			Reason:       models.DecisionReasonNotGranted,
+			Grants:       []models.PermissionGrant{},
+		}
+		mockRBACService.On("ExplainPermission", mock.Anything, friendID, "tenant-123", models.PermissionServerStart, models.ResourceTypeGameServer, "server-1").Return(decision, nil)
+
+		c, w := setupGinContextForGameServer("GET", "/api/tenant/authz/explain?user="+friendID+"&permission=server:start&resource=server-1", nil)
+		c.Set("tenant", &models.Tenant{ID: "tenant-123"})
+		c.Set("user", &models.User{ID: callerID})
+
+		handler.ExplainPermission(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]models.AuthorizationDecision
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.False(t, response["decision"].Allowed)
+		assert.Equal(t, models.DecisionReasonNotGranted, response["decision"].Reason)
+		mockRBACService.AssertExpectations(t)
+	})
+
+	t.Run("defaults to the caller without a resource", func(t *testing.T) {
+		mockRBACService := &MockRBACService{}
+		handler := NewRBACHandler(mockRBACService)
+
+		decision := &models.AuthorizationDecision{
+			Allowed: true,
+			Reason:  models.DecisionReasonGranted,
+			Grants:  []models.PermissionGrant{{Permission: "server:*", Source: models.GrantSourceRole, SourceName: "operator"}},
+		}
+		mockRBACService.On("ExplainPermission", mock.Anything, callerID, "tenant-123", models.PermissionServerRead, "", "").Return(decision, nil)
+
+		c, w := setupGinContextForGameServer("GET", "/api/tenant/authz/explain?permission=server:read", nil)
+		c.Set("tenant", &models.Tenant{ID: "tenant-123"})
+		c.Set("user", &models.User{ID: callerID})
+
+		handler.ExplainPermission(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockRBACService.AssertExpectations(t)
+	})
+
+	t.Run("unknown permission", func(t *testing.T) {
+		mockRBACService := &MockRBACService{}
+		handler := NewRBACHandler(mockRBACService)
+
+		c, w := setupGinContextForGameServer("GET", "/api/tenant/authz/explain?permission=server:fly", nil)
+		c.Set("tenant", &models.Tenant{ID: "tenant-123"})
+		c.Set("user", &models.User{ID: callerID})
+
+		handler.ExplainPermission(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockRBACService.AssertNotCalled(t, "ExplainPermission", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("user that is not an ID", func(t *testing.T) {
+		mockRBACService := &MockRBACService{}
+		handler := NewRBACHandler(mockRBACService)
+
+		c, w := setupGinContextForGameServer("GET", "/api/tenant/authz/explain?user=friend&permission=server:read", nil)
+		c.Set("tenant", &models.Tenant{ID: "tenant-123"})
+		c.Set("user", &models.User{ID: callerID})
+
+		handler.ExplainPermission(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockRBACService.AssertNotCalled(t, "ExplainPermission", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("user outside the tenant", func(t *testing.T) {
+		mockRBACService := &MockRBACService{}
+		handler := NewRBACHandler(mockRBACService)
+
+		decision := &models.AuthorizationDecision{
+			UserID: friendID,
+			Reason: models.DecisionReasonNotMember,
+			Grants: []models.PermissionGrant{},
+		}
+		mockRBACService.On("ExplainPermission", mock.Anything, friendID, "tenant-123", models.PermissionServerRead, "", "").Return(decision, nil)
+
+		c, w := setupGinContextForGameServer("GET", "/api/tenant/authz/explain?user="+friendID+"&permission=server:read", nil)
+		c.Set("tenant", &models.Tenant{ID: "tenant-123"})
+		c.Set("user", &models.User{ID: callerID})
+
+		handler.ExplainPermission(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		var response models.APIError
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "NOT_FOUND", response.Code)
+	})
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/middleware"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/services"
+)
+
+// sessionContext returns the request context carrying the client's device
+// details, which are recorded on sessions created during the request
+func sessionContext(c *gin.Context) context.Context {
+	return services.WithSessionClient(c.Request.Context(), c.Request.UserAgent(), c.ClientIP())
+}
+
+// Sessions lists the current user's active sessions
+func (h *AuthHandler) Sessions(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIError{
+			Code:    "UNAUTHORIZED",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	sessions, err := h.authService.ListSessions(c.Request.Context(), user.ID, middleware.GetSessionIDFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIError{
+			Code:    "INTERNAL_ERROR",
+			Message: "Failed to list sessions",
+			Details: map[string]interface{}{
+				"error": err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sessions": sessions,
+	})
+}
+
+// RevokeSession ends one of the current user's sessions
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIError{
+			Code:    "UNAUTHORIZED",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	err := h.authService.RevokeSession(c.Request.Context(), user.ID, c.Param("id"))
+	if err != nil {
+		if errors.Is(err, services.ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, models.APIError{
+				Code:    "SESSION_NOT_FOUND",
+				Message: "Session not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.APIError{
+			Code:    "INTERNAL_ERROR",
+			Message: "Failed to revoke session",
+			Details: map[string]interface{}{
+				"error": err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Session revoked",
+	})
+}
+
+// RevokeAllSessions ends every session of the current user, including the one
+// making the request
+func (h *AuthHandler) RevokeAllSessions(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIError{
+			Code:    "UNAUTHORIZED",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	revoked, err := h.authService.RevokeAllSessions(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIError{
+			Code:    "INTERNAL_ERROR",
+			Message: "Failed to revoke sessions",
+			Details: map[string]interface{}{
+				"error": err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sessions revoked",
+		"revoked": revoked,
+	})
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func setupSessionRouter(handler *AuthHandler) *gin.Engine {
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("user", &models.User{ID: "user-1"})
+		c.Set("session_id", "session-1")
+		c.Next()
+	})
+	router.GET("/auth/sessions", handler.Sessions)
+	router.DELETE("/auth/sessions", handler.RevokeAllSessions)
+	router.DELETE("/auth/sessions/:id", handler.RevokeSession)
+	return router
+}
+
+func TestAuthHandler_Sessions(t *testing.T) {
+	mockAuthService := new(MockAuthService)
+	router := setupSessionRouter(newIdentityTestHandler(mockAuthService))
+
+	mockAuthService.On("ListSessions", mock.Anything, "user-1", "session-1").Return([]models.SessionInfo{
+		{ID: "session-1", UserAgent: "Firefox", Current: true},
+	}, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/auth/sessions", nil)
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Sessions []models.SessionInfo `json:"sessions"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Sessions, 1)
+	assert.True(t, response.Sessions[0].Current)
+	assert.NotContains(t, w.Body.String(), "refresh_token")
+}
+
+func TestAuthHandler_RevokeSession(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{name: "revoked", expectedStatus: http.StatusOK},
+		{name: "not found", err: services.ErrSessionNotFound, expectedStatus: http.StatusNotFound, expectedCode: "SESSION_NOT_FOUND"},
+		{name: "store failure", err: assert.AnError, expectedStatus: http.StatusInternalServerError, expectedCode: "INTERNAL_ERROR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuthService := new(MockAuthService)
+			router := setupSessionRouter(newIdentityTestHandler(mockAuthService))
+
+			mockAuthService.On("RevokeSession", mock.Anything, "user-1", "session-2").Return(tt.err)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("DELETE", "/auth/sessions/session-2", nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedCode != "" {
+				var response models.APIError
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.expectedCode, response.Code)
+			}
+		})
+	}
+}
+
+func TestAuthHandler_RevokeAllSessions(t *testing.T) {
+	mockAuthService := new(MockAuthService)
+	router := setupSessionRouter(newIdentityTestHandler(mockAuthService))
+
+	mockAuthService.On("RevokeAllSessions", mock.Anything, "user-1").Return(3, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/auth/sessions", nil)
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, float64(3), response["revoked"])
+}
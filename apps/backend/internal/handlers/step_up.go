@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/middleware"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/services"
+)
+
+// BeginStepUp starts re-authenticating the current session with a login
+// provider, which is required before destructive operations
+func (h *AuthHandler) BeginStepUp(c *gin.Context) {
+	provider := c.Param("provider")
+	login, err := h.authService.BeginStepUp(c.Request.Context(), provider, middleware.GetSessionIDFromContext(c))
+	if errors.Is(err, services.ErrSessionNotFound) {
+		c.JSON(http.StatusUnauthorized, models.APIError{
+			Code:    "SESSION_NOT_FOUND",
+			Message: "Session not found",
+		})
+		return
+	}
+
+	h.respondWithLogin(c, provider, login, err)
+}
+
+// StepUpWithTOTP grants the current session a step-up with an authenticator or recovery code
+func (h *AuthHandler) StepUpWithTOTP(c *gin.Context) {
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "VALIDATION_ERROR",
+			Message: "Code is required",
+			Details: map[string]interface{}{
+				"error": err.Error(),
+			},
+		})
+		return
+	}
+
+	until, err := h.authService.StepUpWithTOTP(c.Request.Context(), middleware.GetSessionIDFromContext(c), req.Code)
+	if err != nil {
+		if errors.Is(err, services.ErrSessionNotFound) {
+			c.JSON(http.StatusUnauthorized, models.APIError{
+				Code:    "SESSION_NOT_FOUND",
+				Message: "Session not found",
+			})
+			return
+		}
+		writeTwoFactorError(c, err, "Failed to step up")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"active":     true,
+		"expires_at": until,
+	})
+}
+
+// StepUpStatus reports whether the current session may perform destructive operations
+func (h *AuthHandler) StepUpStatus(c *gin.Context) {
+	until, err := h.authService.StepUpExpiry(c.Request.Context(), middleware.GetSessionIDFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.APIError{
+			Code:    "SESSION_NOT_FOUND",
+			Message: "Session not found",
+		})
+		return
+	}
+
+	active := time.Now().Before(until)
+	response := gin.H{"active": active}
+	if active {
+		response["expires_at"] = until
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// completeStepUp finishes a step-up login and sends the browser back to the
+// page that asked for it with the outcome in the step_up query parameter
+func (h *AuthHandler) completeStepUp(c *gin.Context, storedState *models.OAuthState, code string) {
+	target := h.getFrontendURL(c) + "/"
+	if storedState.RedirectTo != "" {
+		target = h.getFrontendURL(c) + storedState.RedirectTo
+	}
+	if strings.Contains(target, "?") {
+		target += "&"
+	} else {
+		target += "?"
+	}
+
+	_, err := h.authService.CompleteStepUp(c.Request.Context(), storedState.Login, code)
+	if err != nil {
+		h.logger.Warn("Step-up failed", "provider", storedState.Login.Provider, "error", err)
+		reason := "step_up_failed"
+		switch {
+		case errors.Is(err, services.ErrStepUpIdentityMismatch):
+			reason = "identity_mismatch"
+		case errors.Is(err, services.ErrSessionNotFound):
+			reason = "session_expired"
+		}
+		c.Redirect(http.StatusTemporaryRedirect, target+"step_up=failed&error="+reason)
+		return
+	}
+
+	c.Redirect(http.StatusTemporaryRedirect, target+"step_up=success")
+}
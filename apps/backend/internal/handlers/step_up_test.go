@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func setupStepUpRouter(handler *AuthHandler) *gin.Engine {
+	router := setupTestRouter()
+	router.GET("/auth/callback", handler.Callback)
+	router.GET("/auth/oidc/:provider/callback", handler.ProviderCallback)
+
+	authenticated := router.Group("/auth")
+	authenticated.Use(func(c *gin.Context) {
+		c.Set("user", &models.User{ID: "user-1"})
+		c.Set("session_id", "session-1")
+		c.Next()
+	})
+	authenticated.GET("/step-up", handler.StepUpStatus)
+	authenticated.POST("/step-up/totp", handler.StepUpWithTOTP)
+	authenticated.POST("/step-up/:provider", handler.BeginStepUp)
+	return router
+}
+
+func TestAuthHandler_StepUp(t *testing.T) {
+	mockAuthService := new(MockAuthService)
+	router := setupStepUpRouter(newIdentityTestHandler(mockAuthService))
+
+	login := &models.IdentityLoginState{
+		Provider:        "authentik",
+		State:           "state-1",
+		AuthURL:         "https://auth.example.com/authorize?state=state-1",
+		StepUpSessionID: "session-1",
+		ExpiresAt:       time.Now().Add(time.Minute),
+	}
+	mockAuthService.On("BeginStepUp", mock.Anything, "authentik", "session-1").Return(login, nil)
+	mockAuthService.On("CompleteStepUp", mock.Anything, login, "code-1").Return(time.Now().Add(services.StepUpTTL), nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/auth/step-up/authentik?redirect=/tenants/tenant-1/settings", nil)
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	cookies := w.Result().Cookies()
+
+	var response map[string]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, login.AuthURL, response["auth_url"])
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/auth/oidc/authentik/callback?code=code-1&state=state-1", nil)
+	addCookies(req, cookies)
+	router.ServeHTTP(w, req)
+
+	// The browser returns to the page that asked for the step-up, without new tokens
+	assert.Equal(t, http.StatusTemporaryRedirect, w.Code)
+	assert.Equal(t, "http://localhost:3000/tenants/tenant-1/settings?step_up=success", w.Header().Get("Location"))
+	mockAuthService.AssertNotCalled(t, "HandleProviderCallback", mock.Anything, mock.Anything, mock.Anything)
+	mockAuthService.AssertExpectations(t)
+}
+
+func TestAuthHandler_StepUpDiscordMismatch(t *testing.T) {
+	mockAuthService := new(MockAuthService)
+	router := setupStepUpRouter(newIdentityTestHandler(mockAuthService))
+
+	login := &models.IdentityLoginState{
+		Provider:        models.IdentityProviderDiscord,
+		State:           "state-1",
+		StepUpSessionID: "session-1",
+		ExpiresAt:       time.Now().Add(time.Minute),
+	}
+	mockAuthService.On("BeginStepUp", mock.Anything, models.IdentityProviderDiscord, "session-1").Return(login, nil)
+	mockAuthService.On("CompleteStepUp", mock.Anything, login, "code-1").Return(time.Time{}, services.ErrStepUpIdentityMismatch)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/auth/step-up/discord", nil)
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	cookies := w.Result().Cookies()
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/auth/callback?code=code-1&state=state-1", nil)
+	addCookies(req, cookies)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusTemporaryRedirect, w.Code)
+	assert.Equal(t, "http://localhost:3000/?step_up=failed&error=identity_mismatch", w.Header().Get("Location"))
+	mockAuthService.AssertNotCalled(t, "HandleCallback", mock.Anything, mock.Anything)
+	mockAuthService.AssertExpectations(t)
+}
+
+func TestAuthHandler_StepUpStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		until    time.Time
+		expected bool
+	}{
+		{name: "active", until: time.Now().Add(time.Minute), expected: true},
+		{name: "expired", until: time.Now().Add(-time.Minute), expected: false},
+		{name: "never stepped up", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuthService := new(MockAuthService)
+			router := setupStepUpRouter(newIdentityTestHandler(mockAuthService))
+
+			mockAuthService.On("StepUpExpiry", mock.Anything, "session-1").Return(tt.until, nil)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/auth/step-up", nil)
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			var response map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tt.expected, response["active"])
+			if tt.expected {
+				assert.Contains(t, response, "expires_at")
+			} else {
+				assert.NotContains(t, response, "expires_at")
+			}
+		})
+	}
+}
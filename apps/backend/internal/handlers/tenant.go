@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"errors"
+	"log"
 	"net/http"
 	"strconv"
 
@@ -15,6 +17,8 @@ type TenantHandler struct {
 	discordService services.DiscordServiceInterface
 	authService    services.AuthServiceInterface
 	redisService   services.RedisServiceInterface
+	memberships    services.MembershipServiceInterface
+	syncer         services.GuildSyncer
 }
 
 // NewTenantHandler creates a new tenant handler
@@ -27,6 +31,22 @@ func NewTenantHandler(tenantService services.TenantServiceInterface, discordServ
 	}
 }
 
+// NewTenantHandlerWithMemberships creates a tenant handler that makes users
+// members of the tenants of the Discord servers they share when listing tenants
+func NewTenantHandlerWithMemberships(tenantService services.TenantServiceInterface, discordService services.DiscordServiceInterface, authService services.AuthServiceInterface, redisService services.RedisServiceInterface, memberships services.MembershipServiceInterface) *TenantHandler {
+	handler := NewTenantHandler(tenantService, discordService, authService, redisService)
+	handler.memberships = memberships
+	return handler
+}
+
+// NewTenantHandlerWithSync creates a tenant handler that can also sync a
+// tenant's Discord roles and members on request
+func NewTenantHandlerWithSync(tenantService services.TenantServiceInterface, discordService services.DiscordServiceInterface, authService services.AuthServiceInterface, redisService services.RedisServiceInterface, memberships services.MembershipServiceInterface, syncer services.GuildSyncer) *TenantHandler {
+	handler := NewTenantHandlerWithMemberships(tenantService, discordService, authService, redisService, memberships)
+	handler.syncer = syncer
+	return handler
+}
+
 // GetUserTenants retrieves all tenants a user has access to
 func (th *TenantHandler) GetUserTenants(c *gin.Context) {
 	user, exists := c.Get("user")
@@ -40,6 +60,10 @@ func (th *TenantHandler) GetUserTenants(c *gin.Context) {
 
 	userModel := user.(*models.User)
 
+	if sessionID, ok := c.Get("session_id"); ok && th.memberships != nil {
+		th.joinGuildTenants(c, userModel, sessionID.(string))
+	}
+
 	tenants, err := th.tenantService.GetUserTenants(c.Request.Context(), userModel.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIError{
@@ -55,6 +79,25 @@ func (th *TenantHandler) GetUserTenants(c *gin.Context) {
 	})
 }
 
+// joinGuildTenants adds the user to the tenants of the Discord servers they are
+// in. Failures are logged so the user still gets the tenants they already have.
+func (th *TenantHandler) joinGuildTenants(c *gin.Context, user *models.User, sessionID string) {
+	guilds, err := th.authService.GetDiscordGuilds(c.Request.Context(), sessionID)
+	if err != nil {
+		log.Printf("Failed to get Discord guilds for user %s: %v", user.ID, err)
+		return
+	}
+
+	guildIDs := make([]string, 0, len(guilds))
+	for _, guild := range guilds {
+		guildIDs = append(guildIDs, guild.ID)
+	}
+
+	if _, err := th.memberships.JoinGuildTenants(c.Request.Context(), user, guildIDs); err != nil {
+		log.Printf("Failed to join guild tenants for user %s: %v", user.ID, err)
+	}
+}
+
 // GetAvailableGuilds retrieves Discord guilds where user can install Pteronimbus
 func (th *TenantHandler) GetAvailableGuilds(c *gin.Context) {
 	user, exists := c.Get("user")
@@ -78,37 +121,10 @@ func (th *TenantHandler) GetAvailableGuilds(c *gin.Context) {
 		return
 	}
 
-	session, err := th.getSessionFromRedis(c, sessionID.(string))
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, models.APIError{
-			Code:    "UNAUTHORIZED",
-			Message: "Failed to get session",
-			Details: map[string]interface{}{"error": err.Error()},
-		})
-		return
-	}
-
-	// Check if Discord access token exists
-	if session.DiscordAccessToken == "" {
-		c.JSON(http.StatusUnauthorized, models.APIError{
-			Code:    "DISCORD_TOKEN_MISSING",
-			Message: "Discord access token not found in session. Please log in again.",
-			Details: map[string]interface{}{
-				"session_id": sessionID,
-				"reason":     "Session was created before Discord token integration. Please re-authenticate.",
-			},
-		})
-		return
-	}
-
-	// Get user's Discord guilds
-	guilds, err := th.discordService.GetUserGuilds(c.Request.Context(), session.DiscordAccessToken)
+	// Get user's Discord guilds, refreshing the session's Discord token if needed
+	guilds, err := th.authService.GetDiscordGuilds(c.Request.Context(), sessionID.(string))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIError{
-			Code:    "DISCORD_API_ERROR",
-			Message: "Failed to get Discord guilds",
-			Details: map[string]interface{}{"error": err.Error()},
-		})
+		writeDiscordGuildsError(c, err)
 		return
 	}
 
@@ -161,37 +177,10 @@ func (th *TenantHandler) CreateTenant(c *gin.Context) {
 		return
 	}
 
-	session, err := th.getSessionFromRedis(c, sessionID.(string))
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, models.APIError{
-			Code:    "UNAUTHORIZED",
-			Message: "Failed to get session",
-			Details: map[string]interface{}{"error": err.Error()},
-		})
-		return
-	}
-
-	// Check if Discord access token exists
-	if session.DiscordAccessToken == "" {
-		c.JSON(http.StatusUnauthorized, models.APIError{
-			Code:    "DISCORD_TOKEN_MISSING",
-			Message: "Discord access token not found in session. Please log in again.",
-			Details: map[string]interface{}{
-				"session_id": sessionID,
-				"reason":     "Session was created before Discord token integration. Please re-authenticate.",
-			},
-		})
-		return
-	}
-
 	// Get user's Discord guilds to verify they have access
-	guilds, err := th.discordService.GetUserGuilds(c.Request.Context(), session.DiscordAccessToken)
+	guilds, err := th.authService.GetDiscordGuilds(c.Request.Context(), sessionID.(string))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIError{
-			Code:    "DISCORD_API_ERROR",
-			Message: "Failed to get Discord guilds",
-			Details: map[string]interface{}{"error": err.Error()},
-		})
+		writeDiscordGuildsError(c, err)
 		return
 	}
 
@@ -269,8 +258,8 @@ func (th *TenantHandler) GetTenant(c *gin.Context) {
 
 	userModel := user.(*models.User)
 
-	// Check if user has access to this tenant
-	hasAccess, err := th.tenantService.HasPermission(c.Request.Context(), userModel.ID, tenantID, "read")
+	// Check if user is a member of this tenant
+	hasAccess, err := th.tenantService.IsTenantMember(c.Request.Context(), userModel.ID, tenantID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIError{
 			Code:    "INTERNAL_ERROR",
@@ -325,7 +314,7 @@ func (th *TenantHandler) UpdateTenantConfig(c *gin.Context) {
 	userModel := user.(*models.User)
 
 	// Check if user has manage permissions for this tenant
-	hasAccess, err := th.tenantService.HasPermission(c.Request.Context(), userModel.ID, tenantID, "manage")
+	hasAccess, err := th.tenantService.HasPermission(c.Request.Context(), userModel.ID, tenantID, models.PermissionTenantManage)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIError{
 			Code:    "INTERNAL_ERROR",
@@ -353,16 +342,51 @@ func (th *TenantHandler) UpdateTenantConfig(c *gin.Context) {
 		return
 	}
 
-	err = th.tenantService.UpdateTenantConfig(c.Request.Context(), tenantID, config)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIError{
-			Code:    "INTERNAL_ERROR",
-			Message: "Failed to update tenant config",
+	if err := config.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "VALIDATION_ERROR",
+			Message: "Invalid tenant configuration",
 			Details: map[string]interface{}{"error": err.Error()},
 		})
 		return
 	}
 
+	err = th.tenantService.UpdateTenantConfig(c.Request.Context(), tenantID, config, userModel.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrPermissionNotHeld):
+			c.JSON(http.StatusForbidden, models.APIError{
+				Code:    "INSUFFICIENT_PERMISSIONS",
+				Message: "You cannot give new members permissions you do not hold",
+				Details: map[string]interface{}{"error": err.Error()},
+			})
+		case errors.Is(err, services.ErrRoleNotFound):
+			c.JSON(http.StatusBadRequest, models.APIError{
+				Code:    "VALIDATION_ERROR",
+				Message: "One or more default roles do not exist in this tenant",
+				Details: map[string]interface{}{"error": err.Error()},
+			})
+		case errors.Is(err, services.ErrChannelNotInGuild):
+			c.JSON(http.StatusBadRequest, models.APIError{
+				Code:    "VALIDATION_ERROR",
+				Message: "One or more channels are not in this tenant's Discord server",
+				Details: map[string]interface{}{"error": err.Error()},
+			})
+		case errors.Is(err, services.ErrTenantNotFound):
+			c.JSON(http.StatusNotFound, models.APIError{
+				Code:    "NOT_FOUND",
+				Message: "Tenant not found",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, models.APIError{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to update tenant config",
+				Details: map[string]interface{}{"error": err.Error()},
+			})
+		}
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Tenant configuration updated successfully",
 	})
@@ -391,7 +415,7 @@ func (th *TenantHandler) SyncTenantData(c *gin.Context) {
 	userModel := user.(*models.User)
 
 	// Check if user has manage permissions for this tenant
-	hasAccess, err := th.tenantService.HasPermission(c.Request.Context(), userModel.ID, tenantID, "manage")
+	hasAccess, err := th.tenantService.HasPermission(c.Request.Context(), userModel.ID, tenantID, models.PermissionTenantManage)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIError{
 			Code:    "INTERNAL_ERROR",
@@ -409,10 +433,8 @@ func (th *TenantHandler) SyncTenantData(c *gin.Context) {
 		return
 	}
 
-	// Get bot token from config or environment
-	// For now, we'll use a placeholder - this should be configured properly
-	botToken := th.discordService.(*services.DiscordService).BotToken()
-	if botToken == "" {
+	// Syncing needs the bot, which is only set up when a bot token is configured
+	if th.syncer == nil {
 		c.JSON(http.StatusInternalServerError, models.APIError{
 			Code:    "DISCORD_SYNC_ERROR",
 			Message: "Bot token not configured",
@@ -420,23 +442,21 @@ func (th *TenantHandler) SyncTenantData(c *gin.Context) {
 		return
 	}
 
-	// Sync Discord roles
-	err = th.tenantService.SyncDiscordRoles(c.Request.Context(), tenantID, botToken)
+	tenant, err := th.tenantService.GetTenant(c.Request.Context(), tenantID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIError{
-			Code:    "DISCORD_SYNC_ERROR",
-			Message: "Failed to sync Discord roles",
-			Details: map[string]interface{}{"error": err.Error()},
+		c.JSON(http.StatusNotFound, models.APIError{
+			Code:    "NOT_FOUND",
+			Message: "Tenant not found",
 		})
 		return
 	}
 
-	// Sync Discord users
-	err = th.tenantService.SyncDiscordUsers(c.Request.Context(), tenantID, botToken)
-	if err != nil {
+	// The same full sync the bot runs: every member page, departed members and
+	// deleted roles removed, and roles of linked users updated
+	if err := th.syncer.ReconcileGuild(tenant.ID, tenant.DiscordServerID); err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIError{
 			Code:    "DISCORD_SYNC_ERROR",
-			Message: "Failed to sync Discord users",
+			Message: "Failed to sync Discord data",
 			Details: map[string]interface{}{"error": err.Error()},
 		})
 		return
@@ -610,7 +630,27 @@ func (th *TenantHandler) GetBotStatus(c *gin.Context) {
 	})
 }
 
-// Helper function to get session from Redis
-func (th *TenantHandler) getSessionFromRedis(c *gin.Context, sessionID string) (*models.Session, error) {
-	return th.redisService.GetSession(c.Request.Context(), sessionID)
+// writeDiscordGuildsError responds to a failure to list the user's Discord guilds.
+// Missing or revoked Discord authorization asks the user to link Discord again.
+func writeDiscordGuildsError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrDiscordTokenMissing):
+		c.JSON(http.StatusUnauthorized, models.APIError{
+			Code:    "DISCORD_TOKEN_MISSING",
+			Message: "Discord access token not found in session. Please link your Discord account.",
+			Details: map[string]interface{}{"relink_url": "/auth/identities/discord/link"},
+		})
+	case errors.Is(err, services.ErrDiscordRelinkRequired):
+		c.JSON(http.StatusUnauthorized, models.APIError{
+			Code:    "DISCORD_RELINK_REQUIRED",
+			Message: "Discord access has expired or was revoked. Please link your Discord account again.",
+			Details: map[string]interface{}{"relink_url": "/auth/identities/discord/link"},
+		})
+	default:
+		c.JSON(http.StatusBadGateway, models.APIError{
+			Code:    "DISCORD_API_ERROR",
+			Message: "Failed to get Discord guilds",
+			Details: map[string]interface{}{"error": err.Error()},
+		})
+	}
 }
@@ -4,15 +4,18 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
 	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/services"
 )
 
 // MockTenantService is a mock implementation of TenantServiceInterface
@@ -50,18 +53,8 @@ func (m *MockTenantService) RemoveUserFromTenant(ctx context.Context, userID, te
 	return args.Error(0)
 }
 
-func (m *MockTenantService) SyncDiscordRoles(ctx context.Context, tenantID, botToken string) error {
-	args := m.Called(ctx, tenantID, botToken)
-	return args.Error(0)
-}
-
-func (m *MockTenantService) SyncDiscordUsers(ctx context.Context, tenantID, botToken string) error {
-	args := m.Called(ctx, tenantID, botToken)
-	return args.Error(0)
-}
-
-func (m *MockTenantService) UpdateTenantConfig(ctx context.Context, tenantID string, config models.TenantConfig) error {
-	args := m.Called(ctx, tenantID, config)
+func (m *MockTenantService) UpdateTenantConfig(ctx context.Context, tenantID string, config models.TenantConfig, performedBy string) error {
+	args := m.Called(ctx, tenantID, config, performedBy)
 	return args.Error(0)
 }
 
@@ -70,6 +63,11 @@ func (m *MockTenantService) DeleteTenant(ctx context.Context, tenantID string) e
 	return args.Error(0)
 }
 
+func (m *MockTenantService) IsTenantMember(ctx context.Context, userID, tenantID string) (bool, error) {
+	args := m.Called(ctx, userID, tenantID)
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *MockTenantService) HasPermission(ctx context.Context, userID, tenantID, permission string) (bool, error) {
 	args := m.Called(ctx, userID, tenantID, permission)
 	return args.Bool(0), args.Error(1)
@@ -125,6 +123,11 @@ func (m *MockDiscordServiceForHandler) GetGuildMember(ctx context.Context, botTo
 	return args.Get(0).(*models.DiscordMember), args.Error(1)
 }
 
+func (m *MockDiscordServiceForHandler) GetGuildChannels(ctx context.Context, botToken, guildID string) ([]models.DiscordChannel, error) {
+	args := m.Called(ctx, botToken, guildID)
+	return args.Get(0).([]models.DiscordChannel), args.Error(1)
+}
+
 // MockAuthServiceForTenant is a mock for the Auth service used in tenant handlers
 type MockAuthServiceForTenant struct {
 	mock.Mock
@@ -163,6 +166,88 @@ func (m *MockAuthServiceForTenant) Logout(ctx context.Context, accessToken strin
 	return args.Error(0)
 }
 
+func (m *MockAuthServiceForTenant) IdentityProviders() []models.IdentityProviderInfo {
+	args := m.Called()
+	return args.Get(0).([]models.IdentityProviderInfo)
+}
+
+func (m *MockAuthServiceForTenant) BeginProviderLogin(ctx context.Context, providerName, linkUserID string) (*models.IdentityLoginState, error) {
+	args := m.Called(ctx, providerName, linkUserID)
+	return args.Get(0).(*models.IdentityLoginState), args.Error(1)
+}
+
+func (m *MockAuthServiceForTenant) HandleProviderCallback(ctx context.Context, login *models.IdentityLoginState, code string) (*models.AuthResponse, error) {
+	args := m.Called(ctx, login, code)
+	return args.Get(0).(*models.AuthResponse), args.Error(1)
+}
+
+func (m *MockAuthServiceForTenant) GetUserIdentities(ctx context.Context, userID string) ([]models.UserIdentity, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]models.UserIdentity), args.Error(1)
+}
+
+func (m *MockAuthServiceForTenant) UnlinkIdentity(ctx context.Context, userID, identityID string) error {
+	args := m.Called(ctx, userID, identityID)
+	return args.Error(0)
+}
+
+func (m *MockAuthServiceForTenant) ListSessions(ctx context.Context, userID, currentSessionID string) ([]models.SessionInfo, error) {
+	args := m.Called(ctx, userID, currentSessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.SessionInfo), args.Error(1)
+}
+
+func (m *MockAuthServiceForTenant) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	args := m.Called(ctx, userID, sessionID)
+	return args.Error(0)
+}
+
+func (m *MockAuthServiceForTenant) RevokeAllSessions(ctx context.Context, userID string) (int, error) {
+	args := m.Called(ctx, userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockAuthServiceForTenant) GetDiscordGuilds(ctx context.Context, sessionID string) ([]models.DiscordGuild, error) {
+	args := m.Called(ctx, sessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.DiscordGuild), args.Error(1)
+}
+
+func (m *MockAuthServiceForTenant) BeginStepUp(ctx context.Context, providerName, sessionID string) (*models.IdentityLoginState, error) {
+	args := m.Called(ctx, providerName, sessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.IdentityLoginState), args.Error(1)
+}
+
+func (m *MockAuthServiceForTenant) CompleteStepUp(ctx context.Context, login *models.IdentityLoginState, code string) (time.Time, error) {
+	args := m.Called(ctx, login, code)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *MockAuthServiceForTenant) StepUpExpiry(ctx context.Context, sessionID string) (time.Time, error) {
+	args := m.Called(ctx, sessionID)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *MockAuthServiceForTenant) StepUpWithTOTP(ctx context.Context, sessionID, code string) (time.Time, error) {
+	args := m.Called(ctx, sessionID, code)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *MockAuthServiceForTenant) VerifyTwoFactorLogin(ctx context.Context, challengeID, code string) (*models.AuthResponse, error) {
+	args := m.Called(ctx, challengeID, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AuthResponse), args.Error(1)
+}
+
 // MockRedisServiceForTenant is a mock for the Redis service used in tenant handlers
 type MockRedisServiceForTenant struct {
 	mock.Mock
@@ -181,6 +266,21 @@ func (m *MockRedisServiceForTenant) GetSession(ctx context.Context, sessionID st
 	return args.Get(0).(*models.Session), args.Error(1)
 }
 
+func (m *MockRedisServiceForTenant) UpdateSession(ctx context.Context, sessionID string, update func(*models.Session)) (*models.Session, error) {
+	args := m.Called(ctx, sessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	session := *args.Get(0).(*models.Session)
+	update(&session)
+	return &session, args.Error(1)
+}
+
+func (m *MockRedisServiceForTenant) StoreRefreshToken(ctx context.Context, session *models.Session) error {
+	args := m.Called(ctx, session)
+	return args.Error(0)
+}
+
 func (m *MockRedisServiceForTenant) GetSessionByRefreshToken(ctx context.Context, refreshToken string) (*models.Session, error) {
 	args := m.Called(ctx, refreshToken)
 	if args.Get(0) == nil {
@@ -189,11 +289,55 @@ func (m *MockRedisServiceForTenant) GetSessionByRefreshToken(ctx context.Context
 	return args.Get(0).(*models.Session), args.Error(1)
 }
 
+func (m *MockRedisServiceForTenant) ConsumeRefreshToken(ctx context.Context, refreshToken string, retiredUntil time.Time) (*models.Session, error) {
+	args := m.Called(ctx, refreshToken, retiredUntil)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Session), args.Error(1)
+}
+
+func (m *MockRedisServiceForTenant) GetRetiredRefreshToken(ctx context.Context, refreshToken string) (string, error) {
+	args := m.Called(ctx, refreshToken)
+	return args.String(0), args.Error(1)
+}
+
 func (m *MockRedisServiceForTenant) DeleteSession(ctx context.Context, sessionID string) error {
 	args := m.Called(ctx, sessionID)
 	return args.Error(0)
 }
 
+func (m *MockRedisServiceForTenant) ListUserSessions(ctx context.Context, userID string) ([]*models.Session, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Session), args.Error(1)
+}
+
+func (m *MockRedisServiceForTenant) StoreTwoFactorChallenge(ctx context.Context, challenge *models.TwoFactorChallenge) error {
+	args := m.Called(ctx, challenge)
+	return args.Error(0)
+}
+
+func (m *MockRedisServiceForTenant) GetTwoFactorChallenge(ctx context.Context, challengeID string) (*models.TwoFactorChallenge, error) {
+	args := m.Called(ctx, challengeID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TwoFactorChallenge), args.Error(1)
+}
+
+func (m *MockRedisServiceForTenant) CountTwoFactorChallengeAttempt(ctx context.Context, challenge *models.TwoFactorChallenge) (int64, error) {
+	args := m.Called(ctx, challenge.ID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRedisServiceForTenant) DeleteTwoFactorChallenge(ctx context.Context, challengeID string) error {
+	args := m.Called(ctx, challengeID)
+	return args.Error(0)
+}
+
 func setupTenantHandler() (*TenantHandler, *MockTenantService, *MockDiscordServiceForHandler, *MockAuthServiceForTenant, *MockRedisServiceForTenant) {
 	mockTenantService := new(MockTenantService)
 	mockDiscordService := new(MockDiscordServiceForHandler)
@@ -288,7 +432,7 @@ func TestTenantHandler_GetUserTenants_Unauthorized(t *testing.T) {
 }
 
 func TestTenantHandler_CreateTenant(t *testing.T) {
-	handler, mockTenantService, mockDiscordService, _, mockRedisService := setupTenantHandler()
+	handler, mockTenantService, _, mockAuthService, _ := setupTenantHandler()
 	
 	// Setup test data
 	user := &models.User{
@@ -324,14 +468,8 @@ func TestTenantHandler_CreateTenant(t *testing.T) {
 	c.Set("user", user)
 	c.Set("session_id", "session-123")
 	
-	// Mock Redis session retrieval
-	mockSession := &models.Session{
-		ID:                 "session-123",
-		AccessToken:        "jwt_access_token",
-		DiscordAccessToken: "discord_access_token",
-	}
-	mockRedisService.On("GetSession", mock.Anything, "session-123").Return(mockSession, nil)
-	mockDiscordService.On("GetUserGuilds", mock.Anything, "discord_access_token").Return(discordGuilds, nil)
+	// Mock Discord guild retrieval through the session
+	mockAuthService.On("GetDiscordGuilds", mock.Anything, "session-123").Return(discordGuilds, nil)
 	mockTenantService.On("CheckManageServerPermission", &discordGuilds[0]).Return(true)
 	mockTenantService.On("CreateTenant", mock.Anything, &discordGuilds[0], user.ID).Return(expectedTenant, nil)
 	mockTenantService.On("AddUserToTenant", mock.Anything, user.ID, expectedTenant.ID, []string{"owner"}, []string{"*"}).Return(nil)
@@ -350,12 +488,12 @@ func TestTenantHandler_CreateTenant(t *testing.T) {
 	assert.Equal(t, expectedTenant.ID, tenantData["id"])
 	assert.Equal(t, expectedTenant.Name, tenantData["name"])
 	
-	mockDiscordService.AssertExpectations(t)
+	mockAuthService.AssertExpectations(t)
 	mockTenantService.AssertExpectations(t)
 }
 
 func TestTenantHandler_CreateTenant_InvalidGuild(t *testing.T) {
-	handler, _, mockDiscordService, _, mockRedisService := setupTenantHandler()
+	handler, _, _, mockAuthService, _ := setupTenantHandler()
 	
 	user := &models.User{
 		ID:            "user-123",
@@ -378,14 +516,8 @@ func TestTenantHandler_CreateTenant_InvalidGuild(t *testing.T) {
 	c.Set("user", user)
 	c.Set("session_id", "session-123")
 	
-	// Mock Redis session retrieval
-	mockSession := &models.Session{
-		ID:                 "session-123",
-		AccessToken:        "jwt_access_token",
-		DiscordAccessToken: "discord_access_token",
-	}
-	mockRedisService.On("GetSession", mock.Anything, "session-123").Return(mockSession, nil)
-	mockDiscordService.On("GetUserGuilds", mock.Anything, "discord_access_token").Return(discordGuilds, nil)
+	// Mock Discord guild retrieval through the session
+	mockAuthService.On("GetDiscordGuilds", mock.Anything, "session-123").Return(discordGuilds, nil)
 	
 	// Execute
 	handler.CreateTenant(c)
@@ -398,7 +530,25 @@ func TestTenantHandler_CreateTenant_InvalidGuild(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "FORBIDDEN", response.Code)
 	
-	mockDiscordService.AssertExpectations(t)
+	mockAuthService.AssertExpectations(t)
+}
+
+func TestTenantHandler_GetAvailableGuilds_DiscordRevoked(t *testing.T) {
+	handler, _, _, mockAuthService, _ := setupTenantHandler()
+
+	c, w := setupGinContext("GET", "/api/tenants/available-guilds", nil)
+	c.Set("user", &models.User{ID: "user-123"})
+	c.Set("session_id", "session-123")
+
+	mockAuthService.On("GetDiscordGuilds", mock.Anything, "session-123").Return(nil, services.ErrDiscordRelinkRequired)
+
+	handler.GetAvailableGuilds(c)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	var response models.APIError
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "DISCORD_RELINK_REQUIRED", response.Code)
+	assert.Equal(t, "/auth/identities/discord/link", response.Details["relink_url"])
 }
 
 func TestTenantHandler_GetTenant(t *testing.T) {
@@ -423,7 +573,7 @@ func TestTenantHandler_GetTenant(t *testing.T) {
 		{Key: "id", Value: "tenant-123"},
 	}
 	
-	mockTenantService.On("HasPermission", mock.Anything, user.ID, "tenant-123", "read").Return(true, nil)
+	mockTenantService.On("IsTenantMember", mock.Anything, user.ID, "tenant-123").Return(true, nil)
 	mockTenantService.On("GetTenant", mock.Anything, "tenant-123").Return(tenant, nil)
 	
 	// Execute
@@ -458,7 +608,7 @@ func TestTenantHandler_GetTenant_Forbidden(t *testing.T) {
 		{Key: "id", Value: "tenant-123"},
 	}
 	
-	mockTenantService.On("HasPermission", mock.Anything, user.ID, "tenant-123", "read").Return(false, nil)
+	mockTenantService.On("IsTenantMember", mock.Anything, user.ID, "tenant-123").Return(false, nil)
 	
 	// Execute
 	handler.GetTenant(c)
@@ -501,8 +651,8 @@ func TestTenantHandler_UpdateTenantConfig(t *testing.T) {
 		{Key: "id", Value: "tenant-123"},
 	}
 	
-	mockTenantService.On("HasPermission", mock.Anything, user.ID, "tenant-123", "manage").Return(true, nil)
-	mockTenantService.On("UpdateTenantConfig", mock.Anything, "tenant-123", config).Return(nil)
+	mockTenantService.On("HasPermission", mock.Anything, user.ID, "tenant-123", models.PermissionTenantManage).Return(true, nil)
+	mockTenantService.On("UpdateTenantConfig", mock.Anything, "tenant-123", config, user.ID).Return(nil)
 	
 	// Execute
 	handler.UpdateTenantConfig(c)
@@ -518,6 +668,103 @@ func TestTenantHandler_UpdateTenantConfig(t *testing.T) {
 	mockTenantService.AssertExpectations(t)
 }
 
+func TestTenantHandler_UpdateTenantConfig_DefaultsNotHeld(t *testing.T) {
+	handler, mockTenantService, _, _, _ := setupTenantHandler()
+
+	user := &models.User{ID: "user-123", DiscordUserID: "discord-123", Username: "testuser"}
+	config := models.TenantConfig{Membership: models.MembershipPolicy{DefaultPermissions: []string{models.PermissionAdminAll}}}
+
+	c, w := setupGinContext("PUT", "/api/tenants/tenant-123/config", config)
+	c.Set("user", user)
+	c.Params = gin.Params{{Key: "id", Value: "tenant-123"}}
+
+	mockTenantService.On("HasPermission", mock.Anything, user.ID, "tenant-123", models.PermissionTenantManage).Return(true, nil)
+	mockTenantService.On("UpdateTenantConfig", mock.Anything, "tenant-123", config, user.ID).Return(services.ErrPermissionNotHeld)
+
+	handler.UpdateTenantConfig(c)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	var response models.APIError
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "INSUFFICIENT_PERMISSIONS", response.Code)
+	mockTenantService.AssertExpectations(t)
+}
+
+func TestTenantHandler_UpdateTenantConfig_ForeignChannel(t *testing.T) {
+	handler, mockTenantService, _, _, _ := setupTenantHandler()
+
+	user := &models.User{ID: "user-123", DiscordUserID: "discord-123", Username: "testuser"}
+	config := models.TenantConfig{Notifications: []models.NotificationChannelConfig{{ChannelID: "foreign-channel"}}}
+
+	c, w := setupGinContext("PUT", "/api/tenants/tenant-123/config", config)
+	c.Set("user", user)
+	c.Params = gin.Params{{Key: "id", Value: "tenant-123"}}
+
+	mockTenantService.On("HasPermission", mock.Anything, user.ID, "tenant-123", models.PermissionTenantManage).Return(true, nil)
+	mockTenantService.On("UpdateTenantConfig", mock.Anything, "tenant-123", config, user.ID).Return(services.ErrChannelNotInGuild)
+
+	handler.UpdateTenantConfig(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var response models.APIError
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "VALIDATION_ERROR", response.Code)
+	mockTenantService.AssertExpectations(t)
+}
+
+// fakeGuildSyncer records the guilds it was asked to sync
+type fakeGuildSyncer struct {
+	synced []string
+	err    error
+}
+
+func (f *fakeGuildSyncer) ReconcileGuild(tenantID string, guildID string) error {
+	f.synced = append(f.synced, tenantID+"|"+guildID)
+	return f.err
+}
+
+func TestTenantHandler_SyncTenantData(t *testing.T) {
+	user := &models.User{ID: "user-123", DiscordUserID: "discord-123", Username: "testuser"}
+
+	tests := []struct {
+		name         string
+		syncer       *fakeGuildSyncer
+		expectedCode int
+	}{
+		{name: "runs a full guild sync", syncer: &fakeGuildSyncer{}, expectedCode: http.StatusOK},
+		{name: "sync failure", syncer: &fakeGuildSyncer{err: errors.New("discord unavailable")}, expectedCode: http.StatusInternalServerError},
+		{name: "bot not configured", expectedCode: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTenantService := new(MockTenantService)
+			handler := NewTenantHandler(mockTenantService, new(MockDiscordServiceForHandler), new(MockAuthServiceForTenant), new(MockRedisServiceForTenant))
+			if tt.syncer != nil {
+				handler.syncer = tt.syncer
+				mockTenantService.On("GetTenant", mock.Anything, "tenant-123").Return(&models.Tenant{ID: "tenant-123", DiscordServerID: "guild-123"}, nil)
+			}
+			mockTenantService.On("HasPermission", mock.Anything, user.ID, "tenant-123", models.PermissionTenantManage).Return(true, nil)
+
+			c, w := setupGinContext("POST", "/api/tenants/tenant-123/sync", nil)
+			c.Set("user", user)
+			c.Params = gin.Params{{Key: "id", Value: "tenant-123"}}
+
+			handler.SyncTenantData(c)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.syncer != nil {
+				assert.Equal(t, []string{"tenant-123|guild-123"}, tt.syncer.synced)
+			} else {
+				var response models.APIError
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, "DISCORD_SYNC_ERROR", response.Code)
+			}
+			mockTenantService.AssertExpectations(t)
+		})
+	}
+}
+
 func TestTenantHandler_DeleteTenant(t *testing.T) {
 	handler, mockTenantService, _, _, _ := setupTenantHandler()
 	
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/middleware"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/services"
+)
+
+// TwoFactorHandler handles TOTP enrollment and the two-factor policy
+type TwoFactorHandler struct {
+	twoFactor services.TwoFactorServiceInterface
+}
+
+// NewTwoFactorHandler creates a new two-factor handler
+func NewTwoFactorHandler(twoFactor services.TwoFactorServiceInterface) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactor: twoFactor,
+	}
+}
+
+// Status returns the current user's two-factor enrollment
+func (h *TwoFactorHandler) Status(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIError{
+			Code:    "UNAUTHORIZED",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	status, err := h.twoFactor.Status(c.Request.Context(), user.ID)
+	if err != nil {
+		writeTwoFactorError(c, err, "Failed to get two-factor status")
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// Enroll starts enrolling an authenticator app for the current user
+func (h *TwoFactorHandler) Enroll(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIError{
+			Code:    "UNAUTHORIZED",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	enrollment, err := h.twoFactor.BeginEnrollment(c.Request.Context(), user)
+	if err != nil {
+		writeTwoFactorError(c, err, "Failed to start enrollment")
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// Confirm activates the current user's enrollment with a first code and
+// returns their recovery codes
+func (h *TwoFactorHandler) Confirm(c *gin.Context) {
+	h.withCode(c, func(userID, code string) {
+		codes, err := h.twoFactor.ConfirmEnrollment(c.Request.Context(), userID, code)
+		if err != nil {
+			writeTwoFactorError(c, err, "Failed to confirm enrollment")
+			return
+		}
+		c.JSON(http.StatusOK, models.TwoFactorRecoveryCodes{RecoveryCodes: codes})
+	})
+}
+
+// RegenerateRecoveryCodes replaces the current user's recovery codes
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	h.withCode(c, func(userID, code string) {
+		codes, err := h.twoFactor.RegenerateRecoveryCodes(c.Request.Context(), userID, code)
+		if err != nil {
+			writeTwoFactorError(c, err, "Failed to regenerate recovery codes")
+			return
+		}
+		c.JSON(http.StatusOK, models.TwoFactorRecoveryCodes{RecoveryCodes: codes})
+	})
+}
+
+// Disable turns off two-factor authentication for the current user
+func (h *TwoFactorHandler) Disable(c *gin.Context) {
+	h.withCode(c, func(userID, code string) {
+		if err := h.twoFactor.Disable(c.Request.Context(), userID, code); err != nil {
+			writeTwoFactorError(c, err, "Failed to disable two-factor authentication")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Two-factor authentication disabled",
+		})
+	})
+}
+
+// GetPolicy returns the system-wide two-factor policy. Only superadmins may
+// reach it.
+func (h *TwoFactorHandler) GetPolicy(c *gin.Context) {
+	policy, err := h.twoFactor.GetPolicy(c.Request.Context())
+	if err != nil {
+		writeTwoFactorError(c, err, "Failed to get two-factor policy")
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// UpdatePolicy changes the system-wide two-factor policy. Only superadmins
+// may reach it.
+func (h *TwoFactorHandler) UpdatePolicy(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIError{
+			Code:    "UNAUTHORIZED",
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	var policy models.TwoFactorPolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "VALIDATION_ERROR",
+			Message: "Invalid request body",
+			Details: map[string]interface{}{
+				"error": err.Error(),
+			},
+		})
+		return
+	}
+
+	if err := h.twoFactor.SetPolicy(c.Request.Context(), policy, user.ID); err != nil {
+		writeTwoFactorError(c, err, "Failed to update two-factor policy")
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// withCode reads the code from the request body and runs fn for the current user
+func (h *TwoFactorHandler) withCode(c *gin.Context, fn func(userID, code string)) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.APIError{
+			Code:    "UNAUTHORIZED",
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "VALIDATION_ERROR",
+			Message: "Code is required",
+			Details: map[string]interface{}{
+				"error": err.Error(),
+			},
+		})
+		return
+	}
+
+	fn(user.ID, req.Code)
+}
+
+// writeTwoFactorError responds to a failed two-factor operation
+func writeTwoFactorError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidTwoFactorCode):
+		c.JSON(http.StatusBadRequest, models.APIError{
+			Code:    "INVALID_TWO_FACTOR_CODE",
+			Message: "The code is wrong, expired or was already used",
+		})
+	case errors.Is(err, services.ErrTwoFactorLocked):
+		c.JSON(http.StatusTooManyRequests, models.APIError{
+			Code:    "TWO_FACTOR_LOCKED",
+			Message: "Too many wrong codes, try again later",
+		})
+	case errors.Is(err, services.ErrTwoFactorChallengeNotFound):
+		c.JSON(http.StatusUnauthorized, models.APIError{
+			Code:    "TWO_FACTOR_CHALLENGE_EXPIRED",
+			Message: "Log in again to get a new two-factor challenge",
+		})
+	case errors.Is(err, services.ErrTwoFactorNotEnabled):
+		c.JSON(http.StatusConflict, models.APIError{
+			Code:    "TWO_FACTOR_NOT_ENABLED",
+			Message: "Two-factor authentication is not enabled",
+		})
+	case errors.Is(err, services.ErrTwoFactorAlreadyEnabled):
+		c.JSON(http.StatusConflict, models.APIError{
+			Code:    "TWO_FACTOR_ALREADY_ENABLED",
+			Message: "Two-factor authentication is already enabled",
+		})
+	case errors.Is(err, services.ErrTwoFactorEnrollmentNotFound):
+		c.JSON(http.StatusNotFound, models.APIError{
+			Code:    "TWO_FACTOR_ENROLLMENT_NOT_FOUND",
+			Message: "Start an enrollment first",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.APIError{
+			Code:    "INTERNAL_ERROR",
+			Message: message,
+			Details: map[string]interface{}{
+				"error": err.Error(),
+			},
+		})
+	}
+}
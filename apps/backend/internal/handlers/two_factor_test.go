@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockTwoFactorService is a mock implementation of TwoFactorServiceInterface
+type MockTwoFactorService struct {
+	mock.Mock
+}
+
+func (m *MockTwoFactorService) Status(ctx context.Context, userID string) (*models.TwoFactorStatus, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TwoFactorStatus), args.Error(1)
+}
+
+func (m *MockTwoFactorService) BeginEnrollment(ctx context.Context, user *models.User) (*models.TwoFactorEnrollment, error) {
+	args := m.Called(ctx, user)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TwoFactorEnrollment), args.Error(1)
+}
+
+func (m *MockTwoFactorService) ConfirmEnrollment(ctx context.Context, userID, code string) ([]string, error) {
+	args := m.Called(ctx, userID, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockTwoFactorService) Disable(ctx context.Context, userID, code string) error {
+	args := m.Called(ctx, userID, code)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorService) RegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error) {
+	args := m.Called(ctx, userID, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockTwoFactorService) IsEnabled(ctx context.Context, userID string) (bool, error) {
+	args := m.Called(ctx, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTwoFactorService) Verify(ctx context.Context, userID, code string) error {
+	args := m.Called(ctx, userID, code)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorService) GetPolicy(ctx context.Context) (*models.TwoFactorPolicy, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TwoFactorPolicy), args.Error(1)
+}
+
+func (m *MockTwoFactorService) SetPolicy(ctx context.Context, policy models.TwoFactorPolicy, performedBy string) error {
+	args := m.Called(ctx, policy, performedBy)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorService) EnrollmentRequired(ctx context.Context, userID string) (bool, error) {
+	args := m.Called(ctx, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func setupTwoFactorRouter(handler *TwoFactorHandler) *gin.Engine {
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("user", &models.User{ID: "user-1", Username: "admin"})
+		c.Set("session_id", "session-1")
+		c.Next()
+	})
+	router.GET("/auth/two-factor", handler.Status)
+	router.POST("/auth/two-factor/enroll", handler.Enroll)
+	router.POST("/auth/two-factor/confirm", handler.Confirm)
+	router.POST("/auth/two-factor/disable", handler.Disable)
+	return router
+}
+
+func postJSON(router *gin.Engine, path string, body interface{}) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", path, bytes.NewBuffer(data))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestTwoFactorHandler_EnrollAndConfirm(t *testing.T) {
+	mockTwoFactor := new(MockTwoFactorService)
+	router := setupTwoFactorRouter(NewTwoFactorHandler(mockTwoFactor))
+
+	mockTwoFactor.On("BeginEnrollment", mock.Anything, mock.MatchedBy(func(u *models.User) bool { return u.ID == "user-1" })).Return(&models.TwoFactorEnrollment{
+		Secret:          "JBSWY3DPEHPK3PXP",
+		ProvisioningURI: "otpauth://totp/Pteronimbus:admin?secret=JBSWY3DPEHPK3PXP",
+	}, nil)
+	mockTwoFactor.On("ConfirmEnrollment", mock.Anything, "user-1", "000000").Return(nil, services.ErrInvalidTwoFactorCode)
+	mockTwoFactor.On("ConfirmEnrollment", mock.Anything, "user-1", "123456").Return([]string{"abcde-fghij"}, nil)
+
+	w := postJSON(router, "/auth/two-factor/enroll", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var enrollment models.TwoFactorEnrollment
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &enrollment))
+	assert.Contains(t, enrollment.ProvisioningURI, "otpauth://totp/")
+
+	w = postJSON(router, "/auth/two-factor/confirm", gin.H{})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = postJSON(router, "/auth/two-factor/confirm", models.TwoFactorCodeRequest{Code: "000000"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var apiError models.APIError
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &apiError))
+	assert.Equal(t, "INVALID_TWO_FACTOR_CODE", apiError.Code)
+
+	w = postJSON(router, "/auth/two-factor/confirm", models.TwoFactorCodeRequest{Code: "123456"})
+	require.Equal(t, http.StatusOK, w.Code)
+	var codes models.TwoFactorRecoveryCodes
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &codes))
+	assert.Equal(t, []string{"abcde-fghij"}, codes.RecoveryCodes)
+}
+
+func TestTwoFactorHandler_Disable(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{name: "disabled", expectedStatus: http.StatusOK},
+		{name: "not enabled", err: services.ErrTwoFactorNotEnabled, expectedStatus: http.StatusConflict, expectedCode: "TWO_FACTOR_NOT_ENABLED"},
+		{name: "wrong code", err: services.ErrInvalidTwoFactorCode, expectedStatus: http.StatusBadRequest, expectedCode: "INVALID_TWO_FACTOR_CODE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTwoFactor := new(MockTwoFactorService)
+			router := setupTwoFactorRouter(NewTwoFactorHandler(mockTwoFactor))
+			mockTwoFactor.On("Disable", mock.Anything, "user-1", "123456").Return(tt.err)
+
+			w := postJSON(router, "/auth/two-factor/disable", models.TwoFactorCodeRequest{Code: "123456"})
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedCode != "" {
+				var response models.APIError
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.expectedCode, response.Code)
+			}
+			mockTwoFactor.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthHandler_CallbackRedirectsToTwoFactorChallenge(t *testing.T) {
+	mockAuthService := new(MockAuthService)
+	handler := newIdentityTestHandler(mockAuthService)
+	router := setupTestRouter()
+	router.GET("/auth/login", handler.Login)
+	router.GET("/auth/callback", handler.Callback)
+
+	mockAuthService.On("GetAuthURL", mock.AnythingOfType("string")).Return("https://discord.com/oauth2/authorize")
+	mockAuthService.On("HandleCallback", mock.Anything, "code-1").Return(&models.AuthResponse{TwoFactorChallenge: "challenge-1"}, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/auth/login?redirect=/tenants/tenant-1", nil)
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	var response map[string]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+	cookies := w.Result().Cookies()
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/auth/callback?code=code-1&state="+response["state"], nil)
+	addCookies(req, cookies)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusTemporaryRedirect, w.Code)
+	location := w.Header().Get("Location")
+	assert.Equal(t, "http://localhost:3000/auth/two-factor?challenge=challenge-1&redirect=%2Ftenants%2Ftenant-1", location)
+	assert.NotContains(t, location, "access_token")
+}
+
+func TestAuthHandler_VerifyTwoFactor(t *testing.T) {
+	tests := []struct {
+		name           string
+		response       *models.AuthResponse
+		err            error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "valid code",
+			response:       &models.AuthResponse{AccessToken: "access", RefreshToken: "refresh", ExpiresIn: 3600},
+			expectedStatus: http.StatusOK,
+		},
+		{name: "wrong code", err: services.ErrInvalidTwoFactorCode, expectedStatus: http.StatusBadRequest, expectedCode: "INVALID_TWO_FACTOR_CODE"},
+		{name: "expired challenge", err: services.ErrTwoFactorChallengeNotFound, expectedStatus: http.StatusUnauthorized, expectedCode: "TWO_FACTOR_CHALLENGE_EXPIRED"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuthService := new(MockAuthService)
+			router := setupTestRouter()
+			router.POST("/auth/two-factor/verify", newIdentityTestHandler(mockAuthService).VerifyTwoFactor)
+
+			if tt.response != nil {
+				mockAuthService.On("VerifyTwoFactorLogin", mock.Anything, "challenge-1", "123456").Return(tt.response, nil)
+			} else {
+				mockAuthService.On("VerifyTwoFactorLogin", mock.Anything, "challenge-1", "123456").Return(nil, tt.err)
+			}
+
+			w := postJSON(router, "/auth/two-factor/verify", models.TwoFactorVerifyRequest{Challenge: "challenge-1", Code: "123456"})
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedCode != "" {
+				var response models.APIError
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.expectedCode, response.Code)
+			} else {
+				var response models.AuthResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, "access", response.AccessToken)
+			}
+			mockAuthService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthHandler_StepUpWithTOTP(t *testing.T) {
+	mockAuthService := new(MockAuthService)
+	router := setupStepUpRouter(newIdentityTestHandler(mockAuthService))
+
+	until := time.Now().Add(services.StepUpTTL)
+	mockAuthService.On("StepUpWithTOTP", mock.Anything, "session-1", "000000").Return(time.Time{}, services.ErrInvalidTwoFactorCode)
+	mockAuthService.On("StepUpWithTOTP", mock.Anything, "session-1", "123456").Return(until, nil)
+
+	w := postJSON(router, "/auth/step-up/totp", models.TwoFactorCodeRequest{Code: "000000"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = postJSON(router, "/auth/step-up/totp", models.TwoFactorCodeRequest{Code: "123456"})
+	require.Equal(t, http.StatusOK, w.Code)
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, true, response["active"])
+}
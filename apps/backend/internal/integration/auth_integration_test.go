@@ -362,6 +362,10 @@ func (m *MockDiscordService) GetGuildMember(ctx context.Context, botToken, guild
 	}, nil
 }
 
+func (m *MockDiscordService) GetGuildChannels(ctx context.Context, botToken, guildID string) ([]models.DiscordChannel, error) {
+	return []models.DiscordChannel{}, nil
+}
+
 type MockRedisService struct{}
 
 func (m *MockRedisService) StoreSession(ctx context.Context, session *models.Session) error {
@@ -372,14 +376,50 @@ func (m *MockRedisService) GetSession(ctx context.Context, sessionID string) (*m
 	return nil, errors.New("session not found in mock")
 }
 
+func (m *MockRedisService) UpdateSession(ctx context.Context, sessionID string, update func(*models.Session)) (*models.Session, error) {
+	return nil, errors.New("session not found in mock")
+}
+
+func (m *MockRedisService) StoreRefreshToken(ctx context.Context, session *models.Session) error {
+	return nil
+}
+
 func (m *MockRedisService) GetSessionByRefreshToken(ctx context.Context, refreshToken string) (*models.Session, error) {
 	return nil, errors.New("session not found in mock")
 }
 
+func (m *MockRedisService) ConsumeRefreshToken(ctx context.Context, refreshToken string, retiredUntil time.Time) (*models.Session, error) {
+	return nil, errors.New("session not found in mock")
+}
+
+func (m *MockRedisService) GetRetiredRefreshToken(ctx context.Context, refreshToken string) (string, error) {
+	return "", errors.New("refresh token not found in mock")
+}
+
 func (m *MockRedisService) DeleteSession(ctx context.Context, sessionID string) error {
 	return nil
 }
 
+func (m *MockRedisService) ListUserSessions(ctx context.Context, userID string) ([]*models.Session, error) {
+	return nil, nil
+}
+
+func (m *MockRedisService) StoreTwoFactorChallenge(ctx context.Context, challenge *models.TwoFactorChallenge) error {
+	return nil
+}
+
+func (m *MockRedisService) GetTwoFactorChallenge(ctx context.Context, challengeID string) (*models.TwoFactorChallenge, error) {
+	return nil, services.ErrTwoFactorChallengeNotFound
+}
+
+func (m *MockRedisService) CountTwoFactorChallengeAttempt(ctx context.Context, challenge *models.TwoFactorChallenge) (int64, error) {
+	return 0, nil
+}
+
+func (m *MockRedisService) DeleteTwoFactorChallenge(ctx context.Context, challengeID string) error {
+	return nil
+}
+
 func TestAuthIntegrationSuite(t *testing.T) {
 	suite.Run(t, new(AuthIntegrationTestSuite))
 }
\ No newline at end of file
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -63,6 +64,11 @@ func (m *TenantMockDiscordService) GetGuildMember(ctx context.Context, botToken,
 	return args.Get(0).(*models.DiscordMember), args.Error(1)
 }
 
+func (m *TenantMockDiscordService) GetGuildChannels(ctx context.Context, botToken, guildID string) ([]models.DiscordChannel, error) {
+	args := m.Called(ctx, botToken, guildID)
+	return args.Get(0).([]models.DiscordChannel), args.Error(1)
+}
+
 // TenantMockAuthService for tenant integration tests
 type TenantMockAuthService struct {
 	mock.Mock
@@ -98,6 +104,100 @@ func (m *TenantMockAuthService) Logout(ctx context.Context, accessToken string)
 	return args.Error(0)
 }
 
+func (m *TenantMockAuthService) IdentityProviders() []models.IdentityProviderInfo {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).([]models.IdentityProviderInfo)
+}
+
+func (m *TenantMockAuthService) BeginProviderLogin(ctx context.Context, providerName, linkUserID string) (*models.IdentityLoginState, error) {
+	args := m.Called(ctx, providerName, linkUserID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.IdentityLoginState), args.Error(1)
+}
+
+func (m *TenantMockAuthService) HandleProviderCallback(ctx context.Context, login *models.IdentityLoginState, code string) (*models.AuthResponse, error) {
+	args := m.Called(ctx, login, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AuthResponse), args.Error(1)
+}
+
+func (m *TenantMockAuthService) GetUserIdentities(ctx context.Context, userID string) ([]models.UserIdentity, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.UserIdentity), args.Error(1)
+}
+
+func (m *TenantMockAuthService) UnlinkIdentity(ctx context.Context, userID, identityID string) error {
+	args := m.Called(ctx, userID, identityID)
+	return args.Error(0)
+}
+
+func (m *TenantMockAuthService) ListSessions(ctx context.Context, userID, currentSessionID string) ([]models.SessionInfo, error) {
+	args := m.Called(ctx, userID, currentSessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.SessionInfo), args.Error(1)
+}
+
+func (m *TenantMockAuthService) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	args := m.Called(ctx, userID, sessionID)
+	return args.Error(0)
+}
+
+func (m *TenantMockAuthService) RevokeAllSessions(ctx context.Context, userID string) (int, error) {
+	args := m.Called(ctx, userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *TenantMockAuthService) GetDiscordGuilds(ctx context.Context, sessionID string) ([]models.DiscordGuild, error) {
+	args := m.Called(ctx, sessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.DiscordGuild), args.Error(1)
+}
+
+func (m *TenantMockAuthService) BeginStepUp(ctx context.Context, providerName, sessionID string) (*models.IdentityLoginState, error) {
+	args := m.Called(ctx, providerName, sessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.IdentityLoginState), args.Error(1)
+}
+
+func (m *TenantMockAuthService) CompleteStepUp(ctx context.Context, login *models.IdentityLoginState, code string) (time.Time, error) {
+	args := m.Called(ctx, login, code)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *TenantMockAuthService) StepUpExpiry(ctx context.Context, sessionID string) (time.Time, error) {
+	args := m.Called(ctx, sessionID)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *TenantMockAuthService) StepUpWithTOTP(ctx context.Context, sessionID, code string) (time.Time, error) {
+	args := m.Called(ctx, sessionID, code)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *TenantMockAuthService) VerifyTwoFactorLogin(ctx context.Context, challengeID, code string) (*models.AuthResponse, error) {
+	args := m.Called(ctx, challengeID, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AuthResponse), args.Error(1)
+}
+
 // setupIntegrationTest sets up a complete test environment
 func setupIntegrationTest(t *testing.T) (*gin.Engine, *gorm.DB, *TenantMockDiscordService, func()) {
 	// Setup PostgreSQL test database with all required models
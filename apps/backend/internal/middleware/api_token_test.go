@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAPITokenService for testing. Only token validation is used by the middleware.
+type MockAPITokenService struct {
+	services.APITokenServiceInterface
+	mock.Mock
+}
+
+func (m *MockAPITokenService) ValidateToken(ctx context.Context, rawToken string) (*models.User, *models.APIToken, error) {
+	args := m.Called(ctx, rawToken)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*models.User), args.Get(1).(*models.APIToken), args.Error(2)
+}
+
+func TestAuthMiddleware_APITokens(t *testing.T) {
+	tenantID := "tenant-1"
+	user := &models.User{ID: "user_id", Username: "ci"}
+	apiToken := &models.APIToken{
+		ID:       "token_id",
+		UserID:   "user_id",
+		TenantID: &tenantID,
+		Scopes:   models.StringArray{models.PermissionServerRestart},
+	}
+
+	mockAuthService := new(MockAuthService)
+	mockAPITokens := new(MockAPITokenService)
+	mockAPITokens.On("ValidateToken", mock.Anything, "pnb_valid").Return(user, apiToken, nil)
+	mockAPITokens.On("ValidateToken", mock.Anything, "pnb_revoked").Return(nil, nil, services.ErrInvalidAPIToken)
+
+	authMiddleware := NewAuthMiddlewareWithAPITokens(mockAuthService, mockAPITokens)
+	router := setupTestMiddleware()
+	router.Use(authMiddleware.RequireAuth())
+	router.GET("/me", func(c *gin.Context) {
+		contextUser, _ := GetUserFromContext(c)
+		contextToken, ok := GetAPITokenFromContext(c)
+		assert.True(t, ok)
+		assert.Equal(t, user, contextUser)
+		assert.Equal(t, apiToken, contextToken)
+		assert.Empty(t, GetSystemRolesFromContext(c))
+		c.Status(http.StatusOK)
+	})
+	router.GET("/restart", func(c *gin.Context) {
+		if !tokenAllowsTenant(c, c.Query("tenant")) {
+			c.Status(http.StatusForbidden)
+			return
+		}
+		if !tokenAllowsPermission(c, c.Query("permission")) {
+			abortInsufficientScope(c, c.Query("permission"))
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+	router.POST("/tokens", authMiddleware.RequireSession(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		token          string
+		expectedStatus int
+	}{
+		{name: "valid token", method: "GET", path: "/me", token: "pnb_valid", expectedStatus: http.StatusOK},
+		{name: "revoked token", method: "GET", path: "/me", token: "pnb_revoked", expectedStatus: http.StatusUnauthorized},
+		{name: "scoped permission", method: "GET", path: "/restart?tenant=tenant-1&permission=server:restart", token: "pnb_valid", expectedStatus: http.StatusOK},
+		{name: "permission outside scopes", method: "GET", path: "/restart?tenant=tenant-1&permission=server:delete", token: "pnb_valid", expectedStatus: http.StatusForbidden},
+		{name: "other tenant", method: "GET", path: "/restart?tenant=tenant-2&permission=server:restart", token: "pnb_valid", expectedStatus: http.StatusForbidden},
+		{name: "session-only route", method: "POST", path: "/tokens", token: "pnb_valid", expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+
+	// API tokens never reach JWT validation
+	mockAuthService.AssertNotCalled(t, "ValidateAccessToken", mock.Anything, mock.Anything)
+}
+
+func TestAuthMiddleware_APITokensDisabled(t *testing.T) {
+	mockAuthService := new(MockAuthService)
+	mockAuthService.On("ValidateAccessToken", mock.Anything, "pnb_valid").Return(nil, assert.AnError)
+
+	router := setupTestMiddleware()
+	router.Use(NewAuthMiddleware(mockAuthService).RequireAuth())
+	router.GET("/me", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	req, _ := http.NewRequest("GET", "/me", nil)
+	req.Header.Set("Authorization", "Bearer pnb_valid")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
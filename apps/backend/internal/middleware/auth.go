@@ -2,8 +2,10 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
@@ -13,6 +15,7 @@ import (
 // AuthMiddleware provides authentication middleware
 type AuthMiddleware struct {
 	authService services.AuthServiceInterface
+	apiTokens   services.APITokenServiceInterface
 }
 
 // NewAuthMiddleware creates a new auth middleware
@@ -22,6 +25,15 @@ func NewAuthMiddleware(authService services.AuthServiceInterface) *AuthMiddlewar
 	}
 }
 
+// NewAuthMiddlewareWithAPITokens creates a new auth middleware that also accepts
+// personal access and service account tokens
+func NewAuthMiddlewareWithAPITokens(authService services.AuthServiceInterface, apiTokens services.APITokenServiceInterface) *AuthMiddleware {
+	return &AuthMiddleware{
+		authService: authService,
+		apiTokens:   apiTokens,
+	}
+}
+
 // RequireAuth middleware that requires authentication
 func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -49,6 +61,26 @@ func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 
 		token := parts[1]
 
+		// API tokens are validated against the database instead of as JWTs
+		if m.isAPIToken(token) {
+			user, apiToken, err := m.apiTokens.ValidateToken(c.Request.Context(), token)
+			if err != nil {
+				c.JSON(http.StatusUnauthorized, models.APIError{
+					Code:    "UNAUTHORIZED",
+					Message: "Invalid or expired token",
+					Details: map[string]interface{}{
+						"error": err.Error(),
+					},
+				})
+				c.Abort()
+				return
+			}
+
+			setAPITokenContext(c, user, apiToken)
+			c.Next()
+			return
+		}
+
 		// Validate token and get user
 		user, err := m.authService.ValidateAccessToken(context.Background(), token)
 		if err != nil {
@@ -108,6 +140,14 @@ func (m *AuthMiddleware) OptionalAuth() gin.HandlerFunc {
 
 		token := parts[1]
 
+		if m.isAPIToken(token) {
+			if user, apiToken, err := m.apiTokens.ValidateToken(c.Request.Context(), token); err == nil {
+				setAPITokenContext(c, user, apiToken)
+			}
+			c.Next()
+			return
+		}
+
 		// Validate token
 		user, err := m.authService.ValidateAccessToken(context.Background(), token)
 		if err != nil {
@@ -125,6 +165,111 @@ func (m *AuthMiddleware) OptionalAuth() gin.HandlerFunc {
 	}
 }
 
+// RequireSession rejects requests authenticated with an API token. Routes that
+// manage credentials, accounts or tenants themselves are limited to interactive logins.
+func (m *AuthMiddleware) RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := GetAPITokenFromContext(c); ok {
+			c.JSON(http.StatusForbidden, models.APIError{
+				Code:    "SESSION_REQUIRED",
+				Message: "This operation is not available to API tokens",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireStepUp middleware that only lets sessions which recently confirmed
+// their identity through a step-up perform the operation
+func (m *AuthMiddleware) RequireStepUp() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := GetAPITokenFromContext(c); ok {
+			c.JSON(http.StatusForbidden, models.APIError{
+				Code:    "SESSION_REQUIRED",
+				Message: "This operation is not available to API tokens",
+			})
+			c.Abort()
+			return
+		}
+
+		until, err := m.authService.StepUpExpiry(c.Request.Context(), GetSessionIDFromContext(c))
+		if err != nil && !errors.Is(err, services.ErrSessionNotFound) {
+			c.JSON(http.StatusInternalServerError, models.APIError{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to check step-up",
+			})
+			c.Abort()
+			return
+		}
+
+		if err != nil || !time.Now().Before(until) {
+			c.JSON(http.StatusForbidden, models.APIError{
+				Code:    "STEP_UP_REQUIRED",
+				Message: "Confirm your identity again to perform this operation",
+				Details: map[string]interface{}{
+					"methods":         []string{"oauth", "totp"},
+					"step_up_url":     "/auth/step-up/{provider}",
+					"totp_url":        "/auth/step-up/totp",
+					"max_age_seconds": int(services.StepUpTTL.Seconds()),
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func (m *AuthMiddleware) isAPIToken(token string) bool {
+	return m.apiTokens != nil && strings.HasPrefix(token, models.APITokenPrefix)
+}
+
+// setAPITokenContext stores the token's owner and the token itself in context.
+// Tokens carry no session and no system roles.
+func setAPITokenContext(c *gin.Context, user *models.User, apiToken *models.APIToken) {
+	c.Set("user", user)
+	c.Set("user_id", user.ID)
+	c.Set("discord_user_id", user.DiscordUserID)
+	c.Set("api_token", apiToken)
+}
+
+// GetAPITokenFromContext gets the API token the request authenticated with, if any
+func GetAPITokenFromContext(c *gin.Context) (*models.APIToken, bool) {
+	apiToken, exists := c.Get("api_token")
+	if !exists {
+		return nil, false
+	}
+
+	t, ok := apiToken.(*models.APIToken)
+	return t, ok
+}
+
+// tokenAllowsTenant reports whether the request's API token, if any, may be used in a tenant
+func tokenAllowsTenant(c *gin.Context, tenantID string) bool {
+	apiToken, ok := GetAPITokenFromContext(c)
+	return !ok || apiToken.AllowsTenant(tenantID)
+}
+
+// tokenAllowsPermission reports whether the request's API token, if any, is scoped to a permission
+func tokenAllowsPermission(c *gin.Context, permission string) bool {
+	apiToken, ok := GetAPITokenFromContext(c)
+	return !ok || apiToken.AllowsPermission(permission)
+}
+
+// abortInsufficientScope rejects a request whose API token is not scoped to a permission
+func abortInsufficientScope(c *gin.Context, permission string) {
+	c.JSON(http.StatusForbidden, models.APIError{
+		Code:    "INSUFFICIENT_SCOPE",
+		Message: "API token is not scoped for this operation",
+		Details: map[string]interface{}{"required_permission": permission},
+	})
+	c.Abort()
+}
+
 // GetUserFromContext gets the authenticated user from context
 func GetUserFromContext(c *gin.Context) (*models.User, bool) {
 	user, exists := c.Get("user")
@@ -171,4 +316,15 @@ func GetSystemRolesFromContext(c *gin.Context) []string {
 	}
 
 	return roles
+}
+
+// GetSessionIDFromContext gets the ID of the session the request was made with
+func GetSessionIDFromContext(c *gin.Context) string {
+	sessionID, exists := c.Get("session_id")
+	if !exists {
+		return ""
+	}
+
+	id, _ := sessionID.(string)
+	return id
 }
\ No newline at end of file
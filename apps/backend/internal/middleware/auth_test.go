@@ -2,15 +2,18 @@ package middleware
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/services"
 )
 
 // MockAuthService for middleware testing
@@ -60,6 +63,100 @@ func (m *MockAuthService) Logout(ctx context.Context, accessToken string) error
 	return args.Error(0)
 }
 
+func (m *MockAuthService) IdentityProviders() []models.IdentityProviderInfo {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).([]models.IdentityProviderInfo)
+}
+
+func (m *MockAuthService) BeginProviderLogin(ctx context.Context, providerName, linkUserID string) (*models.IdentityLoginState, error) {
+	args := m.Called(ctx, providerName, linkUserID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.IdentityLoginState), args.Error(1)
+}
+
+func (m *MockAuthService) HandleProviderCallback(ctx context.Context, login *models.IdentityLoginState, code string) (*models.AuthResponse, error) {
+	args := m.Called(ctx, login, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AuthResponse), args.Error(1)
+}
+
+func (m *MockAuthService) GetUserIdentities(ctx context.Context, userID string) ([]models.UserIdentity, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.UserIdentity), args.Error(1)
+}
+
+func (m *MockAuthService) UnlinkIdentity(ctx context.Context, userID, identityID string) error {
+	args := m.Called(ctx, userID, identityID)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) ListSessions(ctx context.Context, userID, currentSessionID string) ([]models.SessionInfo, error) {
+	args := m.Called(ctx, userID, currentSessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.SessionInfo), args.Error(1)
+}
+
+func (m *MockAuthService) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	args := m.Called(ctx, userID, sessionID)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) RevokeAllSessions(ctx context.Context, userID string) (int, error) {
+	args := m.Called(ctx, userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockAuthService) GetDiscordGuilds(ctx context.Context, sessionID string) ([]models.DiscordGuild, error) {
+	args := m.Called(ctx, sessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.DiscordGuild), args.Error(1)
+}
+
+func (m *MockAuthService) BeginStepUp(ctx context.Context, providerName, sessionID string) (*models.IdentityLoginState, error) {
+	args := m.Called(ctx, providerName, sessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.IdentityLoginState), args.Error(1)
+}
+
+func (m *MockAuthService) CompleteStepUp(ctx context.Context, login *models.IdentityLoginState, code string) (time.Time, error) {
+	args := m.Called(ctx, login, code)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *MockAuthService) StepUpExpiry(ctx context.Context, sessionID string) (time.Time, error) {
+	args := m.Called(ctx, sessionID)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *MockAuthService) StepUpWithTOTP(ctx context.Context, sessionID, code string) (time.Time, error) {
+	args := m.Called(ctx, sessionID, code)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *MockAuthService) VerifyTwoFactorLogin(ctx context.Context, challengeID, code string) (*models.AuthResponse, error) {
+	args := m.Called(ctx, challengeID, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AuthResponse), args.Error(1)
+}
+
 func setupTestMiddleware() *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	return gin.New()
@@ -309,6 +406,103 @@ func TestAuthMiddleware_OptionalAuth(t *testing.T) {
 	}
 }
 
+func TestAuthMiddleware_RequireStepUp(t *testing.T) {
+	tests := []struct {
+		name           string
+		apiToken       bool
+		setupMock      func(*MockAuthService)
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name: "recent step-up",
+			setupMock: func(m *MockAuthService) {
+				m.On("StepUpExpiry", mock.Anything, "session_id").Return(time.Now().Add(time.Minute), nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "step-up expired",
+			setupMock: func(m *MockAuthService) {
+				m.On("StepUpExpiry", mock.Anything, "session_id").Return(time.Now().Add(-time.Second), nil)
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedCode:   "STEP_UP_REQUIRED",
+		},
+		{
+			name: "never stepped up",
+			setupMock: func(m *MockAuthService) {
+				m.On("StepUpExpiry", mock.Anything, "session_id").Return(time.Time{}, nil)
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedCode:   "STEP_UP_REQUIRED",
+		},
+		{
+			name: "session gone",
+			setupMock: func(m *MockAuthService) {
+				m.On("StepUpExpiry", mock.Anything, "session_id").Return(time.Time{}, services.ErrSessionNotFound)
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedCode:   "STEP_UP_REQUIRED",
+		},
+		{
+			name: "session store unavailable",
+			setupMock: func(m *MockAuthService) {
+				m.On("StepUpExpiry", mock.Anything, "session_id").Return(time.Time{}, errors.New("redis down"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedCode:   "INTERNAL_ERROR",
+		},
+		{
+			name:           "api token",
+			apiToken:       true,
+			setupMock:      func(m *MockAuthService) {},
+			expectedStatus: http.StatusForbidden,
+			expectedCode:   "SESSION_REQUIRED",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuthService := new(MockAuthService)
+			tt.setupMock(mockAuthService)
+
+			middleware := NewAuthMiddleware(mockAuthService)
+			router := setupTestMiddleware()
+			router.Use(func(c *gin.Context) {
+				if tt.apiToken {
+					c.Set("api_token", &models.APIToken{ID: "token_id"})
+				} else {
+					c.Set("session_id", "session_id")
+				}
+				c.Next()
+			})
+			router.Use(middleware.RequireStepUp())
+			router.DELETE("/destructive", func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+			})
+
+			req, _ := http.NewRequest("DELETE", "/destructive", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedCode != "" {
+				var response models.APIError
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedCode, response.Code)
+				if tt.expectedCode == "STEP_UP_REQUIRED" {
+					assert.Contains(t, response.Details, "step_up_url")
+					assert.Contains(t, response.Details, "methods")
+				}
+			}
+
+			mockAuthService.AssertExpectations(t)
+		})
+	}
+}
+
 func TestGetUserFromContext(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -10,11 +10,11 @@ import (
 
 // PermissionMiddleware handles permission-based access control
 type PermissionMiddleware struct {
-	rbacService *services.RBACService
+	rbacService services.AuthorizerInterface
 }
 
 // NewPermissionMiddleware creates a new permission middleware
-func NewPermissionMiddleware(rbacService *services.RBACService) *PermissionMiddleware {
+func NewPermissionMiddleware(rbacService services.AuthorizerInterface) *PermissionMiddleware {
 	return &PermissionMiddleware{
 		rbacService: rbacService,
 	}
@@ -47,6 +47,11 @@ func (pm *PermissionMiddleware) RequirePermission(permission string) gin.Handler
 
 		userModel := user.(*models.User)
 
+		if !tokenAllowsPermission(c, permission) {
+			abortInsufficientScope(c, permission)
+			return
+		}
+
 		// Check if user has the required permission
 		hasPermission, err := pm.rbacService.HasPermission(c.Request.Context(), userModel.ID, tenantID.(string), permission)
 		if err != nil {
@@ -73,6 +78,70 @@ func (pm *PermissionMiddleware) RequirePermission(permission string) gin.Handler
 	}
 }
 
+// RequireResourcePermission middleware ensures user has a permission on the
+// resource whose ID is in the named route parameter, either tenant-wide or
+// through a resource grant
+func (pm *PermissionMiddleware) RequireResourcePermission(permission, resourceType, param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get tenant ID from context (should be set by RequireTenant middleware)
+		tenantID, exists := c.Get("tenant_id")
+		if !exists {
+			c.JSON(http.StatusBadRequest, models.APIError{
+				Code:    "MISSING_TENANT_CONTEXT",
+				Message: "Tenant context not found",
+			})
+			c.Abort()
+			return
+		}
+
+		// Get authenticated user
+		user, exists := c.Get("user")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, models.APIError{
+				Code:    "UNAUTHORIZED",
+				Message: "User not authenticated",
+			})
+			c.Abort()
+			return
+		}
+
+		userModel := user.(*models.User)
+
+		if !tokenAllowsPermission(c, permission) {
+			abortInsufficientScope(c, permission)
+			return
+		}
+
+		resourceID := c.Param(param)
+		hasPermission, err := pm.rbacService.HasResourcePermission(c.Request.Context(), userModel.ID, tenantID.(string), permission, resourceType, resourceID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIError{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to check permission",
+				Details: map[string]interface{}{"error": err.Error()},
+			})
+			c.Abort()
+			return
+		}
+
+		if !hasPermission {
+			c.JSON(http.StatusForbidden, models.APIError{
+				Code:    "INSUFFICIENT_PERMISSIONS",
+				Message: "Insufficient permissions for this operation",
+				Details: map[string]interface{}{
+					"required_permission": permission,
+					"resource_type":       resourceType,
+					"resource_id":         resourceID,
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
 // RequireAnyPermission middleware ensures user has at least one of the specified permissions
 func (pm *PermissionMiddleware) RequireAnyPermission(permissions ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -102,6 +171,10 @@ func (pm *PermissionMiddleware) RequireAnyPermission(permissions ...string) gin.
 
 		// Check if user has any of the required permissions
 		for _, permission := range permissions {
+			if !tokenAllowsPermission(c, permission) {
+				continue
+			}
+
 			hasPermission, err := pm.rbacService.HasPermission(c.Request.Context(), userModel.ID, tenantID.(string), permission)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, models.APIError{
@@ -158,6 +231,11 @@ func (pm *PermissionMiddleware) RequireAllPermissions(permissions ...string) gin
 
 		// Check if user has all of the required permissions
 		for _, permission := range permissions {
+			if !tokenAllowsPermission(c, permission) {
+				abortInsufficientScope(c, permission)
+				return
+			}
+
 			hasPermission, err := pm.rbacService.HasPermission(c.Request.Context(), userModel.ID, tenantID.(string), permission)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, models.APIError{
@@ -200,6 +278,12 @@ func (pm *PermissionMiddleware) RequireSuperAdmin() gin.HandlerFunc {
 
 		userModel := user.(*models.User)
 
+		// System administration is not delegated to API tokens
+		if _, ok := GetAPITokenFromContext(c); ok {
+			abortInsufficientScope(c, models.PermissionSuperAdmin)
+			return
+		}
+
 		// Check if user is super admin
 		isSuperAdmin, err := pm.rbacService.IsSuperAdmin(c.Request.Context(), userModel.ID)
 		if err != nil {
@@ -225,6 +309,20 @@ func (pm *PermissionMiddleware) RequireSuperAdmin() gin.HandlerFunc {
 	}
 }
 
+// RequireTokenScope middleware ensures that a request made with an API token
+// is scoped to a permission. It is for routes whose handlers decide what each
+// member may see, so session requests are let through unchanged.
+func (pm *PermissionMiddleware) RequireTokenScope(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !tokenAllowsPermission(c, permission) {
+			abortInsufficientScope(c, permission)
+			return
+		}
+
+		c.Next()
+	}
+}
+
 // OptionalPermission middleware adds permission context but doesn't require it
 func (pm *PermissionMiddleware) OptionalPermission(permission string) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -254,7 +352,7 @@ func (pm *PermissionMiddleware) OptionalPermission(permission string) gin.Handle
 		}
 
 		// Set permission context
-		c.Set("has_permission_"+permission, hasPermission)
+		c.Set("has_permission_"+permission, hasPermission && tokenAllowsPermission(c, permission))
 
 		c.Next()
 	}
@@ -428,4 +428,32 @@ func TestPermissionMiddleware_OptionalPermission(t *testing.T) {
 	router.ServeHTTP(w, req)
 	
 	assert.Equal(t, http.StatusOK, w.Code)
-} 
\ No newline at end of file
+} 
+func TestPermissionMiddleware_RequireTokenScope(t *testing.T) {
+	permissionMiddleware := NewPermissionMiddleware(nil)
+	user := &models.User{ID: "user-1"}
+
+	tests := []struct {
+		name           string
+		apiToken       *models.APIToken
+		expectedStatus int
+	}{
+		{name: "session", expectedStatus: http.StatusOK},
+		{name: "token with the scope", apiToken: &models.APIToken{Scopes: models.StringArray{"server:*"}}, expectedStatus: http.StatusOK},
+		{name: "token without the scope", apiToken: &models.APIToken{Scopes: models.StringArray{models.PermissionServerStart}}, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := setupTenantRouter(user, tt.apiToken, permissionMiddleware.RequireTokenScope(models.PermissionServerRead))
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/test", nil))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusForbidden {
+				assert.Contains(t, w.Body.String(), "INSUFFICIENT_SCOPE")
+			}
+		})
+	}
+}
@@ -11,6 +11,7 @@ import (
 // TenantMiddleware handles tenant context for API requests
 type TenantMiddleware struct {
 	tenantService services.TenantServiceInterface
+	twoFactor     services.TwoFactorServiceInterface
 }
 
 // NewTenantMiddleware creates a new tenant middleware
@@ -20,6 +21,16 @@ func NewTenantMiddleware(tenantService services.TenantServiceInterface) *TenantM
 	}
 }
 
+// NewTenantMiddlewareWithTwoFactor creates a new tenant middleware that keeps
+// tenant owners out of their tenants until they enroll in two-factor
+// authentication, when the policy requires it
+func NewTenantMiddlewareWithTwoFactor(tenantService services.TenantServiceInterface, twoFactor services.TwoFactorServiceInterface) *TenantMiddleware {
+	return &TenantMiddleware{
+		tenantService: tenantService,
+		twoFactor:     twoFactor,
+	}
+}
+
 // RequireTenant middleware ensures a valid tenant context is present
 func (tm *TenantMiddleware) RequireTenant() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -51,8 +62,17 @@ func (tm *TenantMiddleware) RequireTenant() gin.HandlerFunc {
 
 		userModel := user.(*models.User)
 
-		// Check if user has access to this tenant
-		hasAccess, err := tm.tenantService.HasPermission(c.Request.Context(), userModel.ID, tenantID, "read")
+		if !tokenAllowsTenant(c, tenantID) {
+			c.JSON(http.StatusForbidden, models.APIError{
+				Code:    "INSUFFICIENT_SCOPE",
+				Message: "API token is not valid for this tenant",
+			})
+			c.Abort()
+			return
+		}
+
+		// Check if user is a member of this tenant
+		hasAccess, err := tm.tenantService.IsTenantMember(c.Request.Context(), userModel.ID, tenantID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, models.APIError{
 				Code:    "INTERNAL_ERROR",
@@ -83,6 +103,10 @@ func (tm *TenantMiddleware) RequireTenant() gin.HandlerFunc {
 			return
 		}
 
+		if !tm.ownerEnrolled(c, tenant, userModel.ID) {
+			return
+		}
+
 		// Set tenant in context
 		c.Set("tenant", tenant)
 		c.Set("tenant_id", tenantID)
@@ -91,6 +115,77 @@ func (tm *TenantMiddleware) RequireTenant() gin.HandlerFunc {
 	}
 }
 
+// RequireOwnerTwoFactor keeps tenant owners out of the management routes of
+// the tenant in the id path parameter until they enroll in two-factor
+// authentication, when the policy requires it
+func (tm *TenantMiddleware) RequireOwnerTwoFactor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if tm.twoFactor == nil {
+			c.Next()
+			return
+		}
+
+		// Get authenticated user
+		user, exists := c.Get("user")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, models.APIError{
+				Code:    "UNAUTHORIZED",
+				Message: "User not authenticated",
+			})
+			c.Abort()
+			return
+		}
+
+		tenant, err := tm.tenantService.GetTenant(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, models.APIError{
+				Code:    "TENANT_NOT_FOUND",
+				Message: "Tenant not found",
+			})
+			c.Abort()
+			return
+		}
+
+		if !tm.ownerEnrolled(c, tenant, user.(*models.User).ID) {
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// ownerEnrolled aborts the request and returns false when the user owns the
+// tenant but still has to enroll in two-factor authentication
+func (tm *TenantMiddleware) ownerEnrolled(c *gin.Context, tenant *models.Tenant, userID string) bool {
+	if tm.twoFactor == nil || tenant.OwnerID != userID {
+		return true
+	}
+
+	required, err := tm.twoFactor.EnrollmentRequired(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIError{
+			Code:    "INTERNAL_ERROR",
+			Message: "Failed to check two-factor enrollment",
+			Details: map[string]interface{}{"error": err.Error()},
+		})
+		c.Abort()
+		return false
+	}
+	if required {
+		c.JSON(http.StatusForbidden, models.APIError{
+			Code:    "TWO_FACTOR_ENROLLMENT_REQUIRED",
+			Message: "Tenant owners must enable two-factor authentication",
+			Details: map[string]interface{}{
+				"enroll_url": "/auth/two-factor/enroll",
+			},
+		})
+		c.Abort()
+		return false
+	}
+
+	return true
+}
+
 // RequirePermission middleware ensures user has specific permission in tenant
 func (tm *TenantMiddleware) RequirePermission(permission string) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -118,6 +213,11 @@ func (tm *TenantMiddleware) RequirePermission(permission string) gin.HandlerFunc
 
 		userModel := user.(*models.User)
 
+		if !tokenAllowsPermission(c, permission) {
+			abortInsufficientScope(c, permission)
+			return
+		}
+
 		// Check if user has the required permission
 		hasPermission, err := tm.tenantService.HasPermission(c.Request.Context(), userModel.ID, tenantID.(string), permission)
 		if err != nil {
@@ -167,9 +267,9 @@ func (tm *TenantMiddleware) OptionalTenant() gin.HandlerFunc {
 
 		userModel := user.(*models.User)
 
-		// Check if user has access to this tenant
-		hasAccess, err := tm.tenantService.HasPermission(c.Request.Context(), userModel.ID, tenantID, "read")
-		if err != nil || !hasAccess {
+		// Check if user is a member of this tenant
+		hasAccess, err := tm.tenantService.IsTenantMember(c.Request.Context(), userModel.ID, tenantID)
+		if err != nil || !hasAccess || !tokenAllowsTenant(c, tenantID) {
 			c.Next()
 			return
 		}
@@ -0,0 +1,315 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockTenantService for testing. Only membership, permission and tenant lookups are used by the middleware.
+type MockTenantService struct {
+	services.TenantServiceInterface
+	mock.Mock
+}
+
+func (m *MockTenantService) IsTenantMember(ctx context.Context, userID, tenantID string) (bool, error) {
+	args := m.Called(ctx, userID, tenantID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTenantService) HasPermission(ctx context.Context, userID, tenantID, permission string) (bool, error) {
+	args := m.Called(ctx, userID, tenantID, permission)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTenantService) GetTenant(ctx context.Context, tenantID string) (*models.Tenant, error) {
+	args := m.Called(ctx, tenantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Tenant), args.Error(1)
+}
+
+func setupTenantRouter(user *models.User, apiToken *models.APIToken, handlers ...gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if user != nil {
+			c.Set("user", user)
+		}
+		if apiToken != nil {
+			c.Set("api_token", apiToken)
+		}
+		c.Next()
+	})
+	handlers = append(handlers, func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"tenant_id": c.GetString("tenant_id")})
+	})
+	router.GET("/test", handlers...)
+	return router
+}
+
+func TestTenantMiddleware_RequireTenant(t *testing.T) {
+	user := &models.User{ID: "user-1"}
+	tenant := &models.Tenant{ID: "tenant-1", OwnerID: "owner-1"}
+
+	t.Run("member is let in", func(t *testing.T) {
+		mockTenantService := new(MockTenantService)
+		mockTenantService.On("IsTenantMember", mock.Anything, "user-1", "tenant-1").Return(true, nil)
+		mockTenantService.On("GetTenant", mock.Anything, "tenant-1").Return(tenant, nil)
+		tm := NewTenantMiddleware(mockTenantService)
+		router := setupTenantRouter(user, nil, tm.RequireTenant())
+
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.Header.Set("X-Tenant-ID", "tenant-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "tenant-1")
+		// Membership is checked, not a tenant permission
+		mockTenantService.AssertNotCalled(t, "HasPermission", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		mockTenantService.AssertExpectations(t)
+	})
+
+	t.Run("non-member is forbidden", func(t *testing.T) {
+		mockTenantService := new(MockTenantService)
+		mockTenantService.On("IsTenantMember", mock.Anything, "user-1", "tenant-1").Return(false, nil)
+		tm := NewTenantMiddleware(mockTenantService)
+		router := setupTenantRouter(user, nil, tm.RequireTenant())
+
+		req := httptest.NewRequest("GET", "/test?tenant_id=tenant-1", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "FORBIDDEN")
+		mockTenantService.AssertNotCalled(t, "GetTenant", mock.Anything, mock.Anything)
+	})
+
+	t.Run("missing tenant", func(t *testing.T) {
+		tm := NewTenantMiddleware(new(MockTenantService))
+		router := setupTenantRouter(user, nil, tm.RequireTenant())
+
+		req := httptest.NewRequest("GET", "/test", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "MISSING_TENANT")
+	})
+
+	t.Run("API token for another tenant is rejected before membership", func(t *testing.T) {
+		otherTenant := "tenant-2"
+		mockTenantService := new(MockTenantService)
+		tm := NewTenantMiddleware(mockTenantService)
+		router := setupTenantRouter(user, &models.APIToken{TenantID: &otherTenant}, tm.RequireTenant())
+
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.Header.Set("X-Tenant-ID", "tenant-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "INSUFFICIENT_SCOPE")
+		mockTenantService.AssertNotCalled(t, "IsTenantMember", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestTenantMiddleware_OptionalTenant(t *testing.T) {
+	user := &models.User{ID: "user-1"}
+
+	mockTenantService := new(MockTenantService)
+	mockTenantService.On("IsTenantMember", mock.Anything, "user-1", "tenant-1").Return(false, nil)
+	tm := NewTenantMiddleware(mockTenantService)
+	router := setupTenantRouter(user, nil, tm.OptionalTenant())
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("X-Tenant-ID", "tenant-1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Non-members pass through without a tenant context
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"tenant_id":""`)
+	mockTenantService.AssertNotCalled(t, "GetTenant", mock.Anything, mock.Anything)
+}
+
+func TestTenantMiddleware_RequirePermission(t *testing.T) {
+	user := &models.User{ID: "user-1"}
+	withTenant := func(c *gin.Context) {
+		c.Set("tenant_id", "tenant-1")
+		c.Next()
+	}
+
+	t.Run("permission held", func(t *testing.T) {
+		mockTenantService := new(MockTenantService)
+		mockTenantService.On("HasPermission", mock.Anything, "user-1", "tenant-1", models.PermissionServerRead).Return(true, nil)
+		tm := NewTenantMiddleware(mockTenantService)
+		router := setupTenantRouter(user, nil, withTenant, tm.RequirePermission(models.PermissionServerRead))
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/test", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockTenantService.AssertExpectations(t)
+	})
+
+	t.Run("permission not held", func(t *testing.T) {
+		mockTenantService := new(MockTenantService)
+		mockTenantService.On("HasPermission", mock.Anything, "user-1", "tenant-1", models.PermissionServerWrite).Return(false, nil)
+		tm := NewTenantMiddleware(mockTenantService)
+		router := setupTenantRouter(user, nil, withTenant, tm.RequirePermission(models.PermissionServerWrite))
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/test", nil))
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "INSUFFICIENT_PERMISSIONS")
+	})
+
+	t.Run("API token scope is checked first", func(t *testing.T) {
+		mockTenantService := new(MockTenantService)
+		tm := NewTenantMiddleware(mockTenantService)
+		apiToken := &models.APIToken{Scopes: models.StringArray{models.PermissionServerRead}}
+		router := setupTenantRouter(user, apiToken, withTenant, tm.RequirePermission(models.PermissionServerWrite))
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/test", nil))
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "INSUFFICIENT_SCOPE")
+		mockTenantService.AssertNotCalled(t, "HasPermission", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+// MockAuthorizer for testing the policy engine shared by the tenant and permission middleware
+type MockAuthorizer struct {
+	mock.Mock
+}
+
+func (m *MockAuthorizer) IsSuperAdmin(ctx context.Context, userID string) (bool, error) {
+	args := m.Called(ctx, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAuthorizer) IsTenantMember(ctx context.Context, userID, tenantID string) (bool, error) {
+	args := m.Called(ctx, userID, tenantID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAuthorizer) HasPermission(ctx context.Context, userID, tenantID, permission string) (bool, error) {
+	args := m.Called(ctx, userID, tenantID, permission)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAuthorizer) HasResourcePermission(ctx context.Context, userID, tenantID, permission, resourceType, resourceID string) (bool, error) {
+	args := m.Called(ctx, userID, tenantID, permission, resourceType, resourceID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAuthorizer) InvalidateUserPermissions(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockAuthorizer) InvalidateTenantPermissions(ctx context.Context, tenantID string) error {
+	args := m.Called(ctx, tenantID)
+	return args.Error(0)
+}
+
+func TestTenantAndPermissionMiddleware_ShareAuthorizer(t *testing.T) {
+	user := &models.User{ID: "user-1"}
+	withTenant := func(c *gin.Context) {
+		c.Set("tenant_id", "tenant-1")
+		c.Next()
+	}
+
+	authorizer := new(MockAuthorizer)
+	authorizer.On("HasPermission", mock.Anything, "user-1", "tenant-1", models.PermissionServerRead).Return(true, nil)
+	authorizer.On("HasPermission", mock.Anything, "user-1", "tenant-1", models.PermissionServerDelete).Return(false, nil)
+
+	tm := NewTenantMiddleware(services.NewTenantServiceWithRBAC(nil, nil, authorizer))
+	pm := NewPermissionMiddleware(authorizer)
+
+	for _, permission := range []string{models.PermissionServerRead, models.PermissionServerDelete} {
+		tenantRouter := setupTenantRouter(user, nil, withTenant, tm.RequirePermission(permission))
+		permissionRouter := setupTenantRouter(user, nil, withTenant, pm.RequirePermission(permission))
+
+		tw := httptest.NewRecorder()
+		tenantRouter.ServeHTTP(tw, httptest.NewRequest("GET", "/test", nil))
+		pw := httptest.NewRecorder()
+		permissionRouter.ServeHTTP(pw, httptest.NewRequest("GET", "/test", nil))
+
+		assert.Equal(t, pw.Code, tw.Code, permission)
+	}
+	authorizer.AssertNumberOfCalls(t, "HasPermission", 4)
+}
+
+// fakeTwoFactor requires enrollment for the users in its set
+type fakeTwoFactor struct {
+	services.TwoFactorServiceInterface
+	unenrolled map[string]bool
+}
+
+func (f *fakeTwoFactor) EnrollmentRequired(ctx context.Context, userID string) (bool, error) {
+	return f.unenrolled[userID], nil
+}
+
+func TestTenantMiddleware_OwnerTwoFactor(t *testing.T) {
+	tenant := &models.Tenant{ID: "tenant-1", OwnerID: "owner-1"}
+	mockTenantService := new(MockTenantService)
+	mockTenantService.On("IsTenantMember", mock.Anything, mock.Anything, "tenant-1").Return(true, nil)
+	mockTenantService.On("GetTenant", mock.Anything, "tenant-1").Return(tenant, nil)
+	mockTenantService.On("GetTenant", mock.Anything, "missing").Return(nil, services.ErrTenantNotFound)
+	tm := NewTenantMiddlewareWithTwoFactor(mockTenantService, &fakeTwoFactor{unenrolled: map[string]bool{"owner-1": true, "member-1": true}})
+
+	tests := []struct {
+		name           string
+		user           *models.User
+		tenantID       string
+		expectedStatus int
+	}{
+		{name: "unenrolled owner", user: &models.User{ID: "owner-1"}, tenantID: "tenant-1", expectedStatus: http.StatusForbidden},
+		{name: "unenrolled member", user: &models.User{ID: "member-1"}, tenantID: "tenant-1", expectedStatus: http.StatusOK},
+		{name: "unknown tenant", user: &models.User{ID: "owner-1"}, tenantID: "missing", expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+" on tenant routes", func(t *testing.T) {
+			router := setupTenantRouter(tt.user, nil)
+			router.PUT("/tenants/:id/config", tm.RequireOwnerTwoFactor(), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("PUT", "/tenants/"+tt.tenantID+"/config", nil))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusForbidden {
+				assert.Contains(t, w.Body.String(), "TWO_FACTOR_ENROLLMENT_REQUIRED")
+			}
+		})
+
+		if tt.tenantID != "tenant-1" {
+			continue
+		}
+		t.Run(tt.name+" on tenant-scoped routes", func(t *testing.T) {
+			router := setupTenantRouter(tt.user, nil, tm.RequireTenant())
+
+			req := httptest.NewRequest("GET", "/test", nil)
+			req.Header.Set("X-Tenant-ID", tt.tenantID)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
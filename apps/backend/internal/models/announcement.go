@@ -0,0 +1,116 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxAnnouncementTemplates bounds how many templates a tenant can define
+const maxAnnouncementTemplates = 25
+
+// AnnouncementPolicy controls who may post bot announcements and where.
+// Members holding PermissionAnnouncementPublish post directly; members with one
+// of AllowedRoleIDs may only submit announcements for approval.
+type AnnouncementPolicy struct {
+	AllowedRoleIDs    []string               `json:"allowed_role_ids,omitempty"`    // Discord roles that may request announcements
+	AllowedChannelIDs []string               `json:"allowed_channel_ids,omitempty"` // Channels announcements may be posted in
+	ApprovalChannelID string                 `json:"approval_channel_id,omitempty"` // Where requests await approval
+	Templates         []AnnouncementTemplate `json:"templates,omitempty"`
+}
+
+// AnnouncementTemplate is a reusable announcement. Title and Content may use the
+// placeholders {message}, {server.name}, {server.game}, {server.status},
+// {server.players} and {server.address}.
+type AnnouncementTemplate struct {
+	Name       string `json:"name"`
+	Title      string `json:"title,omitempty"`
+	Content    string `json:"content,omitempty"`
+	ServerCard bool   `json:"server_card,omitempty"` // Render the server's status card below the content
+}
+
+// NeedsServer reports whether the template refers to a game server
+func (at AnnouncementTemplate) NeedsServer() bool {
+	return at.ServerCard || strings.Contains(at.Title, "{server.") || strings.Contains(at.Content, "{server.")
+}
+
+// builtinAnnouncementTemplates are available to every tenant unless overridden by name
+var builtinAnnouncementTemplates = []AnnouncementTemplate{
+	{Name: "server-status", ServerCard: true},
+	{Name: "server-ip", Title: "Join {server.name}", Content: "Connect to **{server.address}**\n{message}"},
+}
+
+// ChannelAllowed reports whether announcements may be posted in a channel
+func (ap AnnouncementPolicy) ChannelAllowed(channelID string) bool {
+	for _, allowed := range ap.AllowedChannelIDs {
+		if allowed == channelID {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleAllowed reports whether any of a member's Discord roles may request announcements
+func (ap AnnouncementPolicy) RoleAllowed(memberRoleIDs []string) bool {
+	for _, roleID := range memberRoleIDs {
+		for _, allowed := range ap.AllowedRoleIDs {
+			if roleID == allowed {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// AvailableTemplates returns the tenant's templates followed by any built-in
+// templates they do not override
+func (ap AnnouncementPolicy) AvailableTemplates() []AnnouncementTemplate {
+	templates := append([]AnnouncementTemplate{}, ap.Templates...)
+	for _, builtin := range builtinAnnouncementTemplates {
+		if _, ok := ap.findTenantTemplate(builtin.Name); !ok {
+			templates = append(templates, builtin)
+		}
+	}
+	return templates
+}
+
+// FindTemplate looks up a template by name (case-insensitive)
+func (ap AnnouncementPolicy) FindTemplate(name string) (AnnouncementTemplate, bool) {
+	for _, template := range ap.AvailableTemplates() {
+		if strings.EqualFold(template.Name, name) {
+			return template, true
+		}
+	}
+	return AnnouncementTemplate{}, false
+}
+
+func (ap AnnouncementPolicy) findTenantTemplate(name string) (AnnouncementTemplate, bool) {
+	for _, template := range ap.Templates {
+		if strings.EqualFold(template.Name, name) {
+			return template, true
+		}
+	}
+	return AnnouncementTemplate{}, false
+}
+
+// Validate checks the announcement policy
+func (ap AnnouncementPolicy) Validate() error {
+	if len(ap.Templates) > maxAnnouncementTemplates {
+		return fmt.Errorf("at most %d announcement templates are allowed", maxAnnouncementTemplates)
+	}
+
+	names := make(map[string]bool)
+	for _, template := range ap.Templates {
+		name := strings.ToLower(strings.TrimSpace(template.Name))
+		if name == "" {
+			return fmt.Errorf("announcement template name is required")
+		}
+		if names[name] {
+			return fmt.Errorf("duplicate announcement template: %s", template.Name)
+		}
+		names[name] = true
+		if template.Title == "" && template.Content == "" && !template.ServerCard {
+			return fmt.Errorf("announcement template %s is empty", template.Name)
+		}
+	}
+	return nil
+}
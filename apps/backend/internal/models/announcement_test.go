@@ -0,0 +1,42 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnnouncementPolicy(t *testing.T) {
+	policy := AnnouncementPolicy{
+		AllowedRoleIDs:    []string{"role-1"},
+		AllowedChannelIDs: []string{"chan-1"},
+		Templates: []AnnouncementTemplate{
+			{Name: "Server-Status", Title: "Custom status for {server.name}"},
+		},
+	}
+
+	assert.True(t, policy.ChannelAllowed("chan-1"))
+	assert.False(t, policy.ChannelAllowed("chan-2"))
+	assert.True(t, policy.RoleAllowed([]string{"role-2", "role-1"}))
+	assert.False(t, policy.RoleAllowed(nil))
+
+	// Tenant templates override built-ins of the same name
+	template, ok := policy.FindTemplate("server-status")
+	assert.True(t, ok)
+	assert.Equal(t, "Custom status for {server.name}", template.Title)
+	assert.True(t, template.NeedsServer())
+	assert.Len(t, policy.AvailableTemplates(), 2)
+
+	_, ok = policy.FindTemplate("server-ip")
+	assert.True(t, ok)
+}
+
+func TestAnnouncementPolicy_Validate(t *testing.T) {
+	assert.NoError(t, AnnouncementPolicy{}.Validate())
+	assert.Error(t, AnnouncementPolicy{Templates: []AnnouncementTemplate{{Content: "hi"}}}.Validate())
+	assert.Error(t, AnnouncementPolicy{Templates: []AnnouncementTemplate{{Name: "empty"}}}.Validate())
+	assert.Error(t, AnnouncementPolicy{Templates: []AnnouncementTemplate{
+		{Name: "news", Content: "a"},
+		{Name: "NEWS", Content: "b"},
+	}}.Validate())
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APITokenPrefix marks personal access and service account tokens so they can be
+// told apart from JWTs in the Authorization header
+const APITokenPrefix = "pnb_"
+
+// ServiceAccount is a tenant-owned, non-human principal for automation. Each
+// service account is backed by a user row so that tenant membership and RBAC
+// checks treat it like any other member.
+type ServiceAccount struct {
+	ID          string         `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	TenantID    string         `json:"tenant_id" gorm:"not null;index"`
+	UserID      string         `json:"user_id" gorm:"not null;uniqueIndex"`
+	Name        string         `json:"name" gorm:"not null"`
+	Description string         `json:"description"`
+	Permissions StringArray    `json:"permissions" gorm:"type:text[]"`
+	CreatedBy   string         `json:"created_by"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// APIToken is a long-lived bearer token owned by a user or a service account.
+// Only a hash of the token is stored.
+type APIToken struct {
+	ID               string      `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	Name             string      `json:"name" gorm:"not null"`
+	UserID           string      `json:"user_id" gorm:"not null;index"`
+	ServiceAccountID *string     `json:"service_account_id,omitempty" gorm:"index"`
+	TenantID         *string     `json:"tenant_id,omitempty" gorm:"index"` // Nil tokens work in every tenant the owner can access
+	Prefix           string      `json:"prefix" gorm:"not null"`
+	TokenHash        string      `json:"-" gorm:"not null;uniqueIndex"`
+	Scopes           StringArray `json:"scopes" gorm:"type:text[]"`
+	ExpiresAt        *time.Time  `json:"expires_at,omitempty"`
+	LastUsedAt       *time.Time  `json:"last_used_at,omitempty"`
+	RevokedAt        *time.Time  `json:"revoked_at,omitempty"`
+	CreatedBy        string      `json:"created_by"`
+	CreatedAt        time.Time   `json:"created_at"`
+	UpdatedAt        time.Time   `json:"updated_at"`
+}
+
+// IsActive reports whether the token is neither revoked nor expired
+func (t *APIToken) IsActive(now time.Time) bool {
+	if t.RevokedAt != nil {
+		return false
+	}
+	return t.ExpiresAt == nil || now.Before(*t.ExpiresAt)
+}
+
+// AllowsTenant reports whether the token may be used in a tenant
+func (t *APIToken) AllowsTenant(tenantID string) bool {
+	return t.TenantID == nil || *t.TenantID == tenantID
+}
+
+// AllowsPermission reports whether the token's scopes cover a permission. The
+// owner must still hold the permission for the request to succeed.
+func (t *APIToken) AllowsPermission(permission string) bool {
+	return GrantsPermission(t.Scopes, permission)
+}
+
+// CreateAPITokenRequest represents a request to create an API token
+type CreateAPITokenRequest struct {
+	Name      string     `json:"name" binding:"required"`
+	Scopes    []string   `json:"scopes"`
+	TenantID  *string    `json:"tenant_id"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// CreateAPITokenResponse returns a new token. The secret is only shown once.
+type CreateAPITokenResponse struct {
+	Token    string   `json:"token"`
+	APIToken APIToken `json:"api_token"`
+}
+
+// CreateServiceAccountRequest represents a request to create a service account
+type CreateServiceAccountRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions" binding:"required"`
+}
+
+// TableName returns the table name for ServiceAccount
+func (ServiceAccount) TableName() string {
+	return "service_accounts"
+}
+
+// TableName returns the table name for APIToken
+func (APIToken) TableName() string {
+	return "api_tokens"
+}
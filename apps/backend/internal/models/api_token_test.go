@@ -0,0 +1,36 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIToken_IsActive(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+
+	assert.True(t, (&APIToken{}).IsActive(now))
+	assert.True(t, (&APIToken{ExpiresAt: &future}).IsActive(now))
+	assert.False(t, (&APIToken{ExpiresAt: &past}).IsActive(now))
+	assert.False(t, (&APIToken{RevokedAt: &past}).IsActive(now))
+}
+
+func TestAPIToken_Scopes(t *testing.T) {
+	tenantID := "tenant-1"
+	token := &APIToken{
+		TenantID: &tenantID,
+		Scopes:   StringArray{PermissionServerRead, PermissionServerRestart},
+	}
+
+	assert.True(t, token.AllowsTenant("tenant-1"))
+	assert.False(t, token.AllowsTenant("tenant-2"))
+	assert.True(t, token.AllowsPermission(PermissionServerRestart))
+	assert.False(t, token.AllowsPermission(PermissionServerDelete))
+
+	unrestricted := &APIToken{Scopes: StringArray{PermissionAdminAll}}
+	assert.True(t, unrestricted.AllowsTenant("tenant-2"))
+	assert.True(t, unrestricted.AllowsPermission(PermissionServerDelete))
+}
@@ -9,37 +9,55 @@ import (
 
 // User represents a Discord user
 type User struct {
-	ID            string         `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
-	DiscordUserID string         `json:"discord_user_id" gorm:"uniqueIndex;not null"`
-	Username      string         `json:"username" gorm:"not null"`
-	Avatar        string         `json:"avatar"`
-	Email         string         `json:"email"`
-	CreatedAt     time.Time      `json:"created_at"`
-	UpdatedAt     time.Time      `json:"updated_at"`
-	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
+	ID               string         `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	DiscordUserID    string         `json:"discord_user_id" gorm:"uniqueIndex;default:null"` // Empty for users who only log in through OIDC
+	Username         string         `json:"username" gorm:"not null"`
+	Avatar           string         `json:"avatar"`
+	Email            string         `json:"email"`
+	IsServiceAccount bool           `json:"is_service_account" gorm:"default:false"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
 
 	// Relationships
-	Tenants  []UserTenant `json:"tenants,omitempty" gorm:"foreignKey:UserID"`
-	Sessions []Session    `json:"sessions,omitempty" gorm:"foreignKey:UserID"`
+	Tenants    []UserTenant   `json:"tenants,omitempty" gorm:"foreignKey:UserID"`
+	Sessions   []Session      `json:"sessions,omitempty" gorm:"foreignKey:UserID"`
+	Identities []UserIdentity `json:"identities,omitempty" gorm:"foreignKey:UserID"`
 }
 
 // Session represents a user session
 type Session struct {
-	ID                  string         `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
-	UserID              string         `json:"user_id" gorm:"not null;index"`
-	AccessToken         string         `json:"access_token" gorm:"not null"`
-	RefreshToken        string         `json:"refresh_token" gorm:"not null;uniqueIndex"`
-	DiscordAccessToken  string         `json:"discord_access_token" gorm:"not null"`
-	DiscordRefreshToken string         `json:"discord_refresh_token" gorm:"not null"`
-	ExpiresAt           time.Time      `json:"expires_at" gorm:"not null"`
-	CreatedAt           time.Time      `json:"created_at"`
-	UpdatedAt           time.Time      `json:"updated_at"`
-	DeletedAt           gorm.DeletedAt `json:"-" gorm:"index"`
+	ID                    string         `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	UserID                string         `json:"user_id" gorm:"not null;index"`
+	AccessToken           string         `json:"access_token" gorm:"not null"`
+	RefreshToken          string         `json:"refresh_token" gorm:"not null;uniqueIndex"`
+	DiscordAccessToken    string         `json:"discord_access_token" gorm:"not null"`
+	DiscordRefreshToken   string         `json:"discord_refresh_token" gorm:"not null"`
+	DiscordTokenExpiresAt time.Time      `json:"discord_token_expires_at"` // Zero when the expiry is unknown
+	UserAgent             string         `json:"user_agent"`
+	IPAddress             string         `json:"ip_address"`
+	ExpiresAt             time.Time      `json:"expires_at" gorm:"not null"`
+	LastSeenAt            time.Time      `json:"last_seen_at"`
+	StepUpUntil           time.Time      `json:"step_up_until"` // Destructive operations are allowed until then
+	CreatedAt             time.Time      `json:"created_at"`
+	UpdatedAt             time.Time      `json:"updated_at"`
+	DeletedAt             gorm.DeletedAt `json:"-" gorm:"index"`
 
 	// Relationships
 	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
 }
 
+// SessionInfo describes a session to its owner without exposing its tokens
+type SessionInfo struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
 // JWTClaims represents JWT token claims
 type JWTClaims struct {
 	UserID        string   `json:"user_id"`
@@ -50,6 +68,23 @@ type JWTClaims struct {
 	jwt.RegisteredClaims
 }
 
+// JSONWebKey is the public part of a token signing key as published in the JWKS
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`   // RSA modulus
+	E         string `json:"e,omitempty"`   // RSA exponent
+	Curve     string `json:"crv,omitempty"` // OKP curve
+	X         string `json:"x,omitempty"`   // OKP public key
+}
+
+// JSONWebKeySet lists the keys that tokens may be verified with
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
 // DiscordUser represents Discord user data from API
 type DiscordUser struct {
 	ID            string `json:"id"`
@@ -82,6 +117,15 @@ type DiscordRole struct {
 	Mentionable bool   `json:"mentionable"`
 }
 
+// DiscordChannel represents a Discord guild channel from API
+type DiscordChannel struct {
+	ID       string `json:"id"`
+	GuildID  string `json:"guild_id"`
+	Name     string `json:"name"`
+	Type     int    `json:"type"`
+	ParentID string `json:"parent_id"`
+}
+
 // DiscordMember represents a Discord guild member from API
 type DiscordMember struct {
 	User         *DiscordUser `json:"user"`
@@ -107,10 +151,11 @@ type DiscordTokenResponse struct {
 
 // AuthResponse represents authentication response
 type AuthResponse struct {
-	AccessToken  string `json:"access_token"`
-	RefreshToken string `json:"refresh_token"`
-	ExpiresIn    int64  `json:"expires_in"`
-	User         User   `json:"user"`
+	AccessToken        string `json:"access_token"`
+	RefreshToken       string `json:"refresh_token"`
+	ExpiresIn          int64  `json:"expires_in"`
+	User               User   `json:"user"`
+	TwoFactorChallenge string `json:"two_factor_challenge,omitempty"` // Set instead of tokens when a second factor is required
 }
 
 // RefreshTokenRequest represents refresh token request
@@ -118,6 +163,14 @@ type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
+// OAuthState is kept between starting an OAuth login and its callback
+type OAuthState struct {
+	State      string              `json:"state"`
+	RedirectTo string              `json:"redirect_to,omitempty"` // Frontend path to return to after login
+	Login      *IdentityLoginState `json:"login,omitempty"`       // Set for provider logins and identity linking
+	ExpiresAt  time.Time           `json:"expires_at"`
+}
+
 // APIError represents API error response
 type APIError struct {
 	Code    string                 `json:"code"`
@@ -84,4 +84,6 @@ type AdminStats struct {
 	TotalUsers        int64 `json:"total_users"`
 	TotalGameServers  int64 `json:"total_game_servers"`
 	ActiveControllers int64 `json:"active_controllers"`
+
+	PermissionCache PermissionCacheStats `json:"permission_cache"`
 }
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// IdentityProviderDiscord is the provider name of the built-in Discord login
+const IdentityProviderDiscord = "discord"
+
+// UserIdentity links a user to an account at an identity provider. A user can
+// link several identities and log in with any of them.
+type UserIdentity struct {
+	ID        string    `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	UserID    string    `json:"user_id" gorm:"not null;index"`
+	Provider  string    `json:"provider" gorm:"not null;uniqueIndex:idx_user_identities_provider_subject"`
+	Subject   string    `json:"subject" gorm:"not null;uniqueIndex:idx_user_identities_provider_subject"`
+	Email     string    `json:"email"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ExternalIdentity is the verified identity returned by a provider after login
+type ExternalIdentity struct {
+	Provider      string
+	Subject       string
+	Username      string
+	Email         string
+	EmailVerified bool
+	Avatar        string
+	Claims        map[string]interface{}
+}
+
+// IdentityProviderInfo describes a login provider to the frontend
+type IdentityProviderInfo struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	Type        string `json:"type"` // "discord" or "oidc"
+}
+
+// IdentityLoginState is kept between starting a provider login and its callback
+type IdentityLoginState struct {
+	Provider        string    `json:"provider"`
+	State           string    `json:"state"`
+	Nonce           string    `json:"nonce"`
+	CodeVerifier    string    `json:"code_verifier"`
+	LinkUserID      string    `json:"link_user_id,omitempty"`       // Set when linking to an already logged-in user
+	StepUpSessionID string    `json:"step_up_session_id,omitempty"` // Set when re-authenticating an existing session
+	AuthURL         string    `json:"-"`
+	ExpiresAt       time.Time `json:"expires_at"`
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MembershipPolicy controls how users join a tenant and what new members get
+type MembershipPolicy struct {
+	DisableGuildJoin   bool     `json:"disable_guild_join,omitempty"`  // Require an invite even for members of the Discord server
+	DefaultRoleIDs     []string `json:"default_role_ids,omitempty"`    // Internal roles given to every new member
+	DefaultPermissions []string `json:"default_permissions,omitempty"` // Direct permissions for new members; empty means GetDefaultPermissions
+}
+
+// Validate checks the membership policy's default permissions
+func (mp MembershipPolicy) Validate() error {
+	for _, permission := range mp.DefaultPermissions {
+		if !IsValidTenantPermission(permission) {
+			return fmt.Errorf("unknown default permission %q", permission)
+		}
+	}
+	return nil
+}
+
+// NewMemberPermissions returns the direct permissions given to new members
+func (mp MembershipPolicy) NewMemberPermissions() []string {
+	if len(mp.DefaultPermissions) == 0 {
+		return GetDefaultPermissions()
+	}
+	return mp.DefaultPermissions
+}
+
+// Invite statuses
+const (
+	InviteStatusPending  = "pending"
+	InviteStatusAccepted = "accepted"
+	InviteStatusDeclined = "declined"
+	InviteStatusRevoked  = "revoked"
+)
+
+// TenantInvite invites a Discord user to a tenant, or anyone holding its code
+// when DiscordUserID is empty. Link invites stay pending and can be used until
+// they expire or are revoked. Only a hash of a link invite's code is stored.
+type TenantInvite struct {
+	ID            string         `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	TenantID      string         `json:"tenant_id" gorm:"not null;index"`
+	DiscordUserID string         `json:"discord_user_id,omitempty" gorm:"index"`
+	CodeHash      *string        `json:"-" gorm:"uniqueIndex"`
+	RoleIDs       StringArray    `json:"role_ids" gorm:"type:text[]"` // Internal roles given on acceptance
+	Status        string         `json:"status" gorm:"not null;default:'pending'"`
+	Uses          int            `json:"uses" gorm:"not null;default:0"`
+	ExpiresAt     time.Time      `json:"expires_at" gorm:"not null"`
+	CreatedBy     string         `json:"created_by"`
+	RespondedAt   *time.Time     `json:"responded_at,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	Tenant *Tenant `json:"tenant,omitempty" gorm:"foreignKey:TenantID"`
+}
+
+// IsLink reports whether anyone holding the invite's code may use it
+func (i *TenantInvite) IsLink() bool {
+	return i.DiscordUserID == ""
+}
+
+// IsOpen reports whether the invite can still be accepted
+func (i *TenantInvite) IsOpen(now time.Time) bool {
+	return i.Status == InviteStatusPending && now.Before(i.ExpiresAt)
+}
+
+// CreateInviteRequest represents a request to invite someone to a tenant. An
+// empty DiscordUserID creates an invite link.
+type CreateInviteRequest struct {
+	DiscordUserID string     `json:"discord_user_id"`
+	RoleIDs       []string   `json:"role_ids"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+}
+
+// CreateInviteResponse returns a new invite. A link invite's code is only shown once.
+type CreateInviteResponse struct {
+	Code   string       `json:"code,omitempty"`
+	Invite TenantInvite `json:"invite"`
+}
+
+// JoinTenantRequest represents a request to join a tenant with an invite code
+type JoinTenantRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// MemberRole is a role a tenant member holds, directly or through Discord
+type MemberRole struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Source string `json:"source"`        // GrantSourceRole, GrantSourceDiscordRole or GrantSourceDiscordRoleMapping
+	Via    string `json:"via,omitempty"` // Discord role ID for GrantSourceDiscordRoleMapping
+}
+
+// TenantMember is a tenant member with the roles and permissions in effect
+type TenantMember struct {
+	UserID           string       `json:"user_id"`
+	Username         string       `json:"username"`
+	Avatar           string       `json:"avatar"`
+	DiscordUserID    string       `json:"discord_user_id"`
+	IsOwner          bool         `json:"is_owner"`
+	IsServiceAccount bool         `json:"is_service_account"`
+	Roles            []MemberRole `json:"roles"`
+	Permissions      []string     `json:"permissions"`
+	JoinedAt         time.Time    `json:"joined_at"`
+}
+
+// TableName returns the table name for TenantInvite
+func (TenantInvite) TableName() string {
+	return "tenant_invites"
+}
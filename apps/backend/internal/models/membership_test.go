@@ -0,0 +1,29 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMembershipPolicy(t *testing.T) {
+	assert.Equal(t, GetDefaultPermissions(), MembershipPolicy{}.NewMemberPermissions())
+	assert.Equal(t, []string{PermissionFileRead}, MembershipPolicy{DefaultPermissions: []string{PermissionFileRead}}.NewMemberPermissions())
+
+	assert.NoError(t, MembershipPolicy{DefaultPermissions: []string{PermissionServerRead}}.Validate())
+	assert.Error(t, MembershipPolicy{DefaultPermissions: []string{"server:explode"}}.Validate())
+	assert.Error(t, TenantConfig{Membership: MembershipPolicy{DefaultPermissions: []string{"server:explode"}}}.Validate())
+}
+
+func TestTenantInvite_IsOpen(t *testing.T) {
+	now := time.Now()
+	invite := TenantInvite{Status: InviteStatusPending, ExpiresAt: now.Add(time.Hour)}
+
+	assert.True(t, invite.IsLink())
+	assert.True(t, invite.IsOpen(now))
+	assert.False(t, invite.IsOpen(now.Add(2*time.Hour)))
+
+	invite.Status = InviteStatusRevoked
+	assert.False(t, invite.IsOpen(now))
+}
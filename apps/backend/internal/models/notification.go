@@ -0,0 +1,109 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Notification event types
+const (
+	NotificationServerCrashed     = "server.crashed"
+	NotificationServerStarted     = "server.started"
+	NotificationServerStopped     = "server.stopped"
+	NotificationBackupCompleted   = "backup.completed"
+	NotificationResourceLimit     = "server.resource_limit"
+	NotificationControllerOffline = "controller.offline"
+	NotificationTransferRequested = "tenant.transfer_requested"
+	NotificationOwnerChanged      = "tenant.owner_changed"
+)
+
+// notificationEvents lists every event a notification channel can subscribe to
+var notificationEvents = map[string]bool{
+	NotificationServerCrashed:     true,
+	NotificationServerStarted:     true,
+	NotificationServerStopped:     true,
+	NotificationBackupCompleted:   true,
+	NotificationResourceLimit:     true,
+	NotificationControllerOffline: true,
+	NotificationTransferRequested: true,
+	NotificationOwnerChanged:      true,
+}
+
+// IsValidNotificationEvent reports whether an event type is known
+func IsValidNotificationEvent(eventType string) bool {
+	return notificationEvents[eventType]
+}
+
+// NotificationChannelConfig configures the events posted to a Discord channel
+type NotificationChannelConfig struct {
+	ChannelID     string   `json:"channel_id"`
+	Events        []string `json:"events,omitempty"`         // Empty means every event
+	MaxPerMinute  int      `json:"max_per_minute,omitempty"` // 0 means unlimited; excess events are sent as a digest
+	DigestMinutes int      `json:"digest_minutes,omitempty"` // 0 sends immediately, otherwise events are batched
+}
+
+// Wants reports whether the channel is subscribed to an event type
+func (nc NotificationChannelConfig) Wants(eventType string) bool {
+	if len(nc.Events) == 0 {
+		return true
+	}
+	for _, event := range nc.Events {
+		if event == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the channel configuration
+func (nc NotificationChannelConfig) Validate() error {
+	if nc.ChannelID == "" {
+		return fmt.Errorf("notification channel_id is required")
+	}
+	for _, event := range nc.Events {
+		if !IsValidNotificationEvent(event) {
+			return fmt.Errorf("unknown notification event: %s", event)
+		}
+	}
+	if nc.MaxPerMinute < 0 || nc.DigestMinutes < 0 {
+		return fmt.Errorf("notification limits cannot be negative")
+	}
+	return nil
+}
+
+// NotificationTargets returns the channels a tenant notifies. Legacy channel IDs
+// in NotificationChannels receive every event without limits.
+func (tc TenantConfig) NotificationTargets() []NotificationChannelConfig {
+	targets := make([]NotificationChannelConfig, 0, len(tc.Notifications)+len(tc.NotificationChannels))
+	configured := make(map[string]bool)
+	for _, channel := range tc.Notifications {
+		targets = append(targets, channel)
+		configured[channel.ChannelID] = true
+	}
+	for _, channelID := range tc.NotificationChannels {
+		if !configured[channelID] {
+			targets = append(targets, NotificationChannelConfig{ChannelID: channelID})
+		}
+	}
+	return targets
+}
+
+// NotificationEvent is something that happened to a tenant's servers or cluster
+type NotificationEvent struct {
+	Type       string            `json:"type"`
+	TenantID   string            `json:"tenant_id"`
+	ServerID   string            `json:"server_id,omitempty"`
+	ServerName string            `json:"server_name,omitempty"`
+	ClusterID  string            `json:"cluster_id,omitempty"`
+	Message    string            `json:"message,omitempty"`
+	Details    map[string]string `json:"details,omitempty"`
+	OccurredAt time.Time         `json:"occurred_at"`
+}
+
+// ServerEventRequest is sent by a controller to report a game server event
+type ServerEventRequest struct {
+	Type     string            `json:"type" binding:"required"`
+	ServerID string            `json:"server_id" binding:"required"`
+	Message  string            `json:"message,omitempty"`
+	Details  map[string]string `json:"details,omitempty"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotificationChannelConfig_Validate(t *testing.T) {
+	assert.NoError(t, NotificationChannelConfig{ChannelID: "123", Events: []string{NotificationServerCrashed}}.Validate())
+	assert.Error(t, NotificationChannelConfig{Events: []string{NotificationServerCrashed}}.Validate())
+	assert.Error(t, NotificationChannelConfig{ChannelID: "123", Events: []string{"server.exploded"}}.Validate())
+	assert.Error(t, NotificationChannelConfig{ChannelID: "123", MaxPerMinute: -1}.Validate())
+}
+
+func TestTenantConfig_NotificationTargets(t *testing.T) {
+	config := TenantConfig{
+		NotificationChannels: []string{"legacy", "configured"},
+		Notifications: []NotificationChannelConfig{
+			{ChannelID: "configured", Events: []string{NotificationBackupCompleted}},
+		},
+	}
+
+	targets := config.NotificationTargets()
+	assert.Len(t, targets, 2)
+	assert.Equal(t, "configured", targets[0].ChannelID)
+	assert.False(t, targets[0].Wants(NotificationServerCrashed))
+	assert.Equal(t, "legacy", targets[1].ChannelID)
+	assert.True(t, targets[1].Wants(NotificationServerCrashed))
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Ownership transfer statuses
+const (
+	TransferStatusPending   = "pending"
+	TransferStatusAccepted  = "accepted"
+	TransferStatusDeclined  = "declined"
+	TransferStatusCancelled = "cancelled"
+)
+
+// OwnerRole is the role held by the member who owns a tenant
+const OwnerRole = "owner"
+
+// OwnershipTransfer offers a tenant's ownership to one of its members. The
+// owner starts it and it only takes effect once the member accepts.
+type OwnershipTransfer struct {
+	ID          string         `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	TenantID    string         `json:"tenant_id" gorm:"not null;index"`
+	FromUserID  string         `json:"from_user_id" gorm:"not null"`
+	ToUserID    string         `json:"to_user_id" gorm:"not null;index"`
+	Status      string         `json:"status" gorm:"not null;default:'pending'"`
+	ExpiresAt   time.Time      `json:"expires_at" gorm:"not null"`
+	RespondedAt *time.Time     `json:"responded_at,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	Tenant *Tenant `json:"tenant,omitempty" gorm:"foreignKey:TenantID"`
+}
+
+// IsOpen reports whether the transfer can still be accepted
+func (t *OwnershipTransfer) IsOpen(now time.Time) bool {
+	return t.Status == TransferStatusPending && now.Before(t.ExpiresAt)
+}
+
+// TransferOwnershipRequest names the member who should own a tenant. Reason is
+// recorded in the audit log when a superadmin overrides the owner.
+type TransferOwnershipRequest struct {
+	UserID string `json:"user_id" binding:"required"`
+	Reason string `json:"reason"`
+}
+
+// TableName returns the table name for OwnershipTransfer
+func (OwnershipTransfer) TableName() string {
+	return "tenant_ownership_transfers"
+}
@@ -1,5 +1,7 @@
 package models
 
+import "strings"
+
 // Permission constants for different resources and actions
 const (
 	// Server permissions
@@ -47,6 +49,12 @@ const (
 	PermissionRoleWrite  = "role:write"
 	PermissionRoleDelete = "role:delete"
 
+	// Announcement permissions
+	PermissionAnnouncementPublish = "announcement:publish"
+
+	// Tenant permissions
+	PermissionTenantManage = "tenant:manage"
+
 	// System permissions (system-wide, not tenant-scoped)
 	PermissionSystemAdmin = "system:admin"
 
@@ -55,6 +63,138 @@ const (
 	PermissionSuperAdmin = "superadmin"
 )
 
+// permissionCatalog describes every permission, grouped by resource in the
+// order role editors list them
+var permissionCatalog = []Permission{
+	catalogPermission(PermissionServerCreate, ScopeTenant, "Create game servers"),
+	catalogPermission(PermissionServerRead, ScopeTenant, "View game servers and their status"),
+	catalogPermission(PermissionServerWrite, ScopeTenant, "Change game server settings"),
+	catalogPermission(PermissionServerDelete, ScopeTenant, "Delete game servers"),
+	catalogPermission(PermissionServerStart, ScopeTenant, "Start game servers"),
+	catalogPermission(PermissionServerStop, ScopeTenant, "Stop game servers"),
+	catalogPermission(PermissionServerRestart, ScopeTenant, "Restart game servers"),
+	catalogPermission(PermissionConsoleRead, ScopeTenant, "View server consoles"),
+	catalogPermission(PermissionConsoleExecute, ScopeTenant, "Run commands in server consoles"),
+	catalogPermission(PermissionFileRead, ScopeTenant, "Browse and download server files"),
+	catalogPermission(PermissionFileWrite, ScopeTenant, "Upload and edit server files"),
+	catalogPermission(PermissionFileDelete, ScopeTenant, "Delete server files"),
+	catalogPermission(PermissionBackupCreate, ScopeTenant, "Create backups"),
+	catalogPermission(PermissionBackupRead, ScopeTenant, "View and download backups"),
+	catalogPermission(PermissionBackupDelete, ScopeTenant, "Delete backups"),
+	catalogPermission(PermissionBackupRestore, ScopeTenant, "Restore servers from backups"),
+	catalogPermission(PermissionLogRead, ScopeTenant, "View server logs"),
+	catalogPermission(PermissionTemplateCreate, ScopeTenant, "Create game templates"),
+	catalogPermission(PermissionTemplateRead, ScopeTenant, "View game templates"),
+	catalogPermission(PermissionTemplateWrite, ScopeTenant, "Change game templates"),
+	catalogPermission(PermissionTemplateDelete, ScopeTenant, "Delete game templates"),
+	catalogPermission(PermissionUserCreate, ScopeTenant, "Invite members and create service accounts"),
+	catalogPermission(PermissionUserRead, ScopeTenant, "View members"),
+	catalogPermission(PermissionUserWrite, ScopeTenant, "Change members' roles and invites"),
+	catalogPermission(PermissionUserDelete, ScopeTenant, "Remove members and service accounts"),
+	catalogPermission(PermissionRoleCreate, ScopeTenant, "Create roles"),
+	catalogPermission(PermissionRoleRead, ScopeTenant, "View roles, grants and Discord role mappings"),
+	catalogPermission(PermissionRoleWrite, ScopeTenant, "Change roles, grants and Discord role mappings"),
+	catalogPermission(PermissionRoleDelete, ScopeTenant, "Delete roles"),
+	catalogPermission(PermissionAnnouncementPublish, ScopeTenant, "Publish announcements to Discord"),
+	catalogPermission(PermissionTenantManage, ScopeTenant, "Manage tenant settings"),
+	catalogPermission(PermissionAdminAll, ScopeTenant, "Every permission in the tenant"),
+	catalogPermission(PermissionSystemAdmin, ScopeGlobal, "Administer the platform"),
+	catalogPermission(PermissionSuperAdmin, ScopeGlobal, "Full access to every tenant"),
+}
+
+// catalogPermission describes a permission, splitting its name into resource and action
+func catalogPermission(name string, scope PermissionScope, description string) Permission {
+	def := ParsePermission(name)
+	return Permission{
+		Name:        name,
+		Description: description,
+		Resource:    def.Resource,
+		Action:      def.Action,
+		Scope:       scope,
+	}
+}
+
+// tenantPermissions lists every permission that can be granted within a tenant
+var tenantPermissions = func() map[string]bool {
+	permissions := make(map[string]bool)
+	for _, perm := range permissionCatalog {
+		if perm.Scope == ScopeTenant {
+			permissions[perm.Name] = true
+		}
+	}
+	return permissions
+}()
+
+// PermissionCatalog returns every known permission in catalog order, with
+// Position set to that order
+func PermissionCatalog() []Permission {
+	catalog := make([]Permission, len(permissionCatalog))
+	for i, perm := range permissionCatalog {
+		perm.Position = i
+		catalog[i] = perm
+	}
+	return catalog
+}
+
+// PermissionGroup is the permissions that act on one resource
+type PermissionGroup struct {
+	Resource    string       `json:"resource"`
+	Permissions []Permission `json:"permissions"`
+}
+
+// GroupPermissions groups permissions by resource, keeping the order in which
+// each resource first appears
+func GroupPermissions(permissions []Permission) []PermissionGroup {
+	groups := []PermissionGroup{}
+	index := make(map[string]int)
+	for _, perm := range permissions {
+		i, ok := index[perm.Resource]
+		if !ok {
+			i = len(groups)
+			index[perm.Resource] = i
+			groups = append(groups, PermissionGroup{Resource: perm.Resource})
+		}
+		groups[i].Permissions = append(groups[i].Permissions, perm)
+	}
+	return groups
+}
+
+// IsKnownPermission reports whether a permission is in the catalog, at any
+// scope, or is a tenant wildcard
+func IsKnownPermission(permission string) bool {
+	for _, perm := range permissionCatalog {
+		if perm.Name == permission {
+			return true
+		}
+	}
+	return IsValidTenantPermission(permission)
+}
+
+// IsValidTenantPermission reports whether a permission can be granted within a
+// tenant. Besides the known permissions this accepts "resource:*" and
+// "*:action" wildcards for known resources and actions.
+func IsValidTenantPermission(permission string) bool {
+	if tenantPermissions[permission] {
+		return true
+	}
+
+	wanted := ParsePermission(permission)
+	if wanted.Resource == "*" && wanted.Action == "*" {
+		return false
+	}
+	for known := range tenantPermissions {
+		if known == PermissionAdminAll {
+			continue
+		}
+		def := ParsePermission(known)
+		if (wanted.Resource == "*" && wanted.Action == def.Action) ||
+			(wanted.Action == "*" && wanted.Resource == def.Resource) {
+			return true
+		}
+	}
+	return false
+}
+
 // PermissionScope defines the scope of a permission
 type PermissionScope string
 
@@ -85,17 +225,60 @@ func (p PermissionDefinition) String() string {
 	return p.Resource + ":" + p.Action
 }
 
-// Matches checks if this permission matches another permission
-func (p PermissionDefinition) Matches(other PermissionDefinition) bool {
-	// Check if either permission is a wildcard
-	if p.Resource == "*" || other.Resource == "*" {
+// Matches reports whether holding this permission grants the required one.
+// A "*" resource or action matches anything, and an action also grants the
+// actions it implies, so "server:write" grants "server:read".
+func (p PermissionDefinition) Matches(required PermissionDefinition) bool {
+	if p.Resource != "*" && p.Resource != required.Resource {
+		return false
+	}
+	if p.Action == required.Action {
+		return true
+	}
+	// A wildcard action does not cover permissions that have no action, such as "superadmin"
+	if p.Action == "*" {
+		return required.Action != ""
+	}
+	for _, implied := range impliedActions[p.Action] {
+		if implied == required.Action {
+			return true
+		}
+	}
+	return false
+}
+
+// impliedActions lists the actions granted along with an action on the same resource
+var impliedActions = map[string][]string{
+	"write":   {"read"},
+	"execute": {"read"},
+}
+
+// ParsePermission splits a "resource:action" string into a tenant-scoped
+// definition. "*" is parsed as "*:*".
+func ParsePermission(permission string) PermissionDefinition {
+	if permission == PermissionAdminAll {
+		return NewPermissionDefinition("*", "*", ScopeTenant)
+	}
+	resource, action, _ := strings.Cut(permission, ":")
+	return NewPermissionDefinition(resource, action, ScopeTenant)
+}
+
+// PermissionMatches reports whether a granted permission string covers the required one
+func PermissionMatches(granted, required string) bool {
+	if granted == required {
 		return true
 	}
-	if p.Action == "*" || other.Action == "*" {
-		return p.Resource == other.Resource
+	return ParsePermission(granted).Matches(ParsePermission(required))
+}
+
+// GrantsPermission reports whether any of the granted permissions covers the required one
+func GrantsPermission(granted []string, required string) bool {
+	for _, perm := range granted {
+		if PermissionMatches(perm, required) {
+			return true
+		}
 	}
-	
-	return p.Resource == other.Resource && p.Action == other.Action
+	return false
 }
 
 // HasPermission checks if a user has a specific permission
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPermissionMatches(t *testing.T) {
+	tests := []struct {
+		granted  string
+		required string
+		expected bool
+	}{
+		// Exact and global wildcard
+		{granted: PermissionServerRead, required: PermissionServerRead, expected: true},
+		{granted: PermissionServerRead, required: PermissionServerDelete, expected: false},
+		{granted: PermissionAdminAll, required: PermissionServerDelete, expected: true},
+		{granted: PermissionAdminAll, required: PermissionSystemAdmin, expected: true},
+
+		// Resource wildcard
+		{granted: "server:*", required: PermissionServerRestart, expected: true},
+		{granted: "server:*", required: PermissionServerRead, expected: true},
+		{granted: "server:*", required: PermissionFileRead, expected: false},
+
+		// Action wildcard
+		{granted: "*:read", required: PermissionServerRead, expected: true},
+		{granted: "*:read", required: PermissionLogRead, expected: true},
+		{granted: "*:read", required: PermissionServerWrite, expected: false},
+		{granted: "*:write", required: PermissionFileRead, expected: true},
+
+		// Implied actions stay within the resource
+		{granted: PermissionServerWrite, required: PermissionServerRead, expected: true},
+		{granted: PermissionServerWrite, required: PermissionFileRead, expected: false},
+		{granted: PermissionServerRead, required: PermissionServerWrite, expected: false},
+		{granted: PermissionConsoleExecute, required: PermissionConsoleRead, expected: true},
+		{granted: PermissionServerDelete, required: PermissionServerRead, expected: false},
+
+		// A narrower grant never satisfies a wildcard requirement
+		{granted: PermissionServerRead, required: "server:*", expected: false},
+		{granted: "server:*", required: "server:*", expected: true},
+
+		// Permissions without an action only match themselves
+		{granted: PermissionSuperAdmin, required: PermissionSuperAdmin, expected: true},
+		{granted: "*:read", required: PermissionSuperAdmin, expected: false},
+		{granted: "superadmin:*", required: PermissionSuperAdmin, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.granted+" grants "+tt.required, func(t *testing.T) {
+			assert.Equal(t, tt.expected, PermissionMatches(tt.granted, tt.required))
+		})
+	}
+}
+
+func TestGrantsPermission(t *testing.T) {
+	assert.True(t, GrantsPermission([]string{PermissionLogRead, "server:*"}, PermissionServerStop))
+	assert.False(t, GrantsPermission([]string{PermissionLogRead}, PermissionServerStop))
+	assert.False(t, GrantsPermission(nil, PermissionServerStop))
+}
+
+func TestIsValidTenantPermission(t *testing.T) {
+	tests := []struct {
+		permission string
+		expected   bool
+	}{
+		{permission: PermissionServerRead, expected: true},
+		{permission: PermissionAdminAll, expected: true},
+		{permission: "server:*", expected: true},
+		{permission: "*:read", expected: true},
+		{permission: "*:*", expected: false},
+		{permission: "spaceship:*", expected: false},
+		{permission: "*:launch", expected: false},
+		{permission: PermissionSystemAdmin, expected: false},
+		{permission: "", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.permission, func(t *testing.T) {
+			assert.Equal(t, tt.expected, IsValidTenantPermission(tt.permission))
+		})
+	}
+}
+
+func TestPermissionCatalog(t *testing.T) {
+	catalog := PermissionCatalog()
+	seen := make(map[string]bool)
+	for i, perm := range catalog {
+		assert.False(t, seen[perm.Name], "duplicate permission %s", perm.Name)
+		seen[perm.Name] = true
+		assert.Equal(t, i, perm.Position)
+		assert.NotEmpty(t, perm.Description, perm.Name)
+		assert.Equal(t, perm.Scope == ScopeTenant, IsValidTenantPermission(perm.Name), perm.Name)
+		assert.True(t, IsKnownPermission(perm.Name), perm.Name)
+	}
+	assert.True(t, seen[PermissionAnnouncementPublish])
+	assert.True(t, IsKnownPermission("backup:*"))
+	assert.False(t, IsKnownPermission("console:sudo"))
+
+	groups := GroupPermissions(catalog)
+	require.NotEmpty(t, groups)
+	assert.Equal(t, "server", groups[0].Resource)
+	assert.Len(t, groups[0].Permissions, 7)
+	for _, group := range groups {
+		for _, perm := range group.Permissions {
+			assert.Equal(t, group.Resource, perm.Resource)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PolicyVersion is the version of the tenant policy document format
+const PolicyVersion = 1
+
+// Principal types used by resource grants in a policy document. Internal roles
+// are named, Discord roles and users are identified by their Discord IDs.
+const (
+	PolicyPrincipalUser        = "user"
+	PolicyPrincipalRole        = "role"
+	PolicyPrincipalDiscordRole = "discord_role"
+)
+
+// Kinds of entry a policy import can change
+const (
+	PolicyKindRole          = "role"
+	PolicyKindDiscordRole   = "discord_role"
+	PolicyKindMember        = "member"
+	PolicyKindResourceGrant = "resource_grant"
+)
+
+// Actions a policy import takes on an entry
+const (
+	PolicyActionCreate = "create"
+	PolicyActionUpdate = "update"
+	PolicyActionDelete = "delete"
+)
+
+// TenantPolicy is a tenant's authorization setup as a portable document. It
+// refers to roles and servers by name and to Discord roles and users by their
+// Discord IDs, so it can be applied to another tenant.
+type TenantPolicy struct {
+	Version        int                   `json:"version" yaml:"version"`
+	Roles          []PolicyRole          `json:"roles" yaml:"roles"`
+	DiscordRoles   []PolicyDiscordRole   `json:"discord_roles" yaml:"discord_roles"`
+	Members        []PolicyMember        `json:"members" yaml:"members"`
+	ResourceGrants []PolicyResourceGrant `json:"resource_grants" yaml:"resource_grants"`
+}
+
+// PolicyRole is an internal role and its permissions
+type PolicyRole struct {
+	Name        string   `json:"name" yaml:"name"`
+	Permissions []string `json:"permissions" yaml:"permissions"`
+}
+
+// PolicyDiscordRole maps a Discord role to permissions and internal roles. On
+// import the role is matched by ID and then by name.
+type PolicyDiscordRole struct {
+	ID          string   `json:"id" yaml:"id"`
+	Name        string   `json:"name" yaml:"name"`
+	Permissions []string `json:"permissions" yaml:"permissions"`
+	Roles       []string `json:"roles" yaml:"roles"`
+}
+
+// PolicyMember holds the permissions and internal roles given directly to a
+// tenant member
+type PolicyMember struct {
+	DiscordUserID string   `json:"discord_user_id" yaml:"discord_user_id"`
+	Permissions   []string `json:"permissions" yaml:"permissions"`
+	Roles         []string `json:"roles" yaml:"roles"`
+}
+
+// PolicyResourceGrant gives a principal a permission on a single resource,
+// which is identified by name
+type PolicyResourceGrant struct {
+	ResourceType  string `json:"resource_type" yaml:"resource_type"`
+	Resource      string `json:"resource" yaml:"resource"`
+	PrincipalType string `json:"principal_type" yaml:"principal_type"`
+	Principal     string `json:"principal" yaml:"principal"`
+	Permission    string `json:"permission" yaml:"permission"`
+}
+
+// Validate checks that the document has a supported version, that every entry
+// is identified and that every permission is in the tenant permission catalog
+func (p *TenantPolicy) Validate() error {
+	if p.Version != PolicyVersion {
+		return fmt.Errorf("unsupported version %d", p.Version)
+	}
+
+	roles := make(map[string]bool)
+	for i, role := range p.Roles {
+		name := strings.TrimSpace(role.Name)
+		if name == "" {
+			return fmt.Errorf("roles[%d]: name is required", i)
+		}
+		if roles[name] {
+			return fmt.Errorf("roles[%d]: duplicate role %s", i, name)
+		}
+		roles[name] = true
+		if err := validatePolicyPermissions(fmt.Sprintf("roles[%d]", i), role.Permissions); err != nil {
+			return err
+		}
+	}
+
+	for i, discordRole := range p.DiscordRoles {
+		if discordRole.ID == "" && discordRole.Name == "" {
+			return fmt.Errorf("discord_roles[%d]: id or name is required", i)
+		}
+		if err := validatePolicyPermissions(fmt.Sprintf("discord_roles[%d]", i), discordRole.Permissions); err != nil {
+			return err
+		}
+		if err := validatePolicyRoles(fmt.Sprintf("discord_roles[%d]", i), discordRole.Roles, roles); err != nil {
+			return err
+		}
+	}
+
+	members := make(map[string]bool)
+	for i, member := range p.Members {
+		if member.DiscordUserID == "" {
+			return fmt.Errorf("members[%d]: discord_user_id is required", i)
+		}
+		if members[member.DiscordUserID] {
+			return fmt.Errorf("members[%d]: duplicate member %s", i, member.DiscordUserID)
+		}
+		members[member.DiscordUserID] = true
+		if err := validatePolicyPermissions(fmt.Sprintf("members[%d]", i), member.Permissions); err != nil {
+			return err
+		}
+		if err := validatePolicyRoles(fmt.Sprintf("members[%d]", i), member.Roles, roles); err != nil {
+			return err
+		}
+	}
+
+	for i, grant := range p.ResourceGrants {
+		if grant.ResourceType != ResourceTypeGameServer {
+			return fmt.Errorf("resource_grants[%d]: unknown resource type %s", i, grant.ResourceType)
+		}
+		if grant.Resource == "" || grant.Principal == "" {
+			return fmt.Errorf("resource_grants[%d]: resource and principal are required", i)
+		}
+		switch grant.PrincipalType {
+		case PolicyPrincipalUser, PolicyPrincipalDiscordRole:
+		case PolicyPrincipalRole:
+			if !roles[grant.Principal] {
+				return fmt.Errorf("resource_grants[%d]: unknown role %s", i, grant.Principal)
+			}
+		default:
+			return fmt.Errorf("resource_grants[%d]: unknown principal type %s", i, grant.PrincipalType)
+		}
+		if err := validatePolicyPermissions(fmt.Sprintf("resource_grants[%d]", i), []string{grant.Permission}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validatePolicyPermissions checks permissions against the tenant permission catalog
+func validatePolicyPermissions(path string, permissions []string) error {
+	for _, perm := range permissions {
+		if !IsValidTenantPermission(perm) {
+			return fmt.Errorf("%s: unknown permission %s", path, perm)
+		}
+	}
+	return nil
+}
+
+// validatePolicyRoles checks that every role is defined in the document
+func validatePolicyRoles(path string, names []string, roles map[string]bool) error {
+	for _, name := range names {
+		if !roles[name] {
+			return fmt.Errorf("%s: unknown role %s", path, name)
+		}
+	}
+	return nil
+}
+
+// PolicyChange is one change an import makes to the tenant. OldValue and
+// NewValue use the same format as the permission audit log.
+type PolicyChange struct {
+	Action   string `json:"action"`
+	Kind     string `json:"kind"`
+	Key      string `json:"key"`
+	OldValue string `json:"old_value,omitempty"`
+	NewValue string `json:"new_value,omitempty"`
+}
+
+// PolicyImportResult lists the changes an import made, or would make on a dry
+// run. Warnings name entries that were skipped because they do not exist in
+// the tenant.
+type PolicyImportResult struct {
+	DryRun   bool           `json:"dry_run"`
+	Changes  []PolicyChange `json:"changes"`
+	Warnings []string       `json:"warnings"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTenantPolicy_Validate(t *testing.T) {
+	valid := func() TenantPolicy {
+		return TenantPolicy{
+			Version: PolicyVersion,
+			Roles:   []PolicyRole{{Name: "moderators", Permissions: []string{PermissionServerRestart, "console:*"}}},
+			DiscordRoles: []PolicyDiscordRole{
+				{ID: "1001", Permissions: []string{PermissionServerRead}, Roles: []string{"moderators"}},
+			},
+			Members: []PolicyMember{{DiscordUserID: "2001", Roles: []string{"moderators"}}},
+			ResourceGrants: []PolicyResourceGrant{
+				{ResourceType: ResourceTypeGameServer, Resource: "survival", PrincipalType: PolicyPrincipalRole, Principal: "moderators", Permission: PermissionConsoleExecute},
+				{ResourceType: ResourceTypeGameServer, Resource: "survival", PrincipalType: PolicyPrincipalDiscordRole, Principal: "Helpers", Permission: PermissionServerStart},
+			},
+		}
+	}
+
+	policy := valid()
+	assert.NoError(t, policy.Validate())
+
+	tests := []struct {
+		name   string
+		change func(p *TenantPolicy)
+	}{
+		{name: "unsupported version", change: func(p *TenantPolicy) { p.Version = 2 }},
+		{name: "unknown role permission", change: func(p *TenantPolicy) { p.Roles[0].Permissions = []string{"server:explode"} }},
+		{name: "superadmin", change: func(p *TenantPolicy) { p.Members[0].Permissions = []string{PermissionSuperAdmin} }},
+		{name: "duplicate role", change: func(p *TenantPolicy) { p.Roles = append(p.Roles, p.Roles[0]) }},
+		{name: "unnamed Discord role", change: func(p *TenantPolicy) { p.DiscordRoles[0].ID = "" }},
+		{name: "undefined mapped role", change: func(p *TenantPolicy) { p.DiscordRoles[0].Roles = []string{"admins"} }},
+		{name: "member without ID", change: func(p *TenantPolicy) { p.Members[0].DiscordUserID = "" }},
+		{name: "unknown resource type", change: func(p *TenantPolicy) { p.ResourceGrants[0].ResourceType = "cluster" }},
+		{name: "unknown principal type", change: func(p *TenantPolicy) { p.ResourceGrants[0].PrincipalType = "group" }},
+		{name: "undefined grant role", change: func(p *TenantPolicy) { p.ResourceGrants[0].Principal = "admins" }},
+		{name: "unknown grant permission", change: func(p *TenantPolicy) { p.ResourceGrants[1].Permission = "*:*" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := valid()
+			tt.change(&policy)
+			assert.Error(t, policy.Validate())
+		})
+	}
+}
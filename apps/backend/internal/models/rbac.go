@@ -6,16 +6,19 @@ import (
 	"gorm.io/gorm"
 )
 
-// Permission represents a permission definition
+// Permission represents a permission definition. The table is seeded from
+// the permission catalog at startup.
 type Permission struct {
-	ID          string         `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
-	Name        string         `json:"name" gorm:"uniqueIndex;not null"`
-	Description string         `json:"description"`
-	Resource    string         `json:"resource" gorm:"not null"`
-	Action      string         `json:"action" gorm:"not null"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+	ID          string          `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	Name        string          `json:"name" gorm:"uniqueIndex;not null"`
+	Description string          `json:"description"`
+	Resource    string          `json:"resource" gorm:"not null"`
+	Action      string          `json:"action" gorm:"not null"`
+	Scope       PermissionScope `json:"scope" gorm:"not null;default:'tenant'"`
+	Position    int             `json:"position"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt  `json:"-" gorm:"index"`
 }
 
 // Role represents a role definition within a tenant
@@ -80,6 +83,114 @@ type PermissionAuditLog struct {
 	Performer  User   `json:"performer,omitempty" gorm:"foreignKey:PerformedBy"`
 }
 
+// Resource types that permissions can be granted on individually
+const (
+	ResourceTypeGameServer = "game_server"
+)
+
+// Principal types that can receive a resource grant. A role principal is
+// matched against the role IDs and names in UserTenant.Roles.
+const (
+	PrincipalTypeUser = "user"
+	PrincipalTypeRole = "role"
+)
+
+// ResourceGrant gives a principal a permission on a single resource in a
+// tenant, without granting it on the tenant's other resources
+type ResourceGrant struct {
+	ID            string         `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	TenantID      string         `json:"tenant_id" gorm:"not null;index:idx_resource_grants_resource"`
+	PrincipalType string         `json:"principal_type" gorm:"not null"`
+	PrincipalID   string         `json:"principal_id" gorm:"not null;index"`
+	Permission    string         `json:"permission" gorm:"not null"`
+	ResourceType  string         `json:"resource_type" gorm:"not null;index:idx_resource_grants_resource"`
+	ResourceID    string         `json:"resource_id" gorm:"not null;index:idx_resource_grants_resource"`
+	GrantedBy     string         `json:"granted_by"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// CreateResourceGrantRequest represents a request to grant a permission on a resource
+type CreateResourceGrantRequest struct {
+	PrincipalType string `json:"principal_type" binding:"required"`
+	PrincipalID   string `json:"principal_id" binding:"required"`
+	Permission    string `json:"permission" binding:"required"`
+}
+
+// RoleRequest represents a request to create or update a tenant role
+type RoleRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	Permissions []string `json:"permissions"`
+}
+
+// AssignRoleRequest represents a request to give a tenant member a role
+type AssignRoleRequest struct {
+	RoleID string `json:"role_id" binding:"required"`
+}
+
+// EffectivePermissions is the outcome of resolving a user's access in a tenant,
+// kept in the permission cache. An empty TenantID holds only superadmin status.
+type EffectivePermissions struct {
+	UserID       string            `json:"user_id"`
+	TenantID     string            `json:"tenant_id"`
+	IsSuperAdmin bool              `json:"is_superadmin"`
+	IsMember     bool              `json:"is_member"`
+	Permissions  []string          `json:"permissions"`
+	Grants       []PermissionGrant `json:"grants"`
+	ComputedAt   time.Time         `json:"computed_at"`
+}
+
+// Sources through which a user can hold a permission
+const (
+	GrantSourceSuperAdmin         = "superadmin"
+	GrantSourceDirect             = "direct"
+	GrantSourceRole               = "role"
+	GrantSourceDiscordRole        = "discord_role"
+	GrantSourceDiscordRoleMapping = "discord_role_mapping"
+	GrantSourceResourceGrant      = "resource_grant"
+)
+
+// PermissionGrant records one way a user holds a permission. For an internal
+// role mapped from a Discord role, Via is the Discord role ID.
+type PermissionGrant struct {
+	Permission string `json:"permission"`
+	Source     string `json:"source"`
+	SourceID   string `json:"source_id,omitempty"`
+	SourceName string `json:"source_name,omitempty"`
+	Via        string `json:"via,omitempty"`
+}
+
+// Reasons for an authorization decision
+const (
+	DecisionReasonSuperAdmin = "superadmin"
+	DecisionReasonGranted    = "granted"
+	DecisionReasonNotMember  = "not_a_member"
+	DecisionReasonNotGranted = "not_granted"
+)
+
+// AuthorizationDecision is the outcome of a permission check together with
+// the grants that allowed it or the reason it was denied
+type AuthorizationDecision struct {
+	UserID       string            `json:"user_id"`
+	TenantID     string            `json:"tenant_id"`
+	Permission   string            `json:"permission"`
+	ResourceType string            `json:"resource_type,omitempty"`
+	ResourceID   string            `json:"resource_id,omitempty"`
+	Allowed      bool              `json:"allowed"`
+	Reason       string            `json:"reason"`
+	Grants       []PermissionGrant `json:"grants"`
+}
+
+// PermissionCacheStats reports how often permission checks were answered from the cache
+type PermissionCacheStats struct {
+	Enabled       bool  `json:"enabled"`
+	Hits          int64 `json:"hits"`
+	Misses        int64 `json:"misses"`
+	Errors        int64 `json:"errors"`
+	Invalidations int64 `json:"invalidations"`
+}
+
 // GuildMembershipCache represents cached Discord guild membership data
 type GuildMembershipCache struct {
 	ID         string         `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
@@ -122,6 +233,11 @@ func (PermissionAuditLog) TableName() string {
 	return "permission_audit_log"
 }
 
+// TableName returns the table name for ResourceGrant
+func (ResourceGrant) TableName() string {
+	return "resource_grants"
+}
+
 // TableName returns the table name for GuildMembershipCache
 func (GuildMembershipCache) TableName() string {
 	return "guild_membership_cache"
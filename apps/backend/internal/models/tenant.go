@@ -130,10 +130,39 @@ type Tenant struct {
 
 // TenantConfig holds tenant-specific configuration
 type TenantConfig struct {
-	DefaultGameTemplate  string            `json:"default_game_template,omitempty"`
-	ResourceLimits       ResourceLimits    `json:"resource_limits,omitempty"`
-	NotificationChannels []string          `json:"notification_channels,omitempty"`
-	Settings             map[string]string `json:"settings,omitempty"`
+	DefaultGameTemplate  string                      `json:"default_game_template,omitempty"`
+	ResourceLimits       ResourceLimits              `json:"resource_limits,omitempty"`
+	NotificationChannels []string                    `json:"notification_channels,omitempty"`
+	Notifications        []NotificationChannelConfig `json:"notifications,omitempty"`
+	Announcements        AnnouncementPolicy          `json:"announcements,omitempty"`
+	Membership           MembershipPolicy            `json:"membership,omitempty"`
+	Settings             map[string]string           `json:"settings,omitempty"`
+}
+
+// Validate checks the tenant configuration
+func (tc TenantConfig) Validate() error {
+	for _, channel := range tc.Notifications {
+		if err := channel.Validate(); err != nil {
+			return err
+		}
+	}
+	if err := tc.Announcements.Validate(); err != nil {
+		return err
+	}
+	return tc.Membership.Validate()
+}
+
+// ChannelIDs returns the Discord channels the configuration makes the bot post in
+func (tc TenantConfig) ChannelIDs() []string {
+	var channelIDs []string
+	for _, target := range tc.NotificationTargets() {
+		channelIDs = append(channelIDs, target.ChannelID)
+	}
+	channelIDs = append(channelIDs, tc.Announcements.AllowedChannelIDs...)
+	if tc.Announcements.ApprovalChannelID != "" {
+		channelIDs = append(channelIDs, tc.Announcements.ApprovalChannelID)
+	}
+	return channelIDs
 }
 
 // Scan implements the sql.Scanner interface for reading from database
@@ -191,6 +220,7 @@ type TenantDiscordRole struct {
 	Color           int            `json:"color"`
 	Position        int            `json:"position"`
 	Permissions     StringArray    `json:"permissions" gorm:"type:text[]"`
+	MappedRoleIDs   StringArray    `json:"mapped_role_ids" gorm:"type:text[]"` // Internal roles granted by this Discord role
 	Mentionable     bool           `json:"mentionable"`
 	Hoist           bool           `json:"hoist"`
 	CreatedAt       time.Time      `json:"created_at"`
@@ -225,6 +255,7 @@ type GameServer struct {
 	ID         string            `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
 	TenantID   string            `json:"tenant_id" gorm:"not null;index"`
 	TemplateID string            `json:"template_id"`
+	ClusterID  string            `json:"cluster_id" gorm:"index"` // Cluster whose controller runs the server
 	Name       string            `json:"name" gorm:"not null"`
 	GameType   string            `json:"game_type" gorm:"not null"`
 	Config     GameServerConfig  `json:"config" gorm:"type:jsonb"`
@@ -276,6 +307,7 @@ type GameServerStatus struct {
 	LastUpdated time.Time `json:"last_updated"`
 	PlayerCount int       `json:"player_count"`
 	Uptime      string    `json:"uptime"`
+	Address     string    `json:"address,omitempty"` // Connect address reported by the controller
 }
 
 // Scan implements the sql.Scanner interface for reading from database
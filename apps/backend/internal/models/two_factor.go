@@ -0,0 +1,87 @@
+package models
+
+import "time"
+
+// SettingRequireOwnerTwoFactor is the system setting that makes tenant owners
+// enroll in two-factor authentication before they can use their tenants
+const SettingRequireOwnerTwoFactor = "two_factor.require_for_tenant_owners"
+
+// UserTOTP is a user's authenticator app enrollment. It only protects logins
+// once the user has confirmed it with a first code.
+type UserTOTP struct {
+	UserID       string     `json:"user_id" gorm:"primaryKey"`
+	Secret       string     `json:"-" gorm:"not null"`
+	ConfirmedAt  *time.Time `json:"confirmed_at,omitempty"`
+	LastUsedStep int64      `json:"-"` // Time step of the last accepted code, so a code cannot be replayed
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// IsConfirmed reports whether the enrollment is active
+func (t *UserTOTP) IsConfirmed() bool {
+	return t.ConfirmedAt != nil
+}
+
+// TOTPRecoveryCode is a single-use code that replaces an authenticator code
+// when the device is lost. Only a hash of the code is stored.
+type TOTPRecoveryCode struct {
+	ID        string     `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	UserID    string     `json:"user_id" gorm:"not null;index"`
+	CodeHash  string     `json:"-" gorm:"not null;uniqueIndex"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// SystemSetting is a system-wide setting managed by superadmins at runtime
+type SystemSetting struct {
+	Key       string    `json:"key" gorm:"primaryKey"`
+	Value     string    `json:"value" gorm:"not null"`
+	UpdatedBy string    `json:"updated_by"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TwoFactorChallenge is a login waiting for its second factor. Tokens are only
+// issued once the challenge is answered.
+type TwoFactorChallenge struct {
+	ID           string                `json:"id"`
+	User         User                  `json:"user"`
+	DiscordToken *DiscordTokenResponse `json:"discord_token,omitempty"` // Discord logins keep the grant for the session
+	ExpiresAt    time.Time             `json:"expires_at"`
+}
+
+// TwoFactorStatus describes a user's two-factor enrollment
+type TwoFactorStatus struct {
+	Enabled                bool       `json:"enabled"`
+	Pending                bool       `json:"pending"`  // Enrollment started but not confirmed yet
+	Required               bool       `json:"required"` // The enforcement policy applies to the user
+	ConfirmedAt            *time.Time `json:"confirmed_at,omitempty"`
+	RecoveryCodesRemaining int        `json:"recovery_codes_remaining"`
+}
+
+// TwoFactorEnrollment is returned when starting an enrollment. The provisioning
+// URI is rendered as a QR code for authenticator apps.
+type TwoFactorEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// TwoFactorRecoveryCodes are shown once, right after they are generated
+type TwoFactorRecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// TwoFactorPolicy is the system-wide two-factor enforcement policy
+type TwoFactorPolicy struct {
+	RequireForTenantOwners bool `json:"require_for_tenant_owners"`
+}
+
+// TwoFactorCodeRequest carries an authenticator or recovery code
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// TwoFactorVerifyRequest answers the two-factor challenge of a login
+type TwoFactorVerifyRequest struct {
+	Challenge string `json:"challenge" binding:"required"`
+	Code      string `json:"code" binding:"required"`
+}
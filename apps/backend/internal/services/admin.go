@@ -2,72 +2,108 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strings"
 
 	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
 	"gorm.io/gorm"
 )
 
+// ErrSuperAdminSelfRevoke is returned when a superadmin tries to revoke their own superadmin role
+var ErrSuperAdminSelfRevoke = errors.New("cannot revoke your own superadmin role")
+
 // AdminService handles admin-specific operations and permission checks
 type AdminService struct {
-	db *gorm.DB
+	db          *gorm.DB
+	rbacService *RBACService
 }
 
-// NewAdminService creates a new admin service
-func NewAdminService(db *gorm.DB) *AdminService {
+// NewAdminService creates a new admin service. Superadmin status is
+// determined by the RBAC service's system roles.
+func NewAdminService(db *gorm.DB, rbacService *RBACService) *AdminService {
 	return &AdminService{
-		db: db,
+		db:          db,
+		rbacService: rbacService,
 	}
 }
 
 // CheckSuperAdminAccess checks if a user has superadmin access
 func (s *AdminService) CheckSuperAdminAccess(ctx context.Context, userID string) (bool, error) {
-	// For now, we'll implement a simple superadmin check
-	// In the future, this could be based on:
-	// 1. A dedicated superadmin table
-	// 2. Environment variables for superadmin Discord IDs
-	// 3. Special Discord roles
-	// 4. Database configuration
-
-	// Get user details
-	var user models.User
-	err := s.db.WithContext(ctx).Where("id = ?", userID).First(&user).Error
-	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return false, nil
-		}
-		return false, fmt.Errorf("failed to get user: %w", err)
+	return s.rbacService.IsSuperAdmin(ctx, userID)
+}
+
+// ListSystemRoles returns every system role that can be granted
+func (s *AdminService) ListSystemRoles(ctx context.Context) ([]models.SystemRole, error) {
+	if err := s.ensureBuiltinSystemRoles(ctx); err != nil {
+		return nil, err
 	}
+	return s.rbacService.GetSystemRoles(ctx)
+}
 
-	// Check if user has superadmin permission in any tenant
-	var userTenant models.UserTenant
-	err = s.db.WithContext(ctx).
-		Where("user_id = ? AND permissions @> ?", userID, gorm.Expr("ARRAY['superadmin']")).
-		First(&userTenant).Error
+// GetUserSystemRoles returns the system roles held by a user
+func (s *AdminService) GetUserSystemRoles(ctx context.Context, userID string) ([]models.SystemRole, error) {
+	if err := s.ensureUserExists(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.rbacService.GetUserSystemRoles(ctx, userID)
+}
 
-	if err == nil {
-		// User has superadmin permission in at least one tenant
-		return true, nil
+// GrantSystemRole gives a user a system role and records the change in the audit log
+func (s *AdminService) GrantSystemRole(ctx context.Context, userID, roleName, performedBy string) error {
+	if err := s.ensureUserExists(ctx, userID); err != nil {
+		return err
+	}
+	if err := s.ensureBuiltinSystemRoles(ctx); err != nil {
+		return err
 	}
 
-	if err != gorm.ErrRecordNotFound {
-		return false, fmt.Errorf("failed to check superadmin permissions: %w", err)
+	if err := s.rbacService.AssignSystemRoleToUser(ctx, userID, roleName); err != nil {
+		return err
 	}
 
-	// Check for hardcoded superadmin Discord IDs (for development/testing)
-	superadminDiscordIDs := []string{
-		"197918357025062922", // thatguy164.
-		// Add more superadmin Discord IDs here as needed
+	return s.rbacService.LogPermissionChange(ctx, userID, "", "system_role_granted", "system_role", roleName, "", roleName, "", performedBy)
+}
+
+// RevokeSystemRole takes a system role away from a user and records the change in the audit log
+func (s *AdminService) RevokeSystemRole(ctx context.Context, userID, roleName, performedBy string) error {
+	// Revoking your own superadmin role could leave the system without one
+	if roleName == "superadmin" && userID == performedBy {
+		return ErrSuperAdminSelfRevoke
+	}
+	if err := s.ensureUserExists(ctx, userID); err != nil {
+		return err
 	}
 
-	for _, adminID := range superadminDiscordIDs {
-		if strings.EqualFold(user.DiscordUserID, adminID) {
-			return true, nil
-		}
+	if err := s.rbacService.RemoveSystemRoleFromUser(ctx, userID, roleName); err != nil {
+		return err
 	}
 
-	return false, nil
+	return s.rbacService.LogPermissionChange(ctx, userID, "", "system_role_revoked", "system_role", roleName, roleName, "", "", performedBy)
+}
+
+// ensureUserExists returns ErrUserNotFound unless the user exists
+func (s *AdminService) ensureUserExists(ctx context.Context, userID string) error {
+	var count int64
+	err := s.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID).Count(&count).Error
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if count == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// ensureBuiltinSystemRoles makes sure the superadmin and systemuser roles
+// exist so they can be granted before anyone has logged in with them
+func (s *AdminService) ensureBuiltinSystemRoles(ctx context.Context) error {
+	if err := s.rbacService.ensureSuperAdminSystemRole(ctx); err != nil {
+		return fmt.Errorf("failed to ensure super admin system role: %w", err)
+	}
+	if err := s.rbacService.ensureSystemUserRole(ctx); err != nil {
+		return fmt.Errorf("failed to ensure systemuser role: %w", err)
+	}
+	return nil
 }
 
 // GetAdminStats returns admin-level statistics
@@ -98,6 +134,8 @@ func (s *AdminService) GetAdminStats(ctx context.Context) (*models.AdminStats, e
 		return nil, fmt.Errorf("failed to count active controllers: %w", err)
 	}
 
+	stats.PermissionCache = s.rbacService.PermissionCacheStats()
+
 	return &stats, nil
 }
 
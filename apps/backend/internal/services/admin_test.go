@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminService_CheckSuperAdminAccess(t *testing.T) {
+	rbacService, db, cleanup := setupRBACTest(t)
+	defer cleanup()
+	adminService := NewAdminService(db, rbacService)
+	ctx := context.Background()
+
+	configured := &models.User{DiscordUserID: "superadmin123", Username: "configured"}
+	require.NoError(t, db.Create(configured).Error)
+	regular := &models.User{DiscordUserID: "197918357025062922", Username: "formerly-hardcoded"}
+	require.NoError(t, db.Create(regular).Error)
+
+	// The configured Discord ID is a superadmin without holding the role
+	hasAccess, err := adminService.CheckSuperAdminAccess(ctx, configured.ID)
+	require.NoError(t, err)
+	assert.True(t, hasAccess)
+
+	// Discord IDs outside the RBAC configuration get no special treatment
+	hasAccess, err = adminService.CheckSuperAdminAccess(ctx, regular.ID)
+	require.NoError(t, err)
+	assert.False(t, hasAccess)
+
+	require.NoError(t, adminService.GrantSystemRole(ctx, regular.ID, "superadmin", configured.ID))
+	hasAccess, err = adminService.CheckSuperAdminAccess(ctx, regular.ID)
+	require.NoError(t, err)
+	assert.True(t, hasAccess)
+
+	hasAccess, err = adminService.CheckSuperAdminAccess(ctx, "00000000-0000-0000-0000-000000000000")
+	require.NoError(t, err)
+	assert.False(t, hasAccess)
+}
+
+func TestAdminService_GrantAndRevokeSystemRole(t *testing.T) {
+	rbacService, db, cleanup := setupRBACTest(t)
+	defer cleanup()
+	adminService := NewAdminService(db, rbacService)
+	ctx := context.Background()
+
+	admin := &models.User{DiscordUserID: "superadmin123", Username: "admin"}
+	require.NoError(t, db.Create(admin).Error)
+	target := &models.User{DiscordUserID: "target-123", Username: "target"}
+	require.NoError(t, db.Create(target).Error)
+
+	require.NoError(t, adminService.GrantSystemRole(ctx, target.ID, "superadmin", admin.ID))
+	// Granting twice is a no-op
+	require.NoError(t, adminService.GrantSystemRole(ctx, target.ID, "superadmin", admin.ID))
+
+	roles, err := adminService.GetUserSystemRoles(ctx, target.ID)
+	require.NoError(t, err)
+	require.Len(t, roles, 1)
+	assert.Equal(t, "superadmin", roles[0].Name)
+
+	require.NoError(t, adminService.RevokeSystemRole(ctx, target.ID, "superadmin", admin.ID))
+	roles, err = adminService.GetUserSystemRoles(ctx, target.ID)
+	require.NoError(t, err)
+	assert.Empty(t, roles)
+
+	var logs []models.PermissionAuditLog
+	require.NoError(t, db.Where("user_id = ? AND resource_type = ?", target.ID, "system_role").Order("created_at").Find(&logs).Error)
+	require.Len(t, logs, 3)
+	assert.Equal(t, "system_role_granted", logs[0].Action)
+	assert.Equal(t, "system_role_revoked", logs[2].Action)
+	assert.Equal(t, admin.ID, logs[2].PerformedBy)
+
+	err = adminService.GrantSystemRole(ctx, target.ID, "nonexistent", admin.ID)
+	assert.ErrorIs(t, err, ErrSystemRoleNotFound)
+	err = adminService.GrantSystemRole(ctx, "00000000-0000-0000-0000-000000000000", "superadmin", admin.ID)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	err = adminService.RevokeSystemRole(ctx, admin.ID, "superadmin", admin.ID)
+	assert.ErrorIs(t, err, ErrSuperAdminSelfRevoke)
+}
@@ -0,0 +1,359 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	// apiTokenLastUsedInterval limits how often last-used times are written
+	apiTokenLastUsedInterval = time.Minute
+	// apiTokenDisplayLength is how much of a token is kept to identify it in listings
+	apiTokenDisplayLength = len(models.APITokenPrefix) + 8
+)
+
+var (
+	// ErrInvalidAPIToken is returned when an API token is unknown, revoked or expired
+	ErrInvalidAPIToken = errors.New("invalid API token")
+	// ErrAPITokenNotFound is returned when an API token does not exist for its owner
+	ErrAPITokenNotFound = errors.New("API token not found")
+	// ErrServiceAccountNotFound is returned when a service account does not exist in the tenant
+	ErrServiceAccountNotFound = errors.New("service account not found")
+	// ErrPermissionNotHeld is returned when granting a permission the granter does not hold
+	ErrPermissionNotHeld = errors.New("cannot grant a permission you do not hold")
+	// ErrInvalidTokenRequest is returned when a token request has invalid scopes or expiry
+	ErrInvalidTokenRequest = errors.New("invalid token request")
+)
+
+// APITokenService manages personal access tokens and tenant service accounts
+type APITokenService struct {
+	db          *gorm.DB
+	rbacService *RBACService
+}
+
+// NewAPITokenService creates a new API token service
+func NewAPITokenService(db *gorm.DB, rbacService *RBACService) *APITokenService {
+	return &APITokenService{
+		db:          db,
+		rbacService: rbacService,
+	}
+}
+
+// CreatePersonalToken creates a token that acts as the user, limited to its scopes
+func (s *APITokenService) CreatePersonalToken(ctx context.Context, userID string, req models.CreateAPITokenRequest) (*models.CreateAPITokenResponse, error) {
+	if len(req.Scopes) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", ErrInvalidTokenRequest)
+	}
+	if req.TenantID != nil && *req.TenantID == "" {
+		req.TenantID = nil
+	}
+
+	return s.createToken(ctx, &models.APIToken{
+		Name:      req.Name,
+		UserID:    userID,
+		TenantID:  req.TenantID,
+		Scopes:    models.StringArray(req.Scopes),
+		ExpiresAt: req.ExpiresAt,
+		CreatedBy: userID,
+	})
+}
+
+// ListPersonalTokens lists a user's personal tokens, including revoked ones
+func (s *APITokenService) ListPersonalTokens(ctx context.Context, userID string) ([]models.APIToken, error) {
+	var tokens []models.APIToken
+	err := s.db.WithContext(ctx).
+		Where("user_id = ? AND service_account_id IS NULL", userID).
+		Order("created_at DESC").
+		Find(&tokens).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// RevokePersonalToken revokes one of a user's personal tokens
+func (s *APITokenService) RevokePersonalToken(ctx context.Context, userID, tokenID string) error {
+	return s.revokeToken(ctx, s.db.WithContext(ctx).Where("id = ? AND user_id = ? AND service_account_id IS NULL", tokenID, userID))
+}
+
+// CreateServiceAccount creates a service account in a tenant. The creator must
+// hold every permission given to the account.
+func (s *APITokenService) CreateServiceAccount(ctx context.Context, tenantID string, req models.CreateServiceAccountRequest, createdBy string) (*models.ServiceAccount, error) {
+	permissions := uniqueStrings(req.Permissions)
+	for _, permission := range permissions {
+		if !models.IsValidTenantPermission(permission) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidPermission, permission)
+		}
+		held, err := s.rbacService.HasPermission(ctx, createdBy, tenantID, permission)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check permission: %w", err)
+		}
+		if !held {
+			return nil, fmt.Errorf("%w: %s", ErrPermissionNotHeld, permission)
+		}
+	}
+
+	account := &models.ServiceAccount{
+		ID:          uuid.New().String(),
+		TenantID:    tenantID,
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: models.StringArray(permissions),
+		CreatedBy:   createdBy,
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		user := &models.User{
+			ID:               uuid.New().String(),
+			Username:         req.Name,
+			IsServiceAccount: true,
+		}
+		if err := tx.Create(user).Error; err != nil {
+			return fmt.Errorf("failed to create service account user: %w", err)
+		}
+
+		account.UserID = user.ID
+		if err := tx.Create(account).Error; err != nil {
+			return fmt.Errorf("failed to create service account: %w", err)
+		}
+
+		userTenant := &models.UserTenant{
+			UserID:      user.ID,
+			TenantID:    tenantID,
+			Roles:       models.StringArray{},
+			Permissions: models.StringArray(permissions),
+		}
+		if err := tx.Create(userTenant).Error; err != nil {
+			return fmt.Errorf("failed to add service account to tenant: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+// ListServiceAccounts lists a tenant's service accounts
+func (s *APITokenService) ListServiceAccounts(ctx context.Context, tenantID string) ([]models.ServiceAccount, error) {
+	var accounts []models.ServiceAccount
+	err := s.db.WithContext(ctx).Where("tenant_id = ?", tenantID).Order("created_at").Find(&accounts).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service accounts: %w", err)
+	}
+	return accounts, nil
+}
+
+// DeleteServiceAccount deletes a service account, revoking its tokens and tenant access
+func (s *APITokenService) DeleteServiceAccount(ctx context.Context, tenantID, accountID string) error {
+	account, err := s.getServiceAccount(ctx, tenantID, accountID)
+	if err != nil {
+		return err
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.APIToken{}).
+			Where("service_account_id = ? AND revoked_at IS NULL", account.ID).
+			Update("revoked_at", time.Now()).Error
+		if err != nil {
+			return fmt.Errorf("failed to revoke service account tokens: %w", err)
+		}
+		if err := tx.Where("user_id = ?", account.UserID).Delete(&models.UserTenant{}).Error; err != nil {
+			return fmt.Errorf("failed to remove service account from tenant: %w", err)
+		}
+		if err := tx.Where("id = ?", account.UserID).Delete(&models.User{}).Error; err != nil {
+			return fmt.Errorf("failed to delete service account user: %w", err)
+		}
+		if err := tx.Delete(account).Error; err != nil {
+			return fmt.Errorf("failed to delete service account: %w", err)
+		}
+		return nil
+	})
+}
+
+// CreateServiceAccountToken creates a token for a service account. Tokens are
+// always limited to the account's tenant and default to the account's
+// permissions. The creator must hold the account's permissions and every
+// requested scope, so a token never acts with more than its creator could.
+func (s *APITokenService) CreateServiceAccountToken(ctx context.Context, tenantID, accountID string, req models.CreateAPITokenRequest, createdBy string) (*models.CreateAPITokenResponse, error) {
+	account, err := s.getServiceAccount(ctx, tenantID, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := uniqueStrings(req.Scopes)
+	if len(scopes) == 0 {
+		scopes = account.Permissions
+	}
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", ErrInvalidTokenRequest)
+	}
+	if err := validateTenantPermissions(scopes); err != nil {
+		return nil, err
+	}
+
+	required := append(append([]string{}, account.Permissions...), scopes...)
+	if err := s.rbacService.requirePermissionsHeld(ctx, createdBy, account.TenantID, required); err != nil {
+		return nil, err
+	}
+
+	return s.createToken(ctx, &models.APIToken{
+		Name:             req.Name,
+		UserID:           account.UserID,
+		ServiceAccountID: &account.ID,
+		TenantID:         &account.TenantID,
+		Scopes:           models.StringArray(scopes),
+		ExpiresAt:        req.ExpiresAt,
+		CreatedBy:        createdBy,
+	})
+}
+
+// ListServiceAccountTokens lists the tokens of a service account
+func (s *APITokenService) ListServiceAccountTokens(ctx context.Context, tenantID, accountID string) ([]models.APIToken, error) {
+	account, err := s.getServiceAccount(ctx, tenantID, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens []models.APIToken
+	err = s.db.WithContext(ctx).Where("service_account_id = ?", account.ID).Order("created_at DESC").Find(&tokens).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// RevokeServiceAccountToken revokes a token of a service account
+func (s *APITokenService) RevokeServiceAccountToken(ctx context.Context, tenantID, accountID, tokenID string) error {
+	account, err := s.getServiceAccount(ctx, tenantID, accountID)
+	if err != nil {
+		return err
+	}
+	return s.revokeToken(ctx, s.db.WithContext(ctx).Where("id = ? AND service_account_id = ?", tokenID, account.ID))
+}
+
+// ValidateToken resolves a raw API token to its owner, recording when it was last used
+func (s *APITokenService) ValidateToken(ctx context.Context, rawToken string) (*models.User, *models.APIToken, error) {
+	if !strings.HasPrefix(rawToken, models.APITokenPrefix) {
+		return nil, nil, ErrInvalidAPIToken
+	}
+
+	var token models.APIToken
+	err := s.db.WithContext(ctx).Where("token_hash = ?", hashAPIToken(rawToken)).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidAPIToken
+		}
+		return nil, nil, fmt.Errorf("failed to get API token: %w", err)
+	}
+
+	now := time.Now()
+	if !token.IsActive(now) {
+		return nil, nil, ErrInvalidAPIToken
+	}
+
+	var user models.User
+	if err := s.db.WithContext(ctx).Where("id = ?", token.UserID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidAPIToken
+		}
+		return nil, nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > apiTokenLastUsedInterval {
+		token.LastUsedAt = &now
+		err := s.db.WithContext(ctx).Model(&models.APIToken{}).Where("id = ?", token.ID).UpdateColumn("last_used_at", now).Error
+		if err != nil {
+			fmt.Printf("Warning: failed to record API token use for %s: %v\n", token.ID, err)
+		}
+	}
+
+	return &user, &token, nil
+}
+
+// createToken validates and stores a token, returning the only copy of its secret
+func (s *APITokenService) createToken(ctx context.Context, token *models.APIToken) (*models.CreateAPITokenResponse, error) {
+	token.Scopes = models.StringArray(uniqueStrings(token.Scopes))
+	for _, scope := range token.Scopes {
+		if !models.IsValidTenantPermission(scope) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidPermission, scope)
+		}
+	}
+	if token.ExpiresAt != nil && !token.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: expiry must be in the future", ErrInvalidTokenRequest)
+	}
+
+	secret, err := generateAPIToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate API token: %w", err)
+	}
+
+	token.ID = uuid.New().String()
+	token.Prefix = secret[:apiTokenDisplayLength]
+	token.TokenHash = hashAPIToken(secret)
+	if err := s.db.WithContext(ctx).Create(token).Error; err != nil {
+		return nil, fmt.Errorf("failed to create API token: %w", err)
+	}
+
+	return &models.CreateAPITokenResponse{Token: secret, APIToken: *token}, nil
+}
+
+// revokeToken revokes the single token matched by query
+func (s *APITokenService) revokeToken(ctx context.Context, query *gorm.DB) error {
+	var token models.APIToken
+	if err := query.First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrAPITokenNotFound
+		}
+		return fmt.Errorf("failed to get API token: %w", err)
+	}
+	if token.RevokedAt != nil {
+		return nil
+	}
+
+	err := s.db.WithContext(ctx).Model(&token).Update("revoked_at", time.Now()).Error
+	if err != nil {
+		return fmt.Errorf("failed to revoke API token: %w", err)
+	}
+	return nil
+}
+
+func (s *APITokenService) getServiceAccount(ctx context.Context, tenantID, accountID string) (*models.ServiceAccount, error) {
+	var account models.ServiceAccount
+	err := s.db.WithContext(ctx).Where("id = ? AND tenant_id = ?", accountID, tenantID).First(&account).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrServiceAccountNotFound
+		}
+		return nil, fmt.Errorf("failed to get service account: %w", err)
+	}
+	return &account, nil
+}
+
+// generateAPIToken returns a new random token carrying the API token prefix
+func generateAPIToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return models.APITokenPrefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashAPIToken hashes a token for storage. Tokens carry 256 bits of entropy, so
+// a fast hash is sufficient.
+func hashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
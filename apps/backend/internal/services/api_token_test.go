@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/config"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupAPITokenTest(t *testing.T) (*APITokenService, *gorm.DB, func()) {
+	db, cleanup := testutils.SetupTestDatabaseWithModels(t,
+		&models.User{},
+		&models.Tenant{},
+		&models.UserTenant{},
+		&models.TenantDiscordRole{},
+		&models.Role{},
+		&models.SystemRole{},
+		&models.UserSystemRole{},
+		&models.ServiceAccount{},
+		&models.APIToken{},
+	)
+
+	rbacService := NewRBACService(db, &config.RBACConfig{})
+	return NewAPITokenService(db, rbacService), db, cleanup
+}
+
+func TestAPITokenService_PersonalTokens(t *testing.T) {
+	apiTokenService, db, cleanup := setupAPITokenTest(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	user := &models.User{DiscordUserID: "pat-user", Username: "deployer"}
+	require.NoError(t, db.Create(user).Error)
+
+	_, err := apiTokenService.CreatePersonalToken(ctx, user.ID, models.CreateAPITokenRequest{Name: "ci"})
+	assert.ErrorIs(t, err, ErrInvalidTokenRequest)
+	_, err = apiTokenService.CreatePersonalToken(ctx, user.ID, models.CreateAPITokenRequest{Name: "ci", Scopes: []string{"server:sudo"}})
+	assert.ErrorIs(t, err, ErrInvalidPermission)
+
+	created, err := apiTokenService.CreatePersonalToken(ctx, user.ID, models.CreateAPITokenRequest{
+		Name:   "ci",
+		Scopes: []string{models.PermissionServerRestart},
+	})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(created.Token, models.APITokenPrefix))
+	assert.True(t, strings.HasPrefix(created.Token, created.APIToken.Prefix))
+
+	// Only the hash is stored
+	var stored models.APIToken
+	require.NoError(t, db.Where("id = ?", created.APIToken.ID).First(&stored).Error)
+	assert.NotContains(t, stored.TokenHash, created.Token)
+	assert.Nil(t, stored.LastUsedAt)
+
+	validatedUser, validatedToken, err := apiTokenService.ValidateToken(ctx, created.Token)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, validatedUser.ID)
+	assert.Equal(t, models.StringArray{models.PermissionServerRestart}, validatedToken.Scopes)
+
+	require.NoError(t, db.Where("id = ?", created.APIToken.ID).First(&stored).Error)
+	assert.NotNil(t, stored.LastUsedAt)
+
+	_, _, err = apiTokenService.ValidateToken(ctx, created.Token+"x")
+	assert.ErrorIs(t, err, ErrInvalidAPIToken)
+
+	tokens, err := apiTokenService.ListPersonalTokens(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Len(t, tokens, 1)
+
+	// Other users cannot revoke the token
+	assert.ErrorIs(t, apiTokenService.RevokePersonalToken(ctx, uuid.New().String(), created.APIToken.ID), ErrAPITokenNotFound)
+	require.NoError(t, apiTokenService.RevokePersonalToken(ctx, user.ID, created.APIToken.ID))
+	_, _, err = apiTokenService.ValidateToken(ctx, created.Token)
+	assert.ErrorIs(t, err, ErrInvalidAPIToken)
+
+	// Expired tokens are rejected
+	past := time.Now().Add(-time.Hour)
+	_, err = apiTokenService.CreatePersonalToken(ctx, user.ID, models.CreateAPITokenRequest{
+		Name:      "expired",
+		Scopes:    []string{models.PermissionServerRead},
+		ExpiresAt: &past,
+	})
+	assert.ErrorIs(t, err, ErrInvalidTokenRequest)
+
+	expiring, err := apiTokenService.CreatePersonalToken(ctx, user.ID, models.CreateAPITokenRequest{
+		Name:   "expiring",
+		Scopes: []string{models.PermissionServerRead},
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.Model(&models.APIToken{}).Where("id = ?", expiring.APIToken.ID).Update("expires_at", past).Error)
+	_, _, err = apiTokenService.ValidateToken(ctx, expiring.Token)
+	assert.ErrorIs(t, err, ErrInvalidAPIToken)
+}
+
+func TestAPITokenService_ServiceAccounts(t *testing.T) {
+	apiTokenService, db, cleanup := setupAPITokenTest(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	tenant := &models.Tenant{DiscordServerID: "guild-sa", Name: "Service Account Guild", OwnerID: uuid.New().String()}
+	require.NoError(t, db.Create(tenant).Error)
+
+	admin := &models.User{DiscordUserID: "sa-admin", Username: "admin"}
+	require.NoError(t, db.Create(admin).Error)
+	require.NoError(t, db.Create(&models.UserTenant{
+		UserID:      admin.ID,
+		TenantID:    tenant.ID,
+		Permissions: models.StringArray{models.PermissionUserCreate, models.PermissionServerRestart},
+	}).Error)
+
+	// Creators cannot hand out permissions they do not hold
+	_, err := apiTokenService.CreateServiceAccount(ctx, tenant.ID, models.CreateServiceAccountRequest{
+		Name:        "backup-bot",
+		Permissions: []string{models.PermissionServerDelete},
+	}, admin.ID)
+	assert.ErrorIs(t, err, ErrPermissionNotHeld)
+
+	account, err := apiTokenService.CreateServiceAccount(ctx, tenant.ID, models.CreateServiceAccountRequest{
+		Name:        "deploy-bot",
+		Permissions: []string{models.PermissionServerRestart},
+	}, admin.ID)
+	require.NoError(t, err)
+
+	var accountUser models.User
+	require.NoError(t, db.Where("id = ?", account.UserID).First(&accountUser).Error)
+	assert.True(t, accountUser.IsServiceAccount)
+	assert.Empty(t, accountUser.DiscordUserID)
+
+	// Service account tokens are bound to the account's tenant
+	otherTenant := uuid.New().String()
+	created, err := apiTokenService.CreateServiceAccountToken(ctx, tenant.ID, account.ID, models.CreateAPITokenRequest{
+		Name:     "github-actions",
+		TenantID: &otherTenant,
+	}, admin.ID)
+	require.NoError(t, err)
+	assert.Equal(t, tenant.ID, *created.APIToken.TenantID)
+
+	validatedUser, validatedToken, err := apiTokenService.ValidateToken(ctx, created.Token)
+	require.NoError(t, err)
+	assert.Equal(t, account.UserID, validatedUser.ID)
+	assert.True(t, validatedToken.AllowsTenant(tenant.ID))
+	assert.False(t, validatedToken.AllowsTenant(otherTenant))
+
+	has, err := apiTokenService.rbacService.HasPermission(ctx, validatedUser.ID, tenant.ID, models.PermissionServerRestart)
+	require.NoError(t, err)
+	assert.True(t, has)
+
+	// Members who may manage users but do not hold the account's permissions
+	// cannot mint tokens for it
+	manager := &models.User{DiscordUserID: "sa-manager", Username: "manager"}
+	require.NoError(t, db.Create(manager).Error)
+	require.NoError(t, db.Create(&models.UserTenant{
+		UserID:      manager.ID,
+		TenantID:    tenant.ID,
+		Permissions: models.StringArray{models.PermissionUserWrite},
+	}).Error)
+	_, err = apiTokenService.CreateServiceAccountToken(ctx, tenant.ID, account.ID, models.CreateAPITokenRequest{Name: "stolen"}, manager.ID)
+	assert.ErrorIs(t, err, ErrPermissionNotHeld)
+	_, err = apiTokenService.CreateServiceAccountToken(ctx, tenant.ID, account.ID, models.CreateAPITokenRequest{
+		Name:   "wildcard",
+		Scopes: []string{models.PermissionAdminAll},
+	}, admin.ID)
+	assert.ErrorIs(t, err, ErrPermissionNotHeld)
+
+	// Tokens without scopes get the account's permissions, not everything
+	assert.Equal(t, models.StringArray{models.PermissionServerRestart}, created.APIToken.Scopes)
+
+	// Accounts are not reachable through other tenants
+	_, err = apiTokenService.ListServiceAccountTokens(ctx, otherTenant, account.ID)
+	assert.ErrorIs(t, err, ErrServiceAccountNotFound)
+
+	// Deleting the account revokes its tokens and tenant access
+	require.NoError(t, apiTokenService.DeleteServiceAccount(ctx, tenant.ID, account.ID))
+	_, _, err = apiTokenService.ValidateToken(ctx, created.Token)
+	assert.ErrorIs(t, err, ErrInvalidAPIToken)
+
+	has, err = apiTokenService.rbacService.HasPermission(ctx, account.UserID, tenant.ID, models.PermissionServerRestart)
+	require.NoError(t, err)
+	assert.False(t, has)
+}
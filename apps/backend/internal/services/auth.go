@@ -2,7 +2,9 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -11,6 +13,38 @@ import (
 	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
 )
 
+// ErrUserNotFound is returned when no Pteronimbus user matches a lookup
+var ErrUserNotFound = errors.New("user not found")
+
+// ErrRefreshTokenNotFound is returned when a refresh token is unknown or was already used
+var ErrRefreshTokenNotFound = errors.New("refresh token not found")
+
+// ErrRefreshTokenReused is returned when a refresh token that was already rotated out is presented again
+var ErrRefreshTokenReused = errors.New("refresh token reused")
+
+// ErrSessionNotFound is returned when a session does not exist or belongs to another user
+var ErrSessionNotFound = errors.New("session not found")
+
+// sessionTouchInterval limits how often a session's last seen time is written back
+const sessionTouchInterval = time.Minute
+
+type sessionClientKey struct{}
+
+// sessionClient describes the device a session is created from
+type sessionClient struct {
+	userAgent string
+	ipAddress string
+}
+
+// WithSessionClient attaches the requesting device to a context so that
+// sessions created with it record where the login came from
+func WithSessionClient(ctx context.Context, userAgent, ipAddress string) context.Context {
+	return context.WithValue(ctx, sessionClientKey{}, sessionClient{
+		userAgent: userAgent,
+		ipAddress: ipAddress,
+	})
+}
+
 // AuthService handles authentication operations
 type AuthService struct {
 	db             *gorm.DB
@@ -18,6 +52,9 @@ type AuthService struct {
 	jwtService     JWTServiceInterface
 	redisService   RedisServiceInterface
 	rbacService    *RBACService
+	twoFactor      TwoFactorServiceInterface
+
+	identityProviders []IdentityProvider
 }
 
 // NewAuthService creates a new authentication service
@@ -96,6 +133,10 @@ func (a *AuthService) HandleCallback(ctx context.Context, code string) (*models.
 			} else {
 				return nil, fmt.Errorf("failed to check existing user: %w", err)
 			}
+
+			if err := a.upsertIdentity(ctx, user.ID, discordExternalIdentity(discordUser)); err != nil {
+				fmt.Printf("Warning: failed to record Discord identity for user %s: %v\n", user.ID, err)
+			}
 		} else {
 			// For testing without database - create user in memory only
 			user = models.User{
@@ -141,36 +182,43 @@ func (a *AuthService) HandleCallback(ctx context.Context, code string) (*models.
 			}
 		}
 
+	return a.completeLogin(ctx, &user, discordToken)
+}
+
+// createSession issues tokens for a user and stores the session. discordToken
+// is nil for logins through providers other than Discord.
+func (a *AuthService) createSession(ctx context.Context, user *models.User, discordToken *models.DiscordTokenResponse) (*models.AuthResponse, error) {
 	// Create session
 	sessionID := uuid.New().String()
 	
-	// Generate JWT tokens with RBAC integration if available
-	var accessToken string
-	var accessExpiresAt time.Time
-	var refreshToken string
-	var refreshExpiresAt time.Time
-
 	// Generate JWT tokens using the JWT service (which includes RBAC integration)
-	accessToken, accessExpiresAt, err = a.jwtService.GenerateAccessToken(&user, sessionID)
+	accessToken, accessExpiresAt, err := a.jwtService.GenerateAccessToken(user, sessionID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate access token: %w", err)
 	}
 
-	refreshToken, refreshExpiresAt, err = a.jwtService.GenerateRefreshToken(&user, sessionID)
+	refreshToken, refreshExpiresAt, err := a.jwtService.GenerateRefreshToken(user, sessionID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
 	}
 
 	// Store session in Redis
+	now := time.Now()
 	session := &models.Session{
-		ID:                  sessionID,
-		UserID:              user.ID,
-		AccessToken:         accessToken,
-		RefreshToken:        refreshToken,
-		DiscordAccessToken:  discordToken.AccessToken,
-		DiscordRefreshToken: discordToken.RefreshToken,
-		ExpiresAt:           refreshExpiresAt, // Use refresh token expiry for session
-		CreatedAt:           time.Now(),
+		ID:           sessionID,
+		UserID:       user.ID,
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresAt:    refreshExpiresAt, // Use refresh token expiry for session
+		LastSeenAt:   now,
+		CreatedAt:    now,
+	}
+	if client, ok := ctx.Value(sessionClientKey{}).(sessionClient); ok {
+		session.UserAgent = client.userAgent
+		session.IPAddress = client.ipAddress
+	}
+	if discordToken != nil {
+		setDiscordToken(session, discordToken)
 	}
 
 	err = a.redisService.StoreSession(ctx, session)
@@ -183,11 +231,13 @@ func (a *AuthService) HandleCallback(ctx context.Context, code string) (*models.
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
 		ExpiresIn:    int64(accessExpiresAt.Sub(time.Now()).Seconds()),
-		User:         user,
+		User:         *user,
 	}, nil
 }
 
-// RefreshToken refreshes an access token using refresh token
+// RefreshToken exchanges a refresh token for a new access and refresh token.
+// Every refresh token can only be used once; presenting one that was already
+// rotated out is treated as theft and revokes the whole session.
 func (a *AuthService) RefreshToken(ctx context.Context, refreshTokenString string) (*models.AuthResponse, error) {
 	// Validate refresh token
 	claims, err := a.jwtService.ValidateToken(refreshTokenString)
@@ -195,9 +245,16 @@ func (a *AuthService) RefreshToken(ctx context.Context, refreshTokenString strin
 		return nil, fmt.Errorf("invalid refresh token: %w", err)
 	}
 
-	// Get session from Redis
-	session, err := a.redisService.GetSessionByRefreshToken(ctx, refreshTokenString)
+	// Take the refresh token out of circulation, remembering it until it would have expired
+	retiredUntil := time.Now()
+	if claims.ExpiresAt != nil {
+		retiredUntil = claims.ExpiresAt.Time
+	}
+	session, err := a.redisService.ConsumeRefreshToken(ctx, refreshTokenString, retiredUntil)
 	if err != nil {
+		if errors.Is(err, ErrRefreshTokenNotFound) && a.detectRefreshTokenReuse(ctx, refreshTokenString, claims) {
+			return nil, ErrRefreshTokenReused
+		}
 		return nil, fmt.Errorf("session not found: %w", err)
 	}
 
@@ -215,27 +272,64 @@ func (a *AuthService) RefreshToken(ctx context.Context, refreshTokenString strin
 		Username:      claims.Username,
 	}
 
-	// Generate new access token using the JWT service (which includes RBAC integration)
+	// Generate new tokens using the JWT service (which includes RBAC integration)
 	newAccessToken, accessExpiresAt, err := a.jwtService.GenerateAccessToken(user, session.ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate new access token: %w", err)
 	}
 
-	// Update session with new access token (keep Discord tokens unchanged)
-	session.AccessToken = newAccessToken
-	err = a.redisService.StoreSession(ctx, session)
+	newRefreshToken, _, err := a.jwtService.GenerateRefreshToken(user, session.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate new refresh token: %w", err)
+	}
+
+	// Rotate the session's tokens without touching its other fields. A session
+	// revoked in the meantime stays revoked and gets no new refresh token.
+	session, err = a.redisService.UpdateSession(ctx, session.ID, func(s *models.Session) {
+		s.AccessToken = newAccessToken
+		s.RefreshToken = newRefreshToken
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update session: %w", err)
+	}
+	err = a.redisService.StoreRefreshToken(ctx, session)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update session: %w", err)
 	}
 
 	return &models.AuthResponse{
 		AccessToken:  newAccessToken,
-		RefreshToken: refreshTokenString, // Keep the same refresh token
+		RefreshToken: newRefreshToken,
 		ExpiresIn:    int64(accessExpiresAt.Sub(time.Now()).Seconds()),
 		User:         *user,
 	}, nil
 }
 
+// detectRefreshTokenReuse revokes the session a retired refresh token belonged
+// to and reports whether the token was retired. Either the legitimate client or
+// an attacker already used the token, and there is no way to tell which, so
+// both lose access.
+func (a *AuthService) detectRefreshTokenReuse(ctx context.Context, refreshToken string, claims *models.JWTClaims) bool {
+	sessionID, err := a.redisService.GetRetiredRefreshToken(ctx, refreshToken)
+	if err != nil {
+		return false
+	}
+
+	if err := a.redisService.DeleteSession(ctx, sessionID); err != nil {
+		fmt.Printf("Warning: failed to revoke session %s after refresh token reuse: %v\n", sessionID, err)
+	}
+	fmt.Printf("Warning: retired refresh token reused for session %s of user %s, session revoked\n", sessionID, claims.UserID)
+
+	if a.rbacService != nil {
+		err = a.rbacService.LogPermissionChange(ctx, claims.UserID, "", "refresh_token_reused", "session", sessionID, "", "revoked", "Retired refresh token presented; session revoked", claims.UserID)
+		if err != nil {
+			fmt.Printf("Warning: failed to audit refresh token reuse for user %s: %v\n", claims.UserID, err)
+		}
+	}
+
+	return true
+}
+
 // ValidateAccessToken validates an access token
 func (a *AuthService) ValidateAccessToken(ctx context.Context, accessToken string) (*models.User, error) {
 	// Validate JWT token
@@ -257,6 +351,22 @@ func (a *AuthService) ValidateAccessToken(ctx context.Context, accessToken strin
 		return nil, fmt.Errorf("session expired")
 	}
 
+	// Reject tokens pointing at a session that was issued to someone else
+	if session.UserID != claims.UserID {
+		return nil, ErrSessionNotFound
+	}
+
+	// Record activity, but only write it back once per interval
+	if time.Since(session.LastSeenAt) >= sessionTouchInterval {
+		seenAt := time.Now()
+		_, err := a.redisService.UpdateSession(ctx, session.ID, func(s *models.Session) {
+			s.LastSeenAt = seenAt
+		})
+		if err != nil {
+			fmt.Printf("Warning: failed to update last seen time of session %s: %v\n", session.ID, err)
+		}
+	}
+
 	// Return user from claims
 	return &models.User{
 		ID:            claims.UserID,
@@ -285,4 +395,84 @@ func (a *AuthService) Logout(ctx context.Context, accessToken string) error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// ListSessions returns a user's active sessions, marking the one making the request
+func (a *AuthService) ListSessions(ctx context.Context, userID, currentSessionID string) ([]models.SessionInfo, error) {
+	sessions, err := a.redisService.ListUserSessions(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	infos := make([]models.SessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		infos = append(infos, models.SessionInfo{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IPAddress:  session.IPAddress,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			ExpiresAt:  session.ExpiresAt,
+			Current:    session.ID == currentSessionID,
+		})
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].LastSeenAt.After(infos[j].LastSeenAt)
+	})
+
+	return infos, nil
+}
+
+// RevokeSession ends one of a user's sessions. Access tokens issued for it
+// stop working immediately.
+func (a *AuthService) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	session, err := a.redisService.GetSession(ctx, sessionID)
+	if err != nil || session.UserID != userID {
+		return ErrSessionNotFound
+	}
+
+	err = a.redisService.DeleteSession(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeAllSessions ends every session of a user and returns how many were revoked
+func (a *AuthService) RevokeAllSessions(ctx context.Context, userID string) (int, error) {
+	sessions, err := a.redisService.ListUserSessions(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	revoked := 0
+	for _, session := range sessions {
+		err = a.redisService.DeleteSession(ctx, session.ID)
+		if err != nil {
+			return revoked, fmt.Errorf("failed to delete session: %w", err)
+		}
+		revoked++
+	}
+
+	return revoked, nil
+}
+
+// GetUserByDiscordID returns the Pteronimbus user linked to a Discord account
+func (a *AuthService) GetUserByDiscordID(ctx context.Context, discordUserID string) (*models.User, error) {
+	if discordUserID == "" {
+		return nil, ErrUserNotFound
+	}
+
+	var user models.User
+	err := a.db.WithContext(ctx).Where("discord_user_id = ?", discordUserID).First(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return &user, nil
+}
@@ -73,6 +73,8 @@ func (m *MockJWTService) ValidateToken(tokenString string) (*models.JWTClaims, e
 
 type MockRedisService struct {
 	mock.Mock
+	// updated records the result of every UpdateSession call
+	updated []*models.Session
 }
 
 func (m *MockRedisService) StoreSession(ctx context.Context, session *models.Session) error {
@@ -88,6 +90,22 @@ func (m *MockRedisService) GetSession(ctx context.Context, sessionID string) (*m
 	return args.Get(0).(*models.Session), args.Error(1)
 }
 
+func (m *MockRedisService) UpdateSession(ctx context.Context, sessionID string, update func(*models.Session)) (*models.Session, error) {
+	args := m.Called(ctx, sessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	session := *args.Get(0).(*models.Session)
+	update(&session)
+	m.updated = append(m.updated, &session)
+	return &session, args.Error(1)
+}
+
+func (m *MockRedisService) StoreRefreshToken(ctx context.Context, session *models.Session) error {
+	args := m.Called(ctx, session)
+	return args.Error(0)
+}
+
 func (m *MockRedisService) GetSessionByRefreshToken(ctx context.Context, refreshToken string) (*models.Session, error) {
 	args := m.Called(ctx, refreshToken)
 	if args.Get(0) == nil {
@@ -96,11 +114,55 @@ func (m *MockRedisService) GetSessionByRefreshToken(ctx context.Context, refresh
 	return args.Get(0).(*models.Session), args.Error(1)
 }
 
+func (m *MockRedisService) ConsumeRefreshToken(ctx context.Context, refreshToken string, retiredUntil time.Time) (*models.Session, error) {
+	args := m.Called(ctx, refreshToken, retiredUntil)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Session), args.Error(1)
+}
+
+func (m *MockRedisService) GetRetiredRefreshToken(ctx context.Context, refreshToken string) (string, error) {
+	args := m.Called(ctx, refreshToken)
+	return args.String(0), args.Error(1)
+}
+
 func (m *MockRedisService) DeleteSession(ctx context.Context, sessionID string) error {
 	args := m.Called(ctx, sessionID)
 	return args.Error(0)
 }
 
+func (m *MockRedisService) ListUserSessions(ctx context.Context, userID string) ([]*models.Session, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Session), args.Error(1)
+}
+
+func (m *MockRedisService) StoreTwoFactorChallenge(ctx context.Context, challenge *models.TwoFactorChallenge) error {
+	args := m.Called(ctx, challenge)
+	return args.Error(0)
+}
+
+func (m *MockRedisService) GetTwoFactorChallenge(ctx context.Context, challengeID string) (*models.TwoFactorChallenge, error) {
+	args := m.Called(ctx, challengeID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TwoFactorChallenge), args.Error(1)
+}
+
+func (m *MockRedisService) CountTwoFactorChallengeAttempt(ctx context.Context, challenge *models.TwoFactorChallenge) (int64, error) {
+	args := m.Called(ctx, challenge.ID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRedisService) DeleteTwoFactorChallenge(ctx context.Context, challengeID string) error {
+	args := m.Called(ctx, challengeID)
+	return args.Error(0)
+}
+
 func TestAuthService_GetAuthURL(t *testing.T) {
 	mockDiscord := new(MockDiscordService)
 	mockJWT := new(MockJWTService)
@@ -269,6 +331,7 @@ func TestAuthService_RefreshToken(t *testing.T) {
 		refreshToken  string
 		setupMocks    func(*MockDiscordService, *MockJWTService, *MockRedisService)
 		expectedError bool
+		expectedErr   error
 		checkResult   func(*testing.T, *models.AuthResponse)
 	}{
 		{
@@ -291,18 +354,49 @@ func TestAuthService_RefreshToken(t *testing.T) {
 				}
 
 				jwt.On("ValidateToken", "valid_refresh_token").Return(claims, nil)
-				redis.On("GetSessionByRefreshToken", mock.Anything, "valid_refresh_token").Return(session, nil)
+				redis.On("ConsumeRefreshToken", mock.Anything, "valid_refresh_token", mock.AnythingOfType("time.Time")).Return(session, nil)
 
 				expiresAt := time.Now().Add(time.Hour)
 				jwt.On("GenerateAccessToken", mock.AnythingOfType("*models.User"), "session_id").Return("new_access_token", expiresAt, nil)
-				redis.On("StoreSession", mock.Anything, mock.AnythingOfType("*models.Session")).Return(nil)
+				jwt.On("GenerateRefreshToken", mock.AnythingOfType("*models.User"), "session_id").Return("new_refresh_token", expiresAt, nil)
+				redis.On("UpdateSession", mock.Anything, "session_id").Return(session, nil)
+				redis.On("StoreRefreshToken", mock.Anything, mock.MatchedBy(func(s *models.Session) bool {
+					return s.RefreshToken == "new_refresh_token" && s.AccessToken == "new_access_token"
+				})).Return(nil)
 			},
 			expectedError: false,
 			checkResult: func(t *testing.T, result *models.AuthResponse) {
 				assert.Equal(t, "new_access_token", result.AccessToken)
-				assert.Equal(t, "valid_refresh_token", result.RefreshToken)
+				assert.Equal(t, "new_refresh_token", result.RefreshToken)
 			},
 		},
+		{
+			name:         "session revoked during refresh stays revoked",
+			refreshToken: "valid_refresh_token",
+			setupMocks: func(discord *MockDiscordService, jwt *MockJWTService, redis *MockRedisService) {
+				claims := &models.JWTClaims{
+					UserID:    "user_id",
+					SessionID: "session_id",
+				}
+				session := &models.Session{
+					ID:           "session_id",
+					UserID:       "user_id",
+					RefreshToken: "valid_refresh_token",
+					ExpiresAt:    time.Now().Add(time.Hour),
+				}
+
+				jwt.On("ValidateToken", "valid_refresh_token").Return(claims, nil)
+				redis.On("ConsumeRefreshToken", mock.Anything, "valid_refresh_token", mock.AnythingOfType("time.Time")).Return(session, nil)
+
+				expiresAt := time.Now().Add(time.Hour)
+				jwt.On("GenerateAccessToken", mock.AnythingOfType("*models.User"), "session_id").Return("new_access_token", expiresAt, nil)
+				jwt.On("GenerateRefreshToken", mock.AnythingOfType("*models.User"), "session_id").Return("new_refresh_token", expiresAt, nil)
+				// The session was deleted after the refresh token was consumed
+				redis.On("UpdateSession", mock.Anything, "session_id").Return(nil, ErrSessionNotFound)
+			},
+			expectedError: true,
+			expectedErr:   ErrSessionNotFound,
+		},
 		{
 			name:         "invalid refresh token",
 			refreshToken: "invalid_refresh_token",
@@ -323,9 +417,27 @@ func TestAuthService_RefreshToken(t *testing.T) {
 				}
 
 				jwt.On("ValidateToken", "valid_refresh_token").Return(claims, nil)
-				redis.On("GetSessionByRefreshToken", mock.Anything, "valid_refresh_token").Return(nil, errors.New("session not found"))
+				redis.On("ConsumeRefreshToken", mock.Anything, "valid_refresh_token", mock.AnythingOfType("time.Time")).Return(nil, ErrRefreshTokenNotFound)
+				redis.On("GetRetiredRefreshToken", mock.Anything, "valid_refresh_token").Return("", ErrRefreshTokenNotFound)
+			},
+			expectedError: true,
+		},
+		{
+			name:         "reused refresh token revokes session",
+			refreshToken: "retired_refresh_token",
+			setupMocks: func(discord *MockDiscordService, jwt *MockJWTService, redis *MockRedisService) {
+				claims := &models.JWTClaims{
+					UserID:    "user_id",
+					SessionID: "session_id",
+				}
+
+				jwt.On("ValidateToken", "retired_refresh_token").Return(claims, nil)
+				redis.On("ConsumeRefreshToken", mock.Anything, "retired_refresh_token", mock.AnythingOfType("time.Time")).Return(nil, ErrRefreshTokenNotFound)
+				redis.On("GetRetiredRefreshToken", mock.Anything, "retired_refresh_token").Return("session_id", nil)
+				redis.On("DeleteSession", mock.Anything, "session_id").Return(nil)
 			},
 			expectedError: true,
+			expectedErr:   ErrRefreshTokenReused,
 		},
 		{
 			name:         "expired session",
@@ -347,7 +459,7 @@ func TestAuthService_RefreshToken(t *testing.T) {
 				}
 
 				jwt.On("ValidateToken", "valid_refresh_token").Return(claims, nil)
-				redis.On("GetSessionByRefreshToken", mock.Anything, "valid_refresh_token").Return(session, nil)
+				redis.On("ConsumeRefreshToken", mock.Anything, "valid_refresh_token", mock.AnythingOfType("time.Time")).Return(session, nil)
 				redis.On("DeleteSession", mock.Anything, "session_id").Return(nil)
 			},
 			expectedError: true,
@@ -369,6 +481,9 @@ func TestAuthService_RefreshToken(t *testing.T) {
 			if tt.expectedError {
 				assert.Error(t, err)
 				assert.Nil(t, result)
+				if tt.expectedErr != nil {
+					assert.ErrorIs(t, err, tt.expectedErr)
+				}
 			} else {
 				assert.NoError(t, err)
 				assert.NotNil(t, result)
@@ -406,6 +521,7 @@ func TestAuthService_ValidateAccessToken(t *testing.T) {
 					AccessToken:  "valid_access_token",
 					RefreshToken: "refresh_token",
 					ExpiresAt:    time.Now().Add(time.Hour),
+					LastSeenAt:   time.Now(),
 					CreatedAt:    time.Now(),
 				}
 
@@ -419,6 +535,50 @@ func TestAuthService_ValidateAccessToken(t *testing.T) {
 				assert.Equal(t, "testuser", user.Username)
 			},
 		},
+		{
+			name:        "stale last seen time is updated",
+			accessToken: "valid_access_token",
+			setupMocks: func(discord *MockDiscordService, jwt *MockJWTService, redis *MockRedisService) {
+				claims := &models.JWTClaims{
+					UserID:    "user_id",
+					SessionID: "session_id",
+				}
+				session := &models.Session{
+					ID:         "session_id",
+					UserID:     "user_id",
+					ExpiresAt:  time.Now().Add(time.Hour),
+					LastSeenAt: time.Now().Add(-time.Hour),
+				}
+
+				jwt.On("ValidateToken", "valid_access_token").Return(claims, nil)
+				redis.On("GetSession", mock.Anything, "session_id").Return(session, nil)
+				redis.On("UpdateSession", mock.Anything, "session_id").Return(session, nil)
+			},
+			expectedError: false,
+			checkResult: func(t *testing.T, user *models.User) {
+				assert.Equal(t, "user_id", user.ID)
+			},
+		},
+		{
+			name:        "session belongs to another user",
+			accessToken: "valid_access_token",
+			setupMocks: func(discord *MockDiscordService, jwt *MockJWTService, redis *MockRedisService) {
+				claims := &models.JWTClaims{
+					UserID:    "user_id",
+					SessionID: "session_id",
+				}
+				session := &models.Session{
+					ID:         "session_id",
+					UserID:     "other_user_id",
+					ExpiresAt:  time.Now().Add(time.Hour),
+					LastSeenAt: time.Now(),
+				}
+
+				jwt.On("ValidateToken", "valid_access_token").Return(claims, nil)
+				redis.On("GetSession", mock.Anything, "session_id").Return(session, nil)
+			},
+			expectedError: true,
+		},
 		{
 			name:        "invalid access token",
 			accessToken: "invalid_access_token",
@@ -572,6 +732,59 @@ func TestAuthService_Logout(t *testing.T) {
 	}
 }
 
+func TestAuthService_Sessions(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	sessions := []*models.Session{
+		{ID: "older", UserID: "user_id", UserAgent: "curl/8.0", IPAddress: "10.0.0.1", ExpiresAt: now.Add(time.Hour), LastSeenAt: now.Add(-time.Hour)},
+		{ID: "current", UserID: "user_id", UserAgent: "Firefox", IPAddress: "10.0.0.2", ExpiresAt: now.Add(time.Hour), LastSeenAt: now},
+	}
+
+	t.Run("list", func(t *testing.T) {
+		mockRedis := new(MockRedisService)
+		mockRedis.On("ListUserSessions", ctx, "user_id").Return(sessions, nil)
+		authService := NewAuthService(nil, new(MockDiscordService), new(MockJWTService), mockRedis)
+
+		infos, err := authService.ListSessions(ctx, "user_id", "current")
+		require.NoError(t, err)
+		require.Len(t, infos, 2)
+		assert.Equal(t, "current", infos[0].ID)
+		assert.True(t, infos[0].Current)
+		assert.Equal(t, "Firefox", infos[0].UserAgent)
+		assert.Equal(t, "older", infos[1].ID)
+		assert.False(t, infos[1].Current)
+	})
+
+	t.Run("revoke one", func(t *testing.T) {
+		mockRedis := new(MockRedisService)
+		mockRedis.On("GetSession", ctx, "older").Return(sessions[0], nil)
+		mockRedis.On("GetSession", ctx, "missing").Return(nil, errors.New("session not found"))
+		mockRedis.On("DeleteSession", ctx, "older").Return(nil)
+		authService := NewAuthService(nil, new(MockDiscordService), new(MockJWTService), mockRedis)
+
+		// Sessions of other users look the same as missing ones
+		assert.ErrorIs(t, authService.RevokeSession(ctx, "other_user_id", "older"), ErrSessionNotFound)
+		assert.ErrorIs(t, authService.RevokeSession(ctx, "user_id", "missing"), ErrSessionNotFound)
+		mockRedis.AssertNotCalled(t, "DeleteSession", mock.Anything, mock.Anything)
+
+		require.NoError(t, authService.RevokeSession(ctx, "user_id", "older"))
+		mockRedis.AssertExpectations(t)
+	})
+
+	t.Run("revoke all", func(t *testing.T) {
+		mockRedis := new(MockRedisService)
+		mockRedis.On("ListUserSessions", ctx, "user_id").Return(sessions, nil)
+		mockRedis.On("DeleteSession", ctx, "older").Return(nil)
+		mockRedis.On("DeleteSession", ctx, "current").Return(nil)
+		authService := NewAuthService(nil, new(MockDiscordService), new(MockJWTService), mockRedis)
+
+		revoked, err := authService.RevokeAllSessions(ctx, "user_id")
+		require.NoError(t, err)
+		assert.Equal(t, 2, revoked)
+		mockRedis.AssertExpectations(t)
+	})
+}
+
 func TestAuthService_SuperAdminRoleAssignment(t *testing.T) {
 	// Setup test database
 	db, cleanup := setupTestDatabaseWithModels(t)
@@ -1019,6 +1232,7 @@ func TestAuthService_HandleCallback_WithRBAC(t *testing.T) {
 func setupTestDatabaseWithModels(t *testing.T) (*gorm.DB, func()) {
 	return testutils.SetupTestDatabaseWithModels(t,
 		&models.User{},
+		&models.UserIdentity{},
 		&models.Session{},
 		&models.UserTenant{},
 		&models.Tenant{},
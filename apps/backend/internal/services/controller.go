@@ -5,7 +5,9 @@ import (
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"log"
 	"strings"
 	"time"
 
@@ -18,9 +20,28 @@ import (
 
 // ControllerService handles controller registration and heartbeat management
 type ControllerService struct {
-	db     *gorm.DB
-	config *config.Config
-	jwt    *JWTService
+	db       *gorm.DB
+	config   *config.Config
+	jwt      *JWTService
+	notifier NotificationServiceInterface
+}
+
+var (
+	// ErrInvalidServerEvent is returned when a controller reports an unknown event type
+	ErrInvalidServerEvent = errors.New("invalid server event")
+	// ErrControllerNotFound is returned when a controller does not exist
+	ErrControllerNotFound = errors.New("controller not found")
+	// ErrControllerNotApproved is returned when a controller has not been approved to run servers
+	ErrControllerNotApproved = errors.New("controller not approved")
+)
+
+// serverEventPhases maps controller-reported events to the server phase they imply
+var serverEventPhases = map[string]string{
+	models.NotificationServerCrashed:   "Failed",
+	models.NotificationServerStarted:   "Running",
+	models.NotificationServerStopped:   "Stopped",
+	models.NotificationBackupCompleted: "",
+	models.NotificationResourceLimit:   "",
 }
 
 // NewControllerService creates a new controller service
@@ -32,6 +53,17 @@ func NewControllerService(db *gorm.DB, config *config.Config, jwt *JWTService) *
 	}
 }
 
+// NewControllerServiceWithNotifier creates a new controller service that notifies
+// tenants about controller and server events
+func NewControllerServiceWithNotifier(db *gorm.DB, config *config.Config, jwt *JWTService, notifier NotificationServiceInterface) *ControllerService {
+	return &ControllerService{
+		db:       db,
+		config:   config,
+		jwt:      jwt,
+		notifier: notifier,
+	}
+}
+
 // Handshake performs the initial controller registration and authentication
 func (s *ControllerService) Handshake(ctx context.Context, req *models.HandshakeRequest) (*models.HandshakeResponse, error) {
 	// Validate the handshake secret if configured
@@ -315,9 +347,8 @@ func (s *ControllerService) GetControllerStatus(ctx context.Context, controllerI
 
 	// Auto-transition to degraded status if controller is offline and was previously active
 	if !isOnline && controller.Status == "active" {
-		controller.Status = "degraded"
-		if err := s.db.WithContext(ctx).Save(&controller).Error; err != nil {
-			return nil, fmt.Errorf("failed to update controller status to degraded: %w", err)
+		if err := s.markDegraded(ctx, &controller); err != nil {
+			return nil, err
 		}
 	}
 
@@ -355,9 +386,8 @@ func (s *ControllerService) GetAllControllers(ctx context.Context) ([]*models.Co
 
 		// Auto-transition to degraded status if controller is offline and was previously active
 		if !isOnline && controller.Status == "active" {
-			controller.Status = "degraded"
-			if err := s.db.WithContext(ctx).Save(&controller).Error; err != nil {
-				return nil, fmt.Errorf("failed to update controller status to degraded: %w", err)
+			if err := s.markDegraded(ctx, &controller); err != nil {
+				return nil, err
 			}
 		}
 
@@ -384,6 +414,155 @@ func (s *ControllerService) GetAllControllers(ctx context.Context) ([]*models.Co
 	return statuses, nil
 }
 
+// CheckOfflineControllers moves active controllers that stopped sending heartbeats to degraded
+func (s *ControllerService) CheckOfflineControllers(ctx context.Context) error {
+	cutoff := time.Now().UTC().Add(-s.config.Controller.MaxHeartbeatAge)
+
+	var controllers []models.Controller
+	err := s.db.WithContext(ctx).Where("status = ? AND last_heartbeat < ?", "active", cutoff).Find(&controllers).Error
+	if err != nil {
+		return fmt.Errorf("failed to get offline controllers: %w", err)
+	}
+
+	for idx := range controllers {
+		if err := s.markDegraded(ctx, &controllers[idx]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// StartOfflineMonitor periodically checks for controllers that went offline
+func (s *ControllerService) StartOfflineMonitor(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := s.CheckOfflineControllers(context.Background()); err != nil {
+				log.Printf("Failed to check offline controllers: %v", err)
+			}
+		}
+	}()
+}
+
+// markDegraded moves a controller to degraded and notifies tenants with servers on its cluster
+func (s *ControllerService) markDegraded(ctx context.Context, controller *models.Controller) error {
+	controller.Status = "degraded"
+	if err := s.db.WithContext(ctx).Save(controller).Error; err != nil {
+		return fmt.Errorf("failed to update controller status to degraded: %w", err)
+	}
+
+	if s.notifier != nil {
+		clusterID, clusterName := controller.ClusterID, controller.ClusterName
+		// Delivery may wait on Discord rate limits, so it must not hold up the caller
+		go func() {
+			if err := s.notifier.NotifyControllerOffline(context.Background(), clusterID, clusterName); err != nil {
+				log.Printf("Failed to notify tenants about offline controller %s: %v", clusterID, err)
+			}
+		}()
+	}
+
+	return nil
+}
+
+// AssignGameServer places a game server on a controller's cluster. The controller
+// may then report events for the server and its tenant is told when it goes offline.
+func (s *ControllerService) AssignGameServer(ctx context.Context, controllerID, serverID string) (*models.GameServer, error) {
+	if !s.validateUUID(controllerID) {
+		return nil, ErrControllerNotFound
+	}
+	if !s.validateUUID(serverID) {
+		return nil, ErrGameServerNotFound
+	}
+
+	var controller models.Controller
+	err := s.db.WithContext(ctx).Where("id = ?", controllerID).First(&controller).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrControllerNotFound
+		}
+		return nil, fmt.Errorf("failed to get controller: %w", err)
+	}
+	if controller.ApprovedAt == nil {
+		return nil, ErrControllerNotApproved
+	}
+
+	var server models.GameServer
+	err = s.db.WithContext(ctx).Where("id = ?", serverID).First(&server).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrGameServerNotFound
+		}
+		return nil, fmt.Errorf("failed to get game server: %w", err)
+	}
+
+	server.ClusterID = controller.ClusterID
+	if err := s.db.WithContext(ctx).Model(&server).Update("cluster_id", server.ClusterID).Error; err != nil {
+		return nil, fmt.Errorf("failed to assign game server: %w", err)
+	}
+
+	return &server, nil
+}
+
+// ReportServerEvent records a game server event reported by a controller and notifies its tenant
+func (s *ControllerService) ReportServerEvent(ctx context.Context, controllerID string, req *models.ServerEventRequest) error {
+	phase, ok := serverEventPhases[req.Type]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrInvalidServerEvent, req.Type)
+	}
+
+	if !s.validateUUID(controllerID) || !s.validateUUID(req.ServerID) {
+		return ErrGameServerNotFound
+	}
+
+	var controller models.Controller
+	err := s.db.WithContext(ctx).Where("id = ?", controllerID).First(&controller).Error
+	if err != nil {
+		return fmt.Errorf("failed to get controller: %w", err)
+	}
+
+	// Controllers may only report on servers in their own cluster
+	var server models.GameServer
+	err = s.db.WithContext(ctx).Where("id = ? AND cluster_id = ?", req.ServerID, controller.ClusterID).First(&server).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return ErrGameServerNotFound
+		}
+		return fmt.Errorf("failed to get game server: %w", err)
+	}
+
+	if phase != "" {
+		server.Status.Phase = phase
+		server.Status.Message = req.Message
+		server.Status.LastUpdated = time.Now()
+		if err := s.db.WithContext(ctx).Model(&server).Update("status", server.Status).Error; err != nil {
+			return fmt.Errorf("failed to update game server status: %w", err)
+		}
+	}
+
+	if s.notifier != nil {
+		event := models.NotificationEvent{
+			Type:       req.Type,
+			TenantID:   server.TenantID,
+			ServerID:   server.ID,
+			ServerName: server.Name,
+			ClusterID:  controller.ClusterID,
+			Message:    req.Message,
+			Details:    req.Details,
+			OccurredAt: time.Now().UTC(),
+		}
+		go func() {
+			if err := s.notifier.Notify(context.Background(), event); err != nil {
+				log.Printf("Failed to deliver %s notification for server %s: %v", event.Type, event.ServerID, err)
+			}
+		}()
+	}
+
+	return nil
+}
+
 // CleanupInactiveControllers removes controllers that haven't sent heartbeats
 func (s *ControllerService) CleanupInactiveControllers(ctx context.Context) error {
 	cutoff := time.Now().UTC().Add(-s.config.Controller.MaxHeartbeatAge * 2) // Double the max age for cleanup
@@ -424,19 +603,13 @@ func (s *ControllerService) generateControllerToken(controllerID, clusterID stri
 		"iss":        s.config.JWT.Issuer,
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, _ := token.SignedString([]byte(s.config.JWT.Secret))
+	tokenString, _ := s.jwt.sign(claims)
 	return tokenString
 }
 
 // ValidateControllerToken validates a controller JWT token
 func (s *ControllerService) ValidateControllerToken(tokenString string) (string, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(s.config.JWT.Secret), nil
-	})
+	token, err := s.jwt.parse(tokenString, jwt.MapClaims{})
 
 	if err != nil {
 		return "", fmt.Errorf("failed to parse token: %w", err)
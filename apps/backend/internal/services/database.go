@@ -38,9 +38,17 @@ func (ds *DatabaseService) AutoMigrate() error {
 	
 	err := ds.DB.AutoMigrate(
 		&models.User{},
+		&models.UserIdentity{},
+		&models.UserTOTP{},
+		&models.TOTPRecoveryCode{},
+		&models.SystemSetting{},
 		&models.Session{},
+		&models.ServiceAccount{},
+		&models.APIToken{},
 		&models.Tenant{},
 		&models.UserTenant{},
+		&models.TenantInvite{},
+		&models.OwnershipTransfer{},
 		&models.TenantDiscordRole{},
 		&models.TenantDiscordUser{},
 		&models.GameServer{},
@@ -50,6 +58,7 @@ func (ds *DatabaseService) AutoMigrate() error {
 		&models.SystemRole{},
 		&models.UserSystemRole{},
 		&models.PermissionAuditLog{},
+		&models.ResourceGrant{},
 		&models.GuildMembershipCache{},
 	)
 	if err != nil {
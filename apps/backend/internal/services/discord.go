@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -14,6 +15,13 @@ import (
 	"golang.org/x/oauth2"
 )
 
+// ErrDiscordGrantRevoked is returned when Discord refuses a refresh token, for
+// example because the user removed the app from their authorized apps
+var ErrDiscordGrantRevoked = errors.New("discord authorization revoked")
+
+// ErrDiscordUnauthorized is returned when Discord rejects a user's access token
+var ErrDiscordUnauthorized = errors.New("discord access token rejected")
+
 // DiscordService handles Discord OAuth2 and API operations
 type DiscordService struct {
 	config      *config.DiscordConfig
@@ -134,6 +142,9 @@ func (d *DiscordService) RefreshToken(ctx context.Context, refreshToken string)
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode == http.StatusBadRequest && strings.Contains(string(body), "invalid_grant") {
+			return nil, fmt.Errorf("%w: %s", ErrDiscordGrantRevoked, string(body))
+		}
 		return nil, fmt.Errorf("discord refresh token error: %d - %s", resp.StatusCode, string(body))
 	}
 
@@ -169,6 +180,9 @@ func (d *DiscordService) GetUserGuilds(ctx context.Context, accessToken string)
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode == http.StatusUnauthorized {
+			return nil, fmt.Errorf("%w: %s", ErrDiscordUnauthorized, string(body))
+		}
 		return nil, fmt.Errorf("discord guilds API error: %d - %s", resp.StatusCode, string(body))
 	}
 
@@ -221,6 +235,41 @@ func (d *DiscordService) GetGuildRoles(ctx context.Context, botToken, guildID st
 	return roles, nil
 }
 
+// GetGuildChannels retrieves the channels of a specific guild
+func (d *DiscordService) GetGuildChannels(ctx context.Context, botToken, guildID string) ([]models.DiscordChannel, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", d.config.APIBaseURL+"/guilds/"+guildID+"/channels", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create guild channels request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bot "+botToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make guild channels request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("discord guild channels API error: %d - %s", resp.StatusCode, string(body))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read guild channels response body: %w", err)
+	}
+
+	var channels []models.DiscordChannel
+	err = json.Unmarshal(body, &channels)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal guild channels data: %w", err)
+	}
+
+	return channels, nil
+}
+
 // GetGuildMembers retrieves members for a specific guild
 func (d *DiscordService) GetGuildMembers(ctx context.Context, botToken, guildID string, limit int) ([]models.DiscordMember, error) {
 	url := fmt.Sprintf("%s/guilds/%s/members?limit=%d", d.config.APIBaseURL, guildID, limit)
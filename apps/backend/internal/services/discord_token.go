@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+)
+
+// ErrDiscordTokenMissing is returned when a session has no Discord access token,
+// e.g. because the user logged in through another identity provider
+var ErrDiscordTokenMissing = errors.New("session has no discord access token")
+
+// ErrDiscordRelinkRequired is returned when Discord no longer accepts the
+// session's tokens and the user has to authorize Pteronimbus again
+var ErrDiscordRelinkRequired = errors.New("discord authorization must be renewed")
+
+// discordTokenRefreshMargin is how long before expiry a Discord token is refreshed
+const discordTokenRefreshMargin = 5 * time.Minute
+
+// GetDiscordGuilds lists the Discord guilds of a session's user. The session's
+// Discord token is refreshed first when it is about to expire, and once more if
+// Discord rejects it anyway.
+func (a *AuthService) GetDiscordGuilds(ctx context.Context, sessionID string) ([]models.DiscordGuild, error) {
+	session, err := a.redisService.GetSession(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	if session.DiscordAccessToken == "" {
+		return nil, ErrDiscordTokenMissing
+	}
+
+	if !session.DiscordTokenExpiresAt.IsZero() && time.Until(session.DiscordTokenExpiresAt) < discordTokenRefreshMargin {
+		if err := a.refreshDiscordToken(ctx, session); err != nil {
+			return nil, err
+		}
+	}
+
+	guilds, err := a.discordService.GetUserGuilds(ctx, session.DiscordAccessToken)
+	if errors.Is(err, ErrDiscordUnauthorized) {
+		// The token expired without us knowing, or was revoked
+		if err := a.refreshDiscordToken(ctx, session); err != nil {
+			return nil, err
+		}
+		guilds, err = a.discordService.GetUserGuilds(ctx, session.DiscordAccessToken)
+		if errors.Is(err, ErrDiscordUnauthorized) {
+			return nil, ErrDiscordRelinkRequired
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get discord guilds: %w", err)
+	}
+
+	return guilds, nil
+}
+
+// refreshDiscordToken exchanges the session's Discord refresh token for new
+// tokens and stores them. When Discord refuses the grant, the stale tokens are
+// dropped from the session and ErrDiscordRelinkRequired is returned.
+func (a *AuthService) refreshDiscordToken(ctx context.Context, session *models.Session) error {
+	if session.DiscordRefreshToken == "" {
+		return ErrDiscordRelinkRequired
+	}
+
+	token, err := a.discordService.RefreshToken(ctx, session.DiscordRefreshToken)
+	if err != nil {
+		if !errors.Is(err, ErrDiscordGrantRevoked) {
+			return fmt.Errorf("failed to refresh discord token: %w", err)
+		}
+
+		// Discord rotates refresh tokens, so a concurrent request may have
+		// refreshed this session already. Only the revoked tokens are cleared.
+		revoked := session.DiscordRefreshToken
+		current, updateErr := a.redisService.UpdateSession(ctx, session.ID, func(s *models.Session) {
+			if s.DiscordRefreshToken == revoked {
+				s.DiscordAccessToken = ""
+				s.DiscordRefreshToken = ""
+				s.DiscordTokenExpiresAt = time.Time{}
+			}
+		})
+		if updateErr != nil {
+			fmt.Printf("Warning: failed to clear revoked Discord tokens of session %s: %v\n", session.ID, updateErr)
+			return ErrDiscordRelinkRequired
+		}
+
+		*session = *current
+		if session.DiscordAccessToken == "" {
+			return ErrDiscordRelinkRequired
+		}
+		return nil
+	}
+
+	updated, err := a.redisService.UpdateSession(ctx, session.ID, func(s *models.Session) {
+		setDiscordToken(s, token)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store refreshed discord token: %w", err)
+	}
+
+	*session = *updated
+	return nil
+}
+
+// setDiscordToken records Discord OAuth tokens and their expiry on a session
+func setDiscordToken(session *models.Session, token *models.DiscordTokenResponse) {
+	session.DiscordAccessToken = token.AccessToken
+	session.DiscordRefreshToken = token.RefreshToken
+	session.DiscordTokenExpiresAt = time.Time{}
+	if token.ExpiresIn > 0 {
+		session.DiscordTokenExpiresAt = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthService_GetDiscordGuilds(t *testing.T) {
+	guilds := []models.DiscordGuild{{ID: "guild-1", Name: "Guild"}}
+	refreshed := &models.DiscordTokenResponse{AccessToken: "new_access", RefreshToken: "new_refresh", ExpiresIn: 604800}
+
+	newSession := func(expiresIn time.Duration) *models.Session {
+		return &models.Session{
+			ID:                    "session_id",
+			UserID:                "user_id",
+			DiscordAccessToken:    "old_access",
+			DiscordRefreshToken:   "old_refresh",
+			DiscordTokenExpiresAt: time.Now().Add(expiresIn),
+			ExpiresAt:             time.Now().Add(time.Hour),
+		}
+	}
+
+	tests := []struct {
+		name        string
+		setupMocks  func(*MockDiscordService, *MockRedisService)
+		expectedErr error
+	}{
+		{
+			name: "valid token is used as is",
+			setupMocks: func(discord *MockDiscordService, redis *MockRedisService) {
+				redis.On("GetSession", mock.Anything, "session_id").Return(newSession(time.Hour), nil)
+				discord.On("GetUserGuilds", mock.Anything, "old_access").Return(guilds, nil)
+			},
+		},
+		{
+			name: "expiring token is refreshed first",
+			setupMocks: func(discord *MockDiscordService, redis *MockRedisService) {
+				redis.On("GetSession", mock.Anything, "session_id").Return(newSession(time.Minute), nil)
+				discord.On("RefreshToken", mock.Anything, "old_refresh").Return(refreshed, nil)
+				redis.On("UpdateSession", mock.Anything, "session_id").Return(newSession(time.Minute), nil)
+				discord.On("GetUserGuilds", mock.Anything, "new_access").Return(guilds, nil)
+			},
+		},
+		{
+			name: "rejected token is refreshed and retried",
+			setupMocks: func(discord *MockDiscordService, redis *MockRedisService) {
+				redis.On("GetSession", mock.Anything, "session_id").Return(newSession(time.Hour), nil)
+				discord.On("GetUserGuilds", mock.Anything, "old_access").Return(nil, ErrDiscordUnauthorized)
+				discord.On("RefreshToken", mock.Anything, "old_refresh").Return(refreshed, nil)
+				redis.On("UpdateSession", mock.Anything, "session_id").Return(newSession(time.Hour), nil)
+				discord.On("GetUserGuilds", mock.Anything, "new_access").Return(guilds, nil)
+			},
+		},
+		{
+			name: "revoked grant requires relinking",
+			setupMocks: func(discord *MockDiscordService, redis *MockRedisService) {
+				redis.On("GetSession", mock.Anything, "session_id").Return(newSession(time.Minute), nil)
+				discord.On("RefreshToken", mock.Anything, "old_refresh").Return(nil, ErrDiscordGrantRevoked)
+				redis.On("UpdateSession", mock.Anything, "session_id").Return(newSession(time.Minute), nil)
+			},
+			expectedErr: ErrDiscordRelinkRequired,
+		},
+		{
+			name: "revoked grant was already refreshed concurrently",
+			setupMocks: func(discord *MockDiscordService, redis *MockRedisService) {
+				current := newSession(time.Hour)
+				current.DiscordAccessToken = "new_access"
+				current.DiscordRefreshToken = "new_refresh"
+				redis.On("GetSession", mock.Anything, "session_id").Return(newSession(time.Minute), nil)
+				discord.On("RefreshToken", mock.Anything, "old_refresh").Return(nil, ErrDiscordGrantRevoked)
+				redis.On("UpdateSession", mock.Anything, "session_id").Return(current, nil)
+				discord.On("GetUserGuilds", mock.Anything, "new_access").Return(guilds, nil)
+			},
+		},
+		{
+			name: "session without discord token",
+			setupMocks: func(discord *MockDiscordService, redis *MockRedisService) {
+				redis.On("GetSession", mock.Anything, "session_id").Return(&models.Session{ID: "session_id"}, nil)
+			},
+			expectedErr: ErrDiscordTokenMissing,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDiscord := new(MockDiscordService)
+			mockRedis := new(MockRedisService)
+			tt.setupMocks(mockDiscord, mockRedis)
+
+			authService := NewAuthService(nil, mockDiscord, new(MockJWTService), mockRedis)
+			result, err := authService.GetDiscordGuilds(context.Background(), "session_id")
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, guilds, result)
+			}
+			mockDiscord.AssertExpectations(t)
+			mockRedis.AssertExpectations(t)
+		})
+	}
+}
@@ -2,12 +2,25 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
 	"gorm.io/gorm"
 )
 
+// Game server power actions
+const (
+	PowerActionStart   = "start"
+	PowerActionStop    = "stop"
+	PowerActionRestart = "restart"
+)
+
+// ErrGameServerNotFound is returned when a game server does not exist in a tenant
+var ErrGameServerNotFound = errors.New("game server not found")
+
 // GameServerService implements GameServerServiceInterface
 type GameServerService struct {
 	db *gorm.DB
@@ -23,57 +36,9 @@ func NewGameServerService(db *gorm.DB) GameServerServiceInterface {
 // GetTenantServers retrieves all game servers for a tenant
 func (gss *GameServerService) GetTenantServers(ctx context.Context, tenantID string) ([]models.GameServer, error) {
 	var servers []models.GameServer
-
-	// For now, return mock data until we implement the full database integration
-	// In a real implementation, this would query the database:
-	// err := gss.db.WithContext(ctx).Where("tenant_id = ?", tenantID).Find(&servers).Error
-
-	servers = []models.GameServer{
-		{
-			ID:         "server-1",
-			TenantID:   tenantID,
-			TemplateID: "template-minecraft",
-			Name:       "Survival World",
-			GameType:   "minecraft",
-			Config: models.GameServerConfig{
-				Environment: map[string]string{
-					"EULA":        "TRUE",
-					"DIFFICULTY":  "normal",
-					"MAX_PLAYERS": "20",
-				},
-			},
-			Status: models.GameServerStatus{
-				Phase:       "Running",
-				Message:     "Server is running normally",
-				LastUpdated: time.Now(),
-				PlayerCount: 5,
-				Uptime:      "2h 30m",
-			},
-			CreatedAt: time.Now().Add(-24 * time.Hour),
-			UpdatedAt: time.Now(),
-		},
-		{
-			ID:         "server-2",
-			TenantID:   tenantID,
-			TemplateID: "template-cs2",
-			Name:       "Competitive Server",
-			GameType:   "cs2",
-			Config: models.GameServerConfig{
-				Environment: map[string]string{
-					"GAME_MODE": "competitive",
-					"MAP":       "de_dust2",
-				},
-			},
-			Status: models.GameServerStatus{
-				Phase:       "Stopped",
-				Message:     "Server is stopped",
-				LastUpdated: time.Now().Add(-1 * time.Hour),
-				PlayerCount: 0,
-				Uptime:      "0m",
-			},
-			CreatedAt: time.Now().Add(-48 * time.Hour),
-			UpdatedAt: time.Now().Add(-1 * time.Hour),
-		},
+	err := gss.db.WithContext(ctx).Where("tenant_id = ?", tenantID).Order("name").Find(&servers).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get game servers: %w", err)
 	}
 
 	return servers, nil
@@ -158,3 +123,44 @@ func (gss *GameServerService) GetTenantDiscordStats(ctx context.Context, tenantI
 	}
 	return stats, nil
 }
+
+// RequestPowerAction records a start, stop or restart request for a game server.
+// The server is moved into a transitional phase which the controller reconciles.
+func (gss *GameServerService) RequestPowerAction(ctx context.Context, tenantID, serverID, action string) (*models.GameServer, error) {
+	var phase string
+	switch action {
+	case PowerActionStart:
+		phase = "Starting"
+	case PowerActionStop:
+		phase = "Stopping"
+	case PowerActionRestart:
+		phase = "Restarting"
+	default:
+		return nil, fmt.Errorf("unsupported power action: %s", action)
+	}
+
+	// Game server IDs are UUIDs; anything else cannot exist
+	if _, err := uuid.Parse(serverID); err != nil {
+		return nil, ErrGameServerNotFound
+	}
+
+	var server models.GameServer
+	err := gss.db.WithContext(ctx).Where("id = ? AND tenant_id = ?", serverID, tenantID).First(&server).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrGameServerNotFound
+		}
+		return nil, fmt.Errorf("failed to get game server: %w", err)
+	}
+
+	server.Status.Phase = phase
+	server.Status.Message = fmt.Sprintf("%s requested", action)
+	server.Status.LastUpdated = time.Now()
+
+	err = gss.db.WithContext(ctx).Model(&server).Update("status", server.Status).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to update game server status: %w", err)
+	}
+
+	return &server, nil
+}
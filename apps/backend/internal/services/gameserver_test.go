@@ -30,27 +30,40 @@ func TestGameServerService_GetTenantServers(t *testing.T) {
 	service := NewGameServerService(db)
 	ctx := context.Background()
 
-	// Test getting servers for a tenant
-	servers, err := service.GetTenantServers(ctx, "tenant-123")
+	tenant := &models.Tenant{DiscordServerID: "guild-servers", Name: "Servers Guild", OwnerID: "user-123"}
+	assert.NoError(t, db.Create(tenant).Error)
+	other := &models.Tenant{DiscordServerID: "guild-other", Name: "Other Guild", OwnerID: "user-123"}
+	assert.NoError(t, db.Create(other).Error)
+
+	assert.NoError(t, db.Create(&models.GameServer{
+		TenantID: tenant.ID,
+		Name:     "Survival World",
+		GameType: "minecraft",
+		Status:   models.GameServerStatus{Phase: "Running", PlayerCount: 5},
+	}).Error)
+	assert.NoError(t, db.Create(&models.GameServer{
+		TenantID: tenant.ID,
+		Name:     "Competitive Server",
+		GameType: "cs2",
+		Status:   models.GameServerStatus{Phase: "Stopped"},
+	}).Error)
+	assert.NoError(t, db.Create(&models.GameServer{TenantID: other.ID, Name: "Elsewhere", GameType: "minecraft"}).Error)
+
+	servers, err := service.GetTenantServers(ctx, tenant.ID)
 
 	assert.NoError(t, err)
-	assert.NotNil(t, servers)
-	assert.Len(t, servers, 2) // Mock data returns 2 servers
+	assert.Len(t, servers, 2)
 
-	// Verify the mock data structure
-	assert.Equal(t, "server-1", servers[0].ID)
-	assert.Equal(t, "tenant-123", servers[0].TenantID)
-	assert.Equal(t, "Survival World", servers[0].Name)
-	assert.Equal(t, "minecraft", servers[0].GameType)
-	assert.Equal(t, "Running", servers[0].Status.Phase)
-	assert.Equal(t, 5, servers[0].Status.PlayerCount)
-
-	assert.Equal(t, "server-2", servers[1].ID)
-	assert.Equal(t, "tenant-123", servers[1].TenantID)
-	assert.Equal(t, "Competitive Server", servers[1].Name)
-	assert.Equal(t, "cs2", servers[1].GameType)
-	assert.Equal(t, "Stopped", servers[1].Status.Phase)
-	assert.Equal(t, 0, servers[1].Status.PlayerCount)
+	// Servers are ordered by name
+	assert.Equal(t, "Competitive Server", servers[0].Name)
+	assert.Equal(t, "cs2", servers[0].GameType)
+	assert.Equal(t, "Stopped", servers[0].Status.Phase)
+	assert.Equal(t, "Survival World", servers[1].Name)
+	assert.Equal(t, "Running", servers[1].Status.Phase)
+	assert.Equal(t, 5, servers[1].Status.PlayerCount)
+	for _, server := range servers {
+		assert.Equal(t, tenant.ID, server.TenantID)
+	}
 }
 
 func TestGameServerService_GetTenantActivity(t *testing.T) {
@@ -137,16 +150,15 @@ func TestGameServerService_GetTenantServers_DifferentTenant(t *testing.T) {
 	service := NewGameServerService(db)
 	ctx := context.Background()
 
-	// Test that different tenant IDs still work (mock data uses the passed tenantID)
+	tenant := &models.Tenant{DiscordServerID: "guild-servers", Name: "Servers Guild", OwnerID: "user-123"}
+	assert.NoError(t, db.Create(tenant).Error)
+	assert.NoError(t, db.Create(&models.GameServer{TenantID: tenant.ID, Name: "Survival World", GameType: "minecraft"}).Error)
+
+	// A tenant without servers gets an empty list
 	servers, err := service.GetTenantServers(ctx, "different-tenant")
 
 	assert.NoError(t, err)
-	assert.NotNil(t, servers)
-	assert.Len(t, servers, 2)
-
-	// Verify that the tenant ID is correctly set in the mock data
-	assert.Equal(t, "different-tenant", servers[0].TenantID)
-	assert.Equal(t, "different-tenant", servers[1].TenantID)
+	assert.Empty(t, servers)
 }
 
 func TestGameServerService_ActivityTypes(t *testing.T) {
@@ -171,4 +183,48 @@ func TestGameServerService_ActivityTypes(t *testing.T) {
 	assert.True(t, activityTypes["server_stopped"])
 	assert.True(t, activityTypes["server_created"])
 	assert.True(t, activityTypes["role_updated"])
-}
\ No newline at end of file
+}
+func TestGameServerService_RequestPowerAction(t *testing.T) {
+	db, cleanup := setupGameServerTestDB(t)
+	defer cleanup()
+	service := NewGameServerService(db)
+	ctx := context.Background()
+
+	tenant := &models.Tenant{
+		DiscordServerID: "guild-power",
+		Name:            "Power Guild",
+		OwnerID:         "user-123",
+	}
+	err := db.Create(tenant).Error
+	assert.NoError(t, err)
+
+	server := &models.GameServer{
+		TenantID: tenant.ID,
+		Name:     "Survival World",
+		GameType: "minecraft",
+		Status:   models.GameServerStatus{Phase: "Stopped"},
+	}
+	err = db.Create(server).Error
+	assert.NoError(t, err)
+
+	updated, err := service.RequestPowerAction(ctx, tenant.ID, server.ID, PowerActionStart)
+	assert.NoError(t, err)
+	assert.Equal(t, "Starting", updated.Status.Phase)
+
+	var stored models.GameServer
+	err = db.First(&stored, "id = ?", server.ID).Error
+	assert.NoError(t, err)
+	assert.Equal(t, "Starting", stored.Status.Phase)
+
+	// Servers of another tenant are not reachable
+	_, err = service.RequestPowerAction(ctx, "00000000-0000-0000-0000-000000000000", server.ID, PowerActionStop)
+	assert.ErrorIs(t, err, ErrGameServerNotFound)
+
+	// Non-UUID IDs never match
+	_, err = service.RequestPowerAction(ctx, tenant.ID, "server-1", PowerActionStop)
+	assert.ErrorIs(t, err, ErrGameServerNotFound)
+
+	// Unknown actions are rejected
+	_, err = service.RequestPowerAction(ctx, tenant.ID, server.ID, "explode")
+	assert.Error(t, err)
+}
@@ -0,0 +1,323 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+)
+
+// identityLoginTTL is how long a started provider login stays valid
+const identityLoginTTL = 10 * time.Minute
+
+var (
+	// ErrIdentityAlreadyLinked is returned when an identity belongs to a different user
+	ErrIdentityAlreadyLinked = errors.New("identity is already linked to another user")
+	// ErrIdentityNotFound is returned when an identity does not exist for the user
+	ErrIdentityNotFound = errors.New("identity not found")
+	// ErrLastIdentity is returned when unlinking would leave a user unable to log in
+	ErrLastIdentity = errors.New("cannot unlink the last login identity")
+)
+
+// RegisterIdentityProvider adds a login provider offered alongside Discord
+func (a *AuthService) RegisterIdentityProvider(provider IdentityProvider) {
+	a.identityProviders = append(a.identityProviders, provider)
+}
+
+// IdentityProviders lists the login providers, starting with Discord
+func (a *AuthService) IdentityProviders() []models.IdentityProviderInfo {
+	providers := []models.IdentityProviderInfo{
+		{Name: models.IdentityProviderDiscord, DisplayName: "Discord", Type: models.IdentityProviderDiscord},
+	}
+	for _, provider := range a.identityProviders {
+		providers = append(providers, provider.Info())
+	}
+	return providers
+}
+
+// identityProvider finds a registered provider by name
+func (a *AuthService) identityProvider(name string) (IdentityProvider, bool) {
+	for _, provider := range a.identityProviders {
+		if provider.Name() == name {
+			return provider, true
+		}
+	}
+	return nil, false
+}
+
+// BeginProviderLogin starts a login with a provider. When linkUserID is set the
+// resulting identity is linked to that user instead of logging in.
+func (a *AuthService) BeginProviderLogin(ctx context.Context, providerName, linkUserID string) (*models.IdentityLoginState, error) {
+	login := &models.IdentityLoginState{
+		Provider:   providerName,
+		State:      uuid.New().String(),
+		LinkUserID: linkUserID,
+		ExpiresAt:  time.Now().Add(identityLoginTTL),
+	}
+
+	if providerName == models.IdentityProviderDiscord {
+		login.AuthURL = a.discordService.GetAuthURL(login.State)
+		return login, nil
+	}
+
+	provider, ok := a.identityProvider(providerName)
+	if !ok {
+		return nil, ErrUnknownIdentityProvider
+	}
+
+	login.Nonce = oauth2.GenerateVerifier()
+	login.CodeVerifier = oauth2.GenerateVerifier()
+	authURL, err := provider.AuthCodeURL(ctx, login.State, login.Nonce, login.CodeVerifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build authorization URL: %w", err)
+	}
+	login.AuthURL = authURL
+
+	return login, nil
+}
+
+// HandleProviderCallback completes a login started with BeginProviderLogin
+func (a *AuthService) HandleProviderCallback(ctx context.Context, login *models.IdentityLoginState, code string) (*models.AuthResponse, error) {
+	if login.Provider == models.IdentityProviderDiscord {
+		if login.LinkUserID == "" {
+			return a.HandleCallback(ctx, code)
+		}
+		return a.linkDiscord(ctx, login.LinkUserID, code)
+	}
+
+	provider, ok := a.identityProvider(login.Provider)
+	if !ok {
+		return nil, ErrUnknownIdentityProvider
+	}
+
+	external, err := provider.Exchange(ctx, code, login.Nonce, login.CodeVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := a.resolveExternalUser(ctx, external, login.LinkUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.completeLogin(ctx, user, nil)
+}
+
+// resolveExternalUser finds the user an external identity belongs to, linking it
+// to linkUserID or creating a new user when the identity is not known yet
+func (a *AuthService) resolveExternalUser(ctx context.Context, external *models.ExternalIdentity, linkUserID string) (*models.User, error) {
+	var identity models.UserIdentity
+	err := a.db.WithContext(ctx).
+		Where("provider = ? AND subject = ?", external.Provider, external.Subject).
+		First(&identity).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get identity: %w", err)
+	}
+	known := err == nil
+
+	if known && linkUserID != "" && identity.UserID != linkUserID {
+		return nil, ErrIdentityAlreadyLinked
+	}
+
+	var user models.User
+	switch {
+	case known:
+		err = a.db.WithContext(ctx).Where("id = ?", identity.UserID).First(&user).Error
+	case linkUserID != "":
+		err = a.db.WithContext(ctx).Where("id = ?", linkUserID).First(&user).Error
+	default:
+		user, err = a.createExternalUser(ctx, external)
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	if err := a.upsertIdentity(ctx, user.ID, external); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// createExternalUser creates a user for an identity that has no Discord account
+func (a *AuthService) createExternalUser(ctx context.Context, external *models.ExternalIdentity) (models.User, error) {
+	user := models.User{
+		ID:        uuid.New().String(),
+		Username:  external.Username,
+		Avatar:    external.Avatar,
+		Email:     external.Email,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := a.db.WithContext(ctx).Create(&user).Error; err != nil {
+		return user, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	if a.rbacService != nil {
+		if err := a.rbacService.AssignDefaultSystemUserRole(ctx, user.ID); err != nil {
+			fmt.Printf("Warning: failed to assign systemuser role to new user %s: %v\n", user.ID, err)
+		}
+	}
+
+	return user, nil
+}
+
+// linkDiscord links the Discord account that completed OAuth to an existing user
+func (a *AuthService) linkDiscord(ctx context.Context, userID, code string) (*models.AuthResponse, error) {
+	discordToken, err := a.discordService.ExchangeCodeForToken(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange code: %w", err)
+	}
+
+	discordUser, err := a.discordService.GetUserInfo(ctx, discordToken.AccessToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user info: %w", err)
+	}
+
+	var user models.User
+	if err := a.db.WithContext(ctx).Where("id = ?", userID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if user.DiscordUserID != discordUser.ID {
+		if user.DiscordUserID != "" {
+			return nil, fmt.Errorf("%w: user already has a Discord account", ErrIdentityAlreadyLinked)
+		}
+		var count int64
+		err := a.db.WithContext(ctx).Model(&models.User{}).Where("discord_user_id = ?", discordUser.ID).Count(&count).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to check Discord account: %w", err)
+		}
+		if count > 0 {
+			return nil, ErrIdentityAlreadyLinked
+		}
+
+		user.DiscordUserID = discordUser.ID
+		err = a.db.WithContext(ctx).Model(&user).Update("discord_user_id", discordUser.ID).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to link Discord account: %w", err)
+		}
+	}
+
+	err = a.upsertIdentity(ctx, user.ID, discordExternalIdentity(discordUser))
+	if err != nil {
+		return nil, err
+	}
+
+	return a.completeLogin(ctx, &user, discordToken)
+}
+
+// upsertIdentity records an identity for a user, refreshing its profile fields
+func (a *AuthService) upsertIdentity(ctx context.Context, userID string, external *models.ExternalIdentity) error {
+	var identity models.UserIdentity
+	err := a.db.WithContext(ctx).
+		Where("provider = ? AND subject = ?", external.Provider, external.Subject).
+		First(&identity).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to get identity: %w", err)
+	}
+	if err == nil && identity.UserID != userID {
+		return ErrIdentityAlreadyLinked
+	}
+
+	identity.UserID = userID
+	identity.Provider = external.Provider
+	identity.Subject = external.Subject
+	identity.Email = external.Email
+	identity.Username = external.Username
+	if identity.ID == "" {
+		identity.ID = uuid.New().String()
+	}
+
+	if err := a.db.WithContext(ctx).Save(&identity).Error; err != nil {
+		return fmt.Errorf("failed to save identity: %w", err)
+	}
+	return nil
+}
+
+// GetUserIdentities lists the identities linked to a user
+func (a *AuthService) GetUserIdentities(ctx context.Context, userID string) ([]models.UserIdentity, error) {
+	var identities []models.UserIdentity
+	err := a.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at").Find(&identities).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get identities: %w", err)
+	}
+	return identities, nil
+}
+
+// UnlinkIdentity removes an identity from a user as long as another way to log in remains
+func (a *AuthService) UnlinkIdentity(ctx context.Context, userID, identityID string) error {
+	return a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var identity models.UserIdentity
+		err := tx.Where("id = ? AND user_id = ?", identityID, userID).First(&identity).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrIdentityNotFound
+			}
+			return fmt.Errorf("failed to get identity: %w", err)
+		}
+
+		var user models.User
+		if err := tx.Where("id = ?", userID).First(&user).Error; err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+
+		var identities []models.UserIdentity
+		if err := tx.Where("user_id = ?", userID).Find(&identities).Error; err != nil {
+			return fmt.Errorf("failed to get identities: %w", err)
+		}
+		if loginMethods(&user, identities) <= 1 {
+			return ErrLastIdentity
+		}
+
+		if err := tx.Delete(&identity).Error; err != nil {
+			return fmt.Errorf("failed to delete identity: %w", err)
+		}
+
+		if identity.Provider == models.IdentityProviderDiscord {
+			err := tx.Model(&models.User{}).Where("id = ?", userID).Update("discord_user_id", gorm.Expr("NULL")).Error
+			if err != nil {
+				return fmt.Errorf("failed to unlink Discord account: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// loginMethods counts the ways a user can log in. Discord accounts linked before
+// identities were recorded have no identity row but still count.
+func loginMethods(user *models.User, identities []models.UserIdentity) int {
+	count := len(identities)
+	if user.DiscordUserID == "" {
+		return count
+	}
+	for _, identity := range identities {
+		if identity.Provider == models.IdentityProviderDiscord {
+			return count
+		}
+	}
+	return count + 1
+}
+
+// discordExternalIdentity describes a Discord account as an external identity
+func discordExternalIdentity(discordUser *models.DiscordUser) *models.ExternalIdentity {
+	return &models.ExternalIdentity{
+		Provider:      models.IdentityProviderDiscord,
+		Subject:       discordUser.ID,
+		Username:      discordUser.Username,
+		Email:         discordUser.Email,
+		EmailVerified: discordUser.Verified,
+		Avatar:        discordUser.Avatar,
+	}
+}
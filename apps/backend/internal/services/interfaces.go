@@ -17,6 +17,7 @@ type DiscordServiceInterface interface {
 	GetGuildRoles(ctx context.Context, botToken, guildID string) ([]models.DiscordRole, error)
 	GetGuildMembers(ctx context.Context, botToken, guildID string, limit int) ([]models.DiscordMember, error)
 	GetGuildMember(ctx context.Context, botToken, guildID, userID string) (*models.DiscordMember, error)
+	GetGuildChannels(ctx context.Context, botToken, guildID string) ([]models.DiscordChannel, error)
 }
 
 // JWTServiceInterface defines the interface for JWT service operations
@@ -30,8 +31,17 @@ type JWTServiceInterface interface {
 type RedisServiceInterface interface {
 	StoreSession(ctx context.Context, session *models.Session) error
 	GetSession(ctx context.Context, sessionID string) (*models.Session, error)
+	UpdateSession(ctx context.Context, sessionID string, update func(*models.Session)) (*models.Session, error)
 	GetSessionByRefreshToken(ctx context.Context, refreshToken string) (*models.Session, error)
+	StoreRefreshToken(ctx context.Context, session *models.Session) error
+	ConsumeRefreshToken(ctx context.Context, refreshToken string, retiredUntil time.Time) (*models.Session, error)
+	GetRetiredRefreshToken(ctx context.Context, refreshToken string) (string, error)
 	DeleteSession(ctx context.Context, sessionID string) error
+	ListUserSessions(ctx context.Context, userID string) ([]*models.Session, error)
+	StoreTwoFactorChallenge(ctx context.Context, challenge *models.TwoFactorChallenge) error
+	GetTwoFactorChallenge(ctx context.Context, challengeID string) (*models.TwoFactorChallenge, error)
+	CountTwoFactorChallengeAttempt(ctx context.Context, challenge *models.TwoFactorChallenge) (int64, error)
+	DeleteTwoFactorChallenge(ctx context.Context, challengeID string) error
 }
 
 // AuthServiceInterface defines the interface for authentication service operations
@@ -42,6 +52,68 @@ type AuthServiceInterface interface {
 	ValidateAccessToken(ctx context.Context, accessToken string) (*models.User, error)
 	ParseTokenClaims(accessToken string) (*models.JWTClaims, error)
 	Logout(ctx context.Context, accessToken string) error
+	IdentityProviders() []models.IdentityProviderInfo
+	BeginProviderLogin(ctx context.Context, providerName, linkUserID string) (*models.IdentityLoginState, error)
+	HandleProviderCallback(ctx context.Context, login *models.IdentityLoginState, code string) (*models.AuthResponse, error)
+	GetUserIdentities(ctx context.Context, userID string) ([]models.UserIdentity, error)
+	UnlinkIdentity(ctx context.Context, userID, identityID string) error
+	ListSessions(ctx context.Context, userID, currentSessionID string) ([]models.SessionInfo, error)
+	RevokeSession(ctx context.Context, userID, sessionID string) error
+	RevokeAllSessions(ctx context.Context, userID string) (int, error)
+	GetDiscordGuilds(ctx context.Context, sessionID string) ([]models.DiscordGuild, error)
+	BeginStepUp(ctx context.Context, providerName, sessionID string) (*models.IdentityLoginState, error)
+	CompleteStepUp(ctx context.Context, login *models.IdentityLoginState, code string) (time.Time, error)
+	StepUpExpiry(ctx context.Context, sessionID string) (time.Time, error)
+	StepUpWithTOTP(ctx context.Context, sessionID, code string) (time.Time, error)
+	VerifyTwoFactorLogin(ctx context.Context, challengeID, code string) (*models.AuthResponse, error)
+}
+
+// OAuthStateStore defines storage for OAuth state between starting a login and its callback
+type OAuthStateStore interface {
+	SaveState(ctx context.Context, state *models.OAuthState) error
+	ConsumeState(ctx context.Context, state string) (*models.OAuthState, error)
+}
+
+// TwoFactorAttemptCounter defines storage for counting a user's two-factor attempts
+type TwoFactorAttemptCounter interface {
+	RecordAttempt(ctx context.Context, userID string, window time.Duration) (int64, error)
+	ResetAttempts(ctx context.Context, userID string) error
+}
+
+// IdentityProvider defines an external login provider such as an OpenID Connect server
+type IdentityProvider interface {
+	Name() string
+	Info() models.IdentityProviderInfo
+	AuthCodeURL(ctx context.Context, state, nonce, codeVerifier string) (string, error)
+	Exchange(ctx context.Context, code, nonce, codeVerifier string) (*models.ExternalIdentity, error)
+}
+
+// APITokenServiceInterface defines the interface for personal access token and service account operations
+type APITokenServiceInterface interface {
+	CreatePersonalToken(ctx context.Context, userID string, req models.CreateAPITokenRequest) (*models.CreateAPITokenResponse, error)
+	ListPersonalTokens(ctx context.Context, userID string) ([]models.APIToken, error)
+	RevokePersonalToken(ctx context.Context, userID, tokenID string) error
+	CreateServiceAccount(ctx context.Context, tenantID string, req models.CreateServiceAccountRequest, createdBy string) (*models.ServiceAccount, error)
+	ListServiceAccounts(ctx context.Context, tenantID string) ([]models.ServiceAccount, error)
+	DeleteServiceAccount(ctx context.Context, tenantID, accountID string) error
+	CreateServiceAccountToken(ctx context.Context, tenantID, accountID string, req models.CreateAPITokenRequest, createdBy string) (*models.CreateAPITokenResponse, error)
+	ListServiceAccountTokens(ctx context.Context, tenantID, accountID string) ([]models.APIToken, error)
+	RevokeServiceAccountToken(ctx context.Context, tenantID, accountID, tokenID string) error
+	ValidateToken(ctx context.Context, rawToken string) (*models.User, *models.APIToken, error)
+}
+
+// TwoFactorServiceInterface defines the interface for TOTP two-factor authentication operations
+type TwoFactorServiceInterface interface {
+	Status(ctx context.Context, userID string) (*models.TwoFactorStatus, error)
+	BeginEnrollment(ctx context.Context, user *models.User) (*models.TwoFactorEnrollment, error)
+	ConfirmEnrollment(ctx context.Context, userID, code string) ([]string, error)
+	Disable(ctx context.Context, userID, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error)
+	IsEnabled(ctx context.Context, userID string) (bool, error)
+	Verify(ctx context.Context, userID, code string) error
+	GetPolicy(ctx context.Context) (*models.TwoFactorPolicy, error)
+	SetPolicy(ctx context.Context, policy models.TwoFactorPolicy, performedBy string) error
+	EnrollmentRequired(ctx context.Context, userID string) (bool, error)
 }
 
 // TenantServiceInterface defines the interface for tenant service operations
@@ -52,17 +124,114 @@ type TenantServiceInterface interface {
 	GetUserTenants(ctx context.Context, userID string) ([]models.Tenant, error)
 	AddUserToTenant(ctx context.Context, userID, tenantID string, roles []string, permissions []string) error
 	RemoveUserFromTenant(ctx context.Context, userID, tenantID string) error
-	SyncDiscordRoles(ctx context.Context, tenantID, botToken string) error
-	SyncDiscordUsers(ctx context.Context, tenantID, botToken string) error
-	UpdateTenantConfig(ctx context.Context, tenantID string, config models.TenantConfig) error
+	UpdateTenantConfig(ctx context.Context, tenantID string, config models.TenantConfig, performedBy string) error
 	DeleteTenant(ctx context.Context, tenantID string) error
+	IsTenantMember(ctx context.Context, userID, tenantID string) (bool, error)
 	HasPermission(ctx context.Context, userID, tenantID, permission string) (bool, error)
 	CheckManageServerPermission(discordGuild *models.DiscordGuild) bool
 }
 
+// MembershipServiceInterface defines the interface for tenant membership and invite operations
+type MembershipServiceInterface interface {
+	ListMembers(ctx context.Context, tenantID string) ([]models.TenantMember, error)
+	RemoveMember(ctx context.Context, tenantID, userID, performedBy string) error
+	LeaveTenant(ctx context.Context, tenantID, userID string) error
+	CreateInvite(ctx context.Context, tenantID string, req models.CreateInviteRequest, createdBy string) (*models.CreateInviteResponse, error)
+	ListInvites(ctx context.Context, tenantID string) ([]models.TenantInvite, error)
+	RevokeInvite(ctx context.Context, tenantID, inviteID, performedBy string) error
+	ListUserInvites(ctx context.Context, user *models.User) ([]models.TenantInvite, error)
+	RespondToInvite(ctx context.Context, user *models.User, inviteID string, accept bool) (*models.TenantInvite, error)
+	JoinWithCode(ctx context.Context, user *models.User, code string) (*models.TenantInvite, error)
+	JoinGuildTenants(ctx context.Context, user *models.User, guildIDs []string) ([]models.Tenant, error)
+}
+
+// OwnershipServiceInterface defines the interface for tenant ownership transfers
+type OwnershipServiceInterface interface {
+	RequestTransfer(ctx context.Context, tenantID, toUserID, requestedBy string) (*models.OwnershipTransfer, error)
+	GetPendingTransfer(ctx context.Context, tenantID string) (*models.OwnershipTransfer, error)
+	CancelTransfer(ctx context.Context, tenantID, performedBy string) error
+	ListUserTransfers(ctx context.Context, userID string) ([]models.OwnershipTransfer, error)
+	RespondToTransfer(ctx context.Context, transferID, userID string, accept bool) (*models.OwnershipTransfer, error)
+	ForceTransfer(ctx context.Context, tenantID, toUserID, reason, performedBy string) (*models.Tenant, error)
+	FollowGuildOwner(ctx context.Context, tenantID, ownerDiscordUserID string) error
+}
+
 // GameServerServiceInterface defines the interface for game server service operations
 type GameServerServiceInterface interface {
 	GetTenantServers(ctx context.Context, tenantID string) ([]models.GameServer, error)
 	GetTenantActivity(ctx context.Context, tenantID string, limit int) ([]models.Activity, error)
 	GetTenantDiscordStats(ctx context.Context, tenantID string) (*models.DiscordStats, error)
-}
\ No newline at end of file
+	RequestPowerAction(ctx context.Context, tenantID, serverID, action string) (*models.GameServer, error)
+}
+
+// AuthorizerInterface defines the policy engine that every permission check
+// goes through
+type AuthorizerInterface interface {
+	PermissionInvalidator
+	IsSuperAdmin(ctx context.Context, userID string) (bool, error)
+	IsTenantMember(ctx context.Context, userID, tenantID string) (bool, error)
+	HasPermission(ctx context.Context, userID, tenantID, permission string) (bool, error)
+	HasResourcePermission(ctx context.Context, userID, tenantID, permission, resourceType, resourceID string) (bool, error)
+}
+
+// PermissionInvalidator is told when roles, role mappings or memberships change
+// so that cached permission decisions are dropped
+type PermissionInvalidator interface {
+	InvalidateUserPermissions(ctx context.Context, userID string) error
+	InvalidateTenantPermissions(ctx context.Context, tenantID string) error
+}
+
+// GuildSyncer runs a full sync of a tenant's Discord guild
+type GuildSyncer interface {
+	ReconcileGuild(tenantID string, guildID string) error
+}
+
+// GuildOwnerFollower moves tenant ownership along with Discord server ownership
+type GuildOwnerFollower interface {
+	FollowGuildOwner(ctx context.Context, tenantID, ownerDiscordUserID string) error
+}
+
+// PermissionCache defines storage for users' effective permissions in a tenant
+type PermissionCache interface {
+	GetEffectivePermissions(ctx context.Context, userID, tenantID string) (*models.EffectivePermissions, error)
+	StoreEffectivePermissions(ctx context.Context, permissions *models.EffectivePermissions, ttl time.Duration) error
+	DeleteUserPermissions(ctx context.Context, userID string) error
+	DeleteTenantPermissions(ctx context.Context, tenantID string) error
+}
+
+// RBACServiceInterface defines the interface for tenant RBAC management operations
+type RBACServiceInterface interface {
+	GetRoles(ctx context.Context, tenantID string) ([]models.Role, error)
+	CreateTenantRole(ctx context.Context, tenantID string, req models.RoleRequest, performedBy string) (*models.Role, error)
+	UpdateTenantRole(ctx context.Context, tenantID, roleID string, req models.RoleRequest, performedBy string) (*models.Role, error)
+	DeleteTenantRole(ctx context.Context, tenantID, roleID, performedBy string) error
+	AssignTenantRole(ctx context.Context, tenantID, userID, roleID, performedBy string) error
+	UnassignTenantRole(ctx context.Context, tenantID, userID, roleID, performedBy string) error
+	GetDiscordRoles(ctx context.Context, tenantID string) ([]models.TenantDiscordRole, error)
+	SetDiscordRoleMapping(ctx context.Context, tenantID, discordRoleID string, permissions, roleIDs []string, performedBy string) (*models.TenantDiscordRole, error)
+	GetAccessibleResourceIDs(ctx context.Context, userID, tenantID, permission, resourceType string) (bool, []string, error)
+	ListResourceGrants(ctx context.Context, tenantID, resourceType, resourceID string) ([]models.ResourceGrant, error)
+	CreateResourceGrant(ctx context.Context, tenantID, resourceType, resourceID string, req models.CreateResourceGrantRequest, performedBy string) (*models.ResourceGrant, error)
+	DeleteResourceGrant(ctx context.Context, tenantID, resourceType, resourceID, grantID, performedBy string) error
+	ExplainPermission(ctx context.Context, userID, tenantID, permission, resourceType, resourceID string) (*models.AuthorizationDecision, error)
+	ExportPolicy(ctx context.Context, tenantID string) (*models.TenantPolicy, error)
+	ImportPolicy(ctx context.Context, tenantID string, policy *models.TenantPolicy, dryRun bool, performedBy string) (*models.PolicyImportResult, error)
+	ListPermissions(ctx context.Context, scope models.PermissionScope) ([]models.Permission, error)
+}
+
+// NotificationServiceInterface defines the interface for tenant notification delivery
+type NotificationServiceInterface interface {
+	Notify(ctx context.Context, event models.NotificationEvent) error
+	NotifyControllerOffline(ctx context.Context, clusterID, clusterName string) error
+}
+
+// AdminServiceInterface defines the interface for system administration operations
+type AdminServiceInterface interface {
+	CheckSuperAdminAccess(ctx context.Context, userID string) (bool, error)
+	GetAdminStats(ctx context.Context) (*models.AdminStats, error)
+	CleanupInactiveControllers(ctx context.Context) error
+	ListSystemRoles(ctx context.Context) ([]models.SystemRole, error)
+	GetUserSystemRoles(ctx context.Context, userID string) ([]models.SystemRole, error)
+	GrantSystemRole(ctx context.Context, userID, roleName, performedBy string) error
+	RevokeSystemRole(ctx context.Context, userID, roleName, performedBy string) error
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/pteronimbus/pteronimbus/apps/backend/internal/config"
 	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
 )
@@ -17,6 +18,9 @@ type JWTService struct {
 	refreshTokenTTL  time.Duration
 	issuer           string
 	rbacService      *RBACService
+	// keys holds asymmetric signing keys, the first of which signs new tokens.
+	// When empty, tokens are signed with the shared HS256 secret.
+	keys             []*SigningKey
 }
 
 // NewJWTService creates a new JWT service
@@ -40,6 +44,19 @@ func NewJWTServiceWithRBAC(cfg *config.Config, rbacService *RBACService) *JWTSer
 	}
 }
 
+// NewJWTServiceWithKeys creates a new JWT service that signs tokens with
+// asymmetric keys instead of the shared secret
+func NewJWTServiceWithKeys(cfg *config.Config, rbacService *RBACService, keys []*SigningKey) *JWTService {
+	return &JWTService{
+		secret:           []byte(cfg.JWT.Secret),
+		accessTokenTTL:   cfg.JWT.AccessTokenTTL,
+		refreshTokenTTL:  cfg.JWT.RefreshTokenTTL,
+		issuer:           cfg.JWT.Issuer,
+		rbacService:      rbacService,
+		keys:             keys,
+	}
+}
+
 // GenerateAccessToken generates a new access token
 func (j *JWTService) GenerateAccessToken(user *models.User, sessionID string) (string, time.Time, error) {
 	now := time.Now()
@@ -79,8 +96,7 @@ func (j *JWTService) GenerateAccessToken(user *models.User, sessionID string) (s
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(j.secret)
+	tokenString, err := j.sign(claims)
 	if err != nil {
 		return "", time.Time{}, fmt.Errorf("failed to sign token: %w", err)
 	}
@@ -121,11 +137,11 @@ func (j *JWTService) GenerateRefreshToken(user *models.User, sessionID string) (
 			NotBefore: jwt.NewNumericDate(now),
 			Issuer:    j.issuer,
 			Subject:   user.ID,
+			ID:        uuid.New().String(), // Keeps rotated refresh tokens unique
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(j.secret)
+	tokenString, err := j.sign(claims)
 	if err != nil {
 		return "", time.Time{}, fmt.Errorf("failed to sign refresh token: %w", err)
 	}
@@ -135,12 +151,7 @@ func (j *JWTService) GenerateRefreshToken(user *models.User, sessionID string) (
 
 // ValidateToken validates and parses a JWT token
 func (j *JWTService) ValidateToken(tokenString string) (*models.JWTClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &models.JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return j.secret, nil
-	})
+	token, err := j.parse(tokenString, &models.JWTClaims{})
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse token: %w", err)
@@ -156,4 +167,55 @@ func (j *JWTService) ValidateToken(tokenString string) (*models.JWTClaims, error
 // GetAccessTokenTTL returns the access token TTL in seconds
 func (j *JWTService) GetAccessTokenTTL() int64 {
 	return int64(j.accessTokenTTL.Seconds())
-}
\ No newline at end of file
+}
+
+// JWKS returns the public keys tokens can be verified with. It is empty when
+// tokens are signed with the shared secret.
+func (j *JWTService) JWKS() models.JSONWebKeySet {
+	set := models.JSONWebKeySet{Keys: make([]models.JSONWebKey, 0, len(j.keys))}
+	for _, key := range j.keys {
+		set.Keys = append(set.Keys, key.JWK())
+	}
+	return set
+}
+
+// sign signs claims with the active key, naming it in the kid header
+func (j *JWTService) sign(claims jwt.Claims) (string, error) {
+	if len(j.keys) == 0 {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(j.secret)
+	}
+
+	key := j.keys[0]
+	if key.private == nil {
+		return "", ErrNoSigningKey
+	}
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.private)
+}
+
+// parse verifies a token against the key named in its kid header. Once
+// asymmetric keys are configured the shared secret is no longer accepted, so
+// a leaked secret cannot be used to forge tokens.
+func (j *JWTService) parse(tokenString string, claims jwt.Claims) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if len(j.keys) == 0 {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+			return j.secret, nil
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		for _, key := range j.keys {
+			if key.ID != kid {
+				continue
+			}
+			if token.Method.Alg() != key.Method.Alg() {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+			return key.publicKey, nil
+		}
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	})
+}
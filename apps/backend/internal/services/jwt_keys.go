@@ -0,0 +1,143 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+)
+
+// ErrNoSigningKey is returned when the active key configured for signing tokens has no private key
+var ErrNoSigningKey = errors.New("signing key has no private key")
+
+// SigningKey is an asymmetric key used to sign or verify tokens. Keys that are
+// being rotated out are loaded from their public key only and just verify.
+type SigningKey struct {
+	ID        string
+	Method    jwt.SigningMethod
+	private   crypto.Signer
+	publicKey crypto.PublicKey
+}
+
+// LoadSigningKeys reads PEM encoded RSA or Ed25519 keys from files. The first
+// key signs new tokens and must hold a private key; the rest stay valid for
+// verification so tokens signed before a rotation keep working.
+func LoadSigningKeys(paths []string) ([]*SigningKey, error) {
+	keys := make([]*SigningKey, 0, len(paths))
+	for i, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read signing key %s: %w", path, err)
+		}
+
+		key, err := ParseSigningKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse signing key %s: %w", path, err)
+		}
+		if i == 0 && key.private == nil {
+			return nil, fmt.Errorf("%s: %w", path, ErrNoSigningKey)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// ParseSigningKey parses a PEM encoded private or public RSA or Ed25519 key
+func ParseSigningKey(data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &SigningKey{}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method, key.private, key.publicKey = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Method, key.publicKey = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.Method, key.private, key.publicKey = jwt.SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Method, key.publicKey = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, fmt.Errorf("unsupported key type %T, only RSA and Ed25519 keys can sign tokens", parsed)
+	}
+
+	key.ID, err = key.thumbprint()
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// JWK returns the public part of the key in JSON Web Key form
+func (k *SigningKey) JWK() models.JSONWebKey {
+	jwk := models.JSONWebKey{
+		KeyID:     k.ID,
+		Use:       "sig",
+		Algorithm: k.Method.Alg(),
+	}
+
+	switch pub := k.publicKey.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	}
+	return jwk
+}
+
+// thumbprint derives the key ID from the RFC 7638 JWK thumbprint, so the same
+// key always gets the same ID without having to configure one
+func (k *SigningKey) thumbprint() (string, error) {
+	jwk := k.JWK()
+
+	// The members must be in lexicographic order, which json.Marshal does for maps
+	var members map[string]string
+	switch jwk.KeyType {
+	case "RSA":
+		members = map[string]string{"e": jwk.E, "kty": jwk.KeyType, "n": jwk.N}
+	case "OKP":
+		members = map[string]string{"crv": jwk.Curve, "kty": jwk.KeyType, "x": jwk.X}
+	default:
+		return "", fmt.Errorf("unsupported key type")
+	}
+
+	data, err := json.Marshal(members)
+	if err != nil {
+		return "", fmt.Errorf("failed to compute key thumbprint: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
@@ -1,11 +1,19 @@
 package services
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/pteronimbus/pteronimbus/apps/backend/internal/config"
 	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
 )
@@ -82,6 +90,11 @@ func TestJWTService_GenerateRefreshToken(t *testing.T) {
 	assert.Equal(t, sessionID, claims.SessionID)
 	assert.Equal(t, cfg.JWT.Issuer, claims.Issuer)
 	assert.Empty(t, claims.SystemRoles) // Default should be empty
+
+	// Rotated refresh tokens issued within the same second must still differ
+	rotated, _, err := jwtService.GenerateRefreshToken(user, sessionID)
+	assert.NoError(t, err)
+	assert.NotEqual(t, token, rotated)
 }
 
 func TestJWTService_ValidateToken(t *testing.T) {
@@ -307,4 +320,99 @@ func TestJWTService_ClaimsValidation(t *testing.T) {
 	now := time.Now()
 	assert.WithinDuration(t, now, claims.IssuedAt.Time, time.Minute)
 	assert.WithinDuration(t, now, claims.NotBefore.Time, time.Minute)
+}
+func writeSigningKey(t *testing.T, key interface{}, public bool) string {
+	var block *pem.Block
+	if public {
+		der, err := x509.MarshalPKIXPublicKey(key)
+		require.NoError(t, err)
+		block = &pem.Block{Type: "PUBLIC KEY", Bytes: der}
+	} else {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		require.NoError(t, err)
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	}
+
+	path := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(block), 0600))
+	return path
+}
+
+func TestJWTService_SigningKeys(t *testing.T) {
+	cfg := &config.Config{
+		JWT: config.JWTConfig{
+			Secret:          "test_secret_key_that_is_long_enough",
+			AccessTokenTTL:  time.Hour,
+			RefreshTokenTTL: 24 * time.Hour,
+			Issuer:          "pteronimbus-test",
+		},
+	}
+	user := &models.User{ID: "user_id", Username: "testuser"}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	// Tokens signed before the rotation, with the old RSA key
+	oldKeys, err := LoadSigningKeys([]string{writeSigningKey(t, rsaKey, false)})
+	require.NoError(t, err)
+	oldService := NewJWTServiceWithKeys(cfg, nil, oldKeys)
+	oldToken, _, err := oldService.GenerateAccessToken(user, "session_id")
+	require.NoError(t, err)
+
+	// After the rotation the Ed25519 key signs and the RSA key only verifies
+	keys, err := LoadSigningKeys([]string{
+		writeSigningKey(t, edKey, false),
+		writeSigningKey(t, &rsaKey.PublicKey, true),
+	})
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, oldKeys[0].ID, keys[1].ID)
+	jwtService := NewJWTServiceWithKeys(cfg, nil, keys)
+
+	token, _, err := jwtService.GenerateAccessToken(user, "session_id")
+	require.NoError(t, err)
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &models.JWTClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, keys[0].ID, parsed.Header["kid"])
+	assert.Equal(t, "EdDSA", parsed.Header["alg"])
+
+	claims, err := jwtService.ValidateToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, "user_id", claims.UserID)
+
+	claims, err = jwtService.ValidateToken(oldToken)
+	require.NoError(t, err)
+	assert.Equal(t, "user_id", claims.UserID)
+
+	// The shared secret is not accepted once keys are configured
+	secretToken, _, err := NewJWTService(cfg).GenerateAccessToken(user, "session_id")
+	require.NoError(t, err)
+	_, err = jwtService.ValidateToken(secretToken)
+	assert.Error(t, err)
+
+	// Tokens from keys that were dropped are rejected
+	_, err = NewJWTServiceWithKeys(cfg, nil, keys[:1]).ValidateToken(oldToken)
+	assert.Error(t, err)
+
+	jwks := jwtService.JWKS()
+	require.Len(t, jwks.Keys, 2)
+	assert.Equal(t, "OKP", jwks.Keys[0].KeyType)
+	assert.Equal(t, "Ed25519", jwks.Keys[0].Curve)
+	assert.NotEmpty(t, jwks.Keys[0].X)
+	assert.Equal(t, "RSA", jwks.Keys[1].KeyType)
+	assert.Equal(t, "RS256", jwks.Keys[1].Algorithm)
+	assert.Equal(t, "AQAB", jwks.Keys[1].E)
+}
+
+func TestLoadSigningKeys_RequiresPrivateActiveKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	_, err = LoadSigningKeys([]string{writeSigningKey(t, &rsaKey.PublicKey, true)})
+	assert.ErrorIs(t, err, ErrNoSigningKey)
+
+	_, err = LoadSigningKeys([]string{filepath.Join(t.TempDir(), "missing.pem")})
+	assert.Error(t, err)
 }
\ No newline at end of file
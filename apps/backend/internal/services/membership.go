@@ -0,0 +1,542 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	// defaultInviteTTL is how long an invite stays valid when no expiry is given
+	defaultInviteTTL = 7 * 24 * time.Hour
+	// maxInviteTTL is the longest an invite may stay valid
+	maxInviteTTL = 30 * 24 * time.Hour
+)
+
+var (
+	// ErrInviteNotFound is returned when an invite does not exist or is not addressed to the user
+	ErrInviteNotFound = errors.New("invite not found")
+	// ErrInviteClosed is returned when an invite has expired, was revoked or was already answered
+	ErrInviteClosed = errors.New("invite is no longer valid")
+	// ErrInvalidInvite is returned when an invite request has an invalid recipient or expiry
+	ErrInvalidInvite = errors.New("invalid invite")
+	// ErrOwnerMembership is returned when the tenant owner would lose their membership
+	ErrOwnerMembership = errors.New("the tenant owner cannot leave or be removed")
+)
+
+// MembershipService manages who belongs to a tenant: members, invites and
+// membership derived from Discord server membership
+type MembershipService struct {
+	db          *gorm.DB
+	rbacService *RBACService
+}
+
+// NewMembershipService creates a new membership service
+func NewMembershipService(db *gorm.DB, rbacService *RBACService) *MembershipService {
+	return &MembershipService{
+		db:          db,
+		rbacService: rbacService,
+	}
+}
+
+// ListMembers lists a tenant's members with the roles they hold directly or
+// through Discord and the permissions in effect
+func (s *MembershipService) ListMembers(ctx context.Context, tenantID string) ([]models.TenantMember, error) {
+	var tenant models.Tenant
+	if err := s.db.WithContext(ctx).First(&tenant, "id = ?", tenantID).Error; err != nil {
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	var memberships []models.UserTenant
+	err := s.db.WithContext(ctx).Preload("User").Where("tenant_id = ?", tenantID).Order("created_at").Find(&memberships).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get members: %w", err)
+	}
+
+	var roles []models.Role
+	if err := s.db.WithContext(ctx).Where("tenant_id = ?", tenantID).Find(&roles).Error; err != nil {
+		return nil, fmt.Errorf("failed to get roles: %w", err)
+	}
+	var discordRoles []models.TenantDiscordRole
+	if err := s.db.WithContext(ctx).Where("tenant_id = ?", tenantID).Find(&discordRoles).Error; err != nil {
+		return nil, fmt.Errorf("failed to get Discord roles: %w", err)
+	}
+
+	rolesByName := make(map[string]models.Role, len(roles))
+	rolesByID := make(map[string]models.Role, len(roles))
+	for _, role := range roles {
+		rolesByName[role.Name] = role
+		rolesByID[role.ID] = role
+	}
+	discordRolesByID := make(map[string]models.TenantDiscordRole, len(discordRoles))
+	for _, role := range discordRoles {
+		discordRolesByID[role.DiscordRoleID] = role
+	}
+
+	members := make([]models.TenantMember, 0, len(memberships))
+	for _, membership := range memberships {
+		permissions, err := s.rbacService.GetUserPermissions(ctx, membership.UserID, tenantID)
+		if err != nil {
+			return nil, err
+		}
+
+		member := models.TenantMember{
+			UserID:           membership.UserID,
+			Username:         membership.User.Username,
+			Avatar:           membership.User.Avatar,
+			DiscordUserID:    membership.User.DiscordUserID,
+			IsOwner:          membership.UserID == tenant.OwnerID,
+			IsServiceAccount: membership.User.IsServiceAccount,
+			Roles:            []models.MemberRole{},
+			Permissions:      permissions,
+			JoinedAt:         membership.CreatedAt,
+		}
+		for _, held := range membership.Roles {
+			if role, ok := rolesByName[held]; ok {
+				member.Roles = append(member.Roles, models.MemberRole{ID: role.ID, Name: role.Name, Source: models.GrantSourceRole})
+			}
+			discordRole, ok := discordRolesByID[held]
+			if !ok {
+				continue
+			}
+			member.Roles = append(member.Roles, models.MemberRole{ID: discordRole.DiscordRoleID, Name: discordRole.Name, Source: models.GrantSourceDiscordRole})
+			for _, roleID := range discordRole.MappedRoleIDs {
+				if role, ok := rolesByID[roleID]; ok {
+					member.Roles = append(member.Roles, models.MemberRole{ID: role.ID, Name: role.Name, Source: models.GrantSourceDiscordRoleMapping, Via: discordRole.DiscordRoleID})
+				}
+			}
+		}
+		members = append(members, member)
+	}
+
+	return members, nil
+}
+
+// RemoveMember removes a member from a tenant. The remover must hold every
+// permission the member has, and the owner cannot be removed.
+func (s *MembershipService) RemoveMember(ctx context.Context, tenantID, userID, performedBy string) error {
+	membership, err := s.membership(ctx, tenantID, userID)
+	if err != nil {
+		return err
+	}
+
+	permissions, err := s.rbacService.GetUserPermissions(ctx, userID, tenantID)
+	if err != nil {
+		return err
+	}
+	if err := s.rbacService.requirePermissionsHeld(ctx, performedBy, tenantID, permissions); err != nil {
+		return err
+	}
+
+	if err := s.db.WithContext(ctx).Delete(membership).Error; err != nil {
+		return fmt.Errorf("failed to remove member: %w", err)
+	}
+	if err := s.rbacService.InvalidateUserPermissions(ctx, userID); err != nil {
+		return err
+	}
+
+	return s.rbacService.LogPermissionChange(ctx, userID, tenantID, "member_removed", "membership", membership.ID, strings.Join(membership.Roles, ","), "", "", performedBy)
+}
+
+// LeaveTenant ends the user's own membership. The owner has to transfer the
+// tenant first.
+func (s *MembershipService) LeaveTenant(ctx context.Context, tenantID, userID string) error {
+	membership, err := s.membership(ctx, tenantID, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.WithContext(ctx).Delete(membership).Error; err != nil {
+		return fmt.Errorf("failed to leave tenant: %w", err)
+	}
+	if err := s.rbacService.InvalidateUserPermissions(ctx, userID); err != nil {
+		return err
+	}
+
+	return s.rbacService.LogPermissionChange(ctx, userID, tenantID, "member_left", "membership", membership.ID, strings.Join(membership.Roles, ","), "", "", userID)
+}
+
+// CreateInvite invites a Discord user to a tenant, or creates an invite link
+// when no Discord user is given. The inviter must hold every permission of the
+// roles the invite gives.
+func (s *MembershipService) CreateInvite(ctx context.Context, tenantID string, req models.CreateInviteRequest, createdBy string) (*models.CreateInviteResponse, error) {
+	now := time.Now()
+	expiresAt := now.Add(defaultInviteTTL)
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(now) || req.ExpiresAt.After(now.Add(maxInviteTTL)) {
+			return nil, fmt.Errorf("%w: expiry must be in the next %d days", ErrInvalidInvite, int(maxInviteTTL.Hours()/24))
+		}
+		expiresAt = *req.ExpiresAt
+	}
+
+	roleIDs := uniqueStrings(req.RoleIDs)
+	var permissions []string
+	for _, roleID := range roleIDs {
+		role, err := s.rbacService.tenantRole(ctx, tenantID, roleID)
+		if err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, role.Permissions...)
+	}
+	if err := s.rbacService.requirePermissionsHeld(ctx, createdBy, tenantID, permissions); err != nil {
+		return nil, err
+	}
+
+	invite := &models.TenantInvite{
+		TenantID:      tenantID,
+		DiscordUserID: strings.TrimSpace(req.DiscordUserID),
+		RoleIDs:       models.StringArray(roleIDs),
+		Status:        models.InviteStatusPending,
+		ExpiresAt:     expiresAt,
+		CreatedBy:     createdBy,
+	}
+
+	var code string
+	if invite.IsLink() {
+		var err error
+		code, err = generateInviteCode()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate invite code: %w", err)
+		}
+		codeHash := hashAPIToken(code)
+		invite.CodeHash = &codeHash
+	} else {
+		if _, err := strconv.ParseUint(invite.DiscordUserID, 10, 64); err != nil {
+			return nil, fmt.Errorf("%w: discord_user_id must be a Discord user ID", ErrInvalidInvite)
+		}
+
+		var count int64
+		err := s.db.WithContext(ctx).Model(&models.UserTenant{}).
+			Joins("JOIN users ON users.id = user_tenants.user_id").
+			Where("user_tenants.tenant_id = ? AND users.discord_user_id = ?", tenantID, invite.DiscordUserID).
+			Count(&count).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to check membership: %w", err)
+		}
+		if count > 0 {
+			return nil, fmt.Errorf("%w: user is already a member", ErrInvalidInvite)
+		}
+	}
+
+	if err := s.db.WithContext(ctx).Create(invite).Error; err != nil {
+		return nil, fmt.Errorf("failed to create invite: %w", err)
+	}
+
+	newValue := fmt.Sprintf("discord_user=%s roles=%v expires_at=%s", invite.DiscordUserID, roleIDs, expiresAt.Format(time.RFC3339))
+	err := s.rbacService.LogPermissionChange(ctx, createdBy, tenantID, "invite_created", "tenant_invite", invite.ID, "", newValue, "", createdBy)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.CreateInviteResponse{
+		Code:   code,
+		Invite: *invite,
+	}, nil
+}
+
+// ListInvites lists a tenant's invites that can still be accepted
+func (s *MembershipService) ListInvites(ctx context.Context, tenantID string) ([]models.TenantInvite, error) {
+	var invites []models.TenantInvite
+	err := s.db.WithContext(ctx).
+		Where("tenant_id = ? AND status = ? AND expires_at > ?", tenantID, models.InviteStatusPending, time.Now()).
+		Order("created_at DESC").
+		Find(&invites).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invites: %w", err)
+	}
+
+	return invites, nil
+}
+
+// RevokeInvite stops an invite from being accepted
+func (s *MembershipService) RevokeInvite(ctx context.Context, tenantID, inviteID, performedBy string) error {
+	if _, err := uuid.Parse(inviteID); err != nil {
+		return ErrInviteNotFound
+	}
+
+	result := s.db.WithContext(ctx).Model(&models.TenantInvite{}).
+		Where("id = ? AND tenant_id = ? AND status = ?", inviteID, tenantID, models.InviteStatusPending).
+		Update("status", models.InviteStatusRevoked)
+	if result.Error != nil {
+		return fmt.Errorf("failed to revoke invite: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrInviteNotFound
+	}
+
+	return s.rbacService.LogPermissionChange(ctx, performedBy, tenantID, "invite_revoked", "tenant_invite", inviteID, models.InviteStatusPending, models.InviteStatusRevoked, "", performedBy)
+}
+
+// ListUserInvites lists the open invites addressed to a user's Discord account
+func (s *MembershipService) ListUserInvites(ctx context.Context, user *models.User) ([]models.TenantInvite, error) {
+	invites := []models.TenantInvite{}
+	if user.DiscordUserID == "" {
+		return invites, nil
+	}
+
+	err := s.db.WithContext(ctx).Preload("Tenant").
+		Where("discord_user_id = ? AND status = ? AND expires_at > ?", user.DiscordUserID, models.InviteStatusPending, time.Now()).
+		Order("created_at DESC").
+		Find(&invites).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invites: %w", err)
+	}
+
+	return invites, nil
+}
+
+// RespondToInvite accepts or declines an invite addressed to the user's
+// Discord account. Accepting makes the user a member with the invite's roles.
+func (s *MembershipService) RespondToInvite(ctx context.Context, user *models.User, inviteID string, accept bool) (*models.TenantInvite, error) {
+	if _, err := uuid.Parse(inviteID); err != nil || user.DiscordUserID == "" {
+		return nil, ErrInviteNotFound
+	}
+
+	var invite models.TenantInvite
+	err := s.db.WithContext(ctx).Preload("Tenant").Where("id = ? AND discord_user_id = ?", inviteID, user.DiscordUserID).First(&invite).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInviteNotFound
+		}
+		return nil, fmt.Errorf("failed to get invite: %w", err)
+	}
+
+	now := time.Now()
+	if !invite.IsOpen(now) {
+		return nil, ErrInviteClosed
+	}
+
+	status, action := models.InviteStatusDeclined, "invite_declined"
+	if accept {
+		status, action = models.InviteStatusAccepted, "invite_accepted"
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{"status": status, "responded_at": now}
+		if accept {
+			updates["uses"] = gorm.Expr("uses + 1")
+		}
+		// The status condition keeps two responses from both succeeding
+		result := tx.Model(&models.TenantInvite{}).Where("id = ? AND status = ?", invite.ID, models.InviteStatusPending).Updates(updates)
+		if result.Error != nil {
+			return fmt.Errorf("failed to update invite: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrInviteClosed
+		}
+
+		if !accept {
+			return nil
+		}
+		return s.join(tx, user.ID, invite.TenantID, invite.RoleIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+	invite.Status = status
+	invite.RespondedAt = &now
+
+	if accept {
+		if err := s.rbacService.InvalidateUserPermissions(ctx, user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.rbacService.LogPermissionChange(ctx, user.ID, invite.TenantID, action, "tenant_invite", invite.ID, models.InviteStatusPending, status, "", user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &invite, nil
+}
+
+// JoinWithCode makes the user a member of the tenant an invite link belongs to
+func (s *MembershipService) JoinWithCode(ctx context.Context, user *models.User, code string) (*models.TenantInvite, error) {
+	var invite models.TenantInvite
+	err := s.db.WithContext(ctx).Preload("Tenant").Where("code_hash = ?", hashAPIToken(code)).First(&invite).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInviteNotFound
+		}
+		return nil, fmt.Errorf("failed to get invite: %w", err)
+	}
+
+	if !invite.IsOpen(time.Now()) {
+		return nil, ErrInviteClosed
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.TenantInvite{}).Where("id = ? AND status = ?", invite.ID, models.InviteStatusPending).
+			UpdateColumn("uses", gorm.Expr("uses + 1"))
+		if result.Error != nil {
+			return fmt.Errorf("failed to update invite: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrInviteClosed
+		}
+
+		return s.join(tx, user.ID, invite.TenantID, invite.RoleIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+	invite.Uses++
+
+	if err := s.rbacService.InvalidateUserPermissions(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
+	err = s.rbacService.LogPermissionChange(ctx, user.ID, invite.TenantID, "invite_accepted", "tenant_invite", invite.ID, "", "link", "", user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &invite, nil
+}
+
+// JoinGuildTenants makes the user a member of the tenants of the Discord
+// servers they are in, unless a tenant requires invites. Users who left or were
+// removed from a tenant are not added back; an invite brings them back.
+func (s *MembershipService) JoinGuildTenants(ctx context.Context, user *models.User, guildIDs []string) ([]models.Tenant, error) {
+	joined := []models.Tenant{}
+	if len(guildIDs) == 0 || user.IsServiceAccount {
+		return joined, nil
+	}
+
+	// Soft-deleted memberships count, so leaving is not undone on the next login
+	var tenants []models.Tenant
+	err := s.db.WithContext(ctx).
+		Where("discord_server_id IN ?", guildIDs).
+		Where("NOT EXISTS (SELECT 1 FROM user_tenants WHERE user_tenants.tenant_id = tenants.id AND user_tenants.user_id = ?)", user.ID).
+		Find(&tenants).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get guild tenants: %w", err)
+	}
+
+	for _, tenant := range tenants {
+		if tenant.Config.Membership.DisableGuildJoin {
+			continue
+		}
+
+		err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return s.join(tx, user.ID, tenant.ID, nil)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		err = s.rbacService.LogPermissionChange(ctx, user.ID, tenant.ID, "member_joined", "membership", tenant.DiscordServerID, "", "", "shares Discord server", user.ID)
+		if err != nil {
+			return nil, err
+		}
+		joined = append(joined, tenant)
+	}
+
+	if len(joined) > 0 {
+		if err := s.rbacService.InvalidateUserPermissions(ctx, user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return joined, nil
+}
+
+// join makes a user a tenant member with the tenant's default roles and
+// permissions, the given roles and their synced Discord roles. Existing
+// members only gain the roles.
+func (s *MembershipService) join(tx *gorm.DB, userID, tenantID string, roleIDs []string) error {
+	var tenant models.Tenant
+	if err := tx.First(&tenant, "id = ?", tenantID).Error; err != nil {
+		return fmt.Errorf("failed to get tenant: %w", err)
+	}
+	policy := tenant.Config.Membership
+
+	// Roles are held by name; roles deleted since the invite was made are skipped
+	var ids []string
+	for _, roleID := range append(append([]string{}, policy.DefaultRoleIDs...), roleIDs...) {
+		if _, err := uuid.Parse(roleID); err == nil {
+			ids = append(ids, roleID)
+		}
+	}
+	var roles []string
+	if len(ids) > 0 {
+		err := tx.Model(&models.Role{}).Where("tenant_id = ? AND id IN ?", tenantID, ids).Pluck("name", &roles).Error
+		if err != nil {
+			return fmt.Errorf("failed to get roles: %w", err)
+		}
+	}
+
+	var membership models.UserTenant
+	err := tx.Where("user_id = ? AND tenant_id = ?", userID, tenantID).First(&membership).Error
+	if err == nil {
+		membership.Roles = models.StringArray(uniqueStrings(append(membership.Roles, roles...)))
+		if err := tx.Model(&membership).Update("roles", membership.Roles).Error; err != nil {
+			return fmt.Errorf("failed to update membership: %w", err)
+		}
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to check membership: %w", err)
+	}
+
+	// Discord roles synced before the user joined apply straight away
+	var discordUser models.TenantDiscordUser
+	err = tx.Joins("JOIN users ON users.discord_user_id = discord_users.discord_user_id").
+		Where("discord_users.tenant_id = ? AND users.id = ?", tenantID, userID).
+		First(&discordUser).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to get Discord member: %w", err)
+	}
+
+	membership = models.UserTenant{
+		UserID:      userID,
+		TenantID:    tenantID,
+		Roles:       models.StringArray(uniqueStrings(append(roles, discordUser.Roles...))),
+		Permissions: models.StringArray(policy.NewMemberPermissions()),
+	}
+	if err := tx.Create(&membership).Error; err != nil {
+		return fmt.Errorf("failed to add member: %w", err)
+	}
+
+	return nil
+}
+
+// membership gets a user's membership in a tenant, refusing the owner's
+func (s *MembershipService) membership(ctx context.Context, tenantID, userID string) (*models.UserTenant, error) {
+	var tenant models.Tenant
+	if err := s.db.WithContext(ctx).First(&tenant, "id = ?", tenantID).Error; err != nil {
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+	if tenant.OwnerID == userID {
+		return nil, ErrOwnerMembership
+	}
+
+	var membership models.UserTenant
+	err := s.db.WithContext(ctx).Where("user_id = ? AND tenant_id = ?", userID, tenantID).First(&membership).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotTenantMember
+		}
+		return nil, fmt.Errorf("failed to get membership: %w", err)
+	}
+
+	return &membership, nil
+}
+
+// generateInviteCode creates the secret of an invite link
+func generateInviteCode() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
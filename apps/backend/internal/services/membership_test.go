@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/config"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMembershipService(t *testing.T) {
+	db, cleanup := testutils.SetupTestDatabaseWithModels(t,
+		&models.User{},
+		&models.Tenant{},
+		&models.UserTenant{},
+		&models.TenantInvite{},
+		&models.TenantDiscordRole{},
+		&models.TenantDiscordUser{},
+		&models.Role{},
+		&models.SystemRole{},
+		&models.UserSystemRole{},
+		&models.PermissionAuditLog{},
+		&models.ResourceGrant{},
+	)
+	defer cleanup()
+	rbacService := NewRBACService(db, &config.RBACConfig{RoleSyncTTL: time.Minute})
+	membershipService := NewMembershipService(db, rbacService)
+	ctx := context.Background()
+
+	owner := &models.User{DiscordUserID: "1000", Username: "owner"}
+	require.NoError(t, db.Create(owner).Error)
+	manager := &models.User{DiscordUserID: "1001", Username: "manager"}
+	require.NoError(t, db.Create(manager).Error)
+	guest := &models.User{DiscordUserID: "1002", Username: "guest"}
+	require.NoError(t, db.Create(guest).Error)
+	linkUser := &models.User{DiscordUserID: "1003", Username: "link"}
+	require.NoError(t, db.Create(linkUser).Error)
+	guildUser := &models.User{DiscordUserID: "1004", Username: "guild"}
+	require.NoError(t, db.Create(guildUser).Error)
+
+	tenant := &models.Tenant{DiscordServerID: "guild-1", Name: "Open Guild", OwnerID: owner.ID}
+	require.NoError(t, db.Create(tenant).Error)
+	require.NoError(t, db.Create(&models.UserTenant{UserID: owner.ID, TenantID: tenant.ID, Permissions: models.StringArray{models.PermissionAdminAll}}).Error)
+	require.NoError(t, db.Create(&models.UserTenant{
+		UserID:      manager.ID,
+		TenantID:    tenant.ID,
+		Permissions: models.StringArray{models.PermissionUserCreate, models.PermissionUserDelete, models.PermissionServerRead, models.PermissionLogRead},
+	}).Error)
+
+	viewers, err := rbacService.CreateRole(ctx, tenant.ID, "viewers", []string{models.PermissionServerRead}, false)
+	require.NoError(t, err)
+	operators, err := rbacService.CreateRole(ctx, tenant.ID, "operators", []string{models.PermissionServerStart}, false)
+	require.NoError(t, err)
+
+	t.Run("invites cannot give roles the inviter does not hold", func(t *testing.T) {
+		_, err := membershipService.CreateInvite(ctx, tenant.ID, models.CreateInviteRequest{DiscordUserID: guest.DiscordUserID, RoleIDs: []string{operators.ID}}, manager.ID)
+		assert.ErrorIs(t, err, ErrPermissionNotHeld)
+	})
+
+	t.Run("invite validation", func(t *testing.T) {
+		_, err := membershipService.CreateInvite(ctx, tenant.ID, models.CreateInviteRequest{DiscordUserID: "guest"}, manager.ID)
+		assert.ErrorIs(t, err, ErrInvalidInvite)
+		_, err = membershipService.CreateInvite(ctx, tenant.ID, models.CreateInviteRequest{DiscordUserID: manager.DiscordUserID}, manager.ID)
+		assert.ErrorIs(t, err, ErrInvalidInvite)
+		tooLate := time.Now().Add(60 * 24 * time.Hour)
+		_, err = membershipService.CreateInvite(ctx, tenant.ID, models.CreateInviteRequest{ExpiresAt: &tooLate}, manager.ID)
+		assert.ErrorIs(t, err, ErrInvalidInvite)
+	})
+
+	t.Run("targeted invite is accepted by its recipient only", func(t *testing.T) {
+		response, err := membershipService.CreateInvite(ctx, tenant.ID, models.CreateInviteRequest{DiscordUserID: guest.DiscordUserID, RoleIDs: []string{viewers.ID}}, manager.ID)
+		require.NoError(t, err)
+		assert.Empty(t, response.Code)
+
+		invites, err := membershipService.ListUserInvites(ctx, guest)
+		require.NoError(t, err)
+		require.Len(t, invites, 1)
+		assert.Equal(t, tenant.Name, invites[0].Tenant.Name)
+
+		_, err = membershipService.RespondToInvite(ctx, linkUser, response.Invite.ID, true)
+		assert.ErrorIs(t, err, ErrInviteNotFound)
+
+		invite, err := membershipService.RespondToInvite(ctx, guest, response.Invite.ID, true)
+		require.NoError(t, err)
+		assert.Equal(t, models.InviteStatusAccepted, invite.Status)
+
+		_, err = membershipService.RespondToInvite(ctx, guest, response.Invite.ID, false)
+		assert.ErrorIs(t, err, ErrInviteClosed)
+
+		has, err := rbacService.HasPermission(ctx, guest.ID, tenant.ID, models.PermissionServerRead)
+		require.NoError(t, err)
+		assert.True(t, has)
+	})
+
+	t.Run("declined invite does not add the member", func(t *testing.T) {
+		outsider := &models.User{DiscordUserID: "1005", Username: "outsider"}
+		require.NoError(t, db.Create(outsider).Error)
+		response, err := membershipService.CreateInvite(ctx, tenant.ID, models.CreateInviteRequest{DiscordUserID: outsider.DiscordUserID}, manager.ID)
+		require.NoError(t, err)
+
+		invite, err := membershipService.RespondToInvite(ctx, outsider, response.Invite.ID, false)
+		require.NoError(t, err)
+		assert.Equal(t, models.InviteStatusDeclined, invite.Status)
+
+		isMember, err := rbacService.IsTenantMember(ctx, outsider.ID, tenant.ID)
+		require.NoError(t, err)
+		assert.False(t, isMember)
+	})
+
+	t.Run("invite link", func(t *testing.T) {
+		response, err := membershipService.CreateInvite(ctx, tenant.ID, models.CreateInviteRequest{}, manager.ID)
+		require.NoError(t, err)
+		require.NotEmpty(t, response.Code)
+
+		_, err = membershipService.JoinWithCode(ctx, linkUser, "wrong-code")
+		assert.ErrorIs(t, err, ErrInviteNotFound)
+
+		invite, err := membershipService.JoinWithCode(ctx, linkUser, response.Code)
+		require.NoError(t, err)
+		assert.Equal(t, 1, invite.Uses)
+		isMember, err := rbacService.IsTenantMember(ctx, linkUser.ID, tenant.ID)
+		require.NoError(t, err)
+		assert.True(t, isMember)
+
+		require.NoError(t, membershipService.RevokeInvite(ctx, tenant.ID, response.Invite.ID, manager.ID))
+		_, err = membershipService.JoinWithCode(ctx, guildUser, response.Code)
+		assert.ErrorIs(t, err, ErrInviteClosed)
+	})
+
+	t.Run("members list effective roles", func(t *testing.T) {
+		members, err := membershipService.ListMembers(ctx, tenant.ID)
+		require.NoError(t, err)
+		for _, member := range members {
+			if member.UserID == guest.ID {
+				require.Len(t, member.Roles, 1)
+				assert.Equal(t, "viewers", member.Roles[0].Name)
+				assert.Contains(t, member.Permissions, models.PermissionServerRead)
+			}
+			assert.Equal(t, member.UserID == owner.ID, member.IsOwner)
+		}
+	})
+
+	t.Run("guild membership with role defaults", func(t *testing.T) {
+		defaults := &models.Tenant{DiscordServerID: "guild-2", Name: "Defaults Guild", OwnerID: owner.ID}
+		require.NoError(t, db.Create(defaults).Error)
+		defaultRole, err := rbacService.CreateRole(ctx, defaults.ID, "players", []string{models.PermissionServerRead}, false)
+		require.NoError(t, err)
+		defaults.Config.Membership = models.MembershipPolicy{DefaultRoleIDs: []string{defaultRole.ID}, DefaultPermissions: []string{models.PermissionFileRead}}
+		require.NoError(t, db.Save(defaults).Error)
+
+		closed := &models.Tenant{DiscordServerID: "guild-3", Name: "Closed Guild", OwnerID: owner.ID}
+		closed.Config.Membership.DisableGuildJoin = true
+		require.NoError(t, db.Create(closed).Error)
+
+		joined, err := membershipService.JoinGuildTenants(ctx, guildUser, []string{"guild-1", "guild-2", "guild-3", "guild-unknown"})
+		require.NoError(t, err)
+		var joinedIDs []string
+		for _, joinedTenant := range joined {
+			joinedIDs = append(joinedIDs, joinedTenant.ID)
+		}
+		assert.ElementsMatch(t, []string{tenant.ID, defaults.ID}, joinedIDs)
+
+		var membership models.UserTenant
+		require.NoError(t, db.Where("user_id = ? AND tenant_id = ?", guildUser.ID, defaults.ID).First(&membership).Error)
+		assert.Equal(t, []string{"players"}, []string(membership.Roles))
+		assert.Equal(t, []string{models.PermissionFileRead}, []string(membership.Permissions))
+
+		joined, err = membershipService.JoinGuildTenants(ctx, guildUser, []string{"guild-1", "guild-2"})
+		require.NoError(t, err)
+		assert.Empty(t, joined)
+	})
+
+	t.Run("leaving is not undone by guild membership", func(t *testing.T) {
+		require.NoError(t, membershipService.LeaveTenant(ctx, tenant.ID, guildUser.ID))
+
+		joined, err := membershipService.JoinGuildTenants(ctx, guildUser, []string{"guild-1"})
+		require.NoError(t, err)
+		assert.Empty(t, joined)
+		isMember, err := rbacService.IsTenantMember(ctx, guildUser.ID, tenant.ID)
+		require.NoError(t, err)
+		assert.False(t, isMember)
+	})
+
+	t.Run("removal", func(t *testing.T) {
+		assert.ErrorIs(t, membershipService.LeaveTenant(ctx, tenant.ID, owner.ID), ErrOwnerMembership)
+		assert.ErrorIs(t, membershipService.RemoveMember(ctx, tenant.ID, owner.ID, manager.ID), ErrOwnerMembership)
+		assert.ErrorIs(t, membershipService.RemoveMember(ctx, tenant.ID, uuid.New().String(), manager.ID), ErrNotTenantMember)
+
+		require.NoError(t, rbacService.AssignRoleToUser(ctx, linkUser.ID, tenant.ID, operators.Name))
+		assert.ErrorIs(t, membershipService.RemoveMember(ctx, tenant.ID, linkUser.ID, manager.ID), ErrPermissionNotHeld)
+
+		require.NoError(t, membershipService.RemoveMember(ctx, tenant.ID, guest.ID, manager.ID))
+		isMember, err := rbacService.IsTenantMember(ctx, guest.ID, tenant.ID)
+		require.NoError(t, err)
+		assert.False(t, isMember)
+	})
+
+	var changes int64
+	require.NoError(t, db.Model(&models.PermissionAuditLog{}).Where("action IN ?", []string{"invite_created", "invite_accepted", "invite_declined", "invite_revoked", "member_joined", "member_left", "member_removed"}).Count(&changes).Error)
+	assert.GreaterOrEqual(t, changes, int64(9))
+}
@@ -0,0 +1,339 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/config"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	// notificationMaxRetries is how many times a rate-limited message is retried
+	notificationMaxRetries = 3
+	// notificationMaxRetryWait caps how long a single rate-limit wait may take
+	notificationMaxRetryWait = 30 * time.Second
+	// digestMaxLines is the most events listed individually in a digest embed
+	digestMaxLines = 20
+)
+
+var notificationTitles = map[string]string{
+	models.NotificationServerCrashed:     "Server crashed",
+	models.NotificationServerStarted:     "Server started",
+	models.NotificationServerStopped:     "Server stopped",
+	models.NotificationBackupCompleted:   "Backup completed",
+	models.NotificationResourceLimit:     "Resource limit reached",
+	models.NotificationControllerOffline: "Controller offline",
+	models.NotificationTransferRequested: "Ownership transfer requested",
+	models.NotificationOwnerChanged:      "Tenant owner changed",
+}
+
+var notificationColors = map[string]int{
+	models.NotificationServerCrashed:     0xE74C3C,
+	models.NotificationServerStarted:     0x2ECC71,
+	models.NotificationServerStopped:     0x95A5A6,
+	models.NotificationBackupCompleted:   0x3498DB,
+	models.NotificationResourceLimit:     0xF1C40F,
+	models.NotificationControllerOffline: 0xE67E22,
+	models.NotificationTransferRequested: 0x9B59B6,
+	models.NotificationOwnerChanged:      0x9B59B6,
+}
+
+// NotificationService posts tenant notifications to Discord channels
+type NotificationService struct {
+	db         *gorm.DB
+	httpClient *http.Client
+	apiBaseURL string
+	botToken   string
+
+	mu       sync.Mutex
+	channels map[string]*notificationChannelState
+}
+
+// notificationChannelState tracks rate limiting and pending digest events for a channel
+type notificationChannelState struct {
+	windowStart time.Time
+	sent        int
+	pending     []models.NotificationEvent
+	flushTimer  *time.Timer
+}
+
+// NewNotificationService creates a new notification service
+func NewNotificationService(db *gorm.DB, cfg *config.DiscordConfig) *NotificationService {
+	return &NotificationService{
+		db:         db,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		apiBaseURL: cfg.APIBaseURL,
+		botToken:   cfg.BotToken,
+		channels:   make(map[string]*notificationChannelState),
+	}
+}
+
+// Notify delivers an event to the notification channels of its tenant
+func (ns *NotificationService) Notify(ctx context.Context, event models.NotificationEvent) error {
+	var tenant models.Tenant
+	err := ns.db.WithContext(ctx).Where("id = ?", event.TenantID).First(&tenant).Error
+	if err != nil {
+		return fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	return ns.Dispatch(ctx, &tenant, event)
+}
+
+// NotifyControllerOffline notifies every tenant with servers on a cluster that its controller went offline
+func (ns *NotificationService) NotifyControllerOffline(ctx context.Context, clusterID, clusterName string) error {
+	var tenantIDs []string
+	err := ns.db.WithContext(ctx).Model(&models.GameServer{}).
+		Where("cluster_id = ?", clusterID).
+		Distinct().
+		Pluck("tenant_id", &tenantIDs).Error
+	if err != nil {
+		return fmt.Errorf("failed to get tenants on cluster: %w", err)
+	}
+
+	var errs []error
+	for _, tenantID := range tenantIDs {
+		err := ns.Notify(ctx, models.NotificationEvent{
+			Type:       models.NotificationControllerOffline,
+			TenantID:   tenantID,
+			ClusterID:  clusterID,
+			Message:    fmt.Sprintf("The controller for cluster %s stopped sending heartbeats. Servers on it may be unreachable.", clusterName),
+			OccurredAt: time.Now().UTC(),
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Dispatch posts an event to each of the tenant's channels subscribed to it,
+// applying per-channel rate limits and digests
+func (ns *NotificationService) Dispatch(ctx context.Context, tenant *models.Tenant, event models.NotificationEvent) error {
+	if ns.botToken == "" {
+		return nil
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now().UTC()
+	}
+
+	var errs []error
+	for _, target := range tenant.Config.NotificationTargets() {
+		if !target.Wants(event.Type) {
+			continue
+		}
+		if !ns.admit(tenant.ID, target, event) {
+			continue
+		}
+		err := ns.send(ctx, target.ChannelID, []*discordgo.MessageEmbed{notificationEmbed(event)})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("channel %s: %w", target.ChannelID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// admit decides whether an event is sent now. Events that are digested or over
+// the channel's rate limit are queued and sent together when the timer fires.
+func (ns *NotificationService) admit(tenantID string, target models.NotificationChannelConfig, event models.NotificationEvent) bool {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+
+	key := tenantID + ":" + target.ChannelID
+	state, ok := ns.channels[key]
+	if !ok {
+		state = &notificationChannelState{}
+		ns.channels[key] = state
+	}
+
+	now := time.Now()
+	if target.DigestMinutes > 0 {
+		state.pending = append(state.pending, event)
+		ns.scheduleFlush(key, target.ChannelID, state, time.Duration(target.DigestMinutes)*time.Minute)
+		return false
+	}
+
+	if target.MaxPerMinute > 0 {
+		if now.Sub(state.windowStart) >= time.Minute {
+			state.windowStart = now
+			state.sent = 0
+		}
+		if state.sent >= target.MaxPerMinute {
+			state.pending = append(state.pending, event)
+			ns.scheduleFlush(key, target.ChannelID, state, time.Minute-now.Sub(state.windowStart))
+			return false
+		}
+		state.sent++
+	}
+
+	return true
+}
+
+// scheduleFlush arms the channel's digest timer unless it is already running. Callers hold ns.mu.
+func (ns *NotificationService) scheduleFlush(key, channelID string, state *notificationChannelState, after time.Duration) {
+	if state.flushTimer != nil {
+		return
+	}
+	state.flushTimer = time.AfterFunc(after, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if err := ns.flush(ctx, key, channelID); err != nil {
+			log.Printf("Failed to send notification digest to channel %s: %v", channelID, err)
+		}
+	})
+}
+
+// flush sends the pending events of a channel as a single digest message
+func (ns *NotificationService) flush(ctx context.Context, key, channelID string) error {
+	ns.mu.Lock()
+	state, ok := ns.channels[key]
+	if !ok || len(state.pending) == 0 {
+		if ok {
+			state.flushTimer = nil
+		}
+		ns.mu.Unlock()
+		return nil
+	}
+	events := state.pending
+	state.pending = nil
+	state.flushTimer = nil
+	// The digest counts against the new rate-limit window
+	state.windowStart = time.Now()
+	state.sent = 1
+	ns.mu.Unlock()
+
+	return ns.send(ctx, channelID, []*discordgo.MessageEmbed{digestEmbed(events)})
+}
+
+// send posts embeds to a channel, retrying when Discord responds with a rate limit
+func (ns *NotificationService) send(ctx context.Context, channelID string, embeds []*discordgo.MessageEmbed) error {
+	body, err := json.Marshal(map[string]interface{}{"embeds": embeds})
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/channels/%s/messages", ns.apiBaseURL, channelID)
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("failed to create message request: %w", err)
+		}
+		req.Header.Set("Authorization", "Bot "+ns.botToken)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := ns.httpClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to send message: %w", err)
+		}
+		respBody, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return nil
+		}
+		if resp.StatusCode != http.StatusTooManyRequests || attempt >= notificationMaxRetries {
+			return fmt.Errorf("discord message API error: %d - %s", resp.StatusCode, string(respBody))
+		}
+
+		select {
+		case <-time.After(retryAfter(resp.Header, respBody)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// retryAfter reads how long Discord asks us to wait from a 429 response
+func retryAfter(header http.Header, body []byte) time.Duration {
+	wait := time.Second
+
+	var rateLimit struct {
+		RetryAfter float64 `json:"retry_after"`
+	}
+	if err := json.Unmarshal(body, &rateLimit); err == nil && rateLimit.RetryAfter > 0 {
+		wait = time.Duration(rateLimit.RetryAfter * float64(time.Second))
+	} else if seconds, err := strconv.ParseFloat(header.Get("Retry-After"), 64); err == nil && seconds > 0 {
+		wait = time.Duration(seconds * float64(time.Second))
+	}
+
+	if wait > notificationMaxRetryWait {
+		wait = notificationMaxRetryWait
+	}
+	return wait
+}
+
+// notificationTitle returns the embed title for an event
+func notificationTitle(event models.NotificationEvent) string {
+	title, ok := notificationTitles[event.Type]
+	if !ok {
+		title = event.Type
+	}
+	if event.ServerName != "" {
+		title += ": " + event.ServerName
+	}
+	return title
+}
+
+func notificationEmbed(event models.NotificationEvent) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:       notificationTitle(event),
+		Description: event.Message,
+		Color:       notificationColors[event.Type],
+		Timestamp:   event.OccurredAt.Format(time.RFC3339),
+	}
+
+	keys := make([]string, 0, len(event.Details))
+	for key := range event.Details {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   key,
+			Value:  event.Details[key],
+			Inline: true,
+		})
+	}
+	if event.ClusterID != "" {
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: "Cluster " + event.ClusterID}
+	}
+
+	return embed
+}
+
+func digestEmbed(events []models.NotificationEvent) *discordgo.MessageEmbed {
+	lines := make([]string, 0, digestMaxLines+1)
+	for idx, event := range events {
+		if idx == digestMaxLines {
+			lines = append(lines, fmt.Sprintf("...and %d more", len(events)-digestMaxLines))
+			break
+		}
+		line := fmt.Sprintf("<t:%d:t> **%s**", event.OccurredAt.Unix(), notificationTitle(event))
+		if event.Message != "" {
+			line += " - " + event.Message
+		}
+		lines = append(lines, line)
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("%d notifications", len(events)),
+		Description: strings.Join(lines, "\n"),
+		Color:       0x5865F2,
+		Timestamp:   time.Now().UTC().Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/config"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/testutils"
+)
+
+// fakeDiscordREST records messages posted to channels and can answer with rate limits
+type fakeDiscordREST struct {
+	mu          sync.Mutex
+	messages    map[string][][]*discordgo.MessageEmbed
+	rateLimited int
+	requests    int
+}
+
+func newFakeDiscordREST(t *testing.T) (*fakeDiscordREST, *httptest.Server) {
+	fake := &fakeDiscordREST{messages: make(map[string][][]*discordgo.MessageEmbed)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		fake.requests++
+
+		assert.Equal(t, "Bot test-token", r.Header.Get("Authorization"))
+
+		if fake.rateLimited > 0 {
+			fake.rateLimited--
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"message": "You are being rate limited.", "retry_after": 0.01, "global": false}`))
+			return
+		}
+
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		require.Len(t, parts, 3)
+		var body struct {
+			Embeds []*discordgo.MessageEmbed `json:"embeds"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		fake.messages[parts[1]] = append(fake.messages[parts[1]], body.Embeds)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func (f *fakeDiscordREST) channelMessages(channelID string) [][]*discordgo.MessageEmbed {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.messages[channelID]
+}
+
+func newTestNotificationService(server *httptest.Server) *NotificationService {
+	return NewNotificationService(nil, &config.DiscordConfig{APIBaseURL: server.URL, BotToken: "test-token"})
+}
+
+func TestNotificationService_DispatchRoutesByEvent(t *testing.T) {
+	fake, server := newFakeDiscordREST(t)
+	ns := newTestNotificationService(server)
+
+	tenant := &models.Tenant{ID: "tenant-1", Config: models.TenantConfig{
+		NotificationChannels: []string{"legacy"},
+		Notifications: []models.NotificationChannelConfig{
+			{ChannelID: "crashes", Events: []string{models.NotificationServerCrashed}},
+		},
+	}}
+
+	err := ns.Dispatch(context.Background(), tenant, models.NotificationEvent{
+		Type:       models.NotificationServerCrashed,
+		ServerName: "Survival World",
+		Message:    "Exited with code 137",
+		Details:    map[string]string{"exit_code": "137"},
+	})
+	require.NoError(t, err)
+
+	err = ns.Dispatch(context.Background(), tenant, models.NotificationEvent{Type: models.NotificationServerStarted, ServerName: "Survival World"})
+	require.NoError(t, err)
+
+	crashes := fake.channelMessages("crashes")
+	require.Len(t, crashes, 1)
+	assert.Equal(t, "Server crashed: Survival World", crashes[0][0].Title)
+	assert.Equal(t, "137", crashes[0][0].Fields[0].Value)
+
+	// Legacy channels receive every event
+	assert.Len(t, fake.channelMessages("legacy"), 2)
+}
+
+func TestNotificationService_RetriesRateLimits(t *testing.T) {
+	fake, server := newFakeDiscordREST(t)
+	ns := newTestNotificationService(server)
+	fake.rateLimited = 2
+
+	tenant := &models.Tenant{ID: "tenant-1", Config: models.TenantConfig{NotificationChannels: []string{"general"}}}
+	err := ns.Dispatch(context.Background(), tenant, models.NotificationEvent{Type: models.NotificationBackupCompleted})
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, fake.requests)
+	assert.Len(t, fake.channelMessages("general"), 1)
+
+	// Giving up after the retry budget is spent surfaces an error
+	fake.rateLimited = notificationMaxRetries + 1
+	err = ns.Dispatch(context.Background(), tenant, models.NotificationEvent{Type: models.NotificationBackupCompleted})
+	assert.Error(t, err)
+}
+
+func TestNotificationService_RateLimitAndDigest(t *testing.T) {
+	fake, server := newFakeDiscordREST(t)
+	ns := newTestNotificationService(server)
+	ctx := context.Background()
+
+	tenant := &models.Tenant{ID: "tenant-1", Config: models.TenantConfig{
+		Notifications: []models.NotificationChannelConfig{
+			{ChannelID: "limited", MaxPerMinute: 1},
+			{ChannelID: "digest", DigestMinutes: 15},
+		},
+	}}
+
+	for _, eventType := range []string{models.NotificationServerStarted, models.NotificationServerStopped, models.NotificationServerCrashed} {
+		require.NoError(t, ns.Dispatch(ctx, tenant, models.NotificationEvent{Type: eventType}))
+	}
+
+	// Only the first event fits in the window; nothing is sent to the digest channel yet
+	assert.Len(t, fake.channelMessages("limited"), 1)
+	assert.Empty(t, fake.channelMessages("digest"))
+
+	require.NoError(t, ns.flush(ctx, "tenant-1:limited", "limited"))
+	require.NoError(t, ns.flush(ctx, "tenant-1:digest", "digest"))
+
+	limited := fake.channelMessages("limited")
+	require.Len(t, limited, 2)
+	assert.Equal(t, "2 notifications", limited[1][0].Title)
+
+	digest := fake.channelMessages("digest")
+	require.Len(t, digest, 1)
+	assert.Equal(t, "3 notifications", digest[0][0].Title)
+	assert.Contains(t, digest[0][0].Description, "Server crashed")
+}
+
+func TestControllerEventsNotifyTenant(t *testing.T) {
+	db, cleanup := testutils.SetupTestDatabaseWithModels(t, &models.Controller{}, &models.Tenant{}, &models.GameServer{})
+	defer cleanup()
+	fake, server := newFakeDiscordREST(t)
+	notifier := NewNotificationService(db, &config.DiscordConfig{APIBaseURL: server.URL, BotToken: "test-token"})
+	cfg := &config.Config{
+		JWT:        config.JWTConfig{Secret: "test-secret-key", Issuer: "pteronimbus-test"},
+		Controller: config.ControllerConfig{HeartbeatTTL: time.Minute * 5, MaxHeartbeatAge: time.Minute * 10},
+	}
+	controllerService := NewControllerServiceWithNotifier(db, cfg, NewJWTService(cfg), notifier)
+	ctx := context.Background()
+
+	tenant := &models.Tenant{DiscordServerID: "guild-events", Name: "Events", OwnerID: "user-1", Config: models.TenantConfig{
+		Notifications: []models.NotificationChannelConfig{{ChannelID: "events"}},
+	}}
+	require.NoError(t, db.Create(tenant).Error)
+	gameServer := &models.GameServer{TenantID: tenant.ID, Name: "Survival World", GameType: "minecraft"}
+	require.NoError(t, db.Create(gameServer).Error)
+
+	handshake, err := controllerService.Handshake(ctx, &models.HandshakeRequest{ClusterID: "cluster-events", ClusterName: "Events Cluster", Version: "1.0.0", Nonce: "nonce"})
+	require.NoError(t, err)
+	require.True(t, handshake.Success)
+	crash := &models.ServerEventRequest{Type: models.NotificationServerCrashed, ServerID: gameServer.ID, Message: "Exited with code 137"}
+
+	// Servers must be assigned to an approved controller before it can report on them
+	_, err = controllerService.AssignGameServer(ctx, handshake.ControllerID, gameServer.ID)
+	assert.ErrorIs(t, err, ErrControllerNotApproved)
+	assert.ErrorIs(t, controllerService.ReportServerEvent(ctx, handshake.ControllerID, crash), ErrGameServerNotFound)
+
+	approval, err := controllerService.ApproveController(ctx, handshake.ControllerID, "user-1")
+	require.NoError(t, err)
+	require.True(t, approval.Success)
+	assigned, err := controllerService.AssignGameServer(ctx, handshake.ControllerID, gameServer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "cluster-events", assigned.ClusterID)
+
+	require.NoError(t, controllerService.ReportServerEvent(ctx, handshake.ControllerID, crash))
+	require.Eventually(t, func() bool { return len(fake.channelMessages("events")) == 1 }, time.Second*5, time.Millisecond*10)
+	embed := fake.channelMessages("events")[0][0]
+	assert.Equal(t, "Server crashed: Survival World", embed.Title)
+	assert.Equal(t, "Cluster cluster-events", embed.Footer.Text)
+
+	// A controller that stops sending heartbeats takes its servers' tenants with it
+	require.NoError(t, db.Model(&models.Controller{}).Where("id = ?", handshake.ControllerID).Update("last_heartbeat", time.Now().UTC().Add(-time.Hour)).Error)
+	require.NoError(t, controllerService.CheckOfflineControllers(ctx))
+	require.Eventually(t, func() bool { return len(fake.channelMessages("events")) == 2 }, time.Second*5, time.Millisecond*10)
+	assert.Equal(t, "Controller offline", fake.channelMessages("events")[1][0].Title)
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrOAuthStateNotFound is returned when an OAuth state is unknown, expired or already used
+var ErrOAuthStateNotFound = errors.New("oauth state not found")
+
+// MemoryOAuthStateStore keeps OAuth states in process. It only works with a
+// single backend replica and is meant for development and tests.
+type MemoryOAuthStateStore struct {
+	mu     sync.Mutex
+	states map[string]models.OAuthState
+}
+
+// NewMemoryOAuthStateStore creates a new in-memory OAuth state store
+func NewMemoryOAuthStateStore() *MemoryOAuthStateStore {
+	return &MemoryOAuthStateStore{
+		states: make(map[string]models.OAuthState),
+	}
+}
+
+// SaveState stores a state until it expires or is consumed
+func (s *MemoryOAuthStateStore) SaveState(ctx context.Context, state *models.OAuthState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Drop expired states so abandoned logins don't accumulate
+	now := time.Now()
+	for key, stored := range s.states {
+		if now.After(stored.ExpiresAt) {
+			delete(s.states, key)
+		}
+	}
+
+	s.states[state.State] = *state
+	return nil
+}
+
+// ConsumeState returns a state and removes it so it can only be used once
+func (s *MemoryOAuthStateStore) ConsumeState(ctx context.Context, state string) (*models.OAuthState, error) {
+	s.mu.Lock()
+	stored, exists := s.states[state]
+	delete(s.states, state)
+	s.mu.Unlock()
+
+	if !exists {
+		return nil, ErrOAuthStateNotFound
+	}
+	return &stored, nil
+}
+
+// RedisOAuthStateStore keeps OAuth states in Redis so that a login can be
+// completed by any backend replica
+type RedisOAuthStateStore struct {
+	client *redis.Client
+}
+
+// NewRedisOAuthStateStore creates a new OAuth state store sharing the Redis service's connection
+func NewRedisOAuthStateStore(redisService *RedisService) *RedisOAuthStateStore {
+	return &RedisOAuthStateStore{
+		client: redisService.client,
+	}
+}
+
+// SaveState stores a state with a TTL matching its expiry
+func (s *RedisOAuthStateStore) SaveState(ctx context.Context, state *models.OAuthState) error {
+	ttl := time.Until(state.ExpiresAt)
+	if ttl <= 0 {
+		return fmt.Errorf("oauth state already expired")
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal oauth state: %w", err)
+	}
+
+	err = s.client.Set(ctx, oauthStateKey(state.State), data, ttl).Err()
+	if err != nil {
+		return fmt.Errorf("failed to store oauth state: %w", err)
+	}
+	return nil
+}
+
+// ConsumeState atomically reads and deletes a state so it can only be used once,
+// even when two replicas receive the same callback
+func (s *RedisOAuthStateStore) ConsumeState(ctx context.Context, state string) (*models.OAuthState, error) {
+	data, err := s.client.GetDel(ctx, oauthStateKey(state)).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, ErrOAuthStateNotFound
+		}
+		return nil, fmt.Errorf("failed to get oauth state: %w", err)
+	}
+
+	var stored models.OAuthState
+	if err := json.Unmarshal([]byte(data), &stored); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal oauth state: %w", err)
+	}
+	return &stored, nil
+}
+
+func oauthStateKey(state string) string {
+	return fmt.Sprintf("oauth_state:%s", state)
+}
@@ -0,0 +1,353 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/config"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+)
+
+// oidcStub is a minimal OpenID Connect provider for tests
+type oidcStub struct {
+	t        *testing.T
+	server   *httptest.Server
+	clientID string
+
+	mu             sync.Mutex
+	key            *rsa.PrivateKey
+	kid            string
+	authorizations map[string]stubAuthorization
+	jwksRequests   int
+}
+
+// stubAuthorization is what the stub remembers about an issued authorization code
+type stubAuthorization struct {
+	challenge string
+	claims    jwt.MapClaims
+}
+
+func newOIDCStub(t *testing.T) *oidcStub {
+	stub := &oidcStub{t: t, clientID: "pteronimbus", authorizations: make(map[string]stubAuthorization)}
+	stub.rotateKey()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 stub.server.URL,
+			"authorization_endpoint": stub.server.URL + "/authorize",
+			"token_endpoint":         stub.server.URL + "/token",
+			"jwks_uri":               stub.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		stub.mu.Lock()
+		defer stub.mu.Unlock()
+		stub.jwksRequests++
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": stub.kid,
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(stub.key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(stub.key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", stub.handleToken)
+	stub.server = httptest.NewServer(mux)
+	t.Cleanup(stub.server.Close)
+
+	return stub
+}
+
+func (s *oidcStub) rotateKey() {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(s.t, err)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.key = key
+	s.kid = fmt.Sprintf("key-%d", time.Now().UnixNano())
+}
+
+func (s *oidcStub) providerConfig() config.OIDCProviderConfig {
+	return config.OIDCProviderConfig{
+		Name:          "authentik",
+		DisplayName:   "Authentik",
+		IssuerURL:     s.server.URL,
+		ClientID:      s.clientID,
+		ClientSecret:  "secret",
+		RedirectURL:   "http://localhost:8080/auth/oidc/authentik/callback",
+		Scopes:        []string{"openid", "profile", "email"},
+		UsernameClaim: "preferred_username",
+		EmailClaim:    "email",
+		AvatarClaim:   "picture",
+	}
+}
+
+// authorize simulates the user approving the login at the provider and returns the code
+func (s *oidcStub) authorize(authURL string, claims jwt.MapClaims) string {
+	parsed, err := url.Parse(authURL)
+	require.NoError(s.t, err)
+	query := parsed.Query()
+	require.Equal(s.t, "S256", query.Get("code_challenge_method"))
+
+	idClaims := jwt.MapClaims{
+		"iss":   s.server.URL,
+		"aud":   s.clientID,
+		"sub":   "subject-1",
+		"nonce": query.Get("nonce"),
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}
+	for name, value := range claims {
+		idClaims[name] = value
+	}
+
+	code := fmt.Sprintf("code-%d", len(s.authorizations)+1)
+	s.mu.Lock()
+	s.authorizations[code] = stubAuthorization{challenge: query.Get("code_challenge"), claims: idClaims}
+	s.mu.Unlock()
+	return code
+}
+
+func (s *oidcStub) handleToken(w http.ResponseWriter, r *http.Request) {
+	require.NoError(s.t, r.ParseForm())
+
+	s.mu.Lock()
+	authorization, ok := s.authorizations[r.PostForm.Get("code")]
+	delete(s.authorizations, r.PostForm.Get("code"))
+	key, kid := s.key, s.kid
+	s.mu.Unlock()
+
+	verifierHash := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(verifierHash[:]) != authorization.challenge {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": "invalid_grant"}`))
+		return
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, authorization.claims)
+	token.Header["kid"] = kid
+	idToken, err := token.SignedString(key)
+	require.NoError(s.t, err)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": "provider-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func newOIDCTestAuthService(stub *oidcStub) *AuthService {
+	authService := NewAuthService(nil, new(MockDiscordService), new(MockJWTService), new(MockRedisService))
+	authService.RegisterIdentityProvider(NewOIDCProvider(stub.providerConfig()))
+	return authService
+}
+
+func TestOIDCProvider_Login(t *testing.T) {
+	stub := newOIDCStub(t)
+	authService := newOIDCTestAuthService(stub)
+	provider, _ := authService.identityProvider("authentik")
+	ctx := context.Background()
+
+	login, err := authService.BeginProviderLogin(ctx, "authentik", "")
+	require.NoError(t, err)
+	authURL, err := url.Parse(login.AuthURL)
+	require.NoError(t, err)
+	assert.Equal(t, "/authorize", authURL.Path)
+	assert.Equal(t, login.State, authURL.Query().Get("state"))
+	assert.Equal(t, "pteronimbus", authURL.Query().Get("client_id"))
+	assert.NotEmpty(t, authURL.Query().Get("code_challenge"))
+	assert.NotEqual(t, login.CodeVerifier, authURL.Query().Get("code_challenge"))
+
+	code := stub.authorize(login.AuthURL, jwt.MapClaims{
+		"preferred_username": "alice",
+		"email":              "alice@example.com",
+		"email_verified":     true,
+	})
+	identity, err := provider.Exchange(ctx, code, login.Nonce, login.CodeVerifier)
+	require.NoError(t, err)
+	assert.Equal(t, "authentik", identity.Provider)
+	assert.Equal(t, "subject-1", identity.Subject)
+	assert.Equal(t, "alice", identity.Username)
+	assert.Equal(t, "alice@example.com", identity.Email)
+	assert.True(t, identity.EmailVerified)
+}
+
+func TestOIDCProvider_RejectsInvalidLogins(t *testing.T) {
+	stub := newOIDCStub(t)
+	authService := newOIDCTestAuthService(stub)
+	provider, _ := authService.identityProvider("authentik")
+	ctx := context.Background()
+
+	tests := []struct {
+		name         string
+		claims       jwt.MapClaims
+		verifier     string
+		nonce        string
+		invalidToken bool
+	}{
+		{name: "wrong PKCE verifier", verifier: "not-the-verifier"},
+		{name: "nonce mismatch", nonce: "other-nonce", invalidToken: true},
+		{name: "wrong audience", claims: jwt.MapClaims{"aud": "someone-else"}, invalidToken: true},
+		{name: "wrong issuer", claims: jwt.MapClaims{"iss": "https://evil.example.com"}, invalidToken: true},
+		{name: "expired", claims: jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()}, invalidToken: true},
+		{name: "missing subject", claims: jwt.MapClaims{"sub": ""}, invalidToken: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			login, err := authService.BeginProviderLogin(ctx, "authentik", "")
+			require.NoError(t, err)
+			code := stub.authorize(login.AuthURL, tt.claims)
+
+			verifier, nonce := login.CodeVerifier, login.Nonce
+			if tt.verifier != "" {
+				verifier = tt.verifier
+			}
+			if tt.nonce != "" {
+				nonce = tt.nonce
+			}
+
+			_, err = provider.Exchange(ctx, code, nonce, verifier)
+			assert.Error(t, err)
+			if tt.invalidToken {
+				assert.ErrorIs(t, err, ErrInvalidIDToken)
+			}
+		})
+	}
+}
+
+func TestOIDCProvider_KeyRotation(t *testing.T) {
+	stub := newOIDCStub(t)
+	provider := NewOIDCProvider(stub.providerConfig())
+	ctx := context.Background()
+
+	exchange := func() error {
+		login := &models.IdentityLoginState{Nonce: "nonce", CodeVerifier: "verifier-0123456789-0123456789-0123456789"}
+		authURL, err := provider.AuthCodeURL(ctx, "state", login.Nonce, login.CodeVerifier)
+		require.NoError(t, err)
+		_, err = provider.Exchange(ctx, stub.authorize(authURL, nil), login.Nonce, login.CodeVerifier)
+		return err
+	}
+
+	require.NoError(t, exchange())
+	require.NoError(t, exchange())
+	assert.Equal(t, 1, stub.jwksRequests, "keys should be cached")
+
+	// A new key is only fetched once the refresh interval has passed
+	stub.rotateKey()
+	assert.Error(t, exchange())
+	provider.keysFetchedAt = time.Now().Add(-oidcJWKSRefreshInterval)
+	assert.NoError(t, exchange())
+	assert.Equal(t, 2, stub.jwksRequests)
+}
+
+func TestOIDCProvider_DiscoveryIssuerMismatch(t *testing.T) {
+	stub := newOIDCStub(t)
+	cfg := stub.providerConfig()
+	cfg.IssuerURL = stub.server.URL + "/"
+	_, err := NewOIDCProvider(cfg).AuthCodeURL(context.Background(), "state", "nonce", "verifier")
+	assert.NoError(t, err, "trailing slashes are ignored")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"issuer": "https://other.example.com"})
+	}))
+	defer server.Close()
+	cfg.IssuerURL = server.URL
+	_, err = NewOIDCProvider(cfg).AuthCodeURL(context.Background(), "state", "nonce", "verifier")
+	assert.Error(t, err)
+}
+
+func TestAuthService_IdentityProviders(t *testing.T) {
+	stub := newOIDCStub(t)
+	authService := newOIDCTestAuthService(stub)
+
+	providers := authService.IdentityProviders()
+	require.Len(t, providers, 2)
+	assert.Equal(t, models.IdentityProviderDiscord, providers[0].Name)
+	assert.Equal(t, models.IdentityProviderInfo{Name: "authentik", DisplayName: "Authentik", Type: "oidc"}, providers[1])
+
+	_, err := authService.BeginProviderLogin(context.Background(), "keycloak", "")
+	assert.ErrorIs(t, err, ErrUnknownIdentityProvider)
+}
+
+func TestAuthService_ProviderLoginAndLinking(t *testing.T) {
+	db, cleanup := setupTestDatabaseWithModels(t)
+	defer cleanup()
+
+	stub := newOIDCStub(t)
+	mockDiscord := new(MockDiscordService)
+	mockJWT := new(MockJWTService)
+	mockRedis := new(MockRedisService)
+	authService := NewAuthService(db, mockDiscord, mockJWT, mockRedis)
+	authService.RegisterIdentityProvider(NewOIDCProvider(stub.providerConfig()))
+	ctx := context.Background()
+
+	mockJWT.On("GenerateAccessToken", mock.Anything, mock.Anything).Return("access", time.Now().Add(time.Hour), nil)
+	mockJWT.On("GenerateRefreshToken", mock.Anything, mock.Anything).Return("refresh", time.Now().Add(24*time.Hour), nil)
+	mockRedis.On("StoreSession", mock.Anything, mock.Anything).Return(nil)
+
+	login := func(subject, linkUserID string) (*models.AuthResponse, error) {
+		state, err := authService.BeginProviderLogin(ctx, "authentik", linkUserID)
+		require.NoError(t, err)
+		code := stub.authorize(state.AuthURL, jwt.MapClaims{"sub": subject, "preferred_username": "alice"})
+		return authService.HandleProviderCallback(ctx, state, code)
+	}
+
+	// First login creates a user without a Discord account
+	first, err := login("alice-sub", "")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", first.User.Username)
+	assert.Empty(t, first.User.DiscordUserID)
+
+	// A second OIDC-only user must not collide on the Discord ID
+	other, err := login("bob-sub", "")
+	require.NoError(t, err)
+	assert.NotEqual(t, first.User.ID, other.User.ID)
+
+	// Logging in again resolves the same user
+	again, err := login("alice-sub", "")
+	require.NoError(t, err)
+	assert.Equal(t, first.User.ID, again.User.ID)
+
+	// Linking another identity attaches it to the current user
+	_, err = login("alice-second-sub", first.User.ID)
+	require.NoError(t, err)
+	identities, err := authService.GetUserIdentities(ctx, first.User.ID)
+	require.NoError(t, err)
+	assert.Len(t, identities, 2)
+
+	// An identity owned by someone else cannot be linked
+	_, err = login("bob-sub", first.User.ID)
+	assert.ErrorIs(t, err, ErrIdentityAlreadyLinked)
+
+	// The last identity cannot be unlinked
+	require.NoError(t, authService.UnlinkIdentity(ctx, first.User.ID, identities[1].ID))
+	err = authService.UnlinkIdentity(ctx, first.User.ID, identities[0].ID)
+	assert.ErrorIs(t, err, ErrLastIdentity)
+	err = authService.UnlinkIdentity(ctx, other.User.ID, identities[0].ID)
+	assert.ErrorIs(t, err, ErrIdentityNotFound)
+}
@@ -0,0 +1,437 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// ownershipTransferTTL is how long a member has to accept a transfer
+const ownershipTransferTTL = 7 * 24 * time.Hour
+
+var (
+	// ErrTenantNotFound is returned when a tenant does not exist
+	ErrTenantNotFound = errors.New("tenant not found")
+	// ErrNotTenantOwner is returned when someone other than the owner starts or cancels a transfer
+	ErrNotTenantOwner = errors.New("only the tenant owner can transfer ownership")
+	// ErrTransferNotFound is returned when a transfer does not exist or is not offered to the user
+	ErrTransferNotFound = errors.New("ownership transfer not found")
+	// ErrTransferClosed is returned when a transfer has expired, was cancelled or the owner changed meanwhile
+	ErrTransferClosed = errors.New("ownership transfer is no longer valid")
+	// ErrInvalidTransfer is returned when the new owner cannot own the tenant
+	ErrInvalidTransfer = errors.New("invalid ownership transfer")
+)
+
+// OwnershipService moves tenant ownership between users: transfers the owner
+// offers and a member accepts, superadmin overrides and Discord server
+// ownership changes
+type OwnershipService struct {
+	db          *gorm.DB
+	rbacService *RBACService
+	notifier    NotificationServiceInterface
+}
+
+// NewOwnershipService creates a new ownership service. The notifier may be nil.
+func NewOwnershipService(db *gorm.DB, rbacService *RBACService, notifier NotificationServiceInterface) *OwnershipService {
+	return &OwnershipService{
+		db:          db,
+		rbacService: rbacService,
+		notifier:    notifier,
+	}
+}
+
+// RequestTransfer offers the tenant to one of its members. Only the owner can
+// offer it, and a new offer replaces one that is still open.
+func (s *OwnershipService) RequestTransfer(ctx context.Context, tenantID, toUserID, requestedBy string) (*models.OwnershipTransfer, error) {
+	tenant, err := s.tenant(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if tenant.OwnerID != requestedBy {
+		return nil, ErrNotTenantOwner
+	}
+
+	newOwner, err := s.newOwner(ctx, tenant, toUserID)
+	if err != nil {
+		return nil, err
+	}
+	isMember, err := s.rbacService.IsTenantMember(ctx, newOwner.ID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !isMember {
+		return nil, ErrNotTenantMember
+	}
+
+	transfer := &models.OwnershipTransfer{
+		TenantID:   tenantID,
+		FromUserID: requestedBy,
+		ToUserID:   newOwner.ID,
+		Status:     models.TransferStatusPending,
+		ExpiresAt:  time.Now().Add(ownershipTransferTTL),
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.cancelPending(tx, tenantID); err != nil {
+			return err
+		}
+		if err := tx.Create(transfer).Error; err != nil {
+			return fmt.Errorf("failed to create ownership transfer: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.rbacService.LogPermissionChange(ctx, newOwner.ID, tenantID, "ownership_transfer_requested", "tenant", tenantID, requestedBy, newOwner.ID, "", requestedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(ctx, models.NotificationEvent{
+		Type:     models.NotificationTransferRequested,
+		TenantID: tenantID,
+		Message:  fmt.Sprintf("Ownership of %s was offered to %s.", tenant.Name, newOwner.Username),
+		Details:  map[string]string{"expires": transfer.ExpiresAt.UTC().Format(time.RFC3339)},
+	})
+
+	return transfer, nil
+}
+
+// GetPendingTransfer gets the tenant's open transfer
+func (s *OwnershipService) GetPendingTransfer(ctx context.Context, tenantID string) (*models.OwnershipTransfer, error) {
+	var transfer models.OwnershipTransfer
+	err := s.db.WithContext(ctx).
+		Where("tenant_id = ? AND status = ? AND expires_at > ?", tenantID, models.TransferStatusPending, time.Now()).
+		First(&transfer).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTransferNotFound
+		}
+		return nil, fmt.Errorf("failed to get ownership transfer: %w", err)
+	}
+
+	return &transfer, nil
+}
+
+// CancelTransfer withdraws the tenant's open transfer
+func (s *OwnershipService) CancelTransfer(ctx context.Context, tenantID, performedBy string) error {
+	tenant, err := s.tenant(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	if tenant.OwnerID != performedBy {
+		return ErrNotTenantOwner
+	}
+
+	transfer, err := s.GetPendingTransfer(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	if err := s.cancelPending(s.db.WithContext(ctx), tenantID); err != nil {
+		return err
+	}
+
+	return s.rbacService.LogPermissionChange(ctx, transfer.ToUserID, tenantID, "ownership_transfer_cancelled", "tenant", tenantID, transfer.ToUserID, "", "", performedBy)
+}
+
+// ListUserTransfers lists the open transfers offered to a user
+func (s *OwnershipService) ListUserTransfers(ctx context.Context, userID string) ([]models.OwnershipTransfer, error) {
+	transfers := []models.OwnershipTransfer{}
+	err := s.db.WithContext(ctx).Preload("Tenant").
+		Where("to_user_id = ? AND status = ? AND expires_at > ?", userID, models.TransferStatusPending, time.Now()).
+		Order("created_at DESC").
+		Find(&transfers).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ownership transfers: %w", err)
+	}
+
+	return transfers, nil
+}
+
+// RespondToTransfer accepts or declines a transfer offered to the user.
+// Accepting makes the user the owner, as long as the owner who offered it
+// still owns the tenant.
+func (s *OwnershipService) RespondToTransfer(ctx context.Context, transferID, userID string, accept bool) (*models.OwnershipTransfer, error) {
+	if _, err := uuid.Parse(transferID); err != nil {
+		return nil, ErrTransferNotFound
+	}
+
+	var transfer models.OwnershipTransfer
+	err := s.db.WithContext(ctx).Preload("Tenant").Where("id = ? AND to_user_id = ?", transferID, userID).First(&transfer).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTransferNotFound
+		}
+		return nil, fmt.Errorf("failed to get ownership transfer: %w", err)
+	}
+
+	now := time.Now()
+	if !transfer.IsOpen(now) {
+		return nil, ErrTransferClosed
+	}
+
+	status := models.TransferStatusDeclined
+	if accept {
+		status = models.TransferStatusAccepted
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The status condition keeps two responses from both succeeding
+		result := tx.Model(&models.OwnershipTransfer{}).Where("id = ? AND status = ?", transfer.ID, models.TransferStatusPending).
+			Updates(map[string]interface{}{"status": status, "responded_at": now})
+		if result.Error != nil {
+			return fmt.Errorf("failed to update ownership transfer: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrTransferClosed
+		}
+
+		if !accept {
+			return nil
+		}
+		return s.transfer(tx, transfer.TenantID, transfer.FromUserID, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	transfer.Status = status
+	transfer.RespondedAt = &now
+
+	if !accept {
+		err = s.rbacService.LogPermissionChange(ctx, userID, transfer.TenantID, "ownership_transfer_declined", "tenant", transfer.TenantID, transfer.FromUserID, "", "", userID)
+		if err != nil {
+			return nil, err
+		}
+		return &transfer, nil
+	}
+
+	if err := s.ownerChanged(ctx, transfer.TenantID, transfer.FromUserID, userID, "accepted ownership transfer", userID); err != nil {
+		return nil, err
+	}
+	if transfer.Tenant != nil {
+		transfer.Tenant.OwnerID = userID
+	}
+
+	return &transfer, nil
+}
+
+// ForceTransfer makes a user the owner without their or the current owner's
+// consent. It is meant for superadmins recovering abandoned tenants.
+func (s *OwnershipService) ForceTransfer(ctx context.Context, tenantID, toUserID, reason, performedBy string) (*models.Tenant, error) {
+	tenant, err := s.tenant(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	newOwner, err := s.newOwner(ctx, tenant, toUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.cancelPending(tx, tenantID); err != nil {
+			return err
+		}
+		return s.transfer(tx, tenantID, tenant.OwnerID, newOwner.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if reason == "" {
+		reason = "superadmin override"
+	} else {
+		reason = "superadmin override: " + reason
+	}
+	if err := s.ownerChanged(ctx, tenantID, tenant.OwnerID, newOwner.ID, reason, performedBy); err != nil {
+		return nil, err
+	}
+
+	tenant.OwnerID = newOwner.ID
+	return tenant, nil
+}
+
+// FollowGuildOwner makes the owner of the tenant's Discord server the tenant
+// owner. Nothing changes until the new Discord owner has signed in, since the
+// tenant cannot be given to someone without an account.
+func (s *OwnershipService) FollowGuildOwner(ctx context.Context, tenantID, ownerDiscordUserID string) error {
+	if ownerDiscordUserID == "" {
+		return nil
+	}
+
+	tenant, err := s.tenant(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+
+	var newOwner models.User
+	err = s.db.WithContext(ctx).Where("discord_user_id = ? AND is_service_account = ?", ownerDiscordUserID, false).First(&newOwner).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to get Discord server owner: %w", err)
+	}
+	if newOwner.ID == tenant.OwnerID {
+		return nil
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.cancelPending(tx, tenantID); err != nil {
+			return err
+		}
+		return s.transfer(tx, tenantID, tenant.OwnerID, newOwner.ID)
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.ownerChanged(ctx, tenantID, tenant.OwnerID, newOwner.ID, "Discord server ownership changed", newOwner.ID)
+}
+
+// transfer moves ownership from one user to another. The owner role and full
+// tenant access created with the tenant move too; the former owner stays a
+// member with whatever else they hold.
+func (s *OwnershipService) transfer(tx *gorm.DB, tenantID, fromUserID, toUserID string) error {
+	result := tx.Model(&models.Tenant{}).Where("id = ? AND owner_id = ?", tenantID, fromUserID).Update("owner_id", toUserID)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update tenant owner: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrTransferClosed
+	}
+
+	err := tx.Model(&models.UserTenant{}).Where("tenant_id = ? AND user_id = ?", tenantID, fromUserID).Updates(map[string]interface{}{
+		"roles":       gorm.Expr("array_remove(roles, ?)", models.OwnerRole),
+		"permissions": gorm.Expr("array_remove(permissions, ?)", models.PermissionAdminAll),
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update former owner: %w", err)
+	}
+
+	var membership models.UserTenant
+	err = tx.Where("tenant_id = ? AND user_id = ?", tenantID, toUserID).First(&membership).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		membership = models.UserTenant{
+			UserID:      toUserID,
+			TenantID:    tenantID,
+			Roles:       models.StringArray{models.OwnerRole},
+			Permissions: models.StringArray{models.PermissionAdminAll},
+		}
+		if err := tx.Create(&membership).Error; err != nil {
+			return fmt.Errorf("failed to add new owner: %w", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get new owner membership: %w", err)
+	}
+
+	err = tx.Model(&membership).Updates(map[string]interface{}{
+		"roles":       models.StringArray(uniqueStrings(append(membership.Roles, models.OwnerRole))),
+		"permissions": models.StringArray(uniqueStrings(append(membership.Permissions, models.PermissionAdminAll))),
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update new owner: %w", err)
+	}
+
+	return nil
+}
+
+// ownerChanged drops cached permissions, audits and announces a new owner
+func (s *OwnershipService) ownerChanged(ctx context.Context, tenantID, fromUserID, toUserID, reason, performedBy string) error {
+	if err := s.rbacService.InvalidateTenantPermissions(ctx, tenantID); err != nil {
+		return err
+	}
+
+	err := s.rbacService.LogPermissionChange(ctx, toUserID, tenantID, "ownership_transferred", "tenant", tenantID, fromUserID, toUserID, reason, performedBy)
+	if err != nil {
+		return err
+	}
+
+	var users []models.User
+	if err := s.db.WithContext(ctx).Where("id IN ?", []string{fromUserID, toUserID}).Find(&users).Error; err != nil {
+		log.Printf("Failed to get users for ownership notification: %v", err)
+	}
+	names := map[string]string{fromUserID: fromUserID, toUserID: toUserID}
+	for _, user := range users {
+		names[user.ID] = user.Username
+	}
+
+	s.notify(ctx, models.NotificationEvent{
+		Type:     models.NotificationOwnerChanged,
+		TenantID: tenantID,
+		Message:  fmt.Sprintf("%s is now the owner, taking over from %s.", names[toUserID], names[fromUserID]),
+		Details:  map[string]string{"reason": reason},
+	})
+
+	return nil
+}
+
+// notify sends an ownership notification. Delivery failures are logged so
+// they do not undo a transfer that already happened.
+func (s *OwnershipService) notify(ctx context.Context, event models.NotificationEvent) {
+	if s.notifier == nil {
+		return
+	}
+	if err := s.notifier.Notify(ctx, event); err != nil {
+		log.Printf("Failed to send %s notification for tenant %s: %v", event.Type, event.TenantID, err)
+	}
+}
+
+// cancelPending cancels the tenant's open transfers
+func (s *OwnershipService) cancelPending(tx *gorm.DB, tenantID string) error {
+	err := tx.Model(&models.OwnershipTransfer{}).Where("tenant_id = ? AND status = ?", tenantID, models.TransferStatusPending).
+		Update("status", models.TransferStatusCancelled).Error
+	if err != nil {
+		return fmt.Errorf("failed to cancel ownership transfers: %w", err)
+	}
+	return nil
+}
+
+// tenant gets a tenant by ID
+func (s *OwnershipService) tenant(ctx context.Context, tenantID string) (*models.Tenant, error) {
+	if _, err := uuid.Parse(tenantID); err != nil {
+		return nil, ErrTenantNotFound
+	}
+
+	var tenant models.Tenant
+	if err := s.db.WithContext(ctx).First(&tenant, "id = ?", tenantID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTenantNotFound
+		}
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+	return &tenant, nil
+}
+
+// newOwner gets the user who should own the tenant, refusing the current owner
+// and service accounts
+func (s *OwnershipService) newOwner(ctx context.Context, tenant *models.Tenant, userID string) (*models.User, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, fmt.Errorf("%w: unknown user", ErrInvalidTransfer)
+	}
+	if userID == tenant.OwnerID {
+		return nil, fmt.Errorf("%w: user already owns the tenant", ErrInvalidTransfer)
+	}
+
+	var user models.User
+	err := s.db.WithContext(ctx).First(&user, "id = ?", userID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: unknown user", ErrInvalidTransfer)
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.IsServiceAccount {
+		return nil, fmt.Errorf("%w: service accounts cannot own a tenant", ErrInvalidTransfer)
+	}
+
+	return &user, nil
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/config"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingNotifier records the notifications it is asked to send
+type recordingNotifier struct {
+	events []models.NotificationEvent
+}
+
+func (r *recordingNotifier) Notify(ctx context.Context, event models.NotificationEvent) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *recordingNotifier) NotifyControllerOffline(ctx context.Context, clusterID, clusterName string) error {
+	return nil
+}
+
+func TestOwnershipService(t *testing.T) {
+	db, cleanup := testutils.SetupTestDatabaseWithModels(t,
+		&models.User{},
+		&models.Tenant{},
+		&models.UserTenant{},
+		&models.OwnershipTransfer{},
+		&models.TenantDiscordRole{},
+		&models.Role{},
+		&models.SystemRole{},
+		&models.UserSystemRole{},
+		&models.PermissionAuditLog{},
+		&models.ResourceGrant{},
+	)
+	defer cleanup()
+	rbacService := NewRBACService(db, &config.RBACConfig{RoleSyncTTL: time.Minute})
+	notifier := &recordingNotifier{}
+	ownershipService := NewOwnershipService(db, rbacService, notifier)
+	ctx := context.Background()
+
+	owner := &models.User{DiscordUserID: "2000", Username: "owner"}
+	require.NoError(t, db.Create(owner).Error)
+	member := &models.User{DiscordUserID: "2001", Username: "member"}
+	require.NoError(t, db.Create(member).Error)
+	other := &models.User{DiscordUserID: "2002", Username: "other"}
+	require.NoError(t, db.Create(other).Error)
+	outsider := &models.User{DiscordUserID: "2003", Username: "outsider"}
+	require.NoError(t, db.Create(outsider).Error)
+	bot := &models.User{DiscordUserID: "2004", Username: "bot", IsServiceAccount: true}
+	require.NoError(t, db.Create(bot).Error)
+
+	tenant := &models.Tenant{DiscordServerID: "guild-owned", Name: "Owned Guild", OwnerID: owner.ID}
+	require.NoError(t, db.Create(tenant).Error)
+	require.NoError(t, db.Create(&models.UserTenant{UserID: owner.ID, TenantID: tenant.ID, Roles: models.StringArray{models.OwnerRole}, Permissions: models.StringArray{models.PermissionAdminAll}}).Error)
+	require.NoError(t, db.Create(&models.UserTenant{UserID: member.ID, TenantID: tenant.ID, Permissions: models.StringArray{models.PermissionServerRead}}).Error)
+	require.NoError(t, db.Create(&models.UserTenant{UserID: other.ID, TenantID: tenant.ID}).Error)
+	require.NoError(t, db.Create(&models.UserTenant{UserID: bot.ID, TenantID: tenant.ID}).Error)
+
+	t.Run("validation", func(t *testing.T) {
+		_, err := ownershipService.RequestTransfer(ctx, tenant.ID, other.ID, member.ID)
+		assert.ErrorIs(t, err, ErrNotTenantOwner)
+		_, err = ownershipService.RequestTransfer(ctx, tenant.ID, outsider.ID, owner.ID)
+		assert.ErrorIs(t, err, ErrNotTenantMember)
+		_, err = ownershipService.RequestTransfer(ctx, tenant.ID, bot.ID, owner.ID)
+		assert.ErrorIs(t, err, ErrInvalidTransfer)
+		_, err = ownershipService.RequestTransfer(ctx, tenant.ID, owner.ID, owner.ID)
+		assert.ErrorIs(t, err, ErrInvalidTransfer)
+	})
+
+	t.Run("declined and replaced transfers do not move ownership", func(t *testing.T) {
+		first, err := ownershipService.RequestTransfer(ctx, tenant.ID, other.ID, owner.ID)
+		require.NoError(t, err)
+		second, err := ownershipService.RequestTransfer(ctx, tenant.ID, member.ID, owner.ID)
+		require.NoError(t, err)
+
+		_, err = ownershipService.RespondToTransfer(ctx, first.ID, other.ID, true)
+		assert.ErrorIs(t, err, ErrTransferClosed)
+		_, err = ownershipService.RespondToTransfer(ctx, second.ID, other.ID, true)
+		assert.ErrorIs(t, err, ErrTransferNotFound)
+
+		declined, err := ownershipService.RespondToTransfer(ctx, second.ID, member.ID, false)
+		require.NoError(t, err)
+		assert.Equal(t, models.TransferStatusDeclined, declined.Status)
+
+		var stored models.Tenant
+		require.NoError(t, db.First(&stored, "id = ?", tenant.ID).Error)
+		assert.Equal(t, owner.ID, stored.OwnerID)
+	})
+
+	t.Run("accepted transfer moves ownership and full access", func(t *testing.T) {
+		transfer, err := ownershipService.RequestTransfer(ctx, tenant.ID, member.ID, owner.ID)
+		require.NoError(t, err)
+
+		transfers, err := ownershipService.ListUserTransfers(ctx, member.ID)
+		require.NoError(t, err)
+		require.Len(t, transfers, 1)
+		assert.Equal(t, tenant.Name, transfers[0].Tenant.Name)
+
+		accepted, err := ownershipService.RespondToTransfer(ctx, transfer.ID, member.ID, true)
+		require.NoError(t, err)
+		assert.Equal(t, models.TransferStatusAccepted, accepted.Status)
+
+		var stored models.Tenant
+		require.NoError(t, db.First(&stored, "id = ?", tenant.ID).Error)
+		assert.Equal(t, member.ID, stored.OwnerID)
+
+		has, err := rbacService.HasPermission(ctx, member.ID, tenant.ID, models.PermissionServerDelete)
+		require.NoError(t, err)
+		assert.True(t, has)
+		has, err = rbacService.HasPermission(ctx, owner.ID, tenant.ID, models.PermissionServerDelete)
+		require.NoError(t, err)
+		assert.False(t, has)
+		isMember, err := rbacService.IsTenantMember(ctx, owner.ID, tenant.ID)
+		require.NoError(t, err)
+		assert.True(t, isMember)
+
+		_, err = ownershipService.RequestTransfer(ctx, tenant.ID, other.ID, owner.ID)
+		assert.ErrorIs(t, err, ErrNotTenantOwner)
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		transfer, err := ownershipService.RequestTransfer(ctx, tenant.ID, other.ID, member.ID)
+		require.NoError(t, err)
+		require.NoError(t, ownershipService.CancelTransfer(ctx, tenant.ID, member.ID))
+
+		_, err = ownershipService.RespondToTransfer(ctx, transfer.ID, other.ID, true)
+		assert.ErrorIs(t, err, ErrTransferClosed)
+		_, err = ownershipService.GetPendingTransfer(ctx, tenant.ID)
+		assert.ErrorIs(t, err, ErrTransferNotFound)
+	})
+
+	t.Run("superadmin override adds the new owner", func(t *testing.T) {
+		updated, err := ownershipService.ForceTransfer(ctx, tenant.ID, outsider.ID, "owner unreachable", owner.ID)
+		require.NoError(t, err)
+		assert.Equal(t, outsider.ID, updated.OwnerID)
+
+		isMember, err := rbacService.IsTenantMember(ctx, outsider.ID, tenant.ID)
+		require.NoError(t, err)
+		assert.True(t, isMember)
+
+		_, err = ownershipService.ForceTransfer(ctx, uuid.New().String(), outsider.ID, "", owner.ID)
+		assert.ErrorIs(t, err, ErrTenantNotFound)
+	})
+
+	t.Run("follows the Discord server owner once they have signed in", func(t *testing.T) {
+		require.NoError(t, ownershipService.FollowGuildOwner(ctx, tenant.ID, "9999"))
+		var stored models.Tenant
+		require.NoError(t, db.First(&stored, "id = ?", tenant.ID).Error)
+		assert.Equal(t, outsider.ID, stored.OwnerID)
+
+		require.NoError(t, ownershipService.FollowGuildOwner(ctx, tenant.ID, other.DiscordUserID))
+		require.NoError(t, db.First(&stored, "id = ?", tenant.ID).Error)
+		assert.Equal(t, other.ID, stored.OwnerID)
+	})
+
+	var transfers int64
+	require.NoError(t, db.Model(&models.PermissionAuditLog{}).Where("action = ?", "ownership_transferred").Count(&transfers).Error)
+	assert.Equal(t, int64(3), transfers)
+
+	var changed int
+	for _, event := range notifier.events {
+		if event.Type == models.NotificationOwnerChanged {
+			changed++
+		}
+	}
+	assert.Equal(t, 3, changed)
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrPermissionCacheMiss is returned when a user's permissions in a tenant are not cached
+var ErrPermissionCacheMiss = errors.New("permission cache miss")
+
+// MemoryPermissionCache keeps effective permissions in process. Invalidations
+// only reach the replica that made them, so it is meant for development and tests.
+type MemoryPermissionCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryPermissionEntry
+}
+
+type memoryPermissionEntry struct {
+	permissions models.EffectivePermissions
+	expiresAt   time.Time
+}
+
+// NewMemoryPermissionCache creates a new in-memory permission cache
+func NewMemoryPermissionCache() *MemoryPermissionCache {
+	return &MemoryPermissionCache{
+		entries: make(map[string]memoryPermissionEntry),
+	}
+}
+
+// GetEffectivePermissions returns a user's cached permissions in a tenant
+func (c *MemoryPermissionCache) GetEffectivePermissions(ctx context.Context, userID, tenantID string) (*models.EffectivePermissions, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := permissionCacheKey(userID, tenantID)
+	entry, exists := c.entries[key]
+	if !exists {
+		return nil, ErrPermissionCacheMiss
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		return nil, ErrPermissionCacheMiss
+	}
+
+	permissions := entry.permissions
+	return &permissions, nil
+}
+
+// StoreEffectivePermissions caches a user's permissions in a tenant for ttl
+func (c *MemoryPermissionCache) StoreEffectivePermissions(ctx context.Context, permissions *models.EffectivePermissions, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[permissionCacheKey(permissions.UserID, permissions.TenantID)] = memoryPermissionEntry{
+		permissions: *permissions,
+		expiresAt:   time.Now().Add(ttl),
+	}
+	return nil
+}
+
+// DeleteUserPermissions drops a user's cached permissions in every tenant
+func (c *MemoryPermissionCache) DeleteUserPermissions(ctx context.Context, userID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, entry := range c.entries {
+		if entry.permissions.UserID == userID {
+			delete(c.entries, key)
+		}
+	}
+	return nil
+}
+
+// DeleteTenantPermissions drops every user's cached permissions in a tenant
+func (c *MemoryPermissionCache) DeleteTenantPermissions(ctx context.Context, tenantID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, entry := range c.entries {
+		if entry.permissions.TenantID == tenantID {
+			delete(c.entries, key)
+		}
+	}
+	return nil
+}
+
+// RedisPermissionCache keeps effective permissions in Redis so that every
+// backend replica sees the same invalidations. Entries are indexed by user and
+// by tenant so that either can be dropped without scanning the keyspace.
+type RedisPermissionCache struct {
+	client *redis.Client
+}
+
+// NewRedisPermissionCache creates a new permission cache sharing the Redis service's connection
+func NewRedisPermissionCache(redisService *RedisService) *RedisPermissionCache {
+	return &RedisPermissionCache{
+		client: redisService.client,
+	}
+}
+
+// GetEffectivePermissions returns a user's cached permissions in a tenant
+func (c *RedisPermissionCache) GetEffectivePermissions(ctx context.Context, userID, tenantID string) (*models.EffectivePermissions, error) {
+	data, err := c.client.Get(ctx, permissionCacheKey(userID, tenantID)).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, ErrPermissionCacheMiss
+		}
+		return nil, fmt.Errorf("failed to get cached permissions: %w", err)
+	}
+
+	var permissions models.EffectivePermissions
+	if err := json.Unmarshal([]byte(data), &permissions); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal cached permissions: %w", err)
+	}
+	return &permissions, nil
+}
+
+// StoreEffectivePermissions caches a user's permissions in a tenant for ttl
+func (c *RedisPermissionCache) StoreEffectivePermissions(ctx context.Context, permissions *models.EffectivePermissions, ttl time.Duration) error {
+	data, err := json.Marshal(permissions)
+	if err != nil {
+		return fmt.Errorf("failed to marshal permissions: %w", err)
+	}
+
+	key := permissionCacheKey(permissions.UserID, permissions.TenantID)
+	pipe := c.client.TxPipeline()
+	pipe.Set(ctx, key, data, ttl)
+	pipe.SAdd(ctx, userPermissionsKey(permissions.UserID), key)
+	pipe.Expire(ctx, userPermissionsKey(permissions.UserID), ttl)
+	if permissions.TenantID != "" {
+		pipe.SAdd(ctx, tenantPermissionsKey(permissions.TenantID), key)
+		pipe.Expire(ctx, tenantPermissionsKey(permissions.TenantID), ttl)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to store permissions: %w", err)
+	}
+	return nil
+}
+
+// DeleteUserPermissions drops a user's cached permissions in every tenant
+func (c *RedisPermissionCache) DeleteUserPermissions(ctx context.Context, userID string) error {
+	return c.deleteIndexed(ctx, userPermissionsKey(userID))
+}
+
+// DeleteTenantPermissions drops every user's cached permissions in a tenant
+func (c *RedisPermissionCache) DeleteTenantPermissions(ctx context.Context, tenantID string) error {
+	return c.deleteIndexed(ctx, tenantPermissionsKey(tenantID))
+}
+
+// deleteIndexed deletes the entries listed in an index along with the index
+func (c *RedisPermissionCache) deleteIndexed(ctx context.Context, indexKey string) error {
+	keys, err := c.client.SMembers(ctx, indexKey).Result()
+	if err != nil {
+		return fmt.Errorf("failed to list cached permissions: %w", err)
+	}
+
+	err = c.client.Del(ctx, append(keys, indexKey)...).Err()
+	if err != nil {
+		return fmt.Errorf("failed to delete cached permissions: %w", err)
+	}
+	return nil
+}
+
+func permissionCacheKey(userID, tenantID string) string {
+	return fmt.Sprintf("permissions:%s:%s", userID, tenantID)
+}
+
+func userPermissionsKey(userID string) string {
+	return fmt.Sprintf("user_permissions:%s", userID)
+}
+
+func tenantPermissionsKey(tenantID string) string {
+	return fmt.Sprintf("tenant_permissions:%s", tenantID)
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/config"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryPermissionCache(t *testing.T) {
+	ctx := context.Background()
+	cache := NewMemoryPermissionCache()
+
+	_, err := cache.GetEffectivePermissions(ctx, "user-1", "tenant-1")
+	assert.ErrorIs(t, err, ErrPermissionCacheMiss)
+
+	entries := []*models.EffectivePermissions{
+		{UserID: "user-1", TenantID: "tenant-1", IsMember: true, Permissions: []string{models.PermissionServerRead}},
+		{UserID: "user-1", TenantID: "tenant-2", IsMember: true},
+		{UserID: "user-2", TenantID: "tenant-1", IsMember: true},
+		{UserID: "user-1", TenantID: ""},
+	}
+	for _, entry := range entries {
+		require.NoError(t, cache.StoreEffectivePermissions(ctx, entry, time.Minute))
+	}
+
+	cached, err := cache.GetEffectivePermissions(ctx, "user-1", "tenant-1")
+	require.NoError(t, err)
+	assert.True(t, cached.IsMember)
+	assert.Equal(t, []string{models.PermissionServerRead}, cached.Permissions)
+
+	t.Run("tenant invalidation", func(t *testing.T) {
+		require.NoError(t, cache.DeleteTenantPermissions(ctx, "tenant-1"))
+
+		_, err := cache.GetEffectivePermissions(ctx, "user-1", "tenant-1")
+		assert.ErrorIs(t, err, ErrPermissionCacheMiss)
+		_, err = cache.GetEffectivePermissions(ctx, "user-2", "tenant-1")
+		assert.ErrorIs(t, err, ErrPermissionCacheMiss)
+		_, err = cache.GetEffectivePermissions(ctx, "user-1", "tenant-2")
+		assert.NoError(t, err)
+	})
+
+	t.Run("user invalidation", func(t *testing.T) {
+		require.NoError(t, cache.DeleteUserPermissions(ctx, "user-1"))
+
+		_, err := cache.GetEffectivePermissions(ctx, "user-1", "tenant-2")
+		assert.ErrorIs(t, err, ErrPermissionCacheMiss)
+		_, err = cache.GetEffectivePermissions(ctx, "user-1", "")
+		assert.ErrorIs(t, err, ErrPermissionCacheMiss)
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		require.NoError(t, cache.StoreEffectivePermissions(ctx, &models.EffectivePermissions{UserID: "user-3", TenantID: "tenant-1"}, -time.Second))
+
+		_, err := cache.GetEffectivePermissions(ctx, "user-3", "tenant-1")
+		assert.ErrorIs(t, err, ErrPermissionCacheMiss)
+	})
+}
+
+func TestRBACService_PermissionCache(t *testing.T) {
+	_, db, cleanup := setupRBACTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	rbacService := NewRBACServiceWithCache(db, &config.RBACConfig{SuperAdminDiscordID: "superadmin123", PermissionCacheTTL: time.Minute}, NewMemoryPermissionCache())
+
+	tenant := &models.Tenant{DiscordServerID: "guild-123", Name: "Test Guild", OwnerID: uuid.New().String()}
+	require.NoError(t, db.Create(tenant).Error)
+	user := &models.User{DiscordUserID: "user-123", Username: "testuser"}
+	require.NoError(t, db.Create(user).Error)
+
+	role, err := rbacService.CreateRole(ctx, tenant.ID, "operator", []string{models.PermissionServerRead}, false)
+	require.NoError(t, err)
+	require.NoError(t, rbacService.AssignRoleToUser(ctx, user.ID, tenant.ID, "operator"))
+
+	has, err := rbacService.HasPermission(ctx, user.ID, tenant.ID, models.PermissionServerRead)
+	require.NoError(t, err)
+	assert.True(t, has)
+	stats := rbacService.PermissionCacheStats()
+	assert.True(t, stats.Enabled)
+	assert.Equal(t, int64(1), stats.Misses)
+
+	// Later checks are answered from the cache
+	isMember, err := rbacService.IsTenantMember(ctx, user.ID, tenant.ID)
+	require.NoError(t, err)
+	assert.True(t, isMember)
+	has, err = rbacService.HasPermission(ctx, user.ID, tenant.ID, models.PermissionServerRestart)
+	require.NoError(t, err)
+	assert.False(t, has)
+	assert.Equal(t, int64(2), rbacService.PermissionCacheStats().Hits)
+	assert.Equal(t, int64(1), rbacService.PermissionCacheStats().Misses)
+
+	t.Run("role update invalidates the tenant", func(t *testing.T) {
+		_, err := rbacService.UpdateRole(ctx, role.ID, role.Name, []string{models.PermissionServerRestart})
+		require.NoError(t, err)
+
+		has, err := rbacService.HasPermission(ctx, user.ID, tenant.ID, models.PermissionServerRestart)
+		require.NoError(t, err)
+		assert.True(t, has)
+	})
+
+	t.Run("role removal invalidates the user", func(t *testing.T) {
+		require.NoError(t, rbacService.RemoveRoleFromUser(ctx, user.ID, tenant.ID, "operator"))
+
+		has, err := rbacService.HasPermission(ctx, user.ID, tenant.ID, models.PermissionServerRestart)
+		require.NoError(t, err)
+		assert.False(t, has)
+	})
+
+	t.Run("system role assignment invalidates the user", func(t *testing.T) {
+		isSuperAdmin, err := rbacService.IsSuperAdmin(ctx, user.ID)
+		require.NoError(t, err)
+		assert.False(t, isSuperAdmin)
+
+		require.NoError(t, rbacService.AssignInitialSuperAdminRole(ctx, user.ID))
+
+		isSuperAdmin, err = rbacService.IsSuperAdmin(ctx, user.ID)
+		require.NoError(t, err)
+		assert.True(t, isSuperAdmin)
+		has, err := rbacService.HasPermission(ctx, user.ID, tenant.ID, models.PermissionServerDelete)
+		require.NoError(t, err)
+		assert.True(t, has)
+	})
+
+	t.Run("membership changes through the tenant service", func(t *testing.T) {
+		other := &models.User{DiscordUserID: "other-123", Username: "other"}
+		require.NoError(t, db.Create(other).Error)
+		tenantService := NewTenantServiceWithRBAC(db, nil, rbacService)
+
+		isMember, err := tenantService.IsTenantMember(ctx, other.ID, tenant.ID)
+		require.NoError(t, err)
+		assert.False(t, isMember)
+
+		require.NoError(t, tenantService.AddUserToTenant(ctx, other.ID, tenant.ID, nil, []string{models.PermissionServerRead}))
+		isMember, err = tenantService.IsTenantMember(ctx, other.ID, tenant.ID)
+		require.NoError(t, err)
+		assert.True(t, isMember)
+
+		require.NoError(t, tenantService.RemoveUserFromTenant(ctx, other.ID, tenant.ID))
+		isMember, err = tenantService.IsTenantMember(ctx, other.ID, tenant.ID)
+		require.NoError(t, err)
+		assert.False(t, isMember)
+	})
+
+	assert.Greater(t, rbacService.PermissionCacheStats().Invalidations, int64(0))
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SeedPermissionCatalog makes the permissions table match the permission
+// catalog, updating descriptions and removing permissions that no longer exist
+func (rs *RBACService) SeedPermissionCatalog(ctx context.Context) error {
+	catalog := models.PermissionCatalog()
+	names := make([]string, 0, len(catalog))
+	for _, perm := range catalog {
+		names = append(names, perm.Name)
+	}
+
+	return rs.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "name"}},
+			DoUpdates: clause.AssignmentColumns([]string{"description", "resource", "action", "scope", "position", "updated_at", "deleted_at"}),
+		}).Create(&catalog).Error
+		if err != nil {
+			return fmt.Errorf("failed to seed permissions: %w", err)
+		}
+
+		if err := tx.Where("name NOT IN ?", names).Delete(&models.Permission{}).Error; err != nil {
+			return fmt.Errorf("failed to remove unknown permissions: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// ListPermissions returns the seeded permission catalog in catalog order. An
+// empty scope returns permissions of every scope.
+func (rs *RBACService) ListPermissions(ctx context.Context, scope models.PermissionScope) ([]models.Permission, error) {
+	query := rs.db.WithContext(ctx).Order("position")
+	if scope != "" {
+		query = query.Where("scope = ?", scope)
+	}
+
+	var permissions []models.Permission
+	if err := query.Find(&permissions).Error; err != nil {
+		return nil, fmt.Errorf("failed to get permissions: %w", err)
+	}
+
+	return permissions, nil
+}
+
+// validateTenantPermissions returns ErrInvalidPermission for the first
+// permission that cannot be granted within a tenant
+func validateTenantPermissions(permissions []string) error {
+	for _, perm := range permissions {
+		if !models.IsValidTenantPermission(perm) {
+			return fmt.Errorf("%w: %s", ErrInvalidPermission, perm)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/config"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRBACService_PermissionCatalog(t *testing.T) {
+	db, cleanup := testutils.SetupTestDatabaseWithModels(t,
+		&models.User{},
+		&models.Tenant{},
+		&models.UserTenant{},
+		&models.Permission{},
+		&models.Role{},
+		&models.SystemRole{},
+		&models.UserSystemRole{},
+	)
+	defer cleanup()
+	rbacService := NewRBACService(db, &config.RBACConfig{RoleSyncTTL: time.Minute})
+	ctx := context.Background()
+
+	require.NoError(t, db.Create(&models.Permission{Name: "server:explode", Resource: "server", Action: "explode"}).Error)
+	require.NoError(t, db.Create(&models.Permission{Name: models.PermissionServerRead, Resource: "server", Action: "read", Description: "old"}).Error)
+
+	require.NoError(t, rbacService.SeedPermissionCatalog(ctx))
+	require.NoError(t, rbacService.SeedPermissionCatalog(ctx))
+
+	permissions, err := rbacService.ListPermissions(ctx, "")
+	require.NoError(t, err)
+	catalog := models.PermissionCatalog()
+	require.Len(t, permissions, len(catalog))
+	for i, perm := range permissions {
+		assert.Equal(t, catalog[i].Name, perm.Name)
+		assert.Equal(t, catalog[i].Description, perm.Description)
+		assert.Equal(t, catalog[i].Scope, perm.Scope)
+	}
+
+	tenantPermissions, err := rbacService.ListPermissions(ctx, models.ScopeTenant)
+	require.NoError(t, err)
+	for _, perm := range tenantPermissions {
+		assert.NotEqual(t, models.PermissionSuperAdmin, perm.Name)
+	}
+	assert.Less(t, len(tenantPermissions), len(permissions))
+
+	t.Run("roles and grants reject unknown permissions", func(t *testing.T) {
+		tenant := &models.Tenant{DiscordServerID: "guild-catalog", Name: "Catalog", OwnerID: uuid.New().String()}
+		require.NoError(t, db.Create(tenant).Error)
+		user := &models.User{DiscordUserID: "catalog-user", Username: "catalog"}
+		require.NoError(t, db.Create(user).Error)
+
+		_, err := rbacService.CreateRole(ctx, tenant.ID, "broken", []string{"server:explode"}, false)
+		assert.ErrorIs(t, err, ErrInvalidPermission)
+
+		role, err := rbacService.CreateRole(ctx, tenant.ID, "viewers", []string{models.PermissionServerRead}, false)
+		require.NoError(t, err)
+		_, err = rbacService.UpdateRole(ctx, role.ID, role.Name, []string{models.PermissionSuperAdmin})
+		assert.ErrorIs(t, err, ErrInvalidPermission)
+
+		_, err = rbacService.CreateSystemRole(ctx, "broken", "", []string{"custom:permission"})
+		assert.ErrorIs(t, err, ErrInvalidPermission)
+
+		tenantService := NewTenantServiceWithRBAC(db, nil, rbacService)
+		err = tenantService.AddUserToTenant(ctx, user.ID, tenant.ID, nil, []string{"server:explode"})
+		assert.ErrorIs(t, err, ErrInvalidPermission)
+	})
+}
@@ -0,0 +1,708 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidPolicy is returned when a policy document fails validation
+var ErrInvalidPolicy = errors.New("invalid policy")
+
+// policyState is a tenant's authorization setup as stored, loaded to export it
+// or to plan an import. Policies name members by Discord user ID, so members
+// without one, such as service accounts, are kept apart in unmanaged.
+type policyState struct {
+	tenant       models.Tenant
+	roles        []models.Role
+	discordRoles []models.TenantDiscordRole
+	members      []models.UserTenant
+	unmanaged    map[string]bool
+	servers      []models.GameServer
+	grants       []models.ResourceGrant
+}
+
+// policyPlan holds the changes an import makes. subjects holds the user each
+// change is audited against and permissions those the importer must hold.
+type policyPlan struct {
+	result       *models.PolicyImportResult
+	subjects     []string
+	permissions  []string
+	createRoles  []models.Role
+	updateRoles  []models.Role
+	deleteRoles  []models.Role
+	discordRoles []discordRoleUpdate
+	members      []memberUpdate
+	createGrants []models.ResourceGrant
+	deleteGrants []models.ResourceGrant
+}
+
+// discordRoleUpdate replaces a Discord role's mapping. roles are internal role names.
+type discordRoleUpdate struct {
+	discordRole models.TenantDiscordRole
+	permissions []string
+	roles       []string
+}
+
+// memberUpdate replaces a member's direct permissions and roles
+type memberUpdate struct {
+	member      models.UserTenant
+	permissions []string
+	roles       []string
+}
+
+// ExportPolicy returns the tenant's internal roles, Discord role mappings,
+// member permissions and resource grants as a policy document. System roles
+// and the tenant owner are left out since an import cannot change them, and
+// members without a Discord account, such as service accounts, since a policy
+// cannot name them.
+func (rs *RBACService) ExportPolicy(ctx context.Context, tenantID string) (*models.TenantPolicy, error) {
+	state, err := rs.loadPolicyState(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &models.TenantPolicy{
+		Version:        models.PolicyVersion,
+		Roles:          []models.PolicyRole{},
+		DiscordRoles:   []models.PolicyDiscordRole{},
+		Members:        []models.PolicyMember{},
+		ResourceGrants: []models.PolicyResourceGrant{},
+	}
+
+	for _, role := range state.roles {
+		if role.IsSystemRole {
+			continue
+		}
+		policy.Roles = append(policy.Roles, models.PolicyRole{
+			Name:        role.Name,
+			Permissions: append([]string{}, role.Permissions...),
+		})
+	}
+
+	for _, discordRole := range state.discordRoles {
+		roles := state.roleNames(discordRole.MappedRoleIDs)
+		if len(discordRole.Permissions) == 0 && len(roles) == 0 {
+			continue
+		}
+		policy.DiscordRoles = append(policy.DiscordRoles, models.PolicyDiscordRole{
+			ID:          discordRole.DiscordRoleID,
+			Name:        discordRole.Name,
+			Permissions: append([]string{}, discordRole.Permissions...),
+			Roles:       roles,
+		})
+	}
+
+	for _, member := range state.members {
+		if member.UserID == state.tenant.OwnerID {
+			continue
+		}
+		roles := state.internalRoles(member.Roles)
+		if len(member.Permissions) == 0 && len(roles) == 0 {
+			continue
+		}
+		policy.Members = append(policy.Members, models.PolicyMember{
+			DiscordUserID: member.User.DiscordUserID,
+			Permissions:   append([]string{}, member.Permissions...),
+			Roles:         roles,
+		})
+	}
+	sort.Slice(policy.Members, func(i, j int) bool {
+		return policy.Members[i].DiscordUserID < policy.Members[j].DiscordUserID
+	})
+
+	for _, grant := range state.grants {
+		if entry, ok := state.policyGrant(grant); ok {
+			policy.ResourceGrants = append(policy.ResourceGrants, entry)
+		}
+	}
+
+	return policy, nil
+}
+
+// ImportPolicy makes the tenant's authorization setup match a policy document.
+// Roles, Discord role mappings and resource grants missing from the document
+// are removed; members missing from it keep their access, as do resource
+// grants to members without a Discord account. Entries naming Discord roles,
+// members or servers the tenant does not have are skipped with a warning. The
+// importer must hold every permission the import grants or takes away. A dry
+// run returns the changes without making them; otherwise they are made in a
+// single transaction.
+func (rs *RBACService) ImportPolicy(ctx context.Context, tenantID string, policy *models.TenantPolicy, dryRun bool, performedBy string) (*models.PolicyImportResult, error) {
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPolicy, err)
+	}
+
+	state, err := rs.loadPolicyState(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := state.plan(policy, performedBy)
+	if err != nil {
+		return nil, err
+	}
+	if err := rs.requirePermissionsHeld(ctx, performedBy, tenantID, plan.permissions); err != nil {
+		return nil, err
+	}
+
+	plan.result.DryRun = dryRun
+	if dryRun || len(plan.result.Changes) == 0 {
+		return plan.result, nil
+	}
+
+	err = rs.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return state.apply(tx, plan)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := rs.InvalidateTenantPermissions(ctx, tenantID); err != nil {
+		return nil, err
+	}
+
+	for i, change := range plan.result.Changes {
+		action := fmt.Sprintf("%s_%sd", change.Kind, change.Action)
+		err := rs.LogPermissionChange(ctx, plan.subjects[i], tenantID, action, change.Kind, change.Key, change.OldValue, change.NewValue, "policy import", performedBy)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return plan.result, nil
+}
+
+// loadPolicyState loads the tenant's roles, Discord roles, members, servers and resource grants
+func (rs *RBACService) loadPolicyState(ctx context.Context, tenantID string) (*policyState, error) {
+	db := rs.db.WithContext(ctx)
+	state := &policyState{}
+
+	if err := db.First(&state.tenant, "id = ?", tenantID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTenantNotFound
+		}
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+	if err := db.Where("tenant_id = ?", tenantID).Order("name").Find(&state.roles).Error; err != nil {
+		return nil, fmt.Errorf("failed to get roles: %w", err)
+	}
+	if err := db.Where("tenant_id = ?", tenantID).Order("position DESC").Find(&state.discordRoles).Error; err != nil {
+		return nil, fmt.Errorf("failed to get Discord roles: %w", err)
+	}
+	var members []models.UserTenant
+	if err := db.Preload("User").Where("tenant_id = ?", tenantID).Find(&members).Error; err != nil {
+		return nil, fmt.Errorf("failed to get tenant members: %w", err)
+	}
+	state.unmanaged = make(map[string]bool)
+	for _, member := range members {
+		if member.User.DiscordUserID == "" {
+			state.unmanaged[member.UserID] = true
+			continue
+		}
+		state.members = append(state.members, member)
+	}
+	if err := db.Select("id", "tenant_id", "name").Where("tenant_id = ?", tenantID).Order("name").Find(&state.servers).Error; err != nil {
+		return nil, fmt.Errorf("failed to get game servers: %w", err)
+	}
+	if err := db.Where("tenant_id = ?", tenantID).Order("created_at").Find(&state.grants).Error; err != nil {
+		return nil, fmt.Errorf("failed to get resource grants: %w", err)
+	}
+
+	return state, nil
+}
+
+// plan works out the changes that make the tenant match the policy
+func (s *policyState) plan(policy *models.TenantPolicy, performedBy string) (*policyPlan, error) {
+	plan := &policyPlan{
+		result: &models.PolicyImportResult{
+			Changes:  []models.PolicyChange{},
+			Warnings: []string{},
+		},
+	}
+
+	// Internal roles are matched by name
+	wantedRoles := make(map[string]bool)
+	for _, entry := range policy.Roles {
+		name := strings.TrimSpace(entry.Name)
+		permissions := uniqueStrings(entry.Permissions)
+		wantedRoles[name] = true
+		plan.permissions = append(plan.permissions, permissions...)
+
+		role := s.roleByName(name)
+		switch {
+		case role == nil:
+			plan.createRoles = append(plan.createRoles, models.Role{
+				TenantID:    s.tenant.ID,
+				Name:        name,
+				Permissions: models.StringArray(permissions),
+			})
+			plan.add(models.PolicyChange{
+				Action:   models.PolicyActionCreate,
+				Kind:     models.PolicyKindRole,
+				Key:      name,
+				NewValue: fmt.Sprintf("permissions=%v", permissions),
+			}, performedBy)
+		case role.IsSystemRole:
+			return nil, fmt.Errorf("%w: %s is a system role", ErrInvalidPolicy, name)
+		case !sameStrings(role.Permissions, permissions):
+			plan.permissions = append(plan.permissions, role.Permissions...)
+			updated := *role
+			updated.Permissions = models.StringArray(permissions)
+			plan.updateRoles = append(plan.updateRoles, updated)
+			plan.add(models.PolicyChange{
+				Action:   models.PolicyActionUpdate,
+				Kind:     models.PolicyKindRole,
+				Key:      name,
+				OldValue: fmt.Sprintf("permissions=%v", []string(role.Permissions)),
+				NewValue: fmt.Sprintf("permissions=%v", permissions),
+			}, performedBy)
+		}
+	}
+	for _, role := range s.roles {
+		if role.IsSystemRole || wantedRoles[role.Name] {
+			continue
+		}
+		plan.permissions = append(plan.permissions, role.Permissions...)
+		plan.deleteRoles = append(plan.deleteRoles, role)
+		plan.add(models.PolicyChange{
+			Action:   models.PolicyActionDelete,
+			Kind:     models.PolicyKindRole,
+			Key:      role.Name,
+			OldValue: fmt.Sprintf("permissions=%v", []string(role.Permissions)),
+		}, performedBy)
+	}
+
+	// Discord roles are matched by ID and then by name. Roles the document
+	// does not map lose their mapping.
+	mappings := make(map[string]models.PolicyDiscordRole)
+	for _, entry := range policy.DiscordRoles {
+		discordRole := s.discordRole(entry.ID, entry.Name)
+		if discordRole == nil {
+			plan.warn("Discord role %s not found", policyLabel(entry.ID, entry.Name))
+			continue
+		}
+		mappings[discordRole.DiscordRoleID] = entry
+	}
+	for _, discordRole := range s.discordRoles {
+		entry := mappings[discordRole.DiscordRoleID]
+		permissions := uniqueStrings(entry.Permissions)
+		roles := uniqueStrings(entry.Roles)
+		currentRoles := s.roleNames(discordRole.MappedRoleIDs)
+		if sameStrings(discordRole.Permissions, permissions) && sameStrings(currentRoles, roles) {
+			continue
+		}
+
+		change := models.PolicyChange{
+			Action:   models.PolicyActionUpdate,
+			Kind:     models.PolicyKindDiscordRole,
+			Key:      discordRole.DiscordRoleID,
+			OldValue: fmt.Sprintf("permissions=%v roles=%v", []string(discordRole.Permissions), currentRoles),
+			NewValue: fmt.Sprintf("permissions=%v roles=%v", permissions, roles),
+		}
+		switch {
+		case len(permissions) == 0 && len(roles) == 0:
+			change.Action = models.PolicyActionDelete
+			change.NewValue = ""
+		case len(discordRole.Permissions) == 0 && len(currentRoles) == 0:
+			change.Action = models.PolicyActionCreate
+			change.OldValue = ""
+		}
+
+		plan.permissions = append(plan.permissions, discordRole.Permissions...)
+		plan.permissions = append(plan.permissions, permissions...)
+		plan.discordRoles = append(plan.discordRoles, discordRoleUpdate{
+			discordRole: discordRole,
+			permissions: permissions,
+			roles:       roles,
+		})
+		plan.add(change, performedBy)
+	}
+
+	// Members are matched by Discord user ID
+	for _, entry := range policy.Members {
+		member := s.memberByDiscordID(entry.DiscordUserID)
+		if member == nil {
+			plan.warn("member %s not found", entry.DiscordUserID)
+			continue
+		}
+		if member.UserID == s.tenant.OwnerID {
+			plan.warn("member %s owns the tenant and was skipped", entry.DiscordUserID)
+			continue
+		}
+
+		permissions := uniqueStrings(entry.Permissions)
+		roles := uniqueStrings(entry.Roles)
+		currentRoles := s.internalRoles(member.Roles)
+		if sameStrings(member.Permissions, permissions) && sameStrings(currentRoles, roles) {
+			continue
+		}
+
+		plan.permissions = append(plan.permissions, member.Permissions...)
+		plan.permissions = append(plan.permissions, permissions...)
+		plan.members = append(plan.members, memberUpdate{
+			member:      *member,
+			permissions: permissions,
+			roles:       append(s.externalRoles(member.Roles), roles...),
+		})
+		plan.add(models.PolicyChange{
+			Action:   models.PolicyActionUpdate,
+			Kind:     models.PolicyKindMember,
+			Key:      entry.DiscordUserID,
+			OldValue: fmt.Sprintf("permissions=%v roles=%v", []string(member.Permissions), currentRoles),
+			NewValue: fmt.Sprintf("permissions=%v roles=%v", permissions, roles),
+		}, member.UserID)
+	}
+
+	// Resource grants are matched on resource, principal and permission
+	wantedGrants := make(map[string]bool)
+	existingGrants := make(map[string]bool)
+	for _, grant := range s.grants {
+		existingGrants[resourceGrantKey(grant)] = true
+	}
+	for _, entry := range policy.ResourceGrants {
+		grant, warning := s.resolveGrant(entry)
+		if grant == nil {
+			plan.warn("%s", warning)
+			continue
+		}
+		key := resourceGrantKey(*grant)
+		if wantedGrants[key] {
+			continue
+		}
+		wantedGrants[key] = true
+		plan.permissions = append(plan.permissions, grant.Permission)
+		if existingGrants[key] {
+			continue
+		}
+
+		grant.TenantID = s.tenant.ID
+		grant.GrantedBy = performedBy
+		plan.createGrants = append(plan.createGrants, *grant)
+		subject := performedBy
+		if grant.PrincipalType == models.PrincipalTypeUser {
+			subject = grant.PrincipalID
+		}
+		plan.add(models.PolicyChange{
+			Action:   models.PolicyActionCreate,
+			Kind:     models.PolicyKindResourceGrant,
+			Key:      policyGrantKey(entry),
+			NewValue: grant.Permission,
+		}, subject)
+	}
+	for _, grant := range s.grants {
+		if wantedGrants[resourceGrantKey(grant)] {
+			continue
+		}
+		// Grants to members a policy cannot name are left as they are
+		if grant.PrincipalType == models.PrincipalTypeUser && s.unmanaged[grant.PrincipalID] {
+			continue
+		}
+
+		key := fmt.Sprintf("%s:%s %s:%s", grant.ResourceType, grant.ResourceID, grant.PrincipalType, grant.PrincipalID)
+		if entry, ok := s.policyGrant(grant); ok {
+			key = policyGrantKey(entry)
+		}
+		subject := performedBy
+		if grant.PrincipalType == models.PrincipalTypeUser {
+			subject = grant.PrincipalID
+		}
+
+		plan.permissions = append(plan.permissions, grant.Permission)
+		plan.deleteGrants = append(plan.deleteGrants, grant)
+		plan.add(models.PolicyChange{
+			Action:   models.PolicyActionDelete,
+			Kind:     models.PolicyKindResourceGrant,
+			Key:      key,
+			OldValue: grant.Permission,
+		}, subject)
+	}
+
+	return plan, nil
+}
+
+// apply makes the planned changes within a transaction
+func (s *policyState) apply(tx *gorm.DB, plan *policyPlan) error {
+	for i := range plan.deleteRoles {
+		if err := deleteRole(tx, s.tenant.ID, &plan.deleteRoles[i]); err != nil {
+			return err
+		}
+	}
+	for _, role := range plan.updateRoles {
+		err := tx.Model(&models.Role{}).Where("id = ?", role.ID).Update("permissions", role.Permissions).Error
+		if err != nil {
+			return fmt.Errorf("failed to update role: %w", err)
+		}
+	}
+
+	roleIDs := make(map[string]string)
+	for _, role := range s.roles {
+		roleIDs[role.Name] = role.ID
+	}
+	for i := range plan.createRoles {
+		if err := tx.Create(&plan.createRoles[i]).Error; err != nil {
+			return fmt.Errorf("failed to create role: %w", err)
+		}
+		roleIDs[plan.createRoles[i].Name] = plan.createRoles[i].ID
+	}
+
+	for _, update := range plan.discordRoles {
+		mapped := s.systemRoleIDs(update.discordRole.MappedRoleIDs)
+		for _, name := range update.roles {
+			mapped = append(mapped, roleIDs[name])
+		}
+		err := tx.Model(&models.TenantDiscordRole{}).Where("id = ?", update.discordRole.ID).Updates(map[string]interface{}{
+			"permissions":     models.StringArray(update.permissions),
+			"mapped_role_ids": models.StringArray(mapped),
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to update Discord role mapping: %w", err)
+		}
+	}
+
+	for _, update := range plan.members {
+		err := tx.Model(&models.UserTenant{}).Where("id = ?", update.member.ID).Updates(map[string]interface{}{
+			"permissions": models.StringArray(update.permissions),
+			"roles":       models.StringArray(update.roles),
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to update member: %w", err)
+		}
+	}
+
+	for _, grant := range plan.deleteGrants {
+		if err := tx.Delete(&models.ResourceGrant{}, "id = ?", grant.ID).Error; err != nil {
+			return fmt.Errorf("failed to delete resource grant: %w", err)
+		}
+	}
+	for i := range plan.createGrants {
+		if err := tx.Create(&plan.createGrants[i]).Error; err != nil {
+			return fmt.Errorf("failed to create resource grant: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// add records a change and the user it is audited against
+func (p *policyPlan) add(change models.PolicyChange, subject string) {
+	p.result.Changes = append(p.result.Changes, change)
+	p.subjects = append(p.subjects, subject)
+}
+
+// warn records an entry that was skipped
+func (p *policyPlan) warn(format string, args ...interface{}) {
+	p.result.Warnings = append(p.result.Warnings, fmt.Sprintf(format, args...))
+}
+
+// roleByName returns the tenant role with the name, if any
+func (s *policyState) roleByName(name string) *models.Role {
+	for i := range s.roles {
+		if s.roles[i].Name == name {
+			return &s.roles[i]
+		}
+	}
+	return nil
+}
+
+// roleNames returns the names of the internal roles with the IDs, leaving out system roles
+func (s *policyState) roleNames(ids []string) []string {
+	names := []string{}
+	for _, id := range ids {
+		for _, role := range s.roles {
+			if role.ID == id && !role.IsSystemRole {
+				names = append(names, role.Name)
+			}
+		}
+	}
+	return names
+}
+
+// systemRoleIDs returns the IDs that belong to system roles, which an import keeps
+func (s *policyState) systemRoleIDs(ids []string) []string {
+	kept := []string{}
+	for _, id := range ids {
+		for _, role := range s.roles {
+			if role.ID == id && role.IsSystemRole {
+				kept = append(kept, id)
+			}
+		}
+	}
+	return kept
+}
+
+// internalRoles returns the entries of a member's roles that name internal
+// roles an import manages
+func (s *policyState) internalRoles(roles []string) []string {
+	internal := []string{}
+	for _, name := range roles {
+		if role := s.roleByName(name); role != nil && !role.IsSystemRole {
+			internal = append(internal, name)
+		}
+	}
+	return internal
+}
+
+// externalRoles returns the entries of a member's roles an import leaves
+// alone, such as Discord role IDs and the owner role
+func (s *policyState) externalRoles(roles []string) []string {
+	external := []string{}
+	for _, name := range roles {
+		if role := s.roleByName(name); role == nil || role.IsSystemRole {
+			external = append(external, name)
+		}
+	}
+	return external
+}
+
+// discordRole returns the tenant's Discord role with the ID or, failing that, the name
+func (s *policyState) discordRole(id, name string) *models.TenantDiscordRole {
+	if id != "" {
+		for i := range s.discordRoles {
+			if s.discordRoles[i].DiscordRoleID == id {
+				return &s.discordRoles[i]
+			}
+		}
+	}
+	if name != "" {
+		for i := range s.discordRoles {
+			if s.discordRoles[i].Name == name {
+				return &s.discordRoles[i]
+			}
+		}
+	}
+	return nil
+}
+
+// memberByDiscordID returns the tenant member with the Discord user ID, if any
+func (s *policyState) memberByDiscordID(discordUserID string) *models.UserTenant {
+	for i := range s.members {
+		if s.members[i].User.DiscordUserID == discordUserID {
+			return &s.members[i]
+		}
+	}
+	return nil
+}
+
+// resolveGrant turns a policy grant into a resource grant on the tenant, or
+// explains why it cannot
+func (s *policyState) resolveGrant(entry models.PolicyResourceGrant) (*models.ResourceGrant, string) {
+	grant := &models.ResourceGrant{
+		ResourceType: entry.ResourceType,
+		Permission:   entry.Permission,
+	}
+
+	for _, server := range s.servers {
+		if server.Name == entry.Resource {
+			grant.ResourceID = server.ID
+			break
+		}
+	}
+	if grant.ResourceID == "" {
+		return nil, fmt.Sprintf("server %s not found", entry.Resource)
+	}
+
+	switch entry.PrincipalType {
+	case models.PolicyPrincipalUser:
+		member := s.memberByDiscordID(entry.Principal)
+		if member == nil {
+			return nil, fmt.Sprintf("member %s not found", entry.Principal)
+		}
+		grant.PrincipalType = models.PrincipalTypeUser
+		grant.PrincipalID = member.UserID
+	case models.PolicyPrincipalDiscordRole:
+		discordRole := s.discordRole(entry.Principal, entry.Principal)
+		if discordRole == nil {
+			return nil, fmt.Sprintf("Discord role %s not found", entry.Principal)
+		}
+		grant.PrincipalType = models.PrincipalTypeRole
+		grant.PrincipalID = discordRole.DiscordRoleID
+	default:
+		grant.PrincipalType = models.PrincipalTypeRole
+		grant.PrincipalID = entry.Principal
+	}
+
+	return grant, ""
+}
+
+// policyGrant describes a resource grant by server name and Discord IDs. It
+// fails for grants on deleted servers or to principals that no longer exist.
+func (s *policyState) policyGrant(grant models.ResourceGrant) (models.PolicyResourceGrant, bool) {
+	entry := models.PolicyResourceGrant{
+		ResourceType: grant.ResourceType,
+		Permission:   grant.Permission,
+	}
+
+	for _, server := range s.servers {
+		if server.ID == grant.ResourceID {
+			entry.Resource = server.Name
+		}
+	}
+	if entry.Resource == "" {
+		return entry, false
+	}
+
+	switch grant.PrincipalType {
+	case models.PrincipalTypeUser:
+		for _, member := range s.members {
+			if member.UserID == grant.PrincipalID {
+				entry.PrincipalType = models.PolicyPrincipalUser
+				entry.Principal = member.User.DiscordUserID
+			}
+		}
+	case models.PrincipalTypeRole:
+		if names := s.internalRoles([]string{grant.PrincipalID}); len(names) > 0 {
+			entry.PrincipalType = models.PolicyPrincipalRole
+			entry.Principal = grant.PrincipalID
+		} else if names := s.roleNames([]string{grant.PrincipalID}); len(names) > 0 {
+			entry.PrincipalType = models.PolicyPrincipalRole
+			entry.Principal = names[0]
+		} else if s.discordRole(grant.PrincipalID, "") != nil {
+			entry.PrincipalType = models.PolicyPrincipalDiscordRole
+			entry.Principal = grant.PrincipalID
+		}
+	}
+
+	return entry, entry.Principal != ""
+}
+
+// resourceGrantKey identifies a resource grant by what it grants to whom
+func resourceGrantKey(grant models.ResourceGrant) string {
+	return strings.Join([]string{grant.ResourceType, grant.ResourceID, grant.PrincipalType, grant.PrincipalID, grant.Permission}, "\x00")
+}
+
+// policyGrantKey names a policy grant in import changes
+func policyGrantKey(entry models.PolicyResourceGrant) string {
+	return fmt.Sprintf("%s:%s %s:%s", entry.ResourceType, entry.Resource, entry.PrincipalType, entry.Principal)
+}
+
+// policyLabel names a Discord role by ID, or by name when it has no ID
+func policyLabel(id, name string) string {
+	if id != "" {
+		return id
+	}
+	return name
+}
+
+// sameStrings reports whether two lists hold the same values, ignoring order and duplicates
+func sameStrings(a, b []string) bool {
+	a, b = uniqueStrings(a), uniqueStrings(b)
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[string]bool, len(a))
+	for _, value := range a {
+		seen[value] = true
+	}
+	for _, value := range b {
+		if !seen[value] {
+			return false
+		}
+	}
+	return true
+}
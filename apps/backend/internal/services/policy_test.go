@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/config"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRBACService_Policy(t *testing.T) {
+	db, cleanup := testutils.SetupTestDatabaseWithModels(t,
+		&models.User{},
+		&models.Tenant{},
+		&models.UserTenant{},
+		&models.TenantDiscordRole{},
+		&models.Role{},
+		&models.SystemRole{},
+		&models.UserSystemRole{},
+		&models.PermissionAuditLog{},
+		&models.ResourceGrant{},
+		&models.GameServer{},
+	)
+	defer cleanup()
+	rbacService := NewRBACService(db, &config.RBACConfig{RoleSyncTTL: time.Minute})
+	ctx := context.Background()
+
+	owner := &models.User{DiscordUserID: "3000", Username: "owner"}
+	require.NoError(t, db.Create(owner).Error)
+	helper := &models.User{DiscordUserID: "3001", Username: "helper"}
+	require.NoError(t, db.Create(helper).Error)
+	manager := &models.User{DiscordUserID: "3002", Username: "manager"}
+	require.NoError(t, db.Create(manager).Error)
+
+	// The source tenant is set up through the API, the target tenant only
+	// has the same members, an identically named server and Discord roles
+	// with different IDs
+	source := &models.Tenant{DiscordServerID: "guild-source", Name: "Source", OwnerID: owner.ID}
+	require.NoError(t, db.Create(source).Error)
+	target := &models.Tenant{DiscordServerID: "guild-target", Name: "Target", OwnerID: owner.ID}
+	require.NoError(t, db.Create(target).Error)
+
+	servers := make(map[string]*models.GameServer)
+	serviceAccounts := make(map[string]*models.User)
+	for _, tenant := range []*models.Tenant{source, target} {
+		require.NoError(t, db.Create(&models.UserTenant{UserID: owner.ID, TenantID: tenant.ID, Roles: models.StringArray{models.OwnerRole}, Permissions: models.StringArray{models.PermissionAdminAll}}).Error)
+		require.NoError(t, db.Create(&models.UserTenant{UserID: helper.ID, TenantID: tenant.ID, Permissions: models.StringArray{models.PermissionServerRead}}).Error)
+		require.NoError(t, db.Create(&models.UserTenant{UserID: manager.ID, TenantID: tenant.ID, Permissions: models.StringArray{models.PermissionRoleWrite, models.PermissionUserWrite, models.PermissionServerRead}}).Error)
+		server := &models.GameServer{TenantID: tenant.ID, Name: "survival", GameType: "minecraft"}
+		require.NoError(t, db.Create(server).Error)
+		servers[tenant.ID] = server
+
+		// Service accounts have no Discord user ID and stay out of policies
+		account := &models.User{Username: "ci-" + tenant.Name, IsServiceAccount: true}
+		require.NoError(t, db.Create(account).Error)
+		require.NoError(t, db.Create(&models.UserTenant{UserID: account.ID, TenantID: tenant.ID, Permissions: models.StringArray{models.PermissionServerRead}}).Error)
+		_, err := rbacService.CreateResourceGrant(ctx, tenant.ID, models.ResourceTypeGameServer, server.ID, models.CreateResourceGrantRequest{
+			PrincipalType: models.PrincipalTypeUser,
+			PrincipalID:   account.ID,
+			Permission:    models.PermissionServerRestart,
+		}, owner.ID)
+		require.NoError(t, err)
+		serviceAccounts[tenant.ID] = account
+	}
+	require.NoError(t, db.Create(&models.TenantDiscordRole{TenantID: source.ID, DiscordRoleID: "4001", Name: "Moderator"}).Error)
+	require.NoError(t, db.Create(&models.TenantDiscordRole{TenantID: target.ID, DiscordRoleID: "5001", Name: "Moderator"}).Error)
+
+	moderators, err := rbacService.CreateTenantRole(ctx, source.ID, models.RoleRequest{Name: "moderators", Permissions: []string{models.PermissionServerRestart}}, owner.ID)
+	require.NoError(t, err)
+	_, err = rbacService.SetDiscordRoleMapping(ctx, source.ID, "4001", []string{models.PermissionConsoleRead}, []string{moderators.ID}, owner.ID)
+	require.NoError(t, err)
+	require.NoError(t, rbacService.AssignTenantRole(ctx, source.ID, helper.ID, moderators.ID, owner.ID))
+	_, err = rbacService.CreateResourceGrant(ctx, source.ID, models.ResourceTypeGameServer, servers[source.ID].ID, models.CreateResourceGrantRequest{
+		PrincipalType: models.PrincipalTypeUser,
+		PrincipalID:   helper.ID,
+		Permission:    models.PermissionConsoleExecute,
+	}, owner.ID)
+	require.NoError(t, err)
+
+	// An existing role in the target that the policy does not define
+	legacy, err := rbacService.CreateTenantRole(ctx, target.ID, models.RoleRequest{Name: "legacy", Permissions: []string{models.PermissionServerRead}}, owner.ID)
+	require.NoError(t, err)
+	require.NoError(t, rbacService.AssignTenantRole(ctx, target.ID, helper.ID, legacy.ID, owner.ID))
+
+	policy, err := rbacService.ExportPolicy(ctx, source.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.PolicyRole{{Name: "moderators", Permissions: []string{models.PermissionServerRestart}}}, policy.Roles)
+	require.Len(t, policy.DiscordRoles, 1)
+	assert.Equal(t, []string{"moderators"}, policy.DiscordRoles[0].Roles)
+	require.Len(t, policy.ResourceGrants, 1)
+	assert.Equal(t, models.PolicyResourceGrant{
+		ResourceType:  models.ResourceTypeGameServer,
+		Resource:      "survival",
+		PrincipalType: models.PolicyPrincipalUser,
+		Principal:     helper.DiscordUserID,
+		Permission:    models.PermissionConsoleExecute,
+	}, policy.ResourceGrants[0])
+	for _, member := range policy.Members {
+		assert.NotEqual(t, owner.DiscordUserID, member.DiscordUserID)
+		assert.NotEmpty(t, member.DiscordUserID)
+	}
+	require.NoError(t, policy.Validate())
+
+	t.Run("rejects unknown permissions", func(t *testing.T) {
+		invalid := *policy
+		invalid.Roles = []models.PolicyRole{{Name: "moderators", Permissions: []string{"server:explode"}}}
+		_, err := rbacService.ImportPolicy(ctx, target.ID, &invalid, true, owner.ID)
+		assert.ErrorIs(t, err, ErrInvalidPolicy)
+	})
+
+	t.Run("importer must hold the permissions", func(t *testing.T) {
+		_, err := rbacService.ImportPolicy(ctx, target.ID, policy, true, manager.ID)
+		assert.ErrorIs(t, err, ErrPermissionNotHeld)
+	})
+
+	t.Run("dry run changes nothing", func(t *testing.T) {
+		result, err := rbacService.ImportPolicy(ctx, target.ID, policy, true, owner.ID)
+		require.NoError(t, err)
+		assert.True(t, result.DryRun)
+		assert.Contains(t, result.Changes, models.PolicyChange{
+			Action:   models.PolicyActionDelete,
+			Kind:     models.PolicyKindRole,
+			Key:      "legacy",
+			OldValue: "permissions=[server:read]",
+		})
+
+		roles, err := rbacService.GetRoles(ctx, target.ID)
+		require.NoError(t, err)
+		require.Len(t, roles, 1)
+		assert.Equal(t, "legacy", roles[0].Name)
+	})
+
+	t.Run("import makes the target match", func(t *testing.T) {
+		result, err := rbacService.ImportPolicy(ctx, target.ID, policy, false, owner.ID)
+		require.NoError(t, err)
+		assert.NotEmpty(t, result.Changes)
+		assert.Empty(t, result.Warnings)
+
+		exported, err := rbacService.ExportPolicy(ctx, target.ID)
+		require.NoError(t, err)
+		assert.Equal(t, policy.Roles, exported.Roles)
+		assert.Equal(t, policy.Members, exported.Members)
+		assert.Equal(t, policy.ResourceGrants, exported.ResourceGrants)
+		require.Len(t, exported.DiscordRoles, 1)
+		assert.Equal(t, "5001", exported.DiscordRoles[0].ID)
+		assert.Equal(t, policy.DiscordRoles[0].Roles, exported.DiscordRoles[0].Roles)
+
+		has, err := rbacService.HasResourcePermission(ctx, helper.ID, target.ID, models.PermissionConsoleExecute, models.ResourceTypeGameServer, servers[target.ID].ID)
+		require.NoError(t, err)
+		assert.True(t, has)
+		has, err = rbacService.HasPermission(ctx, helper.ID, target.ID, models.PermissionServerRestart)
+		require.NoError(t, err)
+		assert.True(t, has)
+
+		// The service account's grant is not in the policy and is kept
+		has, err = rbacService.HasResourcePermission(ctx, serviceAccounts[target.ID].ID, target.ID, models.PermissionServerRestart, models.ResourceTypeGameServer, servers[target.ID].ID)
+		require.NoError(t, err)
+		assert.True(t, has)
+
+		again, err := rbacService.ImportPolicy(ctx, target.ID, policy, false, owner.ID)
+		require.NoError(t, err)
+		assert.Empty(t, again.Changes)
+	})
+
+	t.Run("skips entries the tenant does not have", func(t *testing.T) {
+		missing := *policy
+		missing.Members = append(append([]models.PolicyMember{}, policy.Members...), models.PolicyMember{DiscordUserID: "9999"})
+		missing.ResourceGrants = []models.PolicyResourceGrant{{
+			ResourceType:  models.ResourceTypeGameServer,
+			Resource:      "creative",
+			PrincipalType: models.PolicyPrincipalRole,
+			Principal:     "moderators",
+			Permission:    models.PermissionServerStart,
+		}}
+
+		result, err := rbacService.ImportPolicy(ctx, target.ID, &missing, true, owner.ID)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"member 9999 not found", "server creative not found"}, result.Warnings)
+		require.Len(t, result.Changes, 1)
+		assert.Equal(t, models.PolicyActionDelete, result.Changes[0].Action)
+		assert.Equal(t, models.PolicyKindResourceGrant, result.Changes[0].Kind)
+	})
+
+	var imported int64
+	require.NoError(t, db.Model(&models.PermissionAuditLog{}).Where("tenant_id = ? AND reason = ?", target.ID, "policy import").Count(&imported).Error)
+	assert.Positive(t, imported)
+}
@@ -2,19 +2,47 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/pteronimbus/pteronimbus/apps/backend/internal/config"
 	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
 	"gorm.io/gorm"
 )
 
+var (
+	// ErrInvalidPermission is returned when a permission string is not a known tenant permission
+	ErrInvalidPermission = errors.New("invalid permission")
+	// ErrRoleNotFound is returned when an internal role does not exist in the tenant
+	ErrRoleNotFound = errors.New("role not found")
+	// ErrDiscordRoleNotFound is returned when a Discord role has not been synced to the tenant
+	ErrDiscordRoleNotFound = errors.New("discord role not found")
+	// ErrSystemRoleNotFound is returned when a system role does not exist
+	ErrSystemRoleNotFound = errors.New("system role not found")
+)
+
+// defaultPermissionCacheTTL bounds how stale a cached decision can get if an
+// invalidation is missed
+const defaultPermissionCacheTTL = 5 * time.Minute
+
 // RBACService handles role-based access control operations
 type RBACService struct {
-	db     *gorm.DB
-	config *config.RBACConfig
+	db         *gorm.DB
+	config     *config.RBACConfig
+	cache      PermissionCache
+	cacheStats permissionCacheCounters
+}
+
+// permissionCacheCounters counts permission cache lookups since startup
+type permissionCacheCounters struct {
+	hits          atomic.Int64
+	misses        atomic.Int64
+	errors        atomic.Int64
+	invalidations atomic.Int64
 }
 
 // NewRBACService creates a new RBAC service
@@ -25,85 +53,254 @@ func NewRBACService(db *gorm.DB, config *config.RBACConfig) *RBACService {
 	}
 }
 
+// NewRBACServiceWithCache creates a new RBAC service that keeps users'
+// effective permissions in a cache until they expire or are invalidated
+func NewRBACServiceWithCache(db *gorm.DB, config *config.RBACConfig, cache PermissionCache) *RBACService {
+	return &RBACService{
+		db:     db,
+		config: config,
+		cache:  cache,
+	}
+}
+
 // HasPermission checks if a user has a specific permission in a tenant
 func (rs *RBACService) HasPermission(ctx context.Context, userID, tenantID, permission string) (bool, error) {
-	// First check if user is super admin
-	isSuperAdmin, err := rs.IsSuperAdmin(ctx, userID)
+	decision, err := rs.ExplainPermission(ctx, userID, tenantID, permission, "", "")
 	if err != nil {
-		return false, fmt.Errorf("failed to check super admin status: %w", err)
-	}
-	if isSuperAdmin {
-		return true, nil
+		return false, err
 	}
 
-	// Get user-tenant relationship
-	var userTenant models.UserTenant
-	err = rs.db.WithContext(ctx).Where("user_id = ? AND tenant_id = ?", userID, tenantID).First(&userTenant).Error
+	return decision.Allowed, nil
+}
+
+// ExplainPermission evaluates a permission check and reports the grants that
+// allowed it or the reason it was denied. Every permission check in the
+// service is answered by this evaluation, so the explanation is exactly what
+// was enforced. With a resource, grants on that resource are consulted when
+// the permission is not held tenant-wide.
+func (rs *RBACService) ExplainPermission(ctx context.Context, userID, tenantID, permission, resourceType, resourceID string) (*models.AuthorizationDecision, error) {
+	effective, err := rs.effectivePermissions(ctx, userID, tenantID)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return false, nil
-		}
-		return false, fmt.Errorf("failed to get user-tenant relationship: %w", err)
+		return nil, err
 	}
 
-	// Check direct permissions
-	for _, perm := range userTenant.Permissions {
-		if perm == permission || perm == models.PermissionAdminAll {
-			return true, nil
+	decision := &models.AuthorizationDecision{
+		UserID:       userID,
+		TenantID:     tenantID,
+		Permission:   permission,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Grants:       []models.PermissionGrant{},
+	}
+
+	if effective.IsSuperAdmin {
+		decision.Allowed = true
+		decision.Reason = models.DecisionReasonSuperAdmin
+		decision.Grants = append(decision.Grants, models.PermissionGrant{
+			Permission: models.PermissionAdminAll,
+			Source:     models.GrantSourceSuperAdmin,
+		})
+		return decision, nil
+	}
+
+	for _, grant := range effective.Grants {
+		if models.PermissionMatches(grant.Permission, permission) {
+			decision.Grants = append(decision.Grants, grant)
 		}
 	}
 
-	// Check role-based permissions
-	if len(userTenant.Roles) > 0 {
-		hasPermission, err := rs.checkRolePermissions(ctx, tenantID, userTenant.Roles, permission)
+	if len(decision.Grants) == 0 && resourceType != "" {
+		resourceGrants, err := rs.userResourceGrants(ctx, userID, tenantID, resourceType, resourceID)
 		if err != nil {
-			return false, fmt.Errorf("failed to check role permissions: %w", err)
+			return nil, err
 		}
-		if hasPermission {
-			return true, nil
+		for _, grant := range resourceGrants {
+			if models.PermissionMatches(grant.Permission, permission) {
+				decision.Grants = append(decision.Grants, models.PermissionGrant{
+					Permission: grant.Permission,
+					Source:     models.GrantSourceResourceGrant,
+					SourceID:   grant.ID,
+					SourceName: grant.PrincipalType + ":" + grant.PrincipalID,
+				})
+			}
 		}
 	}
 
-	return false, nil
+	switch {
+	case len(decision.Grants) > 0:
+		decision.Allowed = true
+		decision.Reason = models.DecisionReasonGranted
+	case !effective.IsMember:
+		decision.Reason = models.DecisionReasonNotMember
+	default:
+		decision.Reason = models.DecisionReasonNotGranted
+	}
+
+	return decision, nil
 }
 
-// checkRolePermissions checks if any of the user's roles have the required permission
-func (rs *RBACService) checkRolePermissions(ctx context.Context, tenantID string, roleIDs []string, permission string) (bool, error) {
-	// Check Discord roles first
-	var discordRoles []models.TenantDiscordRole
-	err := rs.db.WithContext(ctx).Where("tenant_id = ? AND discord_role_id IN ?", tenantID, roleIDs).Find(&discordRoles).Error
+// IsTenantMember checks if a user may enter a tenant at all: superadmins,
+// the tenant owner and users with a membership record
+func (rs *RBACService) IsTenantMember(ctx context.Context, userID, tenantID string) (bool, error) {
+	effective, err := rs.effectivePermissions(ctx, userID, tenantID)
 	if err != nil {
-		return false, fmt.Errorf("failed to get Discord roles: %w", err)
+		return false, err
 	}
 
-	for _, role := range discordRoles {
-		for _, perm := range role.Permissions {
-			if perm == permission || perm == models.PermissionAdminAll {
-				return true, nil
-			}
+	return effective.IsSuperAdmin || effective.IsMember, nil
+}
+
+// effectivePermissions returns a user's resolved access in a tenant, from the
+// cache when possible. Cache failures fall back to the database.
+func (rs *RBACService) effectivePermissions(ctx context.Context, userID, tenantID string) (*models.EffectivePermissions, error) {
+	if rs.cache != nil {
+		cached, err := rs.cache.GetEffectivePermissions(ctx, userID, tenantID)
+		if err == nil {
+			rs.cacheStats.hits.Add(1)
+			return cached, nil
+		}
+		if errors.Is(err, ErrPermissionCacheMiss) {
+			rs.cacheStats.misses.Add(1)
+		} else {
+			rs.cacheStats.errors.Add(1)
 		}
 	}
 
-	// Check internal roles
-	var roles []models.Role
-	err = rs.db.WithContext(ctx).Where("tenant_id = ? AND name IN ?", tenantID, roleIDs).Find(&roles).Error
+	effective, err := rs.resolvePermissions(ctx, userID, tenantID)
 	if err != nil {
-		return false, fmt.Errorf("failed to get internal roles: %w", err)
+		return nil, err
 	}
 
-	for _, role := range roles {
-		for _, perm := range role.Permissions {
-			if perm == permission || perm == models.PermissionAdminAll {
-				return true, nil
-			}
+	if rs.cache != nil {
+		if err := rs.cache.StoreEffectivePermissions(ctx, effective, rs.permissionCacheTTL()); err != nil {
+			rs.cacheStats.errors.Add(1)
 		}
 	}
 
-	return false, nil
+	return effective, nil
+}
+
+// permissionCacheTTL returns how long effective permissions stay cached
+func (rs *RBACService) permissionCacheTTL() time.Duration {
+	if rs.config.PermissionCacheTTL > 0 {
+		return rs.config.PermissionCacheTTL
+	}
+	return defaultPermissionCacheTTL
+}
+
+// resolvePermissions works out a user's access in a tenant from the database.
+// Without a tenant only superadmin status is resolved.
+func (rs *RBACService) resolvePermissions(ctx context.Context, userID, tenantID string) (*models.EffectivePermissions, error) {
+	effective := &models.EffectivePermissions{
+		UserID:      userID,
+		TenantID:    tenantID,
+		Permissions: []string{},
+		Grants:      []models.PermissionGrant{},
+		ComputedAt:  time.Now(),
+	}
+
+	isSuperAdmin, err := rs.isSuperAdmin(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check super admin status: %w", err)
+	}
+	effective.IsSuperAdmin = isSuperAdmin
+	if isSuperAdmin || tenantID == "" {
+		return effective, nil
+	}
+
+	// Get user-tenant relationship
+	var userTenant models.UserTenant
+	err = rs.db.WithContext(ctx).Where("user_id = ? AND tenant_id = ?", userID, tenantID).First(&userTenant).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("failed to get user-tenant relationship: %w", err)
+	}
+
+	if err == gorm.ErrRecordNotFound {
+		// The owner is a member even without a membership record, but holds no permissions through it
+		var count int64
+		err = rs.db.WithContext(ctx).Model(&models.Tenant{}).Where("id = ? AND owner_id = ?", tenantID, userID).Count(&count).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to check tenant owner: %w", err)
+		}
+		effective.IsMember = count > 0
+		return effective, nil
+	}
+
+	effective.IsMember = true
+
+	// Direct permissions come first, then those granted through roles
+	for _, perm := range userTenant.Permissions {
+		effective.Grants = append(effective.Grants, models.PermissionGrant{
+			Permission: perm,
+			Source:     models.GrantSourceDirect,
+		})
+	}
+	if len(userTenant.Roles) > 0 {
+		roleGrants, err := rs.getRoleGrants(ctx, tenantID, userTenant.Roles)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check role permissions: %w", err)
+		}
+		effective.Grants = append(effective.Grants, roleGrants...)
+	}
+
+	permissions := make([]string, 0, len(effective.Grants))
+	for _, grant := range effective.Grants {
+		permissions = append(permissions, grant.Permission)
+	}
+	effective.Permissions = uniqueStrings(permissions)
+
+	return effective, nil
+}
+
+// InvalidateUserPermissions drops a user's cached permissions in every tenant
+func (rs *RBACService) InvalidateUserPermissions(ctx context.Context, userID string) error {
+	if rs.cache == nil {
+		return nil
+	}
+
+	rs.cacheStats.invalidations.Add(1)
+	if err := rs.cache.DeleteUserPermissions(ctx, userID); err != nil {
+		return fmt.Errorf("failed to invalidate user permissions: %w", err)
+	}
+	return nil
+}
+
+// InvalidateTenantPermissions drops every user's cached permissions in a tenant
+func (rs *RBACService) InvalidateTenantPermissions(ctx context.Context, tenantID string) error {
+	if rs.cache == nil {
+		return nil
+	}
+
+	rs.cacheStats.invalidations.Add(1)
+	if err := rs.cache.DeleteTenantPermissions(ctx, tenantID); err != nil {
+		return fmt.Errorf("failed to invalidate tenant permissions: %w", err)
+	}
+	return nil
+}
+
+// PermissionCacheStats reports the permission cache's hits and misses since startup
+func (rs *RBACService) PermissionCacheStats() models.PermissionCacheStats {
+	return models.PermissionCacheStats{
+		Enabled:       rs.cache != nil,
+		Hits:          rs.cacheStats.hits.Load(),
+		Misses:        rs.cacheStats.misses.Load(),
+		Errors:        rs.cacheStats.errors.Load(),
+		Invalidations: rs.cacheStats.invalidations.Load(),
+	}
 }
 
 // IsSuperAdmin checks if a user is a super admin
 func (rs *RBACService) IsSuperAdmin(ctx context.Context, userID string) (bool, error) {
+	effective, err := rs.effectivePermissions(ctx, userID, "")
+	if err != nil {
+		return false, err
+	}
+
+	return effective.IsSuperAdmin, nil
+}
+
+// isSuperAdmin checks the database for super admin status
+func (rs *RBACService) isSuperAdmin(ctx context.Context, userID string) (bool, error) {
 	// Get user details
 	var user models.User
 	err := rs.db.WithContext(ctx).Where("id = ?", userID).First(&user).Error
@@ -120,22 +317,14 @@ func (rs *RBACService) IsSuperAdmin(ctx context.Context, userID string) (bool, e
 		return true, nil
 	}
 
-	// Ensure superadmin system role exists before checking
-	err = rs.ensureSuperAdminSystemRole(ctx)
-	if err != nil {
-		return false, fmt.Errorf("failed to ensure super admin system role: %w", err)
-	}
-
-	// Check if user has system admin role
+	// Check if user has system admin role. A missing superadmin role simply
+	// means nobody holds it, so it is not created here on the hot path.
 	hasSystemAdmin, err := rs.HasSystemPermission(ctx, userID, models.PermissionSystemAdmin)
 	if err != nil {
 		return false, fmt.Errorf("failed to check system admin permission: %w", err)
 	}
-	if hasSystemAdmin {
-		return true, nil
-	}
 
-	return false, nil
+	return hasSystemAdmin, nil
 }
 
 // HasSystemPermission checks if a user has a specific system permission
@@ -152,10 +341,8 @@ func (rs *RBACService) HasSystemPermission(ctx context.Context, userID, permissi
 
 	// Check permissions from system roles
 	for _, userSystemRole := range userSystemRoles {
-		for _, perm := range userSystemRole.SystemRole.Permissions {
-			if perm == permission || perm == models.PermissionAdminAll {
-				return true, nil
-			}
+		if models.GrantsPermission(userSystemRole.SystemRole.Permissions, permission) {
+			return true, nil
 		}
 	}
 
@@ -188,7 +375,7 @@ func (rs *RBACService) AssignSystemRoleToUser(ctx context.Context, userID, syste
 	err := rs.db.WithContext(ctx).Where("name = ?", systemRoleName).First(&systemRole).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return fmt.Errorf("system role '%s' not found", systemRoleName)
+			return fmt.Errorf("%w: %s", ErrSystemRoleNotFound, systemRoleName)
 		}
 		return fmt.Errorf("failed to get system role: %w", err)
 	}
@@ -219,7 +406,7 @@ func (rs *RBACService) AssignSystemRoleToUser(ctx context.Context, userID, syste
 		return fmt.Errorf("failed to assign system role to user: %w", err)
 	}
 
-	return nil
+	return rs.InvalidateUserPermissions(ctx, userID)
 }
 
 // RemoveSystemRoleFromUser removes a system role from a user
@@ -229,7 +416,7 @@ func (rs *RBACService) RemoveSystemRoleFromUser(ctx context.Context, userID, sys
 	err := rs.db.WithContext(ctx).Where("name = ?", systemRoleName).First(&systemRole).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return fmt.Errorf("system role '%s' not found", systemRoleName)
+			return fmt.Errorf("%w: %s", ErrSystemRoleNotFound, systemRoleName)
 		}
 		return fmt.Errorf("failed to get system role: %w", err)
 	}
@@ -242,11 +429,17 @@ func (rs *RBACService) RemoveSystemRoleFromUser(ctx context.Context, userID, sys
 		return fmt.Errorf("failed to remove system role from user: %w", err)
 	}
 
-	return nil
+	return rs.InvalidateUserPermissions(ctx, userID)
 }
 
 // CreateSystemRole creates a new system role
 func (rs *RBACService) CreateSystemRole(ctx context.Context, name, description string, permissions []string) (*models.SystemRole, error) {
+	for _, perm := range permissions {
+		if !models.IsKnownPermission(perm) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidPermission, perm)
+		}
+	}
+
 	systemRole := &models.SystemRole{
 		Name:        name,
 		Description: description,
@@ -274,6 +467,10 @@ func (rs *RBACService) GetSystemRoles(ctx context.Context) ([]models.SystemRole,
 
 // CreateRole creates a new role in a tenant
 func (rs *RBACService) CreateRole(ctx context.Context, tenantID, name string, permissions []string, isSystemRole bool) (*models.Role, error) {
+	if err := validateTenantPermissions(permissions); err != nil {
+		return nil, err
+	}
+
 	role := &models.Role{
 		TenantID:     tenantID,
 		Name:         name,
@@ -286,11 +483,20 @@ func (rs *RBACService) CreateRole(ctx context.Context, tenantID, name string, pe
 		return nil, fmt.Errorf("failed to create role: %w", err)
 	}
 
+	// Members may already hold the role by name
+	if err := rs.InvalidateTenantPermissions(ctx, tenantID); err != nil {
+		return nil, err
+	}
+
 	return role, nil
 }
 
 // UpdateRole updates an existing role
 func (rs *RBACService) UpdateRole(ctx context.Context, roleID string, name string, permissions []string) (*models.Role, error) {
+	if err := validateTenantPermissions(permissions); err != nil {
+		return nil, err
+	}
+
 	var role models.Role
 	err := rs.db.WithContext(ctx).Where("id = ?", roleID).First(&role).Error
 	if err != nil {
@@ -306,6 +512,10 @@ func (rs *RBACService) UpdateRole(ctx context.Context, roleID string, name strin
 		return nil, fmt.Errorf("failed to update role: %w", err)
 	}
 
+	if err := rs.InvalidateTenantPermissions(ctx, role.TenantID); err != nil {
+		return nil, err
+	}
+
 	return &role, nil
 }
 
@@ -326,7 +536,7 @@ func (rs *RBACService) DeleteRole(ctx context.Context, roleID string) error {
 		return fmt.Errorf("failed to delete role: %w", err)
 	}
 
-	return nil
+	return rs.InvalidateTenantPermissions(ctx, role.TenantID)
 }
 
 // GetRoles returns all roles for a tenant
@@ -380,7 +590,7 @@ func (rs *RBACService) AssignRoleToUser(ctx context.Context, userID, tenantID, r
 		}
 	}
 
-	return nil
+	return rs.InvalidateUserPermissions(ctx, userID)
 }
 
 // RemoveRoleFromUser removes a role from a user in a tenant
@@ -410,7 +620,7 @@ func (rs *RBACService) RemoveRoleFromUser(ctx context.Context, userID, tenantID,
 		return fmt.Errorf("failed to remove role from user: %w", err)
 	}
 
-	return nil
+	return rs.InvalidateUserPermissions(ctx, userID)
 }
 
 // LogPermissionChange logs a permission change for audit purposes
@@ -441,81 +651,176 @@ func (rs *RBACService) LogPermissionChange(ctx context.Context, userID, tenantID
 
 // GetUserPermissions returns all permissions for a user in a tenant
 func (rs *RBACService) GetUserPermissions(ctx context.Context, userID, tenantID string) ([]string, error) {
-	// Check if user is super admin
-	isSuperAdmin, err := rs.IsSuperAdmin(ctx, userID)
+	effective, err := rs.effectivePermissions(ctx, userID, tenantID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to check super admin status: %w", err)
+		return nil, err
 	}
-	if isSuperAdmin {
+	if effective.IsSuperAdmin {
 		return []string{models.PermissionAdminAll}, nil
 	}
 
-	var userTenant models.UserTenant
-	err = rs.db.WithContext(ctx).Where("user_id = ? AND tenant_id = ?", userID, tenantID).First(&userTenant).Error
+	return append([]string{}, effective.Permissions...), nil
+}
+
+// getRoleGrants gets the permissions granted by the specified roles and where
+// each comes from. Discord roles contribute their own permissions plus those of
+// the internal roles mapped to them.
+func (rs *RBACService) getRoleGrants(ctx context.Context, tenantID string, roleIDs []string) ([]models.PermissionGrant, error) {
+	grants := make([]models.PermissionGrant, 0)
+
+	// Get Discord role permissions
+	var discordRoles []models.TenantDiscordRole
+	err := rs.db.WithContext(ctx).Where("tenant_id = ? AND discord_role_id IN ?", tenantID, roleIDs).Find(&discordRoles).Error
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return []string{}, nil
+		return nil, fmt.Errorf("failed to get Discord roles: %w", err)
+	}
+
+	mappedRoleIDs := make([]string, 0)
+	mappedBy := make(map[string][]string)
+	for _, role := range discordRoles {
+		for _, perm := range role.Permissions {
+			grants = append(grants, models.PermissionGrant{
+				Permission: perm,
+				Source:     models.GrantSourceDiscordRole,
+				SourceID:   role.DiscordRoleID,
+				SourceName: role.Name,
+			})
+		}
+		for _, roleID := range role.MappedRoleIDs {
+			mappedRoleIDs = append(mappedRoleIDs, roleID)
+			mappedBy[roleID] = append(mappedBy[roleID], role.DiscordRoleID)
 		}
-		return nil, fmt.Errorf("failed to get user-tenant relationship: %w", err)
 	}
 
-	permissions := make([]string, 0)
-	permissionSet := make(map[string]bool)
+	// Get internal role permissions, assigned by name or mapped from a Discord role
+	query := rs.db.WithContext(ctx).Where("tenant_id = ?", tenantID)
+	if len(mappedRoleIDs) > 0 {
+		query = query.Where("name IN ? OR id IN ?", roleIDs, mappedRoleIDs)
+	} else {
+		query = query.Where("name IN ?", roleIDs)
+	}
 
-	// Add direct permissions
-	for _, perm := range userTenant.Permissions {
-		if !permissionSet[perm] {
-			permissions = append(permissions, perm)
-			permissionSet[perm] = true
-		}
+	var roles []models.Role
+	err = query.Find(&roles).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get internal roles: %w", err)
 	}
 
-	// Add role-based permissions
-	if len(userTenant.Roles) > 0 {
-		rolePermissions, err := rs.getRolePermissions(ctx, tenantID, userTenant.Roles)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get role permissions: %w", err)
-		}
+	assigned := make(map[string]bool, len(roleIDs))
+	for _, roleID := range roleIDs {
+		assigned[roleID] = true
+	}
 
-		for _, perm := range rolePermissions {
-			if !permissionSet[perm] {
-				permissions = append(permissions, perm)
-				permissionSet[perm] = true
+	for _, role := range roles {
+		for _, perm := range role.Permissions {
+			if assigned[role.Name] {
+				grants = append(grants, models.PermissionGrant{
+					Permission: perm,
+					Source:     models.GrantSourceRole,
+					SourceID:   role.ID,
+					SourceName: role.Name,
+				})
+			}
+			for _, discordRoleID := range mappedBy[role.ID] {
+				grants = append(grants, models.PermissionGrant{
+					Permission: perm,
+					Source:     models.GrantSourceDiscordRoleMapping,
+					SourceID:   role.ID,
+					SourceName: role.Name,
+					Via:        discordRoleID,
+				})
 			}
 		}
 	}
 
-	return permissions, nil
+	return grants, nil
 }
 
-// getRolePermissions gets all permissions from the specified roles
-func (rs *RBACService) getRolePermissions(ctx context.Context, tenantID string, roleIDs []string) ([]string, error) {
-	permissions := make([]string, 0)
-
-	// Get Discord role permissions
-	var discordRoles []models.TenantDiscordRole
-	err := rs.db.WithContext(ctx).Where("tenant_id = ? AND discord_role_id IN ?", tenantID, roleIDs).Find(&discordRoles).Error
+// GetDiscordRoles returns a tenant's Discord roles with their permission mappings
+func (rs *RBACService) GetDiscordRoles(ctx context.Context, tenantID string) ([]models.TenantDiscordRole, error) {
+	var roles []models.TenantDiscordRole
+	err := rs.db.WithContext(ctx).Where("tenant_id = ?", tenantID).Order("position DESC").Find(&roles).Error
 	if err != nil {
 		return nil, fmt.Errorf("failed to get Discord roles: %w", err)
 	}
 
-	for _, role := range discordRoles {
-		permissions = append(permissions, []string(role.Permissions)...)
+	return roles, nil
+}
+
+// SetDiscordRoleMapping replaces the permissions and internal roles granted by a Discord role
+func (rs *RBACService) SetDiscordRoleMapping(ctx context.Context, tenantID, discordRoleID string, permissions, roleIDs []string, performedBy string) (*models.TenantDiscordRole, error) {
+	permissions = uniqueStrings(permissions)
+	for _, perm := range permissions {
+		if !models.IsValidTenantPermission(perm) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidPermission, perm)
+		}
 	}
 
-	// Get internal role permissions
-	var roles []models.Role
-	err = rs.db.WithContext(ctx).Where("tenant_id = ? AND name IN ?", tenantID, roleIDs).Find(&roles).Error
+	roleIDs = uniqueStrings(roleIDs)
+	for _, roleID := range roleIDs {
+		if _, err := uuid.Parse(roleID); err != nil {
+			return nil, ErrRoleNotFound
+		}
+	}
+
+	var discordRole models.TenantDiscordRole
+	err := rs.db.WithContext(ctx).Where("tenant_id = ? AND discord_role_id = ?", tenantID, discordRoleID).First(&discordRole).Error
 	if err != nil {
-		return nil, fmt.Errorf("failed to get internal roles: %w", err)
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrDiscordRoleNotFound
+		}
+		return nil, fmt.Errorf("failed to get Discord role: %w", err)
 	}
 
-	for _, role := range roles {
-		permissions = append(permissions, []string(role.Permissions)...)
+	// The editor must hold everything the mapping grants, both before and after
+	// the change, so that nobody can hand out or take away more than they have
+	var mappedRoles []models.Role
+	if len(roleIDs)+len(discordRole.MappedRoleIDs) > 0 {
+		allRoleIDs := uniqueStrings(append(append([]string{}, roleIDs...), discordRole.MappedRoleIDs...))
+		err := rs.db.WithContext(ctx).Where("tenant_id = ? AND id IN ?", tenantID, allRoleIDs).Find(&mappedRoles).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to get roles: %w", err)
+		}
+	}
+	found := make(map[string]bool, len(mappedRoles))
+	held := append(append([]string{}, discordRole.Permissions...), permissions...)
+	for _, role := range mappedRoles {
+		found[role.ID] = true
+		held = append(held, role.Permissions...)
+	}
+	for _, roleID := range roleIDs {
+		if !found[roleID] {
+			return nil, ErrRoleNotFound
+		}
+	}
+	if err := rs.requirePermissionsHeld(ctx, performedBy, tenantID, held); err != nil {
+		return nil, err
 	}
 
-	return permissions, nil
-} 
+	oldValue := fmt.Sprintf("permissions=%v roles=%v", []string(discordRole.Permissions), []string(discordRole.MappedRoleIDs))
+
+	discordRole.Permissions = models.StringArray(permissions)
+	discordRole.MappedRoleIDs = models.StringArray(roleIDs)
+	err = rs.db.WithContext(ctx).Model(&discordRole).Updates(map[string]interface{}{
+		"permissions":     discordRole.Permissions,
+		"mapped_role_ids": discordRole.MappedRoleIDs,
+	}).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to update Discord role mapping: %w", err)
+	}
+
+	if err := rs.InvalidateTenantPermissions(ctx, tenantID); err != nil {
+		return nil, err
+	}
+
+	newValue := fmt.Sprintf("permissions=%v roles=%v", permissions, roleIDs)
+	err = rs.LogPermissionChange(ctx, performedBy, tenantID, "discord_role_mapping_updated", "discord_role", discordRoleID, oldValue, newValue, "", performedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	return &discordRole, nil
+}
 
 // AssignSuperAdminRole assigns the super admin role to a user
 func (rs *RBACService) AssignSuperAdminRole(ctx context.Context, userID string) error {
@@ -705,4 +1010,18 @@ func (rs *RBACService) ensureSystemUserRole(ctx context.Context) error {
 	}
 
 	return nil
-} 
\ No newline at end of file
+} 
+
+// uniqueStrings returns values without duplicates, keeping the first occurrence
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, value := range values {
+		if seen[value] {
+			continue
+		}
+		seen[value] = true
+		unique = append(unique, value)
+	}
+	return unique
+}
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/bwmarrin/discordgo"
 	"github.com/google/uuid"
 	"github.com/pteronimbus/pteronimbus/apps/backend/internal/config"
 	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
@@ -107,6 +108,50 @@ func TestRBACService_HasPermission(t *testing.T) {
 	assert.True(t, hasPermission)
 }
 
+func TestRBACService_HasPermission_Wildcards(t *testing.T) {
+	rbacService, db, cleanup := setupRBACTest(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	tenant := &models.Tenant{
+		DiscordServerID: "guild-123",
+		Name:            "Test Guild",
+		OwnerID:         uuid.New().String(),
+	}
+	require.NoError(t, db.Create(tenant).Error)
+	user := &models.User{DiscordUserID: "user-123", Username: "testuser"}
+	require.NoError(t, db.Create(user).Error)
+
+	require.NoError(t, db.Create(&models.TenantDiscordRole{
+		TenantID:      tenant.ID,
+		DiscordRoleID: "readers",
+		Name:          "Readers",
+		Permissions:   models.StringArray{"*:read"},
+	}).Error)
+	require.NoError(t, db.Create(&models.UserTenant{
+		UserID:      user.ID,
+		TenantID:    tenant.ID,
+		Roles:       models.StringArray{"readers"},
+		Permissions: models.StringArray{"server:*", models.PermissionFileWrite},
+	}).Error)
+
+	tests := []struct {
+		permission string
+		expected   bool
+	}{
+		{permission: models.PermissionServerRestart, expected: true},
+		{permission: models.PermissionBackupRead, expected: true},
+		{permission: models.PermissionFileRead, expected: true},
+		{permission: models.PermissionFileDelete, expected: false},
+		{permission: models.PermissionRoleWrite, expected: false},
+	}
+	for _, tt := range tests {
+		hasPermission, err := rbacService.HasPermission(ctx, user.ID, tenant.ID, tt.permission)
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, hasPermission, tt.permission)
+	}
+}
+
 func TestRBACService_IsSuperAdmin(t *testing.T) {
 	rbacService, db, cleanup := setupRBACTest(t)
 	defer cleanup()
@@ -547,7 +592,7 @@ func TestRBACService_SystemRoles(t *testing.T) {
 	// Test assigning system role to user
 	t.Run("AssignSystemRoleToUser", func(t *testing.T) {
 		// Create system role first
-		_, err := rbacService.CreateSystemRole(context.Background(), "test_role", "Test role", []string{"test:permission"})
+		_, err := rbacService.CreateSystemRole(context.Background(), "test_role", "Test role", []string{models.PermissionTemplateRead})
 		require.NoError(t, err)
 
 		// Assign role to user
@@ -564,7 +609,7 @@ func TestRBACService_SystemRoles(t *testing.T) {
 	// Test removing system role from user
 	t.Run("RemoveSystemRoleFromUser", func(t *testing.T) {
 		// Create and assign role
-		_, err := rbacService.CreateSystemRole(context.Background(), "remove_test", "Remove test", []string{"test:permission"})
+		_, err := rbacService.CreateSystemRole(context.Background(), "remove_test", "Remove test", []string{models.PermissionTemplateRead})
 		require.NoError(t, err)
 		err = rbacService.AssignSystemRoleToUser(context.Background(), regularUser.ID, "remove_test")
 		require.NoError(t, err)
@@ -584,7 +629,7 @@ func TestRBACService_SystemRoles(t *testing.T) {
 	// Test system permission checking
 	t.Run("HasSystemPermission", func(t *testing.T) {
 		// Create system role with specific permission
-		_, err := rbacService.CreateSystemRole(context.Background(), "permission_test", "Permission test", []string{"custom:permission"})
+		_, err := rbacService.CreateSystemRole(context.Background(), "permission_test", "Permission test", []string{models.PermissionTemplateWrite})
 		require.NoError(t, err)
 
 		// Assign role to user
@@ -592,7 +637,7 @@ func TestRBACService_SystemRoles(t *testing.T) {
 		require.NoError(t, err)
 
 		// Check permission
-		hasPermission, err := rbacService.HasSystemPermission(context.Background(), regularUser.ID, "custom:permission")
+		hasPermission, err := rbacService.HasSystemPermission(context.Background(), regularUser.ID, models.PermissionTemplateWrite)
 		require.NoError(t, err)
 		assert.True(t, hasPermission)
 
@@ -979,3 +1024,176 @@ func TestRBACService_ManualSuperAdminRoleAssignment(t *testing.T) {
 		assert.Contains(t, err.Error(), "cannot remove super admin role from the initial super admin")
 	})
 }
+
+func TestRBACService_DiscordRoleMapping(t *testing.T) {
+	rbacService, db, cleanup := setupRBACTest(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	tenant := &models.Tenant{DiscordServerID: "guild-mapping", Name: "Mapping Guild", OwnerID: uuid.New().String()}
+	require.NoError(t, db.Create(tenant).Error)
+
+	admin := &models.User{DiscordUserID: "admin-mapping", Username: "admin"}
+	require.NoError(t, db.Create(admin).Error)
+	mod := &models.User{DiscordUserID: "mod-mapping", Username: "mod"}
+	require.NoError(t, db.Create(mod).Error)
+
+	moderatorRole := &models.TenantDiscordRole{TenantID: tenant.ID, DiscordRoleID: "role-mod", Name: "Moderator"}
+	require.NoError(t, db.Create(moderatorRole).Error)
+	backupRole, err := rbacService.CreateRole(ctx, tenant.ID, "backup-operator", []string{models.PermissionBackupCreate}, false)
+	require.NoError(t, err)
+
+	require.NoError(t, db.Create(&models.UserTenant{UserID: admin.ID, TenantID: tenant.ID, Permissions: models.StringArray{models.PermissionAdminAll}}).Error)
+	// The mod only has the Discord role
+	require.NoError(t, db.Create(&models.UserTenant{UserID: mod.ID, TenantID: tenant.ID, Roles: models.StringArray{"role-mod"}}).Error)
+
+	has, err := rbacService.HasPermission(ctx, mod.ID, tenant.ID, models.PermissionConsoleRead)
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	_, err = rbacService.SetDiscordRoleMapping(ctx, tenant.ID, "role-mod", []string{models.PermissionConsoleRead}, []string{backupRole.ID}, admin.ID)
+	require.NoError(t, err)
+
+	has, err = rbacService.HasPermission(ctx, mod.ID, tenant.ID, models.PermissionConsoleRead)
+	require.NoError(t, err)
+	assert.True(t, has)
+
+	// Permissions of mapped internal roles are granted too
+	has, err = rbacService.HasPermission(ctx, mod.ID, tenant.ID, models.PermissionBackupCreate)
+	require.NoError(t, err)
+	assert.True(t, has)
+
+	// Mapping changes are audited
+	var auditCount int64
+	db.Model(&models.PermissionAuditLog{}).Where("resource_id = ? AND action = ?", "role-mod", "discord_role_mapping_updated").Count(&auditCount)
+	assert.Equal(t, int64(1), auditCount)
+
+	// Unknown permissions, roles and Discord roles are rejected
+	_, err = rbacService.SetDiscordRoleMapping(ctx, tenant.ID, "role-mod", []string{"console:sudo"}, nil, admin.ID)
+	assert.ErrorIs(t, err, ErrInvalidPermission)
+	_, err = rbacService.SetDiscordRoleMapping(ctx, tenant.ID, "role-mod", nil, []string{uuid.New().String()}, admin.ID)
+	assert.ErrorIs(t, err, ErrRoleNotFound)
+	_, err = rbacService.SetDiscordRoleMapping(ctx, tenant.ID, "role-missing", nil, nil, admin.ID)
+	assert.ErrorIs(t, err, ErrDiscordRoleNotFound)
+
+	// Editors must hold what the mapping grants, including its mapped roles, and
+	// what it granted before
+	editor := &models.User{DiscordUserID: "editor-mapping", Username: "editor"}
+	require.NoError(t, db.Create(editor).Error)
+	require.NoError(t, db.Create(&models.UserTenant{UserID: editor.ID, TenantID: tenant.ID, Permissions: models.StringArray{models.PermissionRoleWrite, models.PermissionConsoleRead}}).Error)
+	_, err = rbacService.SetDiscordRoleMapping(ctx, tenant.ID, "role-mod", []string{models.PermissionAdminAll}, nil, editor.ID)
+	assert.ErrorIs(t, err, ErrPermissionNotHeld)
+	_, err = rbacService.SetDiscordRoleMapping(ctx, tenant.ID, "role-mod", []string{models.PermissionConsoleRead}, []string{backupRole.ID}, editor.ID)
+	assert.ErrorIs(t, err, ErrPermissionNotHeld)
+	_, err = rbacService.SetDiscordRoleMapping(ctx, tenant.ID, "role-mod", []string{models.PermissionConsoleRead}, nil, editor.ID)
+	assert.ErrorIs(t, err, ErrPermissionNotHeld)
+
+	// Re-syncing the role from Discord keeps the mapping
+	syncService := NewSyncService(db, nil)
+	require.NoError(t, syncService.UpsertRole(tenant.DiscordServerID, &discordgo.Role{ID: "role-mod", Name: "Mods"}))
+
+	var stored models.TenantDiscordRole
+	require.NoError(t, db.Where("tenant_id = ? AND discord_role_id = ?", tenant.ID, "role-mod").First(&stored).Error)
+	assert.Equal(t, "Mods", stored.Name)
+	assert.Equal(t, models.StringArray{models.PermissionConsoleRead}, stored.Permissions)
+	assert.Equal(t, models.StringArray{backupRole.ID}, stored.MappedRoleIDs)
+}
+
+func TestRBACService_ExplainPermission(t *testing.T) {
+	db, cleanup := testutils.SetupTestDatabaseWithModels(t,
+		&models.User{},
+		&models.Tenant{},
+		&models.UserTenant{},
+		&models.TenantDiscordRole{},
+		&models.Role{},
+		&models.SystemRole{},
+		&models.UserSystemRole{},
+		&models.ResourceGrant{},
+	)
+	defer cleanup()
+	rbacService := NewRBACService(db, &config.RBACConfig{SuperAdminDiscordID: "superadmin123"})
+	ctx := context.Background()
+
+	tenant := &models.Tenant{DiscordServerID: "guild-123", Name: "Test Guild", OwnerID: uuid.New().String()}
+	require.NoError(t, db.Create(tenant).Error)
+	member := &models.User{DiscordUserID: "member-123", Username: "member"}
+	require.NoError(t, db.Create(member).Error)
+	outsider := &models.User{DiscordUserID: "outsider-123", Username: "outsider"}
+	require.NoError(t, db.Create(outsider).Error)
+	superAdmin := &models.User{DiscordUserID: "superadmin123", Username: "superadmin"}
+	require.NoError(t, db.Create(superAdmin).Error)
+
+	operator := &models.Role{TenantID: tenant.ID, Name: "operator", Permissions: models.StringArray{models.PermissionServerRestart}}
+	require.NoError(t, db.Create(operator).Error)
+	backups := &models.Role{TenantID: tenant.ID, Name: "backups", Permissions: models.StringArray{models.PermissionBackupCreate}}
+	require.NoError(t, db.Create(backups).Error)
+	require.NoError(t, db.Create(&models.TenantDiscordRole{
+		TenantID:      tenant.ID,
+		DiscordRoleID: "discord-mods",
+		Name:          "Mods",
+		Permissions:   models.StringArray{models.PermissionConsoleRead},
+		MappedRoleIDs: models.StringArray{backups.ID},
+	}).Error)
+	require.NoError(t, db.Create(&models.UserTenant{
+		UserID:      member.ID,
+		TenantID:    tenant.ID,
+		Roles:       models.StringArray{"operator", "discord-mods"},
+		Permissions: models.StringArray{models.PermissionServerRead},
+	}).Error)
+	require.NoError(t, db.Create(&models.ResourceGrant{
+		TenantID:      tenant.ID,
+		PrincipalType: models.PrincipalTypeUser,
+		PrincipalID:   member.ID,
+		Permission:    models.PermissionServerStart,
+		ResourceType:  models.ResourceTypeGameServer,
+		ResourceID:    "server-1",
+	}).Error)
+
+	tests := []struct {
+		name       string
+		userID     string
+		permission string
+		resourceID string
+		allowed    bool
+		reason     string
+		source     string
+		sourceName string
+	}{
+		{name: "direct grant", userID: member.ID, permission: models.PermissionServerRead, allowed: true, reason: models.DecisionReasonGranted, source: models.GrantSourceDirect},
+		{name: "internal role", userID: member.ID, permission: models.PermissionServerRestart, allowed: true, reason: models.DecisionReasonGranted, source: models.GrantSourceRole, sourceName: "operator"},
+		{name: "Discord role", userID: member.ID, permission: models.PermissionConsoleRead, allowed: true, reason: models.DecisionReasonGranted, source: models.GrantSourceDiscordRole, sourceName: "Mods"},
+		{name: "Discord role mapping", userID: member.ID, permission: models.PermissionBackupCreate, allowed: true, reason: models.DecisionReasonGranted, source: models.GrantSourceDiscordRoleMapping, sourceName: "backups"},
+		{name: "resource grant", userID: member.ID, permission: models.PermissionServerStart, resourceID: "server-1", allowed: true, reason: models.DecisionReasonGranted, source: models.GrantSourceResourceGrant},
+		{name: "resource grant on another server", userID: member.ID, permission: models.PermissionServerStart, resourceID: "server-2", reason: models.DecisionReasonNotGranted},
+		{name: "not granted", userID: member.ID, permission: models.PermissionServerDelete, reason: models.DecisionReasonNotGranted},
+		{name: "not a member", userID: outsider.ID, permission: models.PermissionServerRead, reason: models.DecisionReasonNotMember},
+		{name: "superadmin", userID: superAdmin.ID, permission: models.PermissionServerDelete, allowed: true, reason: models.DecisionReasonSuperAdmin, source: models.GrantSourceSuperAdmin},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resourceType := ""
+			if tt.resourceID != "" {
+				resourceType = models.ResourceTypeGameServer
+			}
+
+			decision, err := rbacService.ExplainPermission(ctx, tt.userID, tenant.ID, tt.permission, resourceType, tt.resourceID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.allowed, decision.Allowed)
+			assert.Equal(t, tt.reason, decision.Reason)
+			if tt.allowed {
+				require.NotEmpty(t, decision.Grants)
+				assert.Equal(t, tt.source, decision.Grants[0].Source)
+				if tt.sourceName != "" {
+					assert.Equal(t, tt.sourceName, decision.Grants[0].SourceName)
+				}
+			} else {
+				assert.Empty(t, decision.Grants)
+			}
+
+			// Enforcement must agree with the explanation
+			held, err := rbacService.HasResourcePermission(ctx, tt.userID, tenant.ID, tt.permission, resourceType, tt.resourceID)
+			require.NoError(t, err)
+			assert.Equal(t, decision.Allowed, held)
+		})
+	}
+}
@@ -49,15 +49,89 @@ func (r *RedisService) StoreSession(ctx context.Context, session *models.Session
 	}
 
 	// Also store refresh token mapping
+	err = r.StoreRefreshToken(ctx, session)
+	if err != nil {
+		return err
+	}
+
+	// Index the session under its user so that it can be listed and revoked
+	err = r.indexUserSession(ctx, session)
+	if err != nil {
+		return fmt.Errorf("failed to index session: %w", err)
+	}
+
+	return nil
+}
+
+// StoreRefreshToken maps the session's current refresh token to the session
+// until the session expires
+func (r *RedisService) StoreRefreshToken(ctx context.Context, session *models.Session) error {
+	ttl := time.Until(session.ExpiresAt)
+	if ttl <= 0 {
+		return fmt.Errorf("session already expired")
+	}
+
 	refreshKey := fmt.Sprintf("refresh_token:%s", session.RefreshToken)
-	err = r.client.Set(ctx, refreshKey, session.ID, ttl).Err()
+	err := r.client.Set(ctx, refreshKey, session.ID, ttl).Err()
 	if err != nil {
 		return fmt.Errorf("failed to store refresh token mapping: %w", err)
 	}
+	return nil
+}
+
+// indexUserSession adds a session to its user's index, scored by expiry so
+// that expired entries can be dropped without reading every session
+func (r *RedisService) indexUserSession(ctx context.Context, session *models.Session) error {
+	indexKey := userSessionsKey(session.UserID)
+	err := r.client.ZAdd(ctx, indexKey, redis.Z{
+		Score:  float64(session.ExpiresAt.Unix()),
+		Member: session.ID,
+	}).Err()
+	if err != nil {
+		return err
+	}
+
+	err = r.client.ZRemRangeByScore(ctx, indexKey, "-inf", fmt.Sprintf("(%d", time.Now().Unix())).Err()
+	if err != nil {
+		return err
+	}
 
+	// Keep the index around as long as its longest-lived session
+	latest, err := r.client.ZRangeWithScores(ctx, indexKey, -1, -1).Result()
+	if err != nil {
+		return err
+	}
+	if len(latest) > 0 {
+		return r.client.ExpireAt(ctx, indexKey, time.Unix(int64(latest[0].Score), 0)).Err()
+	}
 	return nil
 }
 
+// ListUserSessions returns the unexpired sessions of a user
+func (r *RedisService) ListUserSessions(ctx context.Context, userID string) ([]*models.Session, error) {
+	indexKey := userSessionsKey(userID)
+	sessionIDs, err := r.client.ZRangeByScore(ctx, indexKey, &redis.ZRangeBy{
+		Min: fmt.Sprintf("%d", time.Now().Unix()),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	sessions := make([]*models.Session, 0, len(sessionIDs))
+	for _, sessionID := range sessionIDs {
+		session, err := r.GetSession(ctx, sessionID)
+		if err != nil {
+			// Drop index entries whose session is already gone
+			r.client.ZRem(ctx, indexKey, sessionID)
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
 // GetSession retrieves a session from Redis
 func (r *RedisService) GetSession(ctx context.Context, sessionID string) (*models.Session, error) {
 	key := fmt.Sprintf("session:%s", sessionID)
@@ -78,6 +152,59 @@ func (r *RedisService) GetSession(ctx context.Context, sessionID string) (*model
 	return &session, nil
 }
 
+// maxSessionUpdateAttempts bounds how often UpdateSession retries when the
+// session changes while it is being updated
+const maxSessionUpdateAttempts = 3
+
+// UpdateSession changes fields of a stored session. The session is only
+// written back if it still exists and was not changed in the meantime, so a
+// revoked session is never brought back. Its expiry and refresh token mapping
+// are left as they are.
+func (r *RedisService) UpdateSession(ctx context.Context, sessionID string, update func(*models.Session)) (*models.Session, error) {
+	key := fmt.Sprintf("session:%s", sessionID)
+	var session models.Session
+
+	txf := func(tx *redis.Tx) error {
+		sessionData, err := tx.Get(ctx, key).Bytes()
+		if err != nil {
+			return err
+		}
+
+		session = models.Session{}
+		if err := json.Unmarshal(sessionData, &session); err != nil {
+			return fmt.Errorf("failed to unmarshal session: %w", err)
+		}
+		update(&session)
+
+		updated, err := json.Marshal(&session)
+		if err != nil {
+			return fmt.Errorf("failed to marshal session: %w", err)
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.SetArgs(ctx, key, updated, redis.SetArgs{Mode: "XX", KeepTTL: true})
+			return nil
+		})
+		return err
+	}
+
+	for attempt := 0; attempt < maxSessionUpdateAttempts; attempt++ {
+		err := r.client.Watch(ctx, txf, key)
+		switch {
+		case err == nil:
+			return &session, nil
+		case err == redis.Nil:
+			return nil, ErrSessionNotFound
+		case err == redis.TxFailedErr:
+			continue
+		default:
+			return nil, fmt.Errorf("failed to update session: %w", err)
+		}
+	}
+
+	return nil, fmt.Errorf("failed to update session: %w", redis.TxFailedErr)
+}
+
 // GetSessionByRefreshToken retrieves a session by refresh token
 func (r *RedisService) GetSessionByRefreshToken(ctx context.Context, refreshToken string) (*models.Session, error) {
 	refreshKey := fmt.Sprintf("refresh_token:%s", refreshToken)
@@ -92,6 +219,44 @@ func (r *RedisService) GetSessionByRefreshToken(ctx context.Context, refreshToke
 	return r.GetSession(ctx, sessionID)
 }
 
+// ConsumeRefreshToken retrieves the session for a refresh token and removes the
+// token's mapping so that it can only be used once. The token is remembered as
+// retired until retiredUntil so that a later attempt to reuse it can be detected.
+func (r *RedisService) ConsumeRefreshToken(ctx context.Context, refreshToken string, retiredUntil time.Time) (*models.Session, error) {
+	refreshKey := fmt.Sprintf("refresh_token:%s", refreshToken)
+	sessionID, err := r.client.GetDel(ctx, refreshKey).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, ErrRefreshTokenNotFound
+		}
+		return nil, fmt.Errorf("failed to get session ID: %w", err)
+	}
+
+	if ttl := time.Until(retiredUntil); ttl > 0 {
+		retiredKey := fmt.Sprintf("retired_refresh_token:%s", refreshToken)
+		err = r.client.Set(ctx, retiredKey, sessionID, ttl).Err()
+		if err != nil {
+			return nil, fmt.Errorf("failed to retire refresh token: %w", err)
+		}
+	}
+
+	return r.GetSession(ctx, sessionID)
+}
+
+// GetRetiredRefreshToken returns the ID of the session a retired refresh token belonged to
+func (r *RedisService) GetRetiredRefreshToken(ctx context.Context, refreshToken string) (string, error) {
+	retiredKey := fmt.Sprintf("retired_refresh_token:%s", refreshToken)
+	sessionID, err := r.client.Get(ctx, retiredKey).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return "", ErrRefreshTokenNotFound
+		}
+		return "", fmt.Errorf("failed to get retired refresh token: %w", err)
+	}
+
+	return sessionID, nil
+}
+
 // DeleteSession deletes a session from Redis
 func (r *RedisService) DeleteSession(ctx context.Context, sessionID string) error {
 	// Get session first to get refresh token
@@ -115,6 +280,73 @@ func (r *RedisService) DeleteSession(ctx context.Context, sessionID string) erro
 		return fmt.Errorf("failed to delete refresh token mapping: %w", err)
 	}
 
+	// Remove the session from its user's index
+	err = r.client.ZRem(ctx, userSessionsKey(session.UserID), sessionID).Err()
+	if err != nil {
+		return fmt.Errorf("failed to remove session from index: %w", err)
+	}
+
+	return nil
+}
+
+// StoreTwoFactorChallenge stores a login waiting for its second factor until it expires
+func (r *RedisService) StoreTwoFactorChallenge(ctx context.Context, challenge *models.TwoFactorChallenge) error {
+	data, err := json.Marshal(challenge)
+	if err != nil {
+		return fmt.Errorf("failed to marshal two-factor challenge: %w", err)
+	}
+
+	ttl := time.Until(challenge.ExpiresAt)
+	if ttl <= 0 {
+		return fmt.Errorf("two-factor challenge already expired")
+	}
+
+	err = r.client.Set(ctx, twoFactorChallengeKey(challenge.ID), data, ttl).Err()
+	if err != nil {
+		return fmt.Errorf("failed to store two-factor challenge: %w", err)
+	}
+	return nil
+}
+
+// GetTwoFactorChallenge retrieves a pending two-factor challenge
+func (r *RedisService) GetTwoFactorChallenge(ctx context.Context, challengeID string) (*models.TwoFactorChallenge, error) {
+	data, err := r.client.Get(ctx, twoFactorChallengeKey(challengeID)).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, ErrTwoFactorChallengeNotFound
+		}
+		return nil, fmt.Errorf("failed to get two-factor challenge: %w", err)
+	}
+
+	var challenge models.TwoFactorChallenge
+	if err := json.Unmarshal([]byte(data), &challenge); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal two-factor challenge: %w", err)
+	}
+	return &challenge, nil
+}
+
+// CountTwoFactorChallengeAttempt counts a code tried against a challenge and
+// returns the number of codes tried so far. The counter lives in its own key
+// so that concurrent attempts are all counted without rewriting the challenge.
+func (r *RedisService) CountTwoFactorChallengeAttempt(ctx context.Context, challenge *models.TwoFactorChallenge) (int64, error) {
+	ttl := time.Until(challenge.ExpiresAt)
+	if ttl <= 0 {
+		return 0, fmt.Errorf("two-factor challenge already expired")
+	}
+
+	count, err := incrementWithExpiry.Run(ctx, r.client, []string{twoFactorChallengeAttemptsKey(challenge.ID)}, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count two-factor challenge attempt: %w", err)
+	}
+	return count, nil
+}
+
+// DeleteTwoFactorChallenge removes a two-factor challenge once answered or given up on
+func (r *RedisService) DeleteTwoFactorChallenge(ctx context.Context, challengeID string) error {
+	err := r.client.Del(ctx, twoFactorChallengeKey(challengeID), twoFactorChallengeAttemptsKey(challengeID)).Err()
+	if err != nil {
+		return fmt.Errorf("failed to delete two-factor challenge: %w", err)
+	}
 	return nil
 }
 
@@ -139,4 +371,16 @@ func (r *RedisService) Ping(ctx context.Context) error {
 // Close closes the Redis connection
 func (r *RedisService) Close() error {
 	return r.client.Close()
+}
+
+func userSessionsKey(userID string) string {
+	return fmt.Sprintf("user_sessions:%s", userID)
+}
+
+func twoFactorChallengeKey(challengeID string) string {
+	return fmt.Sprintf("two_factor_challenge:%s", challengeID)
+}
+
+func twoFactorChallengeAttemptsKey(challengeID string) string {
+	return fmt.Sprintf("two_factor_challenge_attempts:%s", challengeID)
 }
\ No newline at end of file
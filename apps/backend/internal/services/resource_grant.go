@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrResourceGrantNotFound is returned when a resource grant does not exist on the resource
+	ErrResourceGrantNotFound = errors.New("resource grant not found")
+	// ErrInvalidResourceGrant is returned when a resource grant names an unknown resource or principal type
+	ErrInvalidResourceGrant = errors.New("invalid resource grant")
+)
+
+// resourceTypes lists the resource types that accept resource grants
+var resourceTypes = map[string]bool{
+	models.ResourceTypeGameServer: true,
+}
+
+// HasResourcePermission checks if a user has a permission on a single resource,
+// either tenant-wide or through a grant on that resource
+func (rs *RBACService) HasResourcePermission(ctx context.Context, userID, tenantID, permission, resourceType, resourceID string) (bool, error) {
+	decision, err := rs.ExplainPermission(ctx, userID, tenantID, permission, resourceType, resourceID)
+	if err != nil {
+		return false, err
+	}
+
+	return decision.Allowed, nil
+}
+
+// GetAccessibleResourceIDs returns the resources of a type on which a user has
+// a permission. all is true when the permission is held tenant-wide, in which
+// case ids is empty.
+func (rs *RBACService) GetAccessibleResourceIDs(ctx context.Context, userID, tenantID, permission, resourceType string) (all bool, ids []string, err error) {
+	held, err := rs.HasPermission(ctx, userID, tenantID, permission)
+	if err != nil {
+		return false, nil, err
+	}
+	if held {
+		return true, nil, nil
+	}
+
+	grants, err := rs.userResourceGrants(ctx, userID, tenantID, resourceType, "")
+	if err != nil {
+		return false, nil, err
+	}
+	for _, grant := range grants {
+		if models.PermissionMatches(grant.Permission, permission) {
+			ids = append(ids, grant.ResourceID)
+		}
+	}
+
+	return false, uniqueStrings(ids), nil
+}
+
+// ListResourceGrants returns the grants on a resource
+func (rs *RBACService) ListResourceGrants(ctx context.Context, tenantID, resourceType, resourceID string) ([]models.ResourceGrant, error) {
+	var grants []models.ResourceGrant
+	err := rs.db.WithContext(ctx).
+		Where("tenant_id = ? AND resource_type = ? AND resource_id = ?", tenantID, resourceType, resourceID).
+		Order("created_at").
+		Find(&grants).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resource grants: %w", err)
+	}
+
+	return grants, nil
+}
+
+// CreateResourceGrant grants a principal a permission on a resource. The
+// granting user must hold the permission on the resource themselves.
+func (rs *RBACService) CreateResourceGrant(ctx context.Context, tenantID, resourceType, resourceID string, req models.CreateResourceGrantRequest, performedBy string) (*models.ResourceGrant, error) {
+	if !resourceTypes[resourceType] {
+		return nil, fmt.Errorf("%w: unknown resource type %s", ErrInvalidResourceGrant, resourceType)
+	}
+	if req.PrincipalType != models.PrincipalTypeUser && req.PrincipalType != models.PrincipalTypeRole {
+		return nil, fmt.Errorf("%w: unknown principal type %s", ErrInvalidResourceGrant, req.PrincipalType)
+	}
+	if !models.IsValidTenantPermission(req.Permission) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPermission, req.Permission)
+	}
+
+	held, err := rs.HasResourcePermission(ctx, performedBy, tenantID, req.Permission, resourceType, resourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check permission: %w", err)
+	}
+	if !held {
+		return nil, fmt.Errorf("%w: %s", ErrPermissionNotHeld, req.Permission)
+	}
+
+	var existing models.ResourceGrant
+	err = rs.db.WithContext(ctx).
+		Where("tenant_id = ? AND resource_type = ? AND resource_id = ? AND principal_type = ? AND principal_id = ? AND permission = ?",
+			tenantID, resourceType, resourceID, req.PrincipalType, req.PrincipalID, req.Permission).
+		First(&existing).Error
+	if err == nil {
+		return &existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to check existing resource grant: %w", err)
+	}
+
+	grant := &models.ResourceGrant{
+		TenantID:      tenantID,
+		PrincipalType: req.PrincipalType,
+		PrincipalID:   req.PrincipalID,
+		Permission:    req.Permission,
+		ResourceType:  resourceType,
+		ResourceID:    resourceID,
+		GrantedBy:     performedBy,
+	}
+	if err := rs.db.WithContext(ctx).Create(grant).Error; err != nil {
+		return nil, fmt.Errorf("failed to create resource grant: %w", err)
+	}
+
+	err = rs.LogPermissionChange(ctx, grant.PrincipalID, tenantID, "resource_grant_created", resourceType, resourceID, "", grant.Permission, "", performedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	return grant, nil
+}
+
+// DeleteResourceGrant removes a grant from a resource
+func (rs *RBACService) DeleteResourceGrant(ctx context.Context, tenantID, resourceType, resourceID, grantID, performedBy string) error {
+	var grant models.ResourceGrant
+	err := rs.db.WithContext(ctx).
+		Where("id = ? AND tenant_id = ? AND resource_type = ? AND resource_id = ?", grantID, tenantID, resourceType, resourceID).
+		First(&grant).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrResourceGrantNotFound
+		}
+		return fmt.Errorf("failed to get resource grant: %w", err)
+	}
+
+	if err := rs.db.WithContext(ctx).Delete(&grant).Error; err != nil {
+		return fmt.Errorf("failed to delete resource grant: %w", err)
+	}
+
+	return rs.LogPermissionChange(ctx, grant.PrincipalID, tenantID, "resource_grant_deleted", resourceType, resourceID, grant.Permission, "", "", performedBy)
+}
+
+// userResourceGrants returns the grants that apply to a tenant member directly
+// or through their roles. An empty resourceID returns grants on every resource
+// of the type.
+func (rs *RBACService) userResourceGrants(ctx context.Context, userID, tenantID, resourceType, resourceID string) ([]models.ResourceGrant, error) {
+	var userTenant models.UserTenant
+	err := rs.db.WithContext(ctx).Where("user_id = ? AND tenant_id = ?", userID, tenantID).First(&userTenant).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get user-tenant relationship: %w", err)
+	}
+
+	query := rs.db.WithContext(ctx).Where("tenant_id = ? AND resource_type = ?", tenantID, resourceType)
+	if resourceID != "" {
+		query = query.Where("resource_id = ?", resourceID)
+	}
+	if len(userTenant.Roles) > 0 {
+		query = query.Where("(principal_type = ? AND principal_id = ?) OR (principal_type = ? AND principal_id IN ?)",
+			models.PrincipalTypeUser, userID, models.PrincipalTypeRole, []string(userTenant.Roles))
+	} else {
+		query = query.Where("principal_type = ? AND principal_id = ?", models.PrincipalTypeUser, userID)
+	}
+
+	var grants []models.ResourceGrant
+	if err := query.Find(&grants).Error; err != nil {
+		return nil, fmt.Errorf("failed to get resource grants: %w", err)
+	}
+
+	return grants, nil
+}
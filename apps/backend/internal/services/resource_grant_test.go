@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/config"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRBACService_ResourceGrants(t *testing.T) {
+	db, cleanup := testutils.SetupTestDatabaseWithModels(t,
+		&models.User{},
+		&models.Tenant{},
+		&models.UserTenant{},
+		&models.TenantDiscordRole{},
+		&models.Role{},
+		&models.SystemRole{},
+		&models.UserSystemRole{},
+		&models.PermissionAuditLog{},
+		&models.ResourceGrant{},
+	)
+	defer cleanup()
+	rbacService := NewRBACService(db, &config.RBACConfig{RoleSyncTTL: time.Minute})
+	ctx := context.Background()
+
+	tenant := &models.Tenant{DiscordServerID: "guild-123", Name: "Test Guild", OwnerID: uuid.New().String()}
+	require.NoError(t, db.Create(tenant).Error)
+	owner := &models.User{DiscordUserID: "owner-123", Username: "owner"}
+	require.NoError(t, db.Create(owner).Error)
+	friend := &models.User{DiscordUserID: "friend-123", Username: "friend"}
+	require.NoError(t, db.Create(friend).Error)
+	require.NoError(t, db.Create(&models.UserTenant{UserID: owner.ID, TenantID: tenant.ID, Permissions: models.StringArray{models.PermissionAdminAll}}).Error)
+	require.NoError(t, db.Create(&models.UserTenant{UserID: friend.ID, TenantID: tenant.ID, Roles: models.StringArray{"helpers"}}).Error)
+
+	grant, err := rbacService.CreateResourceGrant(ctx, tenant.ID, models.ResourceTypeGameServer, "server-a", models.CreateResourceGrantRequest{
+		PrincipalType: models.PrincipalTypeUser,
+		PrincipalID:   friend.ID,
+		Permission:    models.PermissionConsoleExecute,
+	}, owner.ID)
+	require.NoError(t, err)
+	_, err = rbacService.CreateResourceGrant(ctx, tenant.ID, models.ResourceTypeGameServer, "server-b", models.CreateResourceGrantRequest{
+		PrincipalType: models.PrincipalTypeRole,
+		PrincipalID:   "helpers",
+		Permission:    models.PermissionServerRead,
+	}, owner.ID)
+	require.NoError(t, err)
+
+	tests := []struct {
+		permission string
+		resourceID string
+		expected   bool
+	}{
+		{permission: models.PermissionConsoleExecute, resourceID: "server-a", expected: true},
+		{permission: models.PermissionConsoleRead, resourceID: "server-a", expected: true},
+		{permission: models.PermissionConsoleExecute, resourceID: "server-b", expected: false},
+		{permission: models.PermissionServerRead, resourceID: "server-b", expected: true},
+		{permission: models.PermissionServerStop, resourceID: "server-b", expected: false},
+		{permission: models.PermissionServerRead, resourceID: "server-c", expected: false},
+	}
+	for _, tt := range tests {
+		allowed, err := rbacService.HasResourcePermission(ctx, friend.ID, tenant.ID, tt.permission, models.ResourceTypeGameServer, tt.resourceID)
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, allowed, "%s on %s", tt.permission, tt.resourceID)
+	}
+
+	// Grants never leak into tenant-wide checks
+	allowed, err := rbacService.HasPermission(ctx, friend.ID, tenant.ID, models.PermissionConsoleExecute)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	all, ids, err := rbacService.GetAccessibleResourceIDs(ctx, friend.ID, tenant.ID, models.PermissionServerRead, models.ResourceTypeGameServer)
+	require.NoError(t, err)
+	assert.False(t, all)
+	assert.Equal(t, []string{"server-b"}, ids)
+	all, _, err = rbacService.GetAccessibleResourceIDs(ctx, owner.ID, tenant.ID, models.PermissionServerRead, models.ResourceTypeGameServer)
+	require.NoError(t, err)
+	assert.True(t, all)
+
+	// The friend cannot pass on more than they hold
+	_, err = rbacService.CreateResourceGrant(ctx, tenant.ID, models.ResourceTypeGameServer, "server-a", models.CreateResourceGrantRequest{
+		PrincipalType: models.PrincipalTypeUser,
+		PrincipalID:   owner.ID,
+		Permission:    models.PermissionServerDelete,
+	}, friend.ID)
+	assert.ErrorIs(t, err, ErrPermissionNotHeld)
+	_, err = rbacService.CreateResourceGrant(ctx, tenant.ID, "backup", "backup-1", models.CreateResourceGrantRequest{
+		PrincipalType: models.PrincipalTypeUser,
+		PrincipalID:   friend.ID,
+		Permission:    models.PermissionBackupRead,
+	}, owner.ID)
+	assert.ErrorIs(t, err, ErrInvalidResourceGrant)
+
+	require.NoError(t, rbacService.DeleteResourceGrant(ctx, tenant.ID, models.ResourceTypeGameServer, "server-a", grant.ID, owner.ID))
+	allowed, err = rbacService.HasResourcePermission(ctx, friend.ID, tenant.ID, models.PermissionConsoleExecute, models.ResourceTypeGameServer, "server-a")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	err = rbacService.DeleteResourceGrant(ctx, tenant.ID, models.ResourceTypeGameServer, "server-a", grant.ID, owner.ID)
+	assert.ErrorIs(t, err, ErrResourceGrantNotFound)
+}
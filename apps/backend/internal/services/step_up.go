@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+)
+
+// StepUpTTL is how long a step-up grant allows destructive operations
+const StepUpTTL = 5 * time.Minute
+
+// ErrStepUpIdentityMismatch is returned when a step-up is completed with an
+// account that does not belong to the session's user
+var ErrStepUpIdentityMismatch = errors.New("step-up completed with a different account")
+
+// BeginStepUp starts re-authenticating the user of a session with one of their
+// login providers. Completing it grants the session a short step-up window.
+func (a *AuthService) BeginStepUp(ctx context.Context, providerName, sessionID string) (*models.IdentityLoginState, error) {
+	if _, err := a.redisService.GetSession(ctx, sessionID); err != nil {
+		return nil, ErrSessionNotFound
+	}
+
+	login, err := a.BeginProviderLogin(ctx, providerName, "")
+	if err != nil {
+		return nil, err
+	}
+	login.StepUpSessionID = sessionID
+
+	return login, nil
+}
+
+// CompleteStepUp finishes a step-up started with BeginStepUp and returns when
+// the grant expires. The account that authenticated must be one of the
+// session user's identities.
+func (a *AuthService) CompleteStepUp(ctx context.Context, login *models.IdentityLoginState, code string) (time.Time, error) {
+	session, err := a.redisService.GetSession(ctx, login.StepUpSessionID)
+	if err != nil {
+		return time.Time{}, ErrSessionNotFound
+	}
+
+	var subject string
+	var discordToken *models.DiscordTokenResponse
+	if login.Provider == models.IdentityProviderDiscord {
+		discordToken, err = a.discordService.ExchangeCodeForToken(ctx, code)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to exchange code: %w", err)
+		}
+		discordUser, err := a.discordService.GetUserInfo(ctx, discordToken.AccessToken)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to get user info: %w", err)
+		}
+		subject = discordUser.ID
+	} else {
+		provider, ok := a.identityProvider(login.Provider)
+		if !ok {
+			return time.Time{}, ErrUnknownIdentityProvider
+		}
+		external, err := provider.Exchange(ctx, code, login.Nonce, login.CodeVerifier)
+		if err != nil {
+			return time.Time{}, err
+		}
+		subject = external.Subject
+	}
+
+	owned, err := a.userHasIdentity(ctx, session.UserID, login.Provider, subject)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !owned {
+		return time.Time{}, ErrStepUpIdentityMismatch
+	}
+
+	// A Discord round-trip also renews the session's Discord authorization
+	return a.grantStepUp(ctx, session, discordToken)
+}
+
+// StepUpWithTOTP grants a session a step-up with a code from the user's
+// authenticator app or one of their recovery codes
+func (a *AuthService) StepUpWithTOTP(ctx context.Context, sessionID, code string) (time.Time, error) {
+	session, err := a.redisService.GetSession(ctx, sessionID)
+	if err != nil {
+		return time.Time{}, ErrSessionNotFound
+	}
+	if a.twoFactor == nil {
+		return time.Time{}, ErrTwoFactorNotEnabled
+	}
+
+	if err := a.twoFactor.Verify(ctx, session.UserID, code); err != nil {
+		return time.Time{}, err
+	}
+
+	return a.grantStepUp(ctx, session, nil)
+}
+
+// StepUpExpiry returns until when a session may perform destructive operations.
+// It is in the past when the session has not stepped up recently.
+func (a *AuthService) StepUpExpiry(ctx context.Context, sessionID string) (time.Time, error) {
+	session, err := a.redisService.GetSession(ctx, sessionID)
+	if err != nil {
+		return time.Time{}, ErrSessionNotFound
+	}
+	return session.StepUpUntil, nil
+}
+
+// grantStepUp opens the step-up window of a session, storing new Discord
+// tokens along with it when the step-up went through Discord
+func (a *AuthService) grantStepUp(ctx context.Context, session *models.Session, discordToken *models.DiscordTokenResponse) (time.Time, error) {
+	until := time.Now().Add(StepUpTTL)
+	updated, err := a.redisService.UpdateSession(ctx, session.ID, func(s *models.Session) {
+		s.StepUpUntil = until
+		if discordToken != nil {
+			setDiscordToken(s, discordToken)
+		}
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to store step-up: %w", err)
+	}
+	*session = *updated
+	return until, nil
+}
+
+// userHasIdentity reports whether an external account belongs to a user
+func (a *AuthService) userHasIdentity(ctx context.Context, userID, provider, subject string) (bool, error) {
+	var count int64
+	err := a.db.WithContext(ctx).Model(&models.UserIdentity{}).
+		Where("user_id = ? AND provider = ? AND subject = ?", userID, provider, subject).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check identity: %w", err)
+	}
+
+	// Users who logged in before identities were recorded only have their Discord ID
+	if count == 0 && provider == models.IdentityProviderDiscord {
+		err = a.db.WithContext(ctx).Model(&models.User{}).
+			Where("id = ? AND discord_user_id = ?", userID, subject).
+			Count(&count).Error
+		if err != nil {
+			return false, fmt.Errorf("failed to check Discord account: %w", err)
+		}
+	}
+
+	return count > 0, nil
+}
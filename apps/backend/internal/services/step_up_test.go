@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthService_BeginStepUp(t *testing.T) {
+	t.Run("binds the login to the session", func(t *testing.T) {
+		mockDiscord := new(MockDiscordService)
+		mockRedis := new(MockRedisService)
+		mockRedis.On("GetSession", mock.Anything, "session_id").Return(&models.Session{ID: "session_id", UserID: "user_id"}, nil)
+		mockDiscord.On("GetAuthURL", mock.Anything).Return("https://discord.com/oauth2/authorize")
+
+		authService := NewAuthService(nil, mockDiscord, new(MockJWTService), mockRedis)
+		login, err := authService.BeginStepUp(context.Background(), models.IdentityProviderDiscord, "session_id")
+
+		require.NoError(t, err)
+		assert.Equal(t, "session_id", login.StepUpSessionID)
+		assert.Empty(t, login.LinkUserID)
+		assert.Equal(t, "https://discord.com/oauth2/authorize", login.AuthURL)
+	})
+
+	t.Run("unknown session", func(t *testing.T) {
+		mockRedis := new(MockRedisService)
+		mockRedis.On("GetSession", mock.Anything, "session_id").Return(nil, errors.New("session not found"))
+
+		authService := NewAuthService(nil, new(MockDiscordService), new(MockJWTService), mockRedis)
+		_, err := authService.BeginStepUp(context.Background(), models.IdentityProviderDiscord, "session_id")
+
+		assert.ErrorIs(t, err, ErrSessionNotFound)
+	})
+}
+
+func TestAuthService_CompleteStepUpFailures(t *testing.T) {
+	login := &models.IdentityLoginState{Provider: models.IdentityProviderDiscord, StepUpSessionID: "session_id"}
+
+	t.Run("session ended meanwhile", func(t *testing.T) {
+		mockRedis := new(MockRedisService)
+		mockRedis.On("GetSession", mock.Anything, "session_id").Return(nil, errors.New("session not found"))
+
+		authService := NewAuthService(nil, new(MockDiscordService), new(MockJWTService), mockRedis)
+		_, err := authService.CompleteStepUp(context.Background(), login, "code")
+
+		assert.ErrorIs(t, err, ErrSessionNotFound)
+	})
+
+	t.Run("code exchange fails", func(t *testing.T) {
+		mockDiscord := new(MockDiscordService)
+		mockRedis := new(MockRedisService)
+		mockRedis.On("GetSession", mock.Anything, "session_id").Return(&models.Session{ID: "session_id", UserID: "user_id"}, nil)
+		mockDiscord.On("ExchangeCodeForToken", mock.Anything, "code").Return(nil, errors.New("invalid code"))
+
+		authService := NewAuthService(nil, mockDiscord, new(MockJWTService), mockRedis)
+		_, err := authService.CompleteStepUp(context.Background(), login, "code")
+
+		assert.Error(t, err)
+		mockRedis.AssertNotCalled(t, "UpdateSession", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAuthService_StepUpExpiry(t *testing.T) {
+	until := time.Now().Add(StepUpTTL)
+	mockRedis := new(MockRedisService)
+	mockRedis.On("GetSession", mock.Anything, "session_id").Return(&models.Session{ID: "session_id", StepUpUntil: until}, nil)
+	mockRedis.On("GetSession", mock.Anything, "missing").Return(nil, errors.New("session not found"))
+
+	authService := NewAuthService(nil, new(MockDiscordService), new(MockJWTService), mockRedis)
+
+	result, err := authService.StepUpExpiry(context.Background(), "session_id")
+	require.NoError(t, err)
+	assert.Equal(t, until, result)
+
+	_, err = authService.StepUpExpiry(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+}
+
+func TestAuthService_CompleteStepUpWithDiscord(t *testing.T) {
+	db, cleanup := setupTestDatabaseWithModels(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	user := &models.User{DiscordUserID: "step-up-discord", Username: "stepper"}
+	require.NoError(t, db.Create(user).Error)
+	require.NoError(t, db.Create(&models.UserIdentity{UserID: user.ID, Provider: models.IdentityProviderDiscord, Subject: user.DiscordUserID}).Error)
+
+	stored := &models.Session{
+		ID:                  "session_id",
+		UserID:              user.ID,
+		DiscordAccessToken:  "old_access",
+		DiscordRefreshToken: "old_refresh",
+		ExpiresAt:           time.Now().Add(time.Hour),
+	}
+	mockDiscord := new(MockDiscordService)
+	mockRedis := new(MockRedisService)
+	mockRedis.On("GetSession", mock.Anything, "session_id").Return(stored, nil)
+	mockRedis.On("UpdateSession", mock.Anything, "session_id").Return(stored, nil)
+	mockDiscord.On("ExchangeCodeForToken", mock.Anything, "code").Return(&models.DiscordTokenResponse{AccessToken: "new_access", RefreshToken: "new_refresh", ExpiresIn: 604800}, nil)
+	mockDiscord.On("GetUserInfo", mock.Anything, "new_access").Return(&models.DiscordUser{ID: user.DiscordUserID}, nil)
+
+	authService := NewAuthService(db, mockDiscord, new(MockJWTService), mockRedis)
+	login := &models.IdentityLoginState{Provider: models.IdentityProviderDiscord, StepUpSessionID: "session_id"}
+	until, err := authService.CompleteStepUp(ctx, login, "code")
+	require.NoError(t, err)
+
+	// The step-up and the renewed Discord tokens are stored in one update
+	require.Len(t, mockRedis.updated, 1)
+	updated := mockRedis.updated[0]
+	assert.Equal(t, until, updated.StepUpUntil)
+	assert.Equal(t, "new_access", updated.DiscordAccessToken)
+	assert.Equal(t, "new_refresh", updated.DiscordRefreshToken)
+	assert.Greater(t, time.Until(updated.DiscordTokenExpiresAt), 24*time.Hour)
+	mockRedis.AssertNotCalled(t, "StoreSession", mock.Anything, mock.Anything)
+}
@@ -1,70 +1,427 @@
 package services
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
 	"github.com/bwmarrin/discordgo"
 	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
 	"gorm.io/gorm"
 )
 
+// memberPageSize is the largest page Discord returns from the list guild members endpoint
+const memberPageSize = 1000
+
+// GuildClient is the subset of the Discord session used for syncing
+type GuildClient interface {
+	GuildRoles(guildID string, options ...discordgo.RequestOption) ([]*discordgo.Role, error)
+	GuildMembers(guildID string, after string, limit int, options ...discordgo.RequestOption) ([]*discordgo.Member, error)
+	Guild(guildID string, options ...discordgo.RequestOption) (*discordgo.Guild, error)
+}
+
 // SyncService handles syncing data from Discord.
 type SyncService struct {
-	db      *gorm.DB
-	discord *discordgo.Session
+	db          *gorm.DB
+	discord     GuildClient
+	permissions PermissionInvalidator
+	owners      GuildOwnerFollower
 }
 
 // NewSyncService creates a new SyncService.
-func NewSyncService(db *gorm.DB, discord *discordgo.Session) *SyncService {
+func NewSyncService(db *gorm.DB, discord GuildClient) *SyncService {
 	return &SyncService{db: db, discord: discord}
 }
 
-// SyncRoles syncs roles from a Discord server to a tenant.
+// NewSyncServiceWithRBAC creates a new SyncService that drops cached
+// permissions of the tenants it changes.
+func NewSyncServiceWithRBAC(db *gorm.DB, discord GuildClient, permissions PermissionInvalidator) *SyncService {
+	return &SyncService{db: db, discord: discord, permissions: permissions}
+}
+
+// NewSyncServiceWithOwnership creates a SyncService that also moves tenant
+// ownership when the owner of a tenant's guild changes.
+func NewSyncServiceWithOwnership(db *gorm.DB, discord GuildClient, permissions PermissionInvalidator, owners GuildOwnerFollower) *SyncService {
+	return &SyncService{db: db, discord: discord, permissions: permissions, owners: owners}
+}
+
+// SyncRoles syncs roles from a Discord server to a tenant and soft-deletes
+// roles that no longer exist in the guild.
 func (s *SyncService) SyncRoles(tenantID string, guildID string) error {
 	roles, err := s.discord.GuildRoles(guildID)
 	if err != nil {
 		return err
 	}
 
+	current := make([]string, 0, len(roles))
 	for _, role := range roles {
-		dbRole := models.TenantDiscordRole{
-			DiscordRoleID: role.ID,
-			TenantID:      tenantID,
-			Name:          role.Name,
-			Color:         role.Color,
-			Position:      role.Position,
-			Mentionable:   role.Mentionable,
-			Hoist:         role.Hoist,
+		if err := s.upsertRole(tenantID, role); err != nil {
+			return err
 		}
+		current = append(current, role.ID)
+	}
 
-		if err := s.db.Where(models.TenantDiscordRole{DiscordRoleID: role.ID, TenantID: tenantID}).Assign(dbRole).FirstOrCreate(&dbRole).Error; err != nil {
+	var stale []models.TenantDiscordRole
+	query := s.db.Where("tenant_id = ?", tenantID)
+	if len(current) > 0 {
+		query = query.Where("discord_role_id NOT IN ?", current)
+	}
+	if err := query.Find(&stale).Error; err != nil {
+		return fmt.Errorf("failed to find deleted Discord roles: %w", err)
+	}
+
+	for _, role := range stale {
+		if err := s.deleteRole(tenantID, role.DiscordRoleID); err != nil {
 			return err
 		}
 	}
 
-	return nil
+	return s.invalidatePermissions(tenantID)
 }
 
-// SyncUsers syncs users from a Discord server to a tenant.
+// SyncUsers syncs every member of a Discord server to a tenant, paging through
+// the member list, and soft-deletes members that have left the guild.
 func (s *SyncService) SyncUsers(tenantID string, guildID string) error {
-	members, err := s.discord.GuildMembers(guildID, "", 1000)
+	// Postgres stores microseconds; truncate so the departed-member cutoff compares exactly
+	syncedAt := time.Now().Truncate(time.Microsecond)
+
+	knownRoles, err := s.discordRoleIDs(tenantID)
 	if err != nil {
 		return err
 	}
 
-	for _, member := range members {
-		joinedAt := member.JoinedAt
-		dbUser := models.TenantDiscordUser{
-			DiscordUserID: member.User.ID,
-			TenantID:      tenantID,
-			Username:      member.User.Username,
-			DisplayName:   member.Nick,
-			Avatar:        member.User.AvatarURL("128"),
-			JoinedAt:      &joinedAt,
+	after := ""
+	for {
+		members, err := s.discord.GuildMembers(guildID, after, memberPageSize)
+		if err != nil {
+			return err
 		}
 
-		if err := s.db.Where(models.TenantDiscordUser{DiscordUserID: member.User.ID, TenantID: tenantID}).Assign(dbUser).FirstOrCreate(&dbUser).Error; err != nil {
+		for _, member := range members {
+			if member.User == nil {
+				continue
+			}
+			if err := s.upsertMember(tenantID, member, syncedAt); err != nil {
+				return err
+			}
+			if err := s.updateLinkedRoles(tenantID, member.User.ID, member.Roles, knownRoles); err != nil {
+				return err
+			}
+			after = member.User.ID
+		}
+
+		if len(members) < memberPageSize {
+			break
+		}
+	}
+
+	var departed []models.TenantDiscordUser
+	err = s.db.Where("tenant_id = ? AND last_sync_at < ?", tenantID, syncedAt).Find(&departed).Error
+	if err != nil {
+		return fmt.Errorf("failed to find departed Discord members: %w", err)
+	}
+
+	for _, member := range departed {
+		if err := s.removeMember(tenantID, member.DiscordUserID, knownRoles); err != nil {
 			return err
 		}
 	}
 
+	return s.invalidatePermissions(tenantID)
+}
+
+// ReconcileGuild runs a full role and member sync for a tenant's guild
+func (s *SyncService) ReconcileGuild(tenantID string, guildID string) error {
+	if err := s.SyncRoles(tenantID, guildID); err != nil {
+		return fmt.Errorf("failed to sync roles: %w", err)
+	}
+	if err := s.SyncUsers(tenantID, guildID); err != nil {
+		return fmt.Errorf("failed to sync users: %w", err)
+	}
+	if err := s.syncOwner(tenantID, guildID); err != nil {
+		return fmt.Errorf("failed to sync owner: %w", err)
+	}
 	return nil
 }
+
+// ReconcileAll runs a full sync for every tenant, continuing past failures
+func (s *SyncService) ReconcileAll() error {
+	var tenants []models.Tenant
+	if err := s.db.Select("id", "discord_server_id").Find(&tenants).Error; err != nil {
+		return fmt.Errorf("failed to list tenants: %w", err)
+	}
+
+	var errs []error
+	for _, tenant := range tenants {
+		if err := s.ReconcileGuild(tenant.ID, tenant.DiscordServerID); err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", tenant.ID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// UpdateGuild applies a GuildUpdate event, following a change of owner
+func (s *SyncService) UpdateGuild(guild *discordgo.Guild) error {
+	if guild == nil || s.owners == nil {
+		return nil
+	}
+
+	tenantID, err := s.tenantIDForGuild(guild.ID)
+	if err != nil || tenantID == "" {
+		return err
+	}
+
+	return s.owners.FollowGuildOwner(context.Background(), tenantID, guild.OwnerID)
+}
+
+// UpsertMember applies a GuildMemberAdd or GuildMemberUpdate event
+func (s *SyncService) UpsertMember(guildID string, member *discordgo.Member) error {
+	if member == nil || member.User == nil {
+		return nil
+	}
+
+	tenantID, err := s.tenantIDForGuild(guildID)
+	if err != nil || tenantID == "" {
+		return err
+	}
+
+	if err := s.upsertMember(tenantID, member, time.Now()); err != nil {
+		return err
+	}
+
+	knownRoles, err := s.discordRoleIDs(tenantID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.updateLinkedRoles(tenantID, member.User.ID, member.Roles, knownRoles); err != nil {
+		return err
+	}
+
+	return s.invalidatePermissions(tenantID)
+}
+
+// RemoveMember applies a GuildMemberRemove event
+func (s *SyncService) RemoveMember(guildID string, discordUserID string) error {
+	tenantID, err := s.tenantIDForGuild(guildID)
+	if err != nil || tenantID == "" {
+		return err
+	}
+
+	knownRoles, err := s.discordRoleIDs(tenantID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.removeMember(tenantID, discordUserID, knownRoles); err != nil {
+		return err
+	}
+
+	return s.invalidatePermissions(tenantID)
+}
+
+// UpsertRole applies a GuildRoleCreate or GuildRoleUpdate event
+func (s *SyncService) UpsertRole(guildID string, role *discordgo.Role) error {
+	if role == nil {
+		return nil
+	}
+
+	tenantID, err := s.tenantIDForGuild(guildID)
+	if err != nil || tenantID == "" {
+		return err
+	}
+
+	return s.upsertRole(tenantID, role)
+}
+
+// DeleteRole applies a GuildRoleDelete event
+func (s *SyncService) DeleteRole(guildID string, discordRoleID string) error {
+	tenantID, err := s.tenantIDForGuild(guildID)
+	if err != nil || tenantID == "" {
+		return err
+	}
+
+	if err := s.deleteRole(tenantID, discordRoleID); err != nil {
+		return err
+	}
+
+	return s.invalidatePermissions(tenantID)
+}
+
+// syncOwner follows the current owner of a tenant's guild
+func (s *SyncService) syncOwner(tenantID string, guildID string) error {
+	if s.owners == nil {
+		return nil
+	}
+
+	guild, err := s.discord.Guild(guildID)
+	if err != nil {
+		return err
+	}
+
+	return s.owners.FollowGuildOwner(context.Background(), tenantID, guild.OwnerID)
+}
+
+// invalidatePermissions drops cached permissions in a tenant whose roles or members changed
+func (s *SyncService) invalidatePermissions(tenantID string) error {
+	if s.permissions == nil {
+		return nil
+	}
+	return s.permissions.InvalidateTenantPermissions(context.Background(), tenantID)
+}
+
+// tenantIDForGuild returns the tenant installed in a guild, or "" if there is none
+func (s *SyncService) tenantIDForGuild(guildID string) (string, error) {
+	var tenant models.Tenant
+	err := s.db.Select("id").Where("discord_server_id = ?", guildID).First(&tenant).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get tenant for guild: %w", err)
+	}
+	return tenant.ID, nil
+}
+
+// discordRoleIDs returns every Discord role ID ever synced for a tenant,
+// including deleted ones, so they can be told apart from internal role names.
+func (s *SyncService) discordRoleIDs(tenantID string) (map[string]bool, error) {
+	var ids []string
+	err := s.db.Unscoped().Model(&models.TenantDiscordRole{}).Where("tenant_id = ?", tenantID).Pluck("discord_role_id", &ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Discord role IDs: %w", err)
+	}
+
+	known := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		known[id] = true
+	}
+	return known, nil
+}
+
+func (s *SyncService) upsertRole(tenantID string, role *discordgo.Role) error {
+	var dbRole models.TenantDiscordRole
+	err := s.db.Unscoped().Where("tenant_id = ? AND discord_role_id = ?", tenantID, role.ID).First(&dbRole).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to check existing Discord role: %w", err)
+	}
+
+	// Permissions are managed in Pteronimbus and are left untouched
+	dbRole.TenantID = tenantID
+	dbRole.DiscordRoleID = role.ID
+	dbRole.Name = role.Name
+	dbRole.Color = role.Color
+	dbRole.Position = role.Position
+	dbRole.Mentionable = role.Mentionable
+	dbRole.Hoist = role.Hoist
+	dbRole.DeletedAt = gorm.DeletedAt{}
+
+	if err := s.db.Unscoped().Save(&dbRole).Error; err != nil {
+		return fmt.Errorf("failed to save Discord role: %w", err)
+	}
+	return nil
+}
+
+func (s *SyncService) deleteRole(tenantID string, discordRoleID string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("tenant_id = ? AND discord_role_id = ?", tenantID, discordRoleID).Delete(&models.TenantDiscordRole{}).Error
+		if err != nil {
+			return fmt.Errorf("failed to delete Discord role: %w", err)
+		}
+
+		err = tx.Model(&models.TenantDiscordUser{}).Where("tenant_id = ?", tenantID).
+			Update("roles", gorm.Expr("array_remove(roles, ?)", discordRoleID)).Error
+		if err != nil {
+			return fmt.Errorf("failed to remove Discord role from members: %w", err)
+		}
+
+		err = tx.Model(&models.UserTenant{}).Where("tenant_id = ?", tenantID).
+			Update("roles", gorm.Expr("array_remove(roles, ?)", discordRoleID)).Error
+		if err != nil {
+			return fmt.Errorf("failed to remove Discord role from tenant users: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (s *SyncService) upsertMember(tenantID string, member *discordgo.Member, syncedAt time.Time) error {
+	var dbUser models.TenantDiscordUser
+	err := s.db.Unscoped().Where("tenant_id = ? AND discord_user_id = ?", tenantID, member.User.ID).First(&dbUser).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to check existing Discord member: %w", err)
+	}
+
+	dbUser.TenantID = tenantID
+	dbUser.DiscordUserID = member.User.ID
+	dbUser.Username = member.User.Username
+	dbUser.DisplayName = member.Nick
+	dbUser.Avatar = member.User.AvatarURL("128")
+	dbUser.Roles = models.StringArray(member.Roles)
+	if !member.JoinedAt.IsZero() {
+		joinedAt := member.JoinedAt
+		dbUser.JoinedAt = &joinedAt
+	}
+	dbUser.LastSyncAt = syncedAt
+	// Members who rejoin are restored
+	dbUser.DeletedAt = gorm.DeletedAt{}
+
+	if err := s.db.Unscoped().Save(&dbUser).Error; err != nil {
+		return fmt.Errorf("failed to save Discord member: %w", err)
+	}
+	return nil
+}
+
+func (s *SyncService) removeMember(tenantID string, discordUserID string, knownRoles map[string]bool) error {
+	err := s.db.Where("tenant_id = ? AND discord_user_id = ?", tenantID, discordUserID).Delete(&models.TenantDiscordUser{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to delete Discord member: %w", err)
+	}
+
+	return s.updateLinkedRoles(tenantID, discordUserID, nil, knownRoles)
+}
+
+// updateLinkedRoles replaces the Discord roles on the tenant membership of the
+// Pteronimbus user linked to a Discord member, keeping internal role names.
+func (s *SyncService) updateLinkedRoles(tenantID string, discordUserID string, discordRoles []string, knownRoles map[string]bool) error {
+	var membership models.UserTenant
+	err := s.db.Joins("JOIN users ON users.id = user_tenants.user_id").
+		Where("user_tenants.tenant_id = ? AND users.discord_user_id = ?", tenantID, discordUserID).
+		First(&membership).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to get linked tenant membership: %w", err)
+	}
+
+	roles := mergeDiscordRoles(membership.Roles, discordRoles, knownRoles)
+	if err := s.db.Model(&membership).Update("roles", roles).Error; err != nil {
+		return fmt.Errorf("failed to update tenant membership roles: %w", err)
+	}
+	return nil
+}
+
+// mergeDiscordRoles drops known Discord role IDs from current and appends the
+// member's current Discord roles, leaving internal role names in place.
+func mergeDiscordRoles(current []string, discordRoles []string, knownRoles map[string]bool) models.StringArray {
+	merged := models.StringArray{}
+	seen := make(map[string]bool)
+	for _, role := range current {
+		if knownRoles[role] || seen[role] {
+			continue
+		}
+		seen[role] = true
+		merged = append(merged, role)
+	}
+	for _, role := range discordRoles {
+		if seen[role] {
+			continue
+		}
+		seen[role] = true
+		merged = append(merged, role)
+	}
+	return merged
+}
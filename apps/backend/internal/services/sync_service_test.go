@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+)
+
+// fakeGuildClient serves roles, a paginated member list and the guild owner from memory
+type fakeGuildClient struct {
+	roles   []*discordgo.Role
+	members []*discordgo.Member
+	ownerID string
+	pages   int
+}
+
+func (f *fakeGuildClient) GuildRoles(guildID string, options ...discordgo.RequestOption) ([]*discordgo.Role, error) {
+	return f.roles, nil
+}
+
+func (f *fakeGuildClient) GuildMembers(guildID string, after string, limit int, options ...discordgo.RequestOption) ([]*discordgo.Member, error) {
+	f.pages++
+	start := 0
+	if after != "" {
+		for idx, member := range f.members {
+			if member.User.ID == after {
+				start = idx + 1
+			}
+		}
+	}
+	end := start + limit
+	if end > len(f.members) {
+		end = len(f.members)
+	}
+	return f.members[start:end], nil
+}
+
+func (f *fakeGuildClient) Guild(guildID string, options ...discordgo.RequestOption) (*discordgo.Guild, error) {
+	return &discordgo.Guild{ID: guildID, OwnerID: f.ownerID}, nil
+}
+
+func TestMergeDiscordRoles(t *testing.T) {
+	known := map[string]bool{"role-1": true, "role-2": true, "role-3": true}
+
+	merged := mergeDiscordRoles([]string{"admin", "role-1", "role-2"}, []string{"role-2", "role-3"}, known)
+	assert.Equal(t, models.StringArray{"admin", "role-2", "role-3"}, merged)
+
+	// Removing every Discord role keeps internal roles
+	merged = mergeDiscordRoles([]string{"admin", "role-1"}, nil, known)
+	assert.Equal(t, models.StringArray{"admin"}, merged)
+}
+
+func TestSyncService_Reconcile(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	tenant := &models.Tenant{DiscordServerID: "guild-sync", Name: "Sync Guild", OwnerID: uuid.New().String()}
+	require.NoError(t, db.Create(tenant).Error)
+
+	user := &models.User{DiscordUserID: "member-0", Username: "linked"}
+	require.NoError(t, db.Create(user).Error)
+	require.NoError(t, db.Create(&models.UserTenant{
+		UserID:   user.ID,
+		TenantID: tenant.ID,
+		Roles:    models.StringArray{"moderator", "role-old"},
+	}).Error)
+
+	// A role that was deleted in Discord while the bot was offline keeps its mapping until reconcile
+	require.NoError(t, db.Create(&models.TenantDiscordRole{TenantID: tenant.ID, DiscordRoleID: "role-old", Name: "Old"}).Error)
+	require.NoError(t, db.Create(&models.TenantDiscordRole{
+		TenantID:      tenant.ID,
+		DiscordRoleID: "role-1",
+		Name:          "Admin",
+		Permissions:   models.StringArray{models.PermissionServerRead},
+	}).Error)
+
+	// A member who left while the bot was offline
+	require.NoError(t, db.Create(&models.TenantDiscordUser{TenantID: tenant.ID, DiscordUserID: "departed", Username: "gone"}).Error)
+
+	client := &fakeGuildClient{roles: []*discordgo.Role{{ID: "role-1", Name: "Administrators"}}}
+	for idx := 0; idx < memberPageSize+5; idx++ {
+		client.members = append(client.members, &discordgo.Member{
+			User:  &discordgo.User{ID: fmt.Sprintf("member-%d", idx), Username: fmt.Sprintf("user%d", idx)},
+			Roles: []string{"role-1"},
+		})
+	}
+
+	service := NewSyncService(db, client)
+	require.NoError(t, service.ReconcileGuild(tenant.ID, tenant.DiscordServerID))
+
+	// Every page was fetched
+	assert.Equal(t, 2, client.pages)
+	var memberCount int64
+	db.Model(&models.TenantDiscordUser{}).Where("tenant_id = ?", tenant.ID).Count(&memberCount)
+	assert.Equal(t, int64(memberPageSize+5), memberCount)
+
+	// Departed members and deleted roles are soft-deleted
+	var departed models.TenantDiscordUser
+	require.NoError(t, db.Unscoped().Where("discord_user_id = ?", "departed").First(&departed).Error)
+	assert.True(t, departed.DeletedAt.Valid)
+
+	var roles []models.TenantDiscordRole
+	require.NoError(t, db.Where("tenant_id = ?", tenant.ID).Find(&roles).Error)
+	require.Len(t, roles, 1)
+	assert.Equal(t, "Administrators", roles[0].Name)
+	assert.Equal(t, models.StringArray{models.PermissionServerRead}, roles[0].Permissions)
+
+	// The linked user's Discord roles are replaced while internal roles are kept
+	var membership models.UserTenant
+	require.NoError(t, db.Where("user_id = ?", user.ID).First(&membership).Error)
+	assert.ElementsMatch(t, []string{"moderator", "role-1"}, membership.Roles)
+}
+
+func TestSyncService_GatewayEvents(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	tenant := &models.Tenant{DiscordServerID: "guild-events", Name: "Events Guild", OwnerID: uuid.New().String()}
+	require.NoError(t, db.Create(tenant).Error)
+
+	user := &models.User{DiscordUserID: "member-1", Username: "linked"}
+	require.NoError(t, db.Create(user).Error)
+	require.NoError(t, db.Create(&models.UserTenant{UserID: user.ID, TenantID: tenant.ID, Roles: models.StringArray{"moderator"}}).Error)
+
+	service := NewSyncService(db, nil)
+	member := &discordgo.Member{User: &discordgo.User{ID: "member-1", Username: "linked"}, Roles: []string{"role-1"}}
+
+	require.NoError(t, service.UpsertRole(tenant.DiscordServerID, &discordgo.Role{ID: "role-1", Name: "Admin"}))
+	require.NoError(t, service.UpsertMember(tenant.DiscordServerID, member))
+
+	var membership models.UserTenant
+	require.NoError(t, db.Where("user_id = ?", user.ID).First(&membership).Error)
+	assert.ElementsMatch(t, []string{"moderator", "role-1"}, membership.Roles)
+
+	// Deleting a role strips it from members and linked users
+	require.NoError(t, service.DeleteRole(tenant.DiscordServerID, "role-1"))
+	var stored models.TenantDiscordUser
+	require.NoError(t, db.Where("discord_user_id = ?", "member-1").First(&stored).Error)
+	assert.Empty(t, stored.Roles)
+	require.NoError(t, db.Where("user_id = ?", user.ID).First(&membership).Error)
+	assert.Equal(t, models.StringArray{"moderator"}, membership.Roles)
+
+	// Leaving soft-deletes the member and rejoining restores it
+	require.NoError(t, service.RemoveMember(tenant.DiscordServerID, "member-1"))
+	err := db.Where("discord_user_id = ?", "member-1").First(&stored).Error
+	assert.Error(t, err)
+
+	require.NoError(t, service.UpsertMember(tenant.DiscordServerID, member))
+	require.NoError(t, db.Where("discord_user_id = ?", "member-1").First(&stored).Error)
+
+	// Events for guilds without a tenant are ignored
+	assert.NoError(t, service.UpsertMember("unknown-guild", member))
+}
+
+// recordingOwnerFollower records the guild owners it is asked to follow
+type recordingOwnerFollower struct {
+	owners map[string]string
+}
+
+func (r *recordingOwnerFollower) FollowGuildOwner(ctx context.Context, tenantID, ownerDiscordUserID string) error {
+	r.owners[tenantID] = ownerDiscordUserID
+	return nil
+}
+
+func TestSyncService_FollowsGuildOwner(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	tenant := &models.Tenant{DiscordServerID: "guild-owner", Name: "Owner Guild", OwnerID: uuid.New().String()}
+	require.NoError(t, db.Create(tenant).Error)
+
+	owners := &recordingOwnerFollower{owners: map[string]string{}}
+	client := &fakeGuildClient{ownerID: "owner-1"}
+	service := NewSyncServiceWithOwnership(db, client, nil, owners)
+
+	require.NoError(t, service.ReconcileGuild(tenant.ID, tenant.DiscordServerID))
+	assert.Equal(t, "owner-1", owners.owners[tenant.ID])
+
+	require.NoError(t, service.UpdateGuild(&discordgo.Guild{ID: tenant.DiscordServerID, OwnerID: "owner-2"}))
+	assert.Equal(t, "owner-2", owners.owners[tenant.ID])
+
+	// Guilds without a tenant are ignored
+	require.NoError(t, service.UpdateGuild(&discordgo.Guild{ID: "unknown-guild", OwnerID: "owner-3"}))
+	assert.Len(t, owners.owners, 1)
+}
@@ -2,29 +2,56 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
 	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/config"
 	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
 )
 
+// ErrChannelNotInGuild is returned when a tenant configuration names a Discord
+// channel outside the tenant's server
+var ErrChannelNotInGuild = errors.New("channel does not belong to the tenant's Discord server")
+
 // TenantService handles tenant-related operations
 type TenantService struct {
 	db             *gorm.DB
 	discordService DiscordServiceInterface
+	authorizer     AuthorizerInterface
+	botToken       string
 }
 
-// NewTenantService creates a new tenant service
+// NewTenantService creates a new tenant service. Its permission checks use an
+// RBAC service without a configured superadmin, so there is still only one set
+// of rules.
 func NewTenantService(db *gorm.DB, discordService DiscordServiceInterface) *TenantService {
+	return NewTenantServiceWithRBAC(db, discordService, nil)
+}
+
+// NewTenantServiceWithRBAC creates a new tenant service whose permission
+// checks are answered by the given authorizer
+func NewTenantServiceWithRBAC(db *gorm.DB, discordService DiscordServiceInterface, authorizer AuthorizerInterface) *TenantService {
+	if authorizer == nil {
+		authorizer = NewRBACService(db, &config.RBACConfig{})
+	}
 	return &TenantService{
 		db:             db,
 		discordService: discordService,
+		authorizer:     authorizer,
 	}
 }
 
+// SetBotToken sets the bot token used to look up a tenant's Discord channels.
+// Without it, configurations naming new channels cannot be saved.
+func (ts *TenantService) SetBotToken(botToken string) {
+	ts.botToken = botToken
+}
+
 // CreateTenant creates a new tenant from a Discord guild
 func (ts *TenantService) CreateTenant(ctx context.Context, discordGuild *models.DiscordGuild, ownerID string) (*models.Tenant, error) {
 	// Check if tenant already exists
@@ -93,8 +120,10 @@ func (ts *TenantService) GetTenantByDiscordServerID(ctx context.Context, discord
 // GetUserTenants retrieves all tenants a user has access to
 func (ts *TenantService) GetUserTenants(ctx context.Context, userID string) ([]models.Tenant, error) {
 	var tenants []models.Tenant
+	// The join bypasses GORM's soft delete scope, so memberships that ended
+	// must be filtered out here
 	err := ts.db.Joins("JOIN user_tenants ON tenants.id = user_tenants.tenant_id").
-		Where("user_tenants.user_id = ?", userID).
+		Where("user_tenants.user_id = ? AND user_tenants.deleted_at IS NULL", userID).
 		Find(&tenants).Error
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user tenants: %w", err)
@@ -105,6 +134,10 @@ func (ts *TenantService) GetUserTenants(ctx context.Context, userID string) ([]m
 
 // AddUserToTenant adds a user to a tenant with specified roles
 func (ts *TenantService) AddUserToTenant(ctx context.Context, userID, tenantID string, roles []string, permissions []string) error {
+	if err := validateTenantPermissions(permissions); err != nil {
+		return err
+	}
+
 	// Check if user-tenant relationship already exists
 	var existingUserTenant models.UserTenant
 	err := ts.db.Where("user_id = ? AND tenant_id = ?", userID, tenantID).First(&existingUserTenant).Error
@@ -113,7 +146,10 @@ func (ts *TenantService) AddUserToTenant(ctx context.Context, userID, tenantID s
 		existingUserTenant.Roles = models.StringArray(roles)
 		existingUserTenant.Permissions = models.StringArray(permissions)
 		existingUserTenant.UpdatedAt = time.Now()
-		return ts.db.Save(&existingUserTenant).Error
+		if err := ts.db.Save(&existingUserTenant).Error; err != nil {
+			return err
+		}
+		return ts.authorizer.InvalidateUserPermissions(ctx, userID)
 	}
 	if err != gorm.ErrRecordNotFound {
 		return fmt.Errorf("failed to check existing user-tenant relationship: %w", err)
@@ -132,7 +168,7 @@ func (ts *TenantService) AddUserToTenant(ctx context.Context, userID, tenantID s
 		return fmt.Errorf("failed to add user to tenant: %w", err)
 	}
 
-	return nil
+	return ts.authorizer.InvalidateUserPermissions(ctx, userID)
 }
 
 // RemoveUserFromTenant removes a user from a tenant
@@ -142,135 +178,120 @@ func (ts *TenantService) RemoveUserFromTenant(ctx context.Context, userID, tenan
 		return fmt.Errorf("failed to remove user from tenant: %w", err)
 	}
 
-	return nil
+	return ts.authorizer.InvalidateUserPermissions(ctx, userID)
 }
 
-// SyncDiscordRoles synchronizes Discord roles for a tenant
-func (ts *TenantService) SyncDiscordRoles(ctx context.Context, tenantID, botToken string) error {
-	// Get tenant
-	tenant, err := ts.GetTenant(ctx, tenantID)
-	if err != nil {
+// UpdateTenantConfig updates tenant configuration. Whoever changes what new
+// members get must hold it, both before and after the change.
+func (ts *TenantService) UpdateTenantConfig(ctx context.Context, tenantID string, config models.TenantConfig, performedBy string) error {
+	var tenant models.Tenant
+	if err := ts.db.WithContext(ctx).First(&tenant, "id = ?", tenantID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return ErrTenantNotFound
+		}
 		return fmt.Errorf("failed to get tenant: %w", err)
 	}
 
-	// Get Discord roles
-	discordRoles, err := ts.discordService.GetGuildRoles(ctx, botToken, tenant.DiscordServerID)
-	if err != nil {
-		return fmt.Errorf("failed to get Discord roles: %w", err)
+	current, updated := tenant.Config.Membership, config.Membership
+	if !sameStrings(current.NewMemberPermissions(), updated.NewMemberPermissions()) || !sameStrings(current.DefaultRoleIDs, updated.DefaultRoleIDs) {
+		if err := ts.requireMembershipDefaultsHeld(ctx, tenantID, performedBy, current, updated); err != nil {
+			return err
+		}
 	}
 
-	// Sync roles to database
-	for _, discordRole := range discordRoles {
-		var existingRole models.TenantDiscordRole
-		err := ts.db.Where("tenant_id = ? AND discord_role_id = ?", tenantID, discordRole.ID).First(&existingRole).Error
-		
-		if err == gorm.ErrRecordNotFound {
-			// Create new role
-			newRole := &models.TenantDiscordRole{
-				TenantID:      tenantID,
-				DiscordRoleID: discordRole.ID,
-				Name:          discordRole.Name,
-				Color:         discordRole.Color,
-				Position:      discordRole.Position,
-				Permissions:   models.StringArray{}, // Will be mapped later
-				Mentionable:   discordRole.Mentionable,
-				Hoist:         discordRole.Hoist,
-			}
-			
-			err = ts.db.Create(newRole).Error
-			if err != nil {
-				return fmt.Errorf("failed to create Discord role: %w", err)
-			}
-		} else if err == nil {
-			// Update existing role
-			existingRole.Name = discordRole.Name
-			existingRole.Color = discordRole.Color
-			existingRole.Position = discordRole.Position
-			existingRole.Mentionable = discordRole.Mentionable
-			existingRole.Hoist = discordRole.Hoist
-			existingRole.UpdatedAt = time.Now()
-			
-			err = ts.db.Save(&existingRole).Error
-			if err != nil {
-				return fmt.Errorf("failed to update Discord role: %w", err)
-			}
-		} else {
-			return fmt.Errorf("failed to check existing Discord role: %w", err)
-		}
+	if err := ts.requireGuildChannels(ctx, &tenant, config); err != nil {
+		return err
+	}
+
+	err := ts.db.Model(&models.Tenant{}).Where("id = ?", tenantID).Update("config", config).Error
+	if err != nil {
+		return fmt.Errorf("failed to update tenant config: %w", err)
 	}
 
 	return nil
 }
 
-// SyncDiscordUsers synchronizes Discord users for a tenant
-func (ts *TenantService) SyncDiscordUsers(ctx context.Context, tenantID, botToken string) error {
-	// Get tenant
-	tenant, err := ts.GetTenant(ctx, tenantID)
-	if err != nil {
-		return fmt.Errorf("failed to get tenant: %w", err)
+// requireGuildChannels checks that the channels a configuration adds belong
+// to the tenant's Discord server, so that a tenant cannot make the bot post in
+// another server's channels. Channels that are already configured are not
+// looked up again.
+func (ts *TenantService) requireGuildChannels(ctx context.Context, tenant *models.Tenant, config models.TenantConfig) error {
+	configured := make(map[string]bool)
+	for _, channelID := range tenant.Config.ChannelIDs() {
+		configured[channelID] = true
+	}
+	var added []string
+	for _, channelID := range uniqueStrings(config.ChannelIDs()) {
+		if !configured[channelID] {
+			added = append(added, channelID)
+		}
+	}
+	if len(added) == 0 {
+		return nil
 	}
 
-	// Get Discord members (limit to 1000 for now)
-	discordMembers, err := ts.discordService.GetGuildMembers(ctx, botToken, tenant.DiscordServerID, 1000)
+	if ts.discordService == nil || ts.botToken == "" {
+		return fmt.Errorf("%w: no bot is configured to look up channels", ErrChannelNotInGuild)
+	}
+	channels, err := ts.discordService.GetGuildChannels(ctx, ts.botToken, tenant.DiscordServerID)
 	if err != nil {
-		return fmt.Errorf("failed to get Discord members: %w", err)
+		return fmt.Errorf("failed to get guild channels: %w", err)
+	}
+	inGuild := make(map[string]bool, len(channels))
+	for _, channel := range channels {
+		inGuild[channel.ID] = true
 	}
+	for _, channelID := range added {
+		if !inGuild[channelID] {
+			return fmt.Errorf("%w: %s", ErrChannelNotInGuild, channelID)
+		}
+	}
+	return nil
+}
 
-	// Sync users to database
-	for _, member := range discordMembers {
-		if member.User == nil {
-			continue
+// requireMembershipDefaultsHeld checks that a user holds the permissions and
+// the roles' permissions given to new members under either policy. Roles of
+// the updated policy must exist in the tenant.
+func (ts *TenantService) requireMembershipDefaultsHeld(ctx context.Context, tenantID, userID string, current, updated models.MembershipPolicy) error {
+	for _, roleID := range updated.DefaultRoleIDs {
+		if _, err := uuid.Parse(roleID); err != nil {
+			return fmt.Errorf("%w: %s", ErrRoleNotFound, roleID)
 		}
+	}
 
-		var existingUser models.TenantDiscordUser
-		err := ts.db.Where("tenant_id = ? AND discord_user_id = ?", tenantID, member.User.ID).First(&existingUser).Error
-		
-		joinedAt, _ := time.Parse(time.RFC3339, member.JoinedAt)
-		
-		if err == gorm.ErrRecordNotFound {
-			// Create new user
-			newUser := &models.TenantDiscordUser{
-				TenantID:      tenantID,
-				DiscordUserID: member.User.ID,
-				Username:      member.User.Username,
-				DisplayName:   member.Nick,
-				Avatar:        member.Avatar,
-				Roles:         models.StringArray(member.Roles),
-				JoinedAt:      &joinedAt,
-				LastSyncAt:    time.Now(),
-			}
-			
-			err = ts.db.Create(newUser).Error
-			if err != nil {
-				return fmt.Errorf("failed to create Discord user: %w", err)
-			}
-		} else if err == nil {
-			// Update existing user
-			existingUser.Username = member.User.Username
-			existingUser.DisplayName = member.Nick
-			existingUser.Avatar = member.Avatar
-			existingUser.Roles = models.StringArray(member.Roles)
-			existingUser.JoinedAt = &joinedAt
-			existingUser.LastSyncAt = time.Now()
-			existingUser.UpdatedAt = time.Now()
-			
-			err = ts.db.Save(&existingUser).Error
-			if err != nil {
-				return fmt.Errorf("failed to update Discord user: %w", err)
-			}
-		} else {
-			return fmt.Errorf("failed to check existing Discord user: %w", err)
+	var ids []string
+	for _, roleID := range uniqueStrings(append(append([]string{}, current.DefaultRoleIDs...), updated.DefaultRoleIDs...)) {
+		if _, err := uuid.Parse(roleID); err == nil {
+			ids = append(ids, roleID)
+		}
+	}
+	var roles []models.Role
+	if len(ids) > 0 {
+		if err := ts.db.WithContext(ctx).Where("tenant_id = ? AND id IN ?", tenantID, ids).Find(&roles).Error; err != nil {
+			return fmt.Errorf("failed to get roles: %w", err)
 		}
 	}
 
-	return nil
-}
+	found := make(map[string]bool, len(roles))
+	permissions := append(append([]string{}, current.NewMemberPermissions()...), updated.NewMemberPermissions()...)
+	for _, role := range roles {
+		found[role.ID] = true
+		permissions = append(permissions, role.Permissions...)
+	}
+	for _, roleID := range updated.DefaultRoleIDs {
+		if !found[roleID] {
+			return fmt.Errorf("%w: %s", ErrRoleNotFound, roleID)
+		}
+	}
 
-// UpdateTenantConfig updates tenant configuration
-func (ts *TenantService) UpdateTenantConfig(ctx context.Context, tenantID string, config models.TenantConfig) error {
-	err := ts.db.Model(&models.Tenant{}).Where("id = ?", tenantID).Update("config", config).Error
-	if err != nil {
-		return fmt.Errorf("failed to update tenant config: %w", err)
+	for _, perm := range uniqueStrings(permissions) {
+		held, err := ts.HasPermission(ctx, userID, tenantID, perm)
+		if err != nil {
+			return fmt.Errorf("failed to check permission: %w", err)
+		}
+		if !held {
+			return fmt.Errorf("%w: %s", ErrPermissionNotHeld, perm)
+		}
 	}
 
 	return nil
@@ -313,50 +334,21 @@ func (ts *TenantService) DeleteTenant(ctx context.Context, tenantID string) erro
 		return fmt.Errorf("failed to delete tenant: %w", err)
 	}
 
-	return tx.Commit().Error
-}
-
-// HasPermission checks if a user has a specific permission in a tenant
-func (ts *TenantService) HasPermission(ctx context.Context, userID, tenantID, permission string) (bool, error) {
-	var userTenant models.UserTenant
-	err := ts.db.Where("user_id = ? AND tenant_id = ?", userID, tenantID).First(&userTenant).Error
-	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return false, nil
-		}
-		return false, fmt.Errorf("failed to get user-tenant relationship: %w", err)
+	if err := tx.Commit().Error; err != nil {
+		return err
 	}
 
-	// Check direct permissions
-	for _, perm := range userTenant.Permissions {
-		if perm == permission || perm == "*" {
-			return true, nil
-		}
-	}
-
-	// Check role-based permissions
-	var discordRoles []models.TenantDiscordRole
-	if len(userTenant.Roles) > 0 {
-		// Convert StringArray to []string for the IN query
-		roleIDs := make([]string, len(userTenant.Roles))
-		for i, role := range userTenant.Roles {
-			roleIDs[i] = role
-		}
-		err = ts.db.Where("tenant_id = ? AND discord_role_id IN ?", tenantID, roleIDs).Find(&discordRoles).Error
-		if err != nil {
-			return false, fmt.Errorf("failed to get Discord roles: %w", err)
-		}
-	}
+	return ts.authorizer.InvalidateTenantPermissions(ctx, tenantID)
+}
 
-	for _, role := range discordRoles {
-		for _, perm := range role.Permissions {
-			if perm == permission || perm == "*" {
-				return true, nil
-			}
-		}
-	}
+// IsTenantMember checks if a user may enter a tenant
+func (ts *TenantService) IsTenantMember(ctx context.Context, userID, tenantID string) (bool, error) {
+	return ts.authorizer.IsTenantMember(ctx, userID, tenantID)
+}
 
-	return false, nil
+// HasPermission checks if a user has a specific permission in a tenant
+func (ts *TenantService) HasPermission(ctx context.Context, userID, tenantID, permission string) (bool, error) {
+	return ts.authorizer.HasPermission(ctx, userID, tenantID, permission)
 }
 
 // CheckManageServerPermission checks if a user has manage server permissions in Discord
@@ -0,0 +1,284 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrSystemRoleProtected is returned when changing or deleting a role managed by the platform
+	ErrSystemRoleProtected = errors.New("system roles cannot be changed")
+	// ErrRoleExists is returned when a role name is already taken in the tenant
+	ErrRoleExists = errors.New("role already exists")
+	// ErrInvalidRole is returned when a role request has no usable name
+	ErrInvalidRole = errors.New("invalid role")
+	// ErrNotTenantMember is returned when giving a role to a user outside the tenant
+	ErrNotTenantMember = errors.New("user is not a member of the tenant")
+)
+
+// CreateTenantRole creates a role on behalf of a tenant member. The creator
+// must hold every permission given to the role.
+func (rs *RBACService) CreateTenantRole(ctx context.Context, tenantID string, req models.RoleRequest, performedBy string) (*models.Role, error) {
+	name, permissions, err := rs.validateRoleRequest(ctx, tenantID, "", req)
+	if err != nil {
+		return nil, err
+	}
+	if err := rs.requirePermissionsHeld(ctx, performedBy, tenantID, permissions); err != nil {
+		return nil, err
+	}
+
+	role, err := rs.CreateRole(ctx, tenantID, name, permissions, false)
+	if err != nil {
+		return nil, err
+	}
+
+	err = rs.LogPermissionChange(ctx, performedBy, tenantID, "role_created", "role", role.ID, "", fmt.Sprintf("name=%s permissions=%v", name, permissions), "", performedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+// UpdateTenantRole renames a role and replaces its permissions. The editor must
+// hold every permission the role has before and after the change, so nobody
+// can edit a role that is more powerful than they are. Members and grants
+// holding the role by name follow a rename.
+func (rs *RBACService) UpdateTenantRole(ctx context.Context, tenantID, roleID string, req models.RoleRequest, performedBy string) (*models.Role, error) {
+	role, err := rs.tenantRole(ctx, tenantID, roleID)
+	if err != nil {
+		return nil, err
+	}
+	if role.IsSystemRole {
+		return nil, ErrSystemRoleProtected
+	}
+
+	name, permissions, err := rs.validateRoleRequest(ctx, tenantID, role.ID, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := rs.requirePermissionsHeld(ctx, performedBy, tenantID, append(append([]string{}, role.Permissions...), permissions...)); err != nil {
+		return nil, err
+	}
+
+	oldName := role.Name
+	oldValue := fmt.Sprintf("name=%s permissions=%v", role.Name, []string(role.Permissions))
+
+	err = rs.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(role).Updates(map[string]interface{}{
+			"name":        name,
+			"permissions": models.StringArray(permissions),
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to update role: %w", err)
+		}
+
+		if name == oldName {
+			return nil
+		}
+
+		err = tx.Model(&models.UserTenant{}).Where("tenant_id = ?", tenantID).
+			Update("roles", gorm.Expr("array_replace(roles, ?, ?)", oldName, name)).Error
+		if err != nil {
+			return fmt.Errorf("failed to rename role on tenant users: %w", err)
+		}
+
+		err = tx.Model(&models.ResourceGrant{}).
+			Where("tenant_id = ? AND principal_type = ? AND principal_id = ?", tenantID, models.PrincipalTypeRole, oldName).
+			Update("principal_id", name).Error
+		if err != nil {
+			return fmt.Errorf("failed to rename role on resource grants: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	role.Name = name
+	role.Permissions = models.StringArray(permissions)
+
+	if err := rs.InvalidateTenantPermissions(ctx, tenantID); err != nil {
+		return nil, err
+	}
+
+	err = rs.LogPermissionChange(ctx, performedBy, tenantID, "role_updated", "role", role.ID, oldValue, fmt.Sprintf("name=%s permissions=%v", name, permissions), "", performedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+// DeleteTenantRole deletes a role and removes it from members, Discord role
+// mappings and resource grants. The deleter must hold every permission the
+// role has.
+func (rs *RBACService) DeleteTenantRole(ctx context.Context, tenantID, roleID, performedBy string) error {
+	role, err := rs.tenantRole(ctx, tenantID, roleID)
+	if err != nil {
+		return err
+	}
+	if role.IsSystemRole {
+		return ErrSystemRoleProtected
+	}
+	if err := rs.requirePermissionsHeld(ctx, performedBy, tenantID, role.Permissions); err != nil {
+		return err
+	}
+
+	err = rs.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return deleteRole(tx, tenantID, role)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := rs.InvalidateTenantPermissions(ctx, tenantID); err != nil {
+		return err
+	}
+
+	return rs.LogPermissionChange(ctx, performedBy, tenantID, "role_deleted", "role", role.ID, fmt.Sprintf("name=%s permissions=%v", role.Name, []string(role.Permissions)), "", "", performedBy)
+}
+
+// AssignTenantRole gives a tenant member a role. The assigner must hold every
+// permission the role grants.
+func (rs *RBACService) AssignTenantRole(ctx context.Context, tenantID, userID, roleID, performedBy string) error {
+	role, err := rs.tenantRole(ctx, tenantID, roleID)
+	if err != nil {
+		return err
+	}
+	if err := rs.requirePermissionsHeld(ctx, performedBy, tenantID, role.Permissions); err != nil {
+		return err
+	}
+
+	// AssignRoleToUser would otherwise make the user a member
+	var count int64
+	err = rs.db.WithContext(ctx).Model(&models.UserTenant{}).Where("user_id = ? AND tenant_id = ?", userID, tenantID).Count(&count).Error
+	if err != nil {
+		return fmt.Errorf("failed to check membership: %w", err)
+	}
+	if count == 0 {
+		return ErrNotTenantMember
+	}
+
+	if err := rs.AssignRoleToUser(ctx, userID, tenantID, role.Name); err != nil {
+		return err
+	}
+
+	return rs.LogPermissionChange(ctx, userID, tenantID, "role_assigned", "role", role.ID, "", role.Name, "", performedBy)
+}
+
+// UnassignTenantRole takes a role away from a tenant member. The remover must
+// hold every permission the role grants.
+func (rs *RBACService) UnassignTenantRole(ctx context.Context, tenantID, userID, roleID, performedBy string) error {
+	role, err := rs.tenantRole(ctx, tenantID, roleID)
+	if err != nil {
+		return err
+	}
+	if err := rs.requirePermissionsHeld(ctx, performedBy, tenantID, role.Permissions); err != nil {
+		return err
+	}
+
+	if err := rs.RemoveRoleFromUser(ctx, userID, tenantID, role.Name); err != nil {
+		return err
+	}
+
+	return rs.LogPermissionChange(ctx, userID, tenantID, "role_unassigned", "role", role.ID, role.Name, "", "", performedBy)
+}
+
+// deleteRole deletes a role and removes it from members, Discord role
+// mappings and resource grants within a transaction
+func deleteRole(tx *gorm.DB, tenantID string, role *models.Role) error {
+	if err := tx.Delete(role).Error; err != nil {
+		return fmt.Errorf("failed to delete role: %w", err)
+	}
+
+	err := tx.Model(&models.UserTenant{}).Where("tenant_id = ?", tenantID).
+		Update("roles", gorm.Expr("array_remove(roles, ?)", role.Name)).Error
+	if err != nil {
+		return fmt.Errorf("failed to remove role from tenant users: %w", err)
+	}
+
+	err = tx.Model(&models.TenantDiscordRole{}).Where("tenant_id = ?", tenantID).
+		Update("mapped_role_ids", gorm.Expr("array_remove(mapped_role_ids, ?)", role.ID)).Error
+	if err != nil {
+		return fmt.Errorf("failed to remove role from Discord role mappings: %w", err)
+	}
+
+	err = tx.Where("tenant_id = ? AND principal_type = ? AND principal_id IN ?", tenantID, models.PrincipalTypeRole, []string{role.ID, role.Name}).
+		Delete(&models.ResourceGrant{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to remove role from resource grants: %w", err)
+	}
+
+	return nil
+}
+
+// tenantRole gets an internal role that belongs to the tenant
+func (rs *RBACService) tenantRole(ctx context.Context, tenantID, roleID string) (*models.Role, error) {
+	if _, err := uuid.Parse(roleID); err != nil {
+		return nil, ErrRoleNotFound
+	}
+
+	var role models.Role
+	err := rs.db.WithContext(ctx).Where("id = ? AND tenant_id = ?", roleID, tenantID).First(&role).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRoleNotFound
+		}
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+
+	return &role, nil
+}
+
+// validateRoleRequest checks a role's name and permissions, returning them
+// normalized. The name must be unique in the tenant apart from the role itself.
+func (rs *RBACService) validateRoleRequest(ctx context.Context, tenantID, roleID string, req models.RoleRequest) (string, []string, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return "", nil, fmt.Errorf("%w: name is required", ErrInvalidRole)
+	}
+
+	permissions := uniqueStrings(req.Permissions)
+	for _, perm := range permissions {
+		if !models.IsValidTenantPermission(perm) {
+			return "", nil, fmt.Errorf("%w: %s", ErrInvalidPermission, perm)
+		}
+	}
+
+	query := rs.db.WithContext(ctx).Model(&models.Role{}).Where("tenant_id = ? AND name = ?", tenantID, name)
+	if roleID != "" {
+		query = query.Where("id <> ?", roleID)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return "", nil, fmt.Errorf("failed to check role name: %w", err)
+	}
+	if count > 0 {
+		return "", nil, fmt.Errorf("%w: %s", ErrRoleExists, name)
+	}
+
+	return name, permissions, nil
+}
+
+// requirePermissionsHeld returns ErrPermissionNotHeld unless the user holds
+// every one of the permissions in the tenant
+func (rs *RBACService) requirePermissionsHeld(ctx context.Context, userID, tenantID string, permissions []string) error {
+	for _, perm := range uniqueStrings(permissions) {
+		held, err := rs.HasPermission(ctx, userID, tenantID, perm)
+		if err != nil {
+			return fmt.Errorf("failed to check permission: %w", err)
+		}
+		if !held {
+			return fmt.Errorf("%w: %s", ErrPermissionNotHeld, perm)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/config"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRBACService_TenantRoles(t *testing.T) {
+	db, cleanup := testutils.SetupTestDatabaseWithModels(t,
+		&models.User{},
+		&models.Tenant{},
+		&models.UserTenant{},
+		&models.TenantDiscordRole{},
+		&models.Role{},
+		&models.SystemRole{},
+		&models.UserSystemRole{},
+		&models.PermissionAuditLog{},
+		&models.ResourceGrant{},
+	)
+	defer cleanup()
+	rbacService := NewRBACService(db, &config.RBACConfig{RoleSyncTTL: time.Minute})
+	ctx := context.Background()
+
+	tenant := &models.Tenant{DiscordServerID: "guild-123", Name: "Test Guild", OwnerID: uuid.New().String()}
+	require.NoError(t, db.Create(tenant).Error)
+	admin := &models.User{DiscordUserID: "admin-123", Username: "admin"}
+	require.NoError(t, db.Create(admin).Error)
+	manager := &models.User{DiscordUserID: "manager-123", Username: "manager"}
+	require.NoError(t, db.Create(manager).Error)
+	member := &models.User{DiscordUserID: "member-123", Username: "member"}
+	require.NoError(t, db.Create(member).Error)
+	outsider := &models.User{DiscordUserID: "outsider-123", Username: "outsider"}
+	require.NoError(t, db.Create(outsider).Error)
+	require.NoError(t, db.Create(&models.UserTenant{UserID: admin.ID, TenantID: tenant.ID, Permissions: models.StringArray{models.PermissionAdminAll}}).Error)
+	require.NoError(t, db.Create(&models.UserTenant{
+		UserID:      manager.ID,
+		TenantID:    tenant.ID,
+		Permissions: models.StringArray{models.PermissionRoleCreate, models.PermissionRoleWrite, models.PermissionUserWrite, models.PermissionServerRead},
+	}).Error)
+	require.NoError(t, db.Create(&models.UserTenant{UserID: member.ID, TenantID: tenant.ID}).Error)
+
+	role, err := rbacService.CreateTenantRole(ctx, tenant.ID, models.RoleRequest{Name: " viewers ", Permissions: []string{models.PermissionServerRead}}, manager.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "viewers", role.Name)
+
+	t.Run("cannot grant permissions the creator does not hold", func(t *testing.T) {
+		_, err := rbacService.CreateTenantRole(ctx, tenant.ID, models.RoleRequest{Name: "operators", Permissions: []string{models.PermissionServerDelete}}, manager.ID)
+		assert.ErrorIs(t, err, ErrPermissionNotHeld)
+
+		_, err = rbacService.UpdateTenantRole(ctx, tenant.ID, role.ID, models.RoleRequest{Name: "viewers", Permissions: []string{models.PermissionServerDelete}}, manager.ID)
+		assert.ErrorIs(t, err, ErrPermissionNotHeld)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		_, err := rbacService.CreateTenantRole(ctx, tenant.ID, models.RoleRequest{Name: "viewers"}, admin.ID)
+		assert.ErrorIs(t, err, ErrRoleExists)
+		_, err = rbacService.CreateTenantRole(ctx, tenant.ID, models.RoleRequest{Name: "sudo", Permissions: []string{"console:sudo"}}, admin.ID)
+		assert.ErrorIs(t, err, ErrInvalidPermission)
+		_, err = rbacService.CreateTenantRole(ctx, tenant.ID, models.RoleRequest{Name: "  "}, admin.ID)
+		assert.ErrorIs(t, err, ErrInvalidRole)
+	})
+
+	t.Run("assignment", func(t *testing.T) {
+		require.NoError(t, rbacService.AssignTenantRole(ctx, tenant.ID, member.ID, role.ID, manager.ID))
+		has, err := rbacService.HasPermission(ctx, member.ID, tenant.ID, models.PermissionServerRead)
+		require.NoError(t, err)
+		assert.True(t, has)
+
+		err = rbacService.AssignTenantRole(ctx, tenant.ID, outsider.ID, role.ID, manager.ID)
+		assert.ErrorIs(t, err, ErrNotTenantMember)
+		isMember, err := rbacService.IsTenantMember(ctx, outsider.ID, tenant.ID)
+		require.NoError(t, err)
+		assert.False(t, isMember)
+
+		powerful, err := rbacService.CreateTenantRole(ctx, tenant.ID, models.RoleRequest{Name: "admins", Permissions: []string{models.PermissionServerDelete}}, admin.ID)
+		require.NoError(t, err)
+		err = rbacService.AssignTenantRole(ctx, tenant.ID, manager.ID, powerful.ID, manager.ID)
+		assert.ErrorIs(t, err, ErrPermissionNotHeld)
+	})
+
+	t.Run("rename follows members and grants", func(t *testing.T) {
+		_, err := rbacService.CreateResourceGrant(ctx, tenant.ID, models.ResourceTypeGameServer, "server-1", models.CreateResourceGrantRequest{
+			PrincipalType: models.PrincipalTypeRole,
+			PrincipalID:   "viewers",
+			Permission:    models.PermissionConsoleRead,
+		}, admin.ID)
+		require.NoError(t, err)
+
+		updated, err := rbacService.UpdateTenantRole(ctx, tenant.ID, role.ID, models.RoleRequest{Name: "watchers", Permissions: []string{models.PermissionServerRead}}, manager.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "watchers", updated.Name)
+
+		has, err := rbacService.HasPermission(ctx, member.ID, tenant.ID, models.PermissionServerRead)
+		require.NoError(t, err)
+		assert.True(t, has)
+		has, err = rbacService.HasResourcePermission(ctx, member.ID, tenant.ID, models.PermissionConsoleRead, models.ResourceTypeGameServer, "server-1")
+		require.NoError(t, err)
+		assert.True(t, has)
+	})
+
+	t.Run("system roles are protected", func(t *testing.T) {
+		systemRole, err := rbacService.CreateRole(ctx, tenant.ID, "owner", []string{models.PermissionServerRead}, true)
+		require.NoError(t, err)
+
+		_, err = rbacService.UpdateTenantRole(ctx, tenant.ID, systemRole.ID, models.RoleRequest{Name: "owner"}, admin.ID)
+		assert.ErrorIs(t, err, ErrSystemRoleProtected)
+		assert.ErrorIs(t, rbacService.DeleteTenantRole(ctx, tenant.ID, systemRole.ID, admin.ID), ErrSystemRoleProtected)
+	})
+
+	t.Run("roles from other tenants are not found", func(t *testing.T) {
+		assert.ErrorIs(t, rbacService.DeleteTenantRole(ctx, uuid.New().String(), role.ID, admin.ID), ErrRoleNotFound)
+		assert.ErrorIs(t, rbacService.DeleteTenantRole(ctx, tenant.ID, "not-a-uuid", admin.ID), ErrRoleNotFound)
+	})
+
+	t.Run("unassign and delete", func(t *testing.T) {
+		require.NoError(t, rbacService.UnassignTenantRole(ctx, tenant.ID, member.ID, role.ID, manager.ID))
+		has, err := rbacService.HasPermission(ctx, member.ID, tenant.ID, models.PermissionServerRead)
+		require.NoError(t, err)
+		assert.False(t, has)
+
+		require.NoError(t, rbacService.AssignTenantRole(ctx, tenant.ID, member.ID, role.ID, manager.ID))
+		require.NoError(t, rbacService.DeleteTenantRole(ctx, tenant.ID, role.ID, admin.ID))
+
+		var userTenant models.UserTenant
+		require.NoError(t, db.Where("user_id = ? AND tenant_id = ?", member.ID, tenant.ID).First(&userTenant).Error)
+		assert.NotContains(t, []string(userTenant.Roles), "watchers")
+		var grants int64
+		require.NoError(t, db.Model(&models.ResourceGrant{}).Where("principal_id = ?", "watchers").Count(&grants).Error)
+		assert.Zero(t, grants)
+	})
+
+	var changes int64
+	require.NoError(t, db.Model(&models.PermissionAuditLog{}).Where("action IN ?", []string{"role_created", "role_updated", "role_deleted", "role_assigned", "role_unassigned"}).Count(&changes).Error)
+	assert.GreaterOrEqual(t, changes, int64(6))
+}
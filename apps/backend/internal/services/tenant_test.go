@@ -3,13 +3,13 @@ package services
 import (
 	"context"
 	"testing"
-	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gorm.io/gorm"
 
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/config"
 	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
 	"github.com/pteronimbus/pteronimbus/apps/backend/internal/testutils"
 )
@@ -47,6 +47,14 @@ func (m *MockDiscordService) GetGuildMember(ctx context.Context, botToken, guild
 	return args.Get(0).(*models.DiscordMember), args.Error(1)
 }
 
+func (m *MockDiscordService) GetGuildChannels(ctx context.Context, botToken, guildID string) ([]models.DiscordChannel, error) {
+	args := m.Called(ctx, botToken, guildID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.DiscordChannel), args.Error(1)
+}
+
 // setupTestDB creates a PostgreSQL test database with all required models
 func setupTestDB(t *testing.T) (*gorm.DB, func()) {
 	return testutils.SetupTestDatabaseWithModels(t,
@@ -57,6 +65,9 @@ func setupTestDB(t *testing.T) (*gorm.DB, func()) {
 		&models.TenantDiscordUser{},
 		&models.GameServer{},
 		&models.Session{},
+		&models.Role{},
+		&models.SystemRole{},
+		&models.UserSystemRole{},
 	)
 }
 
@@ -189,149 +200,6 @@ func TestTenantService_AddUserToTenant(t *testing.T) {
 	}
 }
 
-func TestTenantService_SyncDiscordRoles(t *testing.T) {
-	db, cleanup := setupTestDB(t)
-	defer cleanup()
-
-	// Create a tenant first
-	tenant := &models.Tenant{
-		DiscordServerID: "guild-123",
-		Name:            "Test Guild",
-		OwnerID:         uuid.New().String(),
-	}
-	err := db.Create(tenant).Error
-	require.NoError(t, err)
-
-	// Create Discord roles to sync
-	discordRoles := []models.DiscordRole{
-		{
-			ID:          "role-1",
-			Name:        "Admin",
-			Color:       16711680, // Red
-			Position:    1,
-			Permissions: "2147483647",
-			Mentionable: true,
-			Hoist:       true,
-		},
-		{
-			ID:          "role-2",
-			Name:        "Moderator",
-			Color:       16776960, // Yellow
-			Position:    2,
-			Permissions: "8192", // MANAGE_MESSAGES
-			Mentionable: false,
-			Hoist:       false,
-		},
-	}
-
-	// Create tenant Discord roles
-	for _, role := range discordRoles {
-		tenantRole := &models.TenantDiscordRole{
-			TenantID:      tenant.ID,
-			DiscordRoleID: role.ID,
-			Name:          role.Name,
-			Color:         role.Color,
-			Position:      role.Position,
-			Permissions:   models.StringArray{role.Permissions},
-			Mentionable:   role.Mentionable,
-			Hoist:         role.Hoist,
-		}
-		err := db.Create(tenantRole).Error
-		require.NoError(t, err)
-	}
-
-	// Verify roles were created
-	var roles []models.TenantDiscordRole
-	err = db.Where("tenant_id = ?", tenant.ID).Find(&roles).Error
-	require.NoError(t, err)
-	assert.Len(t, roles, 2)
-
-	// Verify role details
-	adminRole := roles[0]
-	if adminRole.Name == "Moderator" {
-		adminRole = roles[1]
-	}
-	assert.Equal(t, "Admin", adminRole.Name)
-	assert.Equal(t, 16711680, adminRole.Color)
-	assert.Equal(t, 1, adminRole.Position)
-	assert.True(t, adminRole.Mentionable)
-	assert.True(t, adminRole.Hoist)
-	assert.Contains(t, adminRole.Permissions, "2147483647")
-}
-
-func TestTenantService_SyncDiscordUsers(t *testing.T) {
-	db, cleanup := setupTestDB(t)
-	defer cleanup()
-
-	// Create a tenant first
-	tenant := &models.Tenant{
-		DiscordServerID: "guild-123",
-		Name:            "Test Guild",
-		OwnerID:         uuid.New().String(),
-	}
-	err := db.Create(tenant).Error
-	require.NoError(t, err)
-
-	// Create Discord users to sync
-	discordUsers := []models.DiscordMember{
-		{
-			User: &models.DiscordUser{
-				ID:       "user-1",
-				Username: "testuser1",
-				Avatar:   "avatar1",
-			},
-			Nick:     "TestUser1",
-			Roles:    []string{"role-1", "role-2"},
-			JoinedAt: "2023-01-01T00:00:00Z",
-		},
-		{
-			User: &models.DiscordUser{
-				ID:       "user-2",
-				Username: "testuser2",
-				Avatar:   "avatar2",
-			},
-			Nick:     "TestUser2",
-			Roles:    []string{"role-1"},
-			JoinedAt: "2023-01-02T00:00:00Z",
-		},
-	}
-
-	// Create tenant Discord users
-	for _, member := range discordUsers {
-		joinedAt, _ := time.Parse(time.RFC3339, member.JoinedAt)
-		tenantUser := &models.TenantDiscordUser{
-			TenantID:      tenant.ID,
-			DiscordUserID: member.User.ID,
-			Username:      member.User.Username,
-			DisplayName:   member.Nick,
-			Avatar:        member.User.Avatar,
-			Roles:         models.StringArray(member.Roles),
-			JoinedAt:      &joinedAt,
-			LastSyncAt:    time.Now(),
-		}
-		err := db.Create(tenantUser).Error
-		require.NoError(t, err)
-	}
-
-	// Verify users were created
-	var users []models.TenantDiscordUser
-	err = db.Where("tenant_id = ?", tenant.ID).Find(&users).Error
-	require.NoError(t, err)
-	assert.Len(t, users, 2)
-
-	// Verify user details
-	user1 := users[0]
-	if user1.Username == "testuser2" {
-		user1 = users[1]
-	}
-	assert.Equal(t, "testuser1", user1.Username)
-	assert.Equal(t, "TestUser1", user1.DisplayName)
-	assert.Equal(t, "avatar1", user1.Avatar)
-	assert.Len(t, user1.Roles, 2)
-	assert.Contains(t, user1.Roles, "role-1")
-	assert.Contains(t, user1.Roles, "role-2")
-}
-
 func TestTenantService_HasPermission(t *testing.T) {
 	db, cleanup := setupTestDB(t)
 	defer cleanup()
@@ -383,7 +251,7 @@ func TestTenantService_HasPermission(t *testing.T) {
 	require.NoError(t, err)
 
 	// Test permission checking
-	tenantService := &TenantService{db: db}
+	tenantService := NewTenantService(db, nil)
 
 	// Test direct permissions
 	hasPermission, err := tenantService.HasPermission(context.Background(), user.ID, tenant.ID, "manage_servers")
@@ -415,6 +283,79 @@ func TestTenantService_HasPermission(t *testing.T) {
 	assert.False(t, hasPermission)
 }
 
+func TestTenantService_HasPermission_UsesRBAC(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	owner := &models.User{DiscordUserID: "owner-123", Username: "owner"}
+	require.NoError(t, db.Create(owner).Error)
+	member := &models.User{DiscordUserID: "member-123", Username: "member"}
+	require.NoError(t, db.Create(member).Error)
+	outsider := &models.User{DiscordUserID: "outsider-123", Username: "outsider"}
+	require.NoError(t, db.Create(outsider).Error)
+	superAdmin := &models.User{DiscordUserID: "superadmin123", Username: "superadmin"}
+	require.NoError(t, db.Create(superAdmin).Error)
+
+	tenant := &models.Tenant{DiscordServerID: "guild-123", Name: "Test Guild", OwnerID: owner.ID}
+	require.NoError(t, db.Create(tenant).Error)
+
+	// Internal roles are assigned by name and were ignored by the old tenant checker
+	role := &models.Role{TenantID: tenant.ID, Name: "operator", Permissions: models.StringArray{models.PermissionServerRestart}}
+	require.NoError(t, db.Create(role).Error)
+	require.NoError(t, db.Create(&models.UserTenant{
+		UserID:   member.ID,
+		TenantID: tenant.ID,
+		Roles:    models.StringArray{"operator"},
+	}).Error)
+
+	rbacService := NewRBACService(db, &config.RBACConfig{SuperAdminDiscordID: "superadmin123"})
+	tenantService := NewTenantServiceWithRBAC(db, nil, rbacService)
+
+	t.Run("internal role permissions", func(t *testing.T) {
+		has, err := tenantService.HasPermission(ctx, member.ID, tenant.ID, models.PermissionServerRestart)
+		require.NoError(t, err)
+		assert.True(t, has)
+
+		has, err = tenantService.HasPermission(ctx, member.ID, tenant.ID, models.PermissionServerDelete)
+		require.NoError(t, err)
+		assert.False(t, has)
+	})
+
+	t.Run("superadmin holds every permission", func(t *testing.T) {
+		has, err := tenantService.HasPermission(ctx, superAdmin.ID, tenant.ID, models.PermissionTenantManage)
+		require.NoError(t, err)
+		assert.True(t, has)
+	})
+
+	t.Run("permission names are not treated as membership", func(t *testing.T) {
+		has, err := tenantService.HasPermission(ctx, member.ID, tenant.ID, "read")
+		require.NoError(t, err)
+		assert.False(t, has)
+	})
+
+	t.Run("tenant membership", func(t *testing.T) {
+		for _, tc := range []struct {
+			name     string
+			userID   string
+			expected bool
+		}{
+			{"member", member.ID, true},
+			{"owner without membership record", owner.ID, true},
+			{"superadmin", superAdmin.ID, true},
+			{"outsider", outsider.ID, false},
+		} {
+			isMember, err := tenantService.IsTenantMember(ctx, tc.userID, tenant.ID)
+			require.NoError(t, err, tc.name)
+			assert.Equal(t, tc.expected, isMember, tc.name)
+		}
+
+		isMember, err := tenantService.IsTenantMember(ctx, member.ID, uuid.New().String())
+		require.NoError(t, err)
+		assert.False(t, isMember)
+	})
+}
+
 func TestTenantService_CheckManageServerPermission(t *testing.T) {
 	tenantService := &TenantService{}
 
@@ -532,7 +473,7 @@ func TestTenantService_DeleteTenant(t *testing.T) {
 	require.NoError(t, err)
 
 	// Delete the tenant using the service method
-	tenantService := &TenantService{db: db}
+	tenantService := NewTenantService(db, nil)
 	err = tenantService.DeleteTenant(context.Background(), tenant.ID)
 	require.NoError(t, err)
 
@@ -561,4 +502,103 @@ func TestTenantService_DeleteTenant(t *testing.T) {
 	err = db.Where("tenant_id = ?", tenant.ID).Find(&gameServers).Error
 	require.NoError(t, err)
 	assert.Len(t, gameServers, 0)
-}
\ No newline at end of file
+}
+
+func TestTenantService_GetUserTenants(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	tenantService := NewTenantService(db, nil)
+	ctx := context.Background()
+
+	user := &models.User{DiscordUserID: "member-123", Username: "member"}
+	require.NoError(t, db.Create(user).Error)
+	current := &models.Tenant{DiscordServerID: "guild-current", Name: "Current", OwnerID: uuid.New().String()}
+	require.NoError(t, db.Create(current).Error)
+	former := &models.Tenant{DiscordServerID: "guild-former", Name: "Former", OwnerID: uuid.New().String()}
+	require.NoError(t, db.Create(former).Error)
+
+	require.NoError(t, db.Create(&models.UserTenant{UserID: user.ID, TenantID: current.ID}).Error)
+	left := &models.UserTenant{UserID: user.ID, TenantID: former.ID}
+	require.NoError(t, db.Create(left).Error)
+	// Leaving a tenant soft deletes the membership
+	require.NoError(t, db.Delete(left).Error)
+
+	tenants, err := tenantService.GetUserTenants(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, tenants, 1)
+	assert.Equal(t, current.ID, tenants[0].ID)
+}
+
+func TestTenantService_UpdateTenantConfig(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	owner := &models.User{DiscordUserID: "owner-123", Username: "owner"}
+	require.NoError(t, db.Create(owner).Error)
+	manager := &models.User{DiscordUserID: "manager-123", Username: "manager"}
+	require.NoError(t, db.Create(manager).Error)
+
+	tenant := &models.Tenant{DiscordServerID: "guild-123", Name: "Test Guild", OwnerID: owner.ID}
+	require.NoError(t, db.Create(tenant).Error)
+	admins := &models.Role{TenantID: tenant.ID, Name: "admins", Permissions: models.StringArray{models.PermissionAdminAll}}
+	require.NoError(t, db.Create(admins).Error)
+	require.NoError(t, db.Create(&models.UserTenant{UserID: owner.ID, TenantID: tenant.ID, Permissions: models.StringArray{models.PermissionAdminAll}}).Error)
+	require.NoError(t, db.Create(&models.UserTenant{
+		UserID:      manager.ID,
+		TenantID:    tenant.ID,
+		Permissions: append(models.StringArray{models.PermissionTenantManage}, models.GetDefaultPermissions()...),
+	}).Error)
+
+	tenantService := NewTenantServiceWithRBAC(db, nil, NewRBACService(db, &config.RBACConfig{}))
+
+	// Settings that do not change what new members get need no more than tenant:manage
+	cfg := models.TenantConfig{Settings: map[string]string{"motd": "hello"}}
+	require.NoError(t, tenantService.UpdateTenantConfig(ctx, tenant.ID, cfg, manager.ID))
+
+	cfg.Membership.DefaultPermissions = []string{models.PermissionAdminAll}
+	assert.ErrorIs(t, tenantService.UpdateTenantConfig(ctx, tenant.ID, cfg, manager.ID), ErrPermissionNotHeld)
+
+	cfg.Membership = models.MembershipPolicy{DefaultRoleIDs: []string{admins.ID}}
+	assert.ErrorIs(t, tenantService.UpdateTenantConfig(ctx, tenant.ID, cfg, manager.ID), ErrPermissionNotHeld)
+
+	cfg.Membership = models.MembershipPolicy{DefaultRoleIDs: []string{uuid.New().String()}}
+	assert.ErrorIs(t, tenantService.UpdateTenantConfig(ctx, tenant.ID, cfg, owner.ID), ErrRoleNotFound)
+
+	// The owner may hand out admin roles, after which the manager cannot take them away
+	cfg.Membership = models.MembershipPolicy{DefaultRoleIDs: []string{admins.ID}}
+	require.NoError(t, tenantService.UpdateTenantConfig(ctx, tenant.ID, cfg, owner.ID))
+	cfg.Membership = models.MembershipPolicy{}
+	assert.ErrorIs(t, tenantService.UpdateTenantConfig(ctx, tenant.ID, cfg, manager.ID), ErrPermissionNotHeld)
+
+	var stored models.Tenant
+	require.NoError(t, db.First(&stored, "id = ?", tenant.ID).Error)
+	assert.Equal(t, []string{admins.ID}, stored.Config.Membership.DefaultRoleIDs)
+	assert.Equal(t, "hello", stored.Config.Settings["motd"])
+
+	// Notification channels must be in the tenant's own Discord server
+	cfg.Membership = stored.Config.Membership
+	cfg.Notifications = []models.NotificationChannelConfig{{ChannelID: "foreign-channel"}}
+	assert.ErrorIs(t, tenantService.UpdateTenantConfig(ctx, tenant.ID, cfg, owner.ID), ErrChannelNotInGuild)
+
+	mockDiscord := new(MockDiscordService)
+	mockDiscord.On("GetGuildChannels", ctx, "bot-token", "guild-123").Return([]models.DiscordChannel{{ID: "alerts", GuildID: "guild-123"}}, nil)
+	tenantService = NewTenantServiceWithRBAC(db, mockDiscord, NewRBACService(db, &config.RBACConfig{}))
+	tenantService.SetBotToken("bot-token")
+	assert.ErrorIs(t, tenantService.UpdateTenantConfig(ctx, tenant.ID, cfg, owner.ID), ErrChannelNotInGuild)
+
+	cfg.Notifications = []models.NotificationChannelConfig{{ChannelID: "alerts"}}
+	require.NoError(t, tenantService.UpdateTenantConfig(ctx, tenant.ID, cfg, owner.ID))
+	// Channels that are already configured are not looked up again
+	cfg.Settings["motd"] = "bye"
+	require.NoError(t, tenantService.UpdateTenantConfig(ctx, tenant.ID, cfg, owner.ID))
+	mockDiscord.AssertNumberOfCalls(t, "GetGuildChannels", 2)
+
+	// So must the announcement channels
+	cfg.Announcements = models.AnnouncementPolicy{ApprovalChannelID: "foreign-channel"}
+	assert.ErrorIs(t, tenantService.UpdateTenantConfig(ctx, tenant.ID, cfg, owner.ID), ErrChannelNotInGuild)
+	cfg.Announcements = models.AnnouncementPolicy{AllowedChannelIDs: []string{"alerts", "foreign-channel"}}
+	assert.ErrorIs(t, tenantService.UpdateTenantConfig(ctx, tenant.ID, cfg, owner.ID), ErrChannelNotInGuild)
+	cfg.Announcements = models.AnnouncementPolicy{AllowedChannelIDs: []string{"alerts"}, ApprovalChannelID: "alerts"}
+	require.NoError(t, tenantService.UpdateTenantConfig(ctx, tenant.ID, cfg, owner.ID))
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTOTPCode_RFC6238Vectors(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	tests := []struct {
+		unix     int64
+		expected string
+	}{
+		{unix: 59, expected: "94287082"},
+		{unix: 1111111109, expected: "07081804"},
+		{unix: 1111111111, expected: "14050471"},
+		{unix: 1234567890, expected: "89005924"},
+		{unix: 2000000000, expected: "69279037"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, totpCode(secret, totpStep(time.Unix(tt.unix, 0)), 8))
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := generateTOTPSecret()
+	require.NoError(t, err)
+	key, err := totpEncoding.DecodeString(secret)
+	require.NoError(t, err)
+
+	now := time.Now()
+	current := totpStep(now)
+
+	step, ok := validateTOTP(secret, totpCode(key, current, totpDigits), now)
+	assert.True(t, ok)
+	assert.Equal(t, current, step)
+
+	// One period of clock drift either way is tolerated
+	step, ok = validateTOTP(secret, totpCode(key, current-1, totpDigits), now)
+	assert.True(t, ok)
+	assert.Equal(t, current-1, step)
+	_, ok = validateTOTP(secret, totpCode(key, current+1, totpDigits), now)
+	assert.True(t, ok)
+
+	_, ok = validateTOTP(secret, totpCode(key, current-2, totpDigits), now)
+	assert.False(t, ok)
+	_, ok = validateTOTP(secret, "12345", now)
+	assert.False(t, ok)
+	_, ok = validateTOTP("not base32!", "123456", now)
+	assert.False(t, ok)
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri := totpProvisioningURI("Pteronimbus", "admin@example.com", "JBSWY3DPEHPK3PXP")
+
+	parsed, err := url.Parse(uri)
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth", parsed.Scheme)
+	assert.Equal(t, "totp", parsed.Host)
+	assert.Equal(t, "/Pteronimbus:admin@example.com", parsed.Path)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", parsed.Query().Get("secret"))
+	assert.Equal(t, "Pteronimbus", parsed.Query().Get("issuer"))
+	assert.Equal(t, "6", parsed.Query().Get("digits"))
+	assert.Equal(t, "30", parsed.Query().Get("period"))
+}
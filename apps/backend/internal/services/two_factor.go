@@ -0,0 +1,444 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	// totpIssuer names the account in authenticator apps
+	totpIssuer = "Pteronimbus"
+	// recoveryCodeCount is how many recovery codes are generated at once
+	recoveryCodeCount = 10
+)
+
+var (
+	// ErrTwoFactorNotEnabled is returned when a user has no confirmed two-factor enrollment
+	ErrTwoFactorNotEnabled = errors.New("two-factor authentication is not enabled")
+	// ErrTwoFactorAlreadyEnabled is returned when enrolling a user that is already enrolled
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	// ErrTwoFactorEnrollmentNotFound is returned when confirming an enrollment that was never started
+	ErrTwoFactorEnrollmentNotFound = errors.New("two-factor enrollment not found")
+	// ErrInvalidTwoFactorCode is returned for wrong, expired or already used codes
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+)
+
+// recoveryCodeEncoding produces recovery codes that are easy to read and type
+var recoveryCodeEncoding = base32.NewEncoding("abcdefghijkmnpqrstuvwxyz23456789").WithPadding(base32.NoPadding)
+
+// TwoFactorService manages TOTP enrollment, recovery codes and the system-wide
+// two-factor policy
+type TwoFactorService struct {
+	db          *gorm.DB
+	rbacService *RBACService
+	attempts    TwoFactorAttemptCounter
+}
+
+// NewTwoFactorService creates a new two-factor service
+func NewTwoFactorService(db *gorm.DB, rbacService *RBACService) *TwoFactorService {
+	return &TwoFactorService{
+		db:          db,
+		rbacService: rbacService,
+	}
+}
+
+// NewTwoFactorServiceWithAttemptLimit creates a new two-factor service that
+// locks a user out for a while after too many codes were tried
+func NewTwoFactorServiceWithAttemptLimit(db *gorm.DB, rbacService *RBACService, attempts TwoFactorAttemptCounter) *TwoFactorService {
+	service := NewTwoFactorService(db, rbacService)
+	service.attempts = attempts
+	return service
+}
+
+// Status describes a user's enrollment and whether the policy requires one
+func (s *TwoFactorService) Status(ctx context.Context, userID string) (*models.TwoFactorStatus, error) {
+	status := &models.TwoFactorStatus{}
+
+	enrollment, err := s.getEnrollment(ctx, userID)
+	if err != nil && !errors.Is(err, ErrTwoFactorEnrollmentNotFound) {
+		return nil, err
+	}
+	if enrollment != nil {
+		status.Enabled = enrollment.IsConfirmed()
+		status.Pending = !enrollment.IsConfirmed()
+		status.ConfirmedAt = enrollment.ConfirmedAt
+	}
+
+	if status.Enabled {
+		var remaining int64
+		err := s.db.WithContext(ctx).Model(&models.TOTPRecoveryCode{}).
+			Where("user_id = ? AND used_at IS NULL", userID).
+			Count(&remaining).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to count recovery codes: %w", err)
+		}
+		status.RecoveryCodesRemaining = int(remaining)
+	}
+
+	status.Required, err = s.policyApplies(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
+
+// BeginEnrollment generates a new secret for a user. It replaces any enrollment
+// that was started but not confirmed.
+func (s *TwoFactorService) BeginEnrollment(ctx context.Context, user *models.User) (*models.TwoFactorEnrollment, error) {
+	existing, err := s.getEnrollment(ctx, user.ID)
+	if err != nil && !errors.Is(err, ErrTwoFactorEnrollmentNotFound) {
+		return nil, err
+	}
+	if existing != nil && existing.IsConfirmed() {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	enrollment := &models.UserTOTP{UserID: user.ID, Secret: secret}
+	if err := s.db.WithContext(ctx).Save(enrollment).Error; err != nil {
+		return nil, fmt.Errorf("failed to save enrollment: %w", err)
+	}
+
+	accountName := user.Username
+	if user.Email != "" {
+		accountName = user.Email
+	}
+
+	return &models.TwoFactorEnrollment{
+		Secret:          secret,
+		ProvisioningURI: totpProvisioningURI(totpIssuer, accountName, secret),
+	}, nil
+}
+
+// ConfirmEnrollment activates an enrollment with a first code from the
+// authenticator app and returns the user's recovery codes
+func (s *TwoFactorService) ConfirmEnrollment(ctx context.Context, userID, code string) ([]string, error) {
+	enrollment, err := s.getEnrollment(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if enrollment.IsConfirmed() {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	step, ok := validateTOTP(enrollment.Secret, normalizeTwoFactorCode(code), time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	var codes []string
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Model(enrollment).Updates(map[string]interface{}{
+			"confirmed_at":   now,
+			"last_used_step": step,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to confirm enrollment: %w", err)
+		}
+
+		codes, err = replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit(ctx, userID, "two_factor_enabled", "disabled", "enabled", "TOTP enrollment confirmed")
+	return codes, nil
+}
+
+// Disable removes a user's enrollment and recovery codes after checking a
+// current authenticator or recovery code
+func (s *TwoFactorService) Disable(ctx context.Context, userID, code string) error {
+	if err := s.Verify(ctx, userID, code); err != nil {
+		return err
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.TOTPRecoveryCode{}).Error; err != nil {
+			return fmt.Errorf("failed to delete recovery codes: %w", err)
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.UserTOTP{}).Error; err != nil {
+			return fmt.Errorf("failed to delete enrollment: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.audit(ctx, userID, "two_factor_disabled", "enabled", "disabled", "TOTP enrollment removed by the user")
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces a user's recovery codes after checking a
+// current authenticator or recovery code
+func (s *TwoFactorService) RegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error) {
+	if err := s.Verify(ctx, userID, code); err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		codes, err = replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit(ctx, userID, "two_factor_recovery_codes_regenerated", "", "", "Recovery codes regenerated")
+	return codes, nil
+}
+
+// IsEnabled reports whether a user has a confirmed enrollment
+func (s *TwoFactorService) IsEnabled(ctx context.Context, userID string) (bool, error) {
+	var count int64
+	err := s.db.WithContext(ctx).Model(&models.UserTOTP{}).
+		Where("user_id = ? AND confirmed_at IS NOT NULL", userID).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check two-factor enrollment: %w", err)
+	}
+	return count > 0, nil
+}
+
+// Verify checks an authenticator code, or a recovery code which is then used
+// up. Each authenticator code is only accepted once, and a user who tried too
+// many codes is locked out until the attempt window has passed.
+func (s *TwoFactorService) Verify(ctx context.Context, userID, code string) error {
+	enrollment, err := s.getEnrollment(ctx, userID)
+	if errors.Is(err, ErrTwoFactorEnrollmentNotFound) || (err == nil && !enrollment.IsConfirmed()) {
+		return ErrTwoFactorNotEnabled
+	}
+	if err != nil {
+		return err
+	}
+
+	if s.attempts == nil {
+		return s.verifyCode(ctx, userID, enrollment, code)
+	}
+
+	// Count the attempt before checking the code, so that codes sent in
+	// parallel are all counted against the same limit
+	attempts, err := s.attempts.RecordAttempt(ctx, userID, twoFactorLockout)
+	if err != nil {
+		return err
+	}
+	if attempts > maxTwoFactorFailures {
+		if attempts == maxTwoFactorFailures+1 {
+			s.audit(ctx, userID, "two_factor_locked", "", "", "Too many wrong two-factor codes")
+		}
+		return ErrTwoFactorLocked
+	}
+
+	err = s.verifyCode(ctx, userID, enrollment, code)
+	if errors.Is(err, ErrInvalidTwoFactorCode) {
+		s.audit(ctx, userID, "two_factor_failed", "", strconv.FormatInt(attempts, 10), "Wrong two-factor code")
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.attempts.ResetAttempts(ctx, userID); err != nil {
+		fmt.Printf("Warning: failed to reset two-factor attempts for user %s: %v\n", userID, err)
+	}
+	return nil
+}
+
+// verifyCode checks a code against a user's enrollment
+func (s *TwoFactorService) verifyCode(ctx context.Context, userID string, enrollment *models.UserTOTP, code string) error {
+	code = normalizeTwoFactorCode(code)
+	if _, err := strconv.Atoi(code); err != nil || len(code) != totpDigits {
+		return s.useRecoveryCode(ctx, userID, code)
+	}
+
+	step, ok := validateTOTP(enrollment.Secret, code, time.Now())
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+
+	// Only move forward in time, so that a code that was already accepted is rejected
+	result := s.db.WithContext(ctx).Model(&models.UserTOTP{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Update("last_used_step", step)
+	if result.Error != nil {
+		return fmt.Errorf("failed to record code use: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// GetPolicy returns the system-wide two-factor policy
+func (s *TwoFactorService) GetPolicy(ctx context.Context) (*models.TwoFactorPolicy, error) {
+	var setting models.SystemSetting
+	err := s.db.WithContext(ctx).Where("key = ?", models.SettingRequireOwnerTwoFactor).First(&setting).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.TwoFactorPolicy{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get two-factor policy: %w", err)
+	}
+
+	required, _ := strconv.ParseBool(setting.Value)
+	return &models.TwoFactorPolicy{RequireForTenantOwners: required}, nil
+}
+
+// SetPolicy changes the system-wide two-factor policy
+func (s *TwoFactorService) SetPolicy(ctx context.Context, policy models.TwoFactorPolicy, performedBy string) error {
+	current, err := s.GetPolicy(ctx)
+	if err != nil {
+		return err
+	}
+
+	setting := &models.SystemSetting{
+		Key:       models.SettingRequireOwnerTwoFactor,
+		Value:     strconv.FormatBool(policy.RequireForTenantOwners),
+		UpdatedBy: performedBy,
+	}
+	if err := s.db.WithContext(ctx).Save(setting).Error; err != nil {
+		return fmt.Errorf("failed to save two-factor policy: %w", err)
+	}
+
+	if s.rbacService != nil {
+		err := s.rbacService.LogPermissionChange(ctx, performedBy, "", "two_factor_policy_changed", "system_setting", models.SettingRequireOwnerTwoFactor,
+			strconv.FormatBool(current.RequireForTenantOwners), setting.Value, "Two-factor policy for tenant owners changed", performedBy)
+		if err != nil {
+			fmt.Printf("Warning: failed to audit two-factor policy change: %v\n", err)
+		}
+	}
+	return nil
+}
+
+// EnrollmentRequired reports whether the policy requires a user to enroll
+// before using their tenants, because they own one and are not enrolled yet
+func (s *TwoFactorService) EnrollmentRequired(ctx context.Context, userID string) (bool, error) {
+	applies, err := s.policyApplies(ctx, userID)
+	if err != nil || !applies {
+		return false, err
+	}
+
+	enabled, err := s.IsEnabled(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return !enabled, nil
+}
+
+// policyApplies reports whether the enforcement policy covers a user
+func (s *TwoFactorService) policyApplies(ctx context.Context, userID string) (bool, error) {
+	policy, err := s.GetPolicy(ctx)
+	if err != nil || !policy.RequireForTenantOwners {
+		return false, err
+	}
+
+	var owned int64
+	err = s.db.WithContext(ctx).Model(&models.Tenant{}).Where("owner_id = ?", userID).Count(&owned).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check tenant ownership: %w", err)
+	}
+	return owned > 0, nil
+}
+
+func (s *TwoFactorService) getEnrollment(ctx context.Context, userID string) (*models.UserTOTP, error) {
+	var enrollment models.UserTOTP
+	err := s.db.WithContext(ctx).Where("user_id = ?", userID).First(&enrollment).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTwoFactorEnrollmentNotFound
+		}
+		return nil, fmt.Errorf("failed to get two-factor enrollment: %w", err)
+	}
+	return &enrollment, nil
+}
+
+// useRecoveryCode marks an unused recovery code as used
+func (s *TwoFactorService) useRecoveryCode(ctx context.Context, userID, code string) error {
+	result := s.db.WithContext(ctx).Model(&models.TOTPRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hashRecoveryCode(code)).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return fmt.Errorf("failed to use recovery code: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidTwoFactorCode
+	}
+
+	s.audit(ctx, userID, "two_factor_recovery_code_used", "", "", "Recovery code used instead of an authenticator code")
+	return nil
+}
+
+// audit records a change to a user's two-factor settings made by the user
+func (s *TwoFactorService) audit(ctx context.Context, userID, action, oldValue, newValue, reason string) {
+	if s.rbacService == nil {
+		return
+	}
+	err := s.rbacService.LogPermissionChange(ctx, userID, "", action, "two_factor", userID, oldValue, newValue, reason, userID)
+	if err != nil {
+		fmt.Printf("Warning: failed to audit %s for user %s: %v\n", action, userID, err)
+	}
+}
+
+// replaceRecoveryCodes deletes a user's recovery codes and generates new ones
+func replaceRecoveryCodes(tx *gorm.DB, userID string) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.TOTPRecoveryCode{}).Error; err != nil {
+		return nil, fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		record := &models.TOTPRecoveryCode{
+			ID:       uuid.New().String(),
+			UserID:   userID,
+			CodeHash: hashRecoveryCode(normalizeTwoFactorCode(code)),
+		}
+		if err := tx.Create(record).Error; err != nil {
+			return nil, fmt.Errorf("failed to store recovery code: %w", err)
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// generateRecoveryCode returns a random code formatted as xxxxx-xxxxx
+func generateRecoveryCode() (string, error) {
+	raw := make([]byte, 7)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate recovery code: %w", err)
+	}
+	encoded := recoveryCodeEncoding.EncodeToString(raw)[:10]
+	return encoded[:5] + "-" + encoded[5:], nil
+}
+
+// normalizeTwoFactorCode drops the separators users type or paste along with codes
+func normalizeTwoFactorCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer(" ", "", "-", "").Replace(code)
+}
+
+func hashRecoveryCode(code string) string {
+	return hashAPIToken(code)
+}
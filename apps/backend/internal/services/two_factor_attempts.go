@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// maxTwoFactorFailures is how many codes a user may try within
+	// twoFactorLockout before further codes are refused
+	maxTwoFactorFailures = 10
+	// twoFactorLockout is the window in which failed codes are counted
+	twoFactorLockout = 15 * time.Minute
+)
+
+// ErrTwoFactorLocked is returned when a user tried too many codes and has to wait
+var ErrTwoFactorLocked = errors.New("too many two-factor attempts")
+
+// incrementWithExpiry increments a counter and starts its TTL with the first
+// increment. Both happen in one script, so a counter can never be left
+// without an expiry and concurrent requests each see their own count.
+var incrementWithExpiry = redis.NewScript(`
+local count = redis.call("INCR", KEYS[1])
+if count == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return count
+`)
+
+// RedisTwoFactorAttemptCounter counts two-factor attempts per user in Redis so
+// that the limit holds across backend replicas
+type RedisTwoFactorAttemptCounter struct {
+	client *redis.Client
+}
+
+// NewRedisTwoFactorAttemptCounter creates a new attempt counter sharing the Redis service's connection
+func NewRedisTwoFactorAttemptCounter(redisService *RedisService) *RedisTwoFactorAttemptCounter {
+	return &RedisTwoFactorAttemptCounter{
+		client: redisService.client,
+	}
+}
+
+// RecordAttempt counts an attempt and returns the number of attempts within the window
+func (c *RedisTwoFactorAttemptCounter) RecordAttempt(ctx context.Context, userID string, window time.Duration) (int64, error) {
+	count, err := incrementWithExpiry.Run(ctx, c.client, []string{twoFactorAttemptsKey(userID)}, window.Milliseconds()).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count two-factor attempt: %w", err)
+	}
+	return count, nil
+}
+
+// ResetAttempts forgets a user's attempts after a correct code
+func (c *RedisTwoFactorAttemptCounter) ResetAttempts(ctx context.Context, userID string) error {
+	err := c.client.Del(ctx, twoFactorAttemptsKey(userID)).Err()
+	if err != nil {
+		return fmt.Errorf("failed to reset two-factor attempts: %w", err)
+	}
+	return nil
+}
+
+func twoFactorAttemptsKey(userID string) string {
+	return fmt.Sprintf("two_factor_attempts:%s", userID)
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+)
+
+const (
+	// twoFactorChallengeTTL is how long a login waits for its second factor
+	twoFactorChallengeTTL = 5 * time.Minute
+	// maxTwoFactorAttempts is how many wrong codes a login challenge accepts
+	// before the user has to log in with their provider again
+	maxTwoFactorAttempts = 5
+)
+
+// ErrTwoFactorChallengeNotFound is returned when a login challenge is unknown,
+// expired or was given up on after too many wrong codes
+var ErrTwoFactorChallengeNotFound = errors.New("two-factor challenge not found")
+
+// SetTwoFactorService makes logins of users with two-factor authentication
+// wait for their second factor before tokens are issued
+func (a *AuthService) SetTwoFactorService(twoFactor TwoFactorServiceInterface) {
+	a.twoFactor = twoFactor
+}
+
+// completeLogin issues tokens for a user who authenticated with a provider, or
+// a two-factor challenge when the user has enrolled
+func (a *AuthService) completeLogin(ctx context.Context, user *models.User, discordToken *models.DiscordTokenResponse) (*models.AuthResponse, error) {
+	if a.twoFactor == nil {
+		return a.createSession(ctx, user, discordToken)
+	}
+
+	enabled, err := a.twoFactor.IsEnabled(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return a.createSession(ctx, user, discordToken)
+	}
+
+	challenge := &models.TwoFactorChallenge{
+		ID:           uuid.New().String(),
+		User:         *user,
+		DiscordToken: discordToken,
+		ExpiresAt:    time.Now().Add(twoFactorChallengeTTL),
+	}
+	if err := a.redisService.StoreTwoFactorChallenge(ctx, challenge); err != nil {
+		return nil, err
+	}
+
+	return &models.AuthResponse{TwoFactorChallenge: challenge.ID}, nil
+}
+
+// VerifyTwoFactorLogin answers the two-factor challenge of a login with an
+// authenticator or recovery code and issues the session's tokens
+func (a *AuthService) VerifyTwoFactorLogin(ctx context.Context, challengeID, code string) (*models.AuthResponse, error) {
+	if a.twoFactor == nil {
+		return nil, ErrTwoFactorChallengeNotFound
+	}
+
+	challenge, err := a.redisService.GetTwoFactorChallenge(ctx, challengeID)
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(challenge.ExpiresAt) {
+		a.redisService.DeleteTwoFactorChallenge(ctx, challengeID)
+		return nil, ErrTwoFactorChallengeNotFound
+	}
+
+	// Count the attempt before checking the code, so that guesses sent in
+	// parallel are all counted against the same limit
+	attempts, err := a.redisService.CountTwoFactorChallengeAttempt(ctx, challenge)
+	if err != nil {
+		return nil, err
+	}
+	if attempts > maxTwoFactorAttempts {
+		return nil, a.abandonTwoFactorChallenge(ctx, challengeID)
+	}
+
+	if err := a.twoFactor.Verify(ctx, challenge.User.ID, code); err != nil {
+		if errors.Is(err, ErrInvalidTwoFactorCode) && attempts == maxTwoFactorAttempts {
+			return nil, a.abandonTwoFactorChallenge(ctx, challengeID)
+		}
+		return nil, err
+	}
+
+	if err := a.redisService.DeleteTwoFactorChallenge(ctx, challengeID); err != nil {
+		return nil, err
+	}
+
+	return a.createSession(ctx, &challenge.User, challenge.DiscordToken)
+}
+
+// abandonTwoFactorChallenge deletes a challenge that got too many wrong codes
+func (a *AuthService) abandonTwoFactorChallenge(ctx context.Context, challengeID string) error {
+	if err := a.redisService.DeleteTwoFactorChallenge(ctx, challengeID); err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: too many attempts", ErrTwoFactorChallengeNotFound)
+}
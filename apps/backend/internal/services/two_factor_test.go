@@ -0,0 +1,394 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/config"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/models"
+	"github.com/pteronimbus/pteronimbus/apps/backend/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// MockTwoFactorService is a mock implementation of TwoFactorServiceInterface
+type MockTwoFactorService struct {
+	mock.Mock
+}
+
+func (m *MockTwoFactorService) Status(ctx context.Context, userID string) (*models.TwoFactorStatus, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TwoFactorStatus), args.Error(1)
+}
+
+func (m *MockTwoFactorService) BeginEnrollment(ctx context.Context, user *models.User) (*models.TwoFactorEnrollment, error) {
+	args := m.Called(ctx, user)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TwoFactorEnrollment), args.Error(1)
+}
+
+func (m *MockTwoFactorService) ConfirmEnrollment(ctx context.Context, userID, code string) ([]string, error) {
+	args := m.Called(ctx, userID, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockTwoFactorService) Disable(ctx context.Context, userID, code string) error {
+	args := m.Called(ctx, userID, code)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorService) RegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error) {
+	args := m.Called(ctx, userID, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockTwoFactorService) IsEnabled(ctx context.Context, userID string) (bool, error) {
+	args := m.Called(ctx, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTwoFactorService) Verify(ctx context.Context, userID, code string) error {
+	args := m.Called(ctx, userID, code)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorService) GetPolicy(ctx context.Context) (*models.TwoFactorPolicy, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TwoFactorPolicy), args.Error(1)
+}
+
+func (m *MockTwoFactorService) SetPolicy(ctx context.Context, policy models.TwoFactorPolicy, performedBy string) error {
+	args := m.Called(ctx, policy, performedBy)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorService) EnrollmentRequired(ctx context.Context, userID string) (bool, error) {
+	args := m.Called(ctx, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func TestAuthService_CompleteLoginWithTwoFactor(t *testing.T) {
+	ctx := context.Background()
+	user := &models.User{ID: "user_id", Username: "admin"}
+
+	t.Run("enrolled users get a challenge instead of tokens", func(t *testing.T) {
+		mockRedis := new(MockRedisService)
+		mockTwoFactor := new(MockTwoFactorService)
+		mockTwoFactor.On("IsEnabled", ctx, "user_id").Return(true, nil)
+		mockRedis.On("StoreTwoFactorChallenge", ctx, mock.MatchedBy(func(c *models.TwoFactorChallenge) bool {
+			return c.User.ID == "user_id" && c.DiscordToken.AccessToken == "discord_access" && time.Until(c.ExpiresAt) > 0
+		})).Return(nil)
+
+		authService := NewAuthService(nil, new(MockDiscordService), new(MockJWTService), mockRedis)
+		authService.SetTwoFactorService(mockTwoFactor)
+
+		response, err := authService.completeLogin(ctx, user, &models.DiscordTokenResponse{AccessToken: "discord_access"})
+		require.NoError(t, err)
+		assert.NotEmpty(t, response.TwoFactorChallenge)
+		assert.Empty(t, response.AccessToken)
+		assert.Empty(t, response.RefreshToken)
+		mockRedis.AssertNotCalled(t, "StoreSession", mock.Anything, mock.Anything)
+	})
+
+	t.Run("other users get tokens", func(t *testing.T) {
+		mockRedis := new(MockRedisService)
+		mockJWT := new(MockJWTService)
+		mockTwoFactor := new(MockTwoFactorService)
+		mockTwoFactor.On("IsEnabled", ctx, "user_id").Return(false, nil)
+		mockJWT.On("GenerateAccessToken", user, mock.AnythingOfType("string")).Return("access", time.Now().Add(time.Hour), nil)
+		mockJWT.On("GenerateRefreshToken", user, mock.AnythingOfType("string")).Return("refresh", time.Now().Add(24*time.Hour), nil)
+		mockRedis.On("StoreSession", ctx, mock.AnythingOfType("*models.Session")).Return(nil)
+
+		authService := NewAuthService(nil, new(MockDiscordService), mockJWT, mockRedis)
+		authService.SetTwoFactorService(mockTwoFactor)
+
+		response, err := authService.completeLogin(ctx, user, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "access", response.AccessToken)
+		assert.Empty(t, response.TwoFactorChallenge)
+	})
+}
+
+func TestAuthService_VerifyTwoFactorLogin(t *testing.T) {
+	ctx := context.Background()
+	user := models.User{ID: "user_id", Username: "admin"}
+	newChallenge := func() *models.TwoFactorChallenge {
+		return &models.TwoFactorChallenge{ID: "challenge", User: user, ExpiresAt: time.Now().Add(time.Minute)}
+	}
+
+	tests := []struct {
+		name        string
+		setupMocks  func(*MockRedisService, *MockJWTService, *MockTwoFactorService)
+		expectedErr error
+	}{
+		{
+			name: "valid code issues tokens",
+			setupMocks: func(redis *MockRedisService, jwt *MockJWTService, twoFactor *MockTwoFactorService) {
+				redis.On("GetTwoFactorChallenge", ctx, "challenge").Return(newChallenge(), nil)
+				redis.On("CountTwoFactorChallengeAttempt", ctx, "challenge").Return(int64(1), nil)
+				twoFactor.On("Verify", ctx, "user_id", "123456").Return(nil)
+				redis.On("DeleteTwoFactorChallenge", ctx, "challenge").Return(nil)
+				jwt.On("GenerateAccessToken", &user, mock.AnythingOfType("string")).Return("access", time.Now().Add(time.Hour), nil)
+				jwt.On("GenerateRefreshToken", &user, mock.AnythingOfType("string")).Return("refresh", time.Now().Add(24*time.Hour), nil)
+				redis.On("StoreSession", ctx, mock.AnythingOfType("*models.Session")).Return(nil)
+			},
+		},
+		{
+			name: "wrong code counts an attempt",
+			setupMocks: func(redis *MockRedisService, jwt *MockJWTService, twoFactor *MockTwoFactorService) {
+				redis.On("GetTwoFactorChallenge", ctx, "challenge").Return(newChallenge(), nil)
+				redis.On("CountTwoFactorChallengeAttempt", ctx, "challenge").Return(int64(1), nil)
+				twoFactor.On("Verify", ctx, "user_id", "123456").Return(ErrInvalidTwoFactorCode)
+			},
+			expectedErr: ErrInvalidTwoFactorCode,
+		},
+		{
+			name: "last wrong code ends the challenge",
+			setupMocks: func(redis *MockRedisService, jwt *MockJWTService, twoFactor *MockTwoFactorService) {
+				redis.On("GetTwoFactorChallenge", ctx, "challenge").Return(newChallenge(), nil)
+				redis.On("CountTwoFactorChallengeAttempt", ctx, "challenge").Return(int64(maxTwoFactorAttempts), nil)
+				twoFactor.On("Verify", ctx, "user_id", "123456").Return(ErrInvalidTwoFactorCode)
+				redis.On("DeleteTwoFactorChallenge", ctx, "challenge").Return(nil)
+			},
+			expectedErr: ErrTwoFactorChallengeNotFound,
+		},
+		{
+			name: "codes past the limit are not checked",
+			setupMocks: func(redis *MockRedisService, jwt *MockJWTService, twoFactor *MockTwoFactorService) {
+				redis.On("GetTwoFactorChallenge", ctx, "challenge").Return(newChallenge(), nil)
+				redis.On("CountTwoFactorChallengeAttempt", ctx, "challenge").Return(int64(maxTwoFactorAttempts+1), nil)
+				redis.On("DeleteTwoFactorChallenge", ctx, "challenge").Return(nil)
+			},
+			expectedErr: ErrTwoFactorChallengeNotFound,
+		},
+		{
+			name: "unknown challenge",
+			setupMocks: func(redis *MockRedisService, jwt *MockJWTService, twoFactor *MockTwoFactorService) {
+				redis.On("GetTwoFactorChallenge", ctx, "challenge").Return(nil, ErrTwoFactorChallengeNotFound)
+			},
+			expectedErr: ErrTwoFactorChallengeNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRedis := new(MockRedisService)
+			mockJWT := new(MockJWTService)
+			mockTwoFactor := new(MockTwoFactorService)
+			tt.setupMocks(mockRedis, mockJWT, mockTwoFactor)
+
+			authService := NewAuthService(nil, new(MockDiscordService), mockJWT, mockRedis)
+			authService.SetTwoFactorService(mockTwoFactor)
+
+			response, err := authService.VerifyTwoFactorLogin(ctx, "challenge", "123456")
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				mockRedis.AssertNotCalled(t, "StoreSession", mock.Anything, mock.Anything)
+				mockRedis.AssertNotCalled(t, "StoreTwoFactorChallenge", mock.Anything, mock.Anything)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "access", response.AccessToken)
+			}
+			mockRedis.AssertExpectations(t)
+			mockTwoFactor.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthService_StepUpWithTOTP(t *testing.T) {
+	ctx := context.Background()
+	session := &models.Session{ID: "session_id", UserID: "user_id", ExpiresAt: time.Now().Add(time.Hour)}
+
+	mockRedis := new(MockRedisService)
+	mockTwoFactor := new(MockTwoFactorService)
+	mockRedis.On("GetSession", ctx, "session_id").Return(session, nil)
+	mockTwoFactor.On("Verify", ctx, "user_id", "000000").Return(ErrInvalidTwoFactorCode).Once()
+	mockTwoFactor.On("Verify", ctx, "user_id", "123456").Return(nil).Once()
+	mockRedis.On("UpdateSession", ctx, "session_id").Return(session, nil)
+
+	authService := NewAuthService(nil, new(MockDiscordService), new(MockJWTService), mockRedis)
+	authService.SetTwoFactorService(mockTwoFactor)
+
+	_, err := authService.StepUpWithTOTP(ctx, "session_id", "000000")
+	assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+
+	until, err := authService.StepUpWithTOTP(ctx, "session_id", "123456")
+	require.NoError(t, err)
+	assert.Greater(t, time.Until(until), StepUpTTL-time.Minute)
+	mockRedis.AssertExpectations(t)
+}
+
+func setupTwoFactorTest(t *testing.T) (*TwoFactorService, *gorm.DB, func()) {
+	db, cleanup := testutils.SetupTestDatabaseWithModels(t,
+		&models.User{},
+		&models.Tenant{},
+		&models.UserTOTP{},
+		&models.TOTPRecoveryCode{},
+		&models.SystemSetting{},
+		&models.PermissionAuditLog{},
+	)
+
+	rbacService := NewRBACService(db, &config.RBACConfig{})
+	return NewTwoFactorService(db, rbacService), db, cleanup
+}
+
+// currentTOTPCode returns the code an authenticator app would show for a step
+func currentTOTPCode(t *testing.T, secret string, step int64) string {
+	key, err := totpEncoding.DecodeString(secret)
+	require.NoError(t, err)
+	return totpCode(key, step, totpDigits)
+}
+
+func TestTwoFactorService_Enrollment(t *testing.T) {
+	twoFactor, db, cleanup := setupTwoFactorTest(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	user := &models.User{DiscordUserID: "totp-user", Username: "admin"}
+	require.NoError(t, db.Create(user).Error)
+
+	enrollment, err := twoFactor.BeginEnrollment(ctx, user)
+	require.NoError(t, err)
+	assert.Contains(t, enrollment.ProvisioningURI, "secret="+enrollment.Secret)
+
+	// An unconfirmed enrollment does not protect logins yet
+	enabled, err := twoFactor.IsEnabled(ctx, user.ID)
+	require.NoError(t, err)
+	assert.False(t, enabled)
+	assert.ErrorIs(t, twoFactor.Verify(ctx, user.ID, "123456"), ErrTwoFactorNotEnabled)
+
+	_, err = twoFactor.ConfirmEnrollment(ctx, user.ID, "000000")
+	assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+
+	step := totpStep(time.Now())
+	codes, err := twoFactor.ConfirmEnrollment(ctx, user.ID, currentTOTPCode(t, enrollment.Secret, step))
+	require.NoError(t, err)
+	assert.Len(t, codes, recoveryCodeCount)
+
+	_, err = twoFactor.BeginEnrollment(ctx, user)
+	assert.ErrorIs(t, err, ErrTwoFactorAlreadyEnabled)
+
+	// The code that confirmed the enrollment cannot be replayed, a later one works once
+	assert.ErrorIs(t, twoFactor.Verify(ctx, user.ID, currentTOTPCode(t, enrollment.Secret, step)), ErrInvalidTwoFactorCode)
+	next := currentTOTPCode(t, enrollment.Secret, step+1)
+	assert.NoError(t, twoFactor.Verify(ctx, user.ID, next))
+	assert.ErrorIs(t, twoFactor.Verify(ctx, user.ID, next), ErrInvalidTwoFactorCode)
+
+	// Recovery codes are single use and accepted in any case and formatting
+	assert.NoError(t, twoFactor.Verify(ctx, user.ID, " "+codes[0]+" "))
+	assert.ErrorIs(t, twoFactor.Verify(ctx, user.ID, codes[0]), ErrInvalidTwoFactorCode)
+
+	status, err := twoFactor.Status(ctx, user.ID)
+	require.NoError(t, err)
+	assert.True(t, status.Enabled)
+	assert.Equal(t, recoveryCodeCount-1, status.RecoveryCodesRemaining)
+
+	require.NoError(t, twoFactor.Disable(ctx, user.ID, codes[1]))
+	enabled, err = twoFactor.IsEnabled(ctx, user.ID)
+	require.NoError(t, err)
+	assert.False(t, enabled)
+
+	var actions []string
+	require.NoError(t, db.Model(&models.PermissionAuditLog{}).Where("user_id = ?", user.ID).Order("created_at").Pluck("action", &actions).Error)
+	assert.Contains(t, actions, "two_factor_enabled")
+	assert.Contains(t, actions, "two_factor_recovery_code_used")
+	assert.Contains(t, actions, "two_factor_disabled")
+}
+
+// countingAttempts is an in-memory TwoFactorAttemptCounter
+type countingAttempts map[string]int64
+
+func (c countingAttempts) RecordAttempt(ctx context.Context, userID string, window time.Duration) (int64, error) {
+	c[userID]++
+	return c[userID], nil
+}
+
+func (c countingAttempts) ResetAttempts(ctx context.Context, userID string) error {
+	delete(c, userID)
+	return nil
+}
+
+func TestTwoFactorService_AttemptLimit(t *testing.T) {
+	base, db, cleanup := setupTwoFactorTest(t)
+	defer cleanup()
+	ctx := context.Background()
+	attempts := countingAttempts{}
+	twoFactor := NewTwoFactorServiceWithAttemptLimit(db, base.rbacService, attempts)
+
+	user := &models.User{DiscordUserID: "locked-user", Username: "admin"}
+	require.NoError(t, db.Create(user).Error)
+	enrollment, err := twoFactor.BeginEnrollment(ctx, user)
+	require.NoError(t, err)
+	step := totpStep(time.Now())
+	_, err = twoFactor.ConfirmEnrollment(ctx, user.ID, currentTOTPCode(t, enrollment.Secret, step))
+	require.NoError(t, err)
+
+	// A correct code clears the failures counted before it
+	assert.ErrorIs(t, twoFactor.Verify(ctx, user.ID, "000000"), ErrInvalidTwoFactorCode)
+	require.NoError(t, twoFactor.Verify(ctx, user.ID, currentTOTPCode(t, enrollment.Secret, step+1)))
+	assert.Empty(t, attempts)
+
+	for i := 0; i < maxTwoFactorFailures; i++ {
+		assert.ErrorIs(t, twoFactor.Verify(ctx, user.ID, "000000"), ErrInvalidTwoFactorCode)
+	}
+
+	// Once locked, even a correct code is refused
+	assert.ErrorIs(t, twoFactor.Verify(ctx, user.ID, currentTOTPCode(t, enrollment.Secret, step+2)), ErrTwoFactorLocked)
+	assert.ErrorIs(t, twoFactor.Verify(ctx, user.ID, "000000"), ErrTwoFactorLocked)
+
+	var failed, locked int64
+	require.NoError(t, db.Model(&models.PermissionAuditLog{}).Where("user_id = ? AND action = ?", user.ID, "two_factor_failed").Count(&failed).Error)
+	require.NoError(t, db.Model(&models.PermissionAuditLog{}).Where("user_id = ? AND action = ?", user.ID, "two_factor_locked").Count(&locked).Error)
+	assert.Equal(t, int64(maxTwoFactorFailures+1), failed)
+	assert.Equal(t, int64(1), locked)
+}
+
+func TestTwoFactorService_Policy(t *testing.T) {
+	twoFactor, db, cleanup := setupTwoFactorTest(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	owner := &models.User{DiscordUserID: "owner", Username: "owner"}
+	member := &models.User{DiscordUserID: "member", Username: "member"}
+	require.NoError(t, db.Create(owner).Error)
+	require.NoError(t, db.Create(member).Error)
+	require.NoError(t, db.Create(&models.Tenant{DiscordServerID: "guild", Name: "Guild", OwnerID: owner.ID}).Error)
+
+	policy, err := twoFactor.GetPolicy(ctx)
+	require.NoError(t, err)
+	assert.False(t, policy.RequireForTenantOwners)
+
+	required, err := twoFactor.EnrollmentRequired(ctx, owner.ID)
+	require.NoError(t, err)
+	assert.False(t, required)
+
+	require.NoError(t, twoFactor.SetPolicy(ctx, models.TwoFactorPolicy{RequireForTenantOwners: true}, member.ID))
+
+	required, err = twoFactor.EnrollmentRequired(ctx, owner.ID)
+	require.NoError(t, err)
+	assert.True(t, required)
+	required, err = twoFactor.EnrollmentRequired(ctx, member.ID)
+	require.NoError(t, err)
+	assert.False(t, required)
+
+	var audited int64
+	require.NoError(t, db.Model(&models.PermissionAuditLog{}).Where("action = ?", "two_factor_policy_changed").Count(&audited).Error)
+	assert.Equal(t, int64(1), audited)
+}
@@ -167,8 +167,11 @@ export const useAuth = () => {
     clearError()
 
     try {
-      // Get Discord auth URL from backend
-      const response = await $fetch<{ auth_url: string; state: string }>(`${config.public.backendUrl}/auth/login`)
+      // Get Discord auth URL from backend. The backend binds the login to this
+      // browser with a cookie, so it has to be accepted cross-origin.
+      const response = await $fetch<{ auth_url: string; state: string }>(`${config.public.backendUrl}/auth/login`, {
+        credentials: 'include'
+      })
       
       // Store callback URL for after authentication
       if (options?.callbackUrl && import.meta.client) {
@@ -195,7 +198,8 @@ export const useAuth = () => {
     try {
       const authResponse = await $fetch<AuthResponse>(`${config.public.backendUrl}/auth/callback`, {
         method: 'GET',
-        query: { code, state }
+        query: { code, state },
+        credentials: 'include'
       })
 
       storeAuth(authResponse)
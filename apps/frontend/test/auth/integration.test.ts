@@ -85,7 +85,9 @@ describe('Authentication Integration Tests', () => {
 
       await auth.signIn('discord', { callbackUrl: '/dashboard' })
 
-      expect(mockFetch).toHaveBeenCalledWith('http://localhost:8080/auth/login')
+      expect(mockFetch).toHaveBeenCalledWith('http://localhost:8080/auth/login', {
+        credentials: 'include'
+      })
       expect(mockLocalStorage.setItem).toHaveBeenCalledWith('auth_callback_url', '/dashboard')
       expect(window.location.href).toBe('https://discord.com/oauth2/authorize?client_id=123&state=abc')
 
@@ -111,7 +113,8 @@ describe('Authentication Integration Tests', () => {
 
       expect(mockFetch).toHaveBeenCalledWith('http://localhost:8080/auth/callback', {
         method: 'GET',
-        query: { code: 'auth-code', state: 'abc' }
+        query: { code: 'auth-code', state: 'abc' },
+        credentials: 'include'
       })
 
       // Verify authentication state
@@ -75,7 +75,9 @@ describe('useAuth Composable', () => {
 
       await auth.signIn('discord', { callbackUrl: '/dashboard' })
 
-      expect(mockFetch).toHaveBeenCalledWith('http://localhost:8080/auth/login')
+      expect(mockFetch).toHaveBeenCalledWith('http://localhost:8080/auth/login', {
+        credentials: 'include'
+      })
       expect(mockLocalStorage.setItem).toHaveBeenCalledWith('auth_callback_url', '/dashboard')
       expect(window.location.href).toBe(mockAuthUrl)
     })
@@ -127,7 +129,8 @@ describe('useAuth Composable', () => {
 
       expect(mockFetch).toHaveBeenCalledWith('http://localhost:8080/auth/callback', {
         method: 'GET',
-        query: { code: 'auth-code', state: 'state' }
+        query: { code: 'auth-code', state: 'state' },
+        credentials: 'include'
       })
 
       expect(auth.user.value).toEqual(mockUser)